@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
 	"time"
@@ -16,6 +17,31 @@ import (
 
 const tokenSize = 32
 
+// The scopes of the access token, a token without any scope has the full permission
+const (
+	// ScopeChainRead allows the read-only queries of the blockchain and the tx pool
+	ScopeChainRead = "chain-read"
+	// ScopeChainWrite allows submitting transactions to the network
+	ScopeChainWrite = "chain-write"
+	// ScopeWalletRead allows the read-only queries of the wallet
+	ScopeWalletRead = "wallet-read"
+	// ScopeWalletWrite allows creating and updating the wallet objects, and building transactions
+	ScopeWalletWrite = "wallet-write"
+	// ScopeSign allows the operations on the private keys, include signing and key management
+	ScopeSign = "sign"
+	// ScopePeerAdmin allows managing the peers and the mining of the node
+	ScopePeerAdmin = "peer-admin"
+)
+
+var validScopes = map[string]bool{
+	ScopeChainRead:   true,
+	ScopeChainWrite:  true,
+	ScopeWalletRead:  true,
+	ScopeWalletWrite: true,
+	ScopeSign:        true,
+	ScopePeerAdmin:   true,
+}
+
 var (
 	// ErrBadID is returned when Create is called on an invalid id string.
 	ErrBadID = errors.New("invalid id")
@@ -27,18 +53,96 @@ var (
 	ErrNoMatchID = errors.New("nonexisting access token ID")
 	// ErrInvalidToken is returned when Check is called on invalid token
 	ErrInvalidToken = errors.New("invalid token")
+	// ErrBadScope is returned when Create is called with an unknown scope.
+	ErrBadScope = errors.New("invalid access token scope")
+	// ErrBadAllowedIP is returned when Create is called with an invalid IP or CIDR.
+	ErrBadAllowedIP = errors.New("invalid access token allowed ip")
+	// ErrBadExpiry is returned when Create is called with an expired time.
+	ErrBadExpiry = errors.New("access token expiry time has passed")
 
 	// validIDRegexp checks that all characters are alphumeric, _ or -.
 	// It also must have a length of at least 1.
 	validIDRegexp = regexp.MustCompile(`^[\w-]+$`)
 )
 
+// Permission restricts what the access token can do, the zero value has no restriction.
+type Permission struct {
+	Scopes     []string   `json:"scopes,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
+}
+
+func (p *Permission) validate() error {
+	for _, scope := range p.Scopes {
+		if !validScopes[scope] {
+			return errors.WithDetailf(ErrBadScope, "unknown scope %q", scope)
+		}
+	}
+
+	for _, allowedIP := range p.AllowedIPs {
+		if net.ParseIP(allowedIP) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(allowedIP); err != nil {
+			return errors.WithDetailf(ErrBadAllowedIP, "invalid ip %q", allowedIP)
+		}
+	}
+
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(time.Now()) {
+		return errors.WithDetailf(ErrBadExpiry, "expires at %s", p.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// HasScope returns whether the token is permitted to the scope
+func (p *Permission) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired returns whether the token has been expired at the given time
+func (p *Permission) Expired(now time.Time) bool {
+	return p.ExpiresAt != nil && !now.Before(*p.ExpiresAt)
+}
+
+// AllowIP returns whether the request from the ip is allowed
+func (p *Permission) AllowIP(ip net.IP) bool {
+	if len(p.AllowedIPs) == 0 {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, allowedIP := range p.AllowedIPs {
+		if _, ipNet, err := net.ParseCIDR(allowedIP); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		} else if ip.Equal(net.ParseIP(allowedIP)) {
+			return true
+		}
+	}
+	return false
+}
+
 // Token describe the access token.
 type Token struct {
 	ID      string    `json:"id"`
 	Token   string    `json:"token,omitempty"`
 	Type    string    `json:"type,omitempty"`
 	Created time.Time `json:"created_at"`
+	Permission
 }
 
 // CredentialStore store user access credential.
@@ -55,10 +159,20 @@ func NewStore(db dbm.DB) *CredentialStore {
 
 // Create generates a new access token with the given ID.
 func (cs *CredentialStore) Create(id, typ string) (*Token, error) {
+	return cs.CreateWithPermission(id, typ, &Permission{})
+}
+
+// CreateWithPermission generates a new access token with the given ID, the token can
+// only be used within the permission.
+func (cs *CredentialStore) CreateWithPermission(id, typ string, permission *Permission) (*Token, error) {
 	if !validIDRegexp.MatchString(id) {
 		return nil, errors.WithDetailf(ErrBadID, "invalid id %q", id)
 	}
 
+	if err := permission.validate(); err != nil {
+		return nil, err
+	}
+
 	key := []byte(id)
 	if cs.DB.Get(key) != nil {
 		return nil, errors.WithDetailf(ErrDuplicateID, "id %q already in use", id)
@@ -70,10 +184,11 @@ func (cs *CredentialStore) Create(id, typ string) (*Token, error) {
 	}
 
 	token := &Token{
-		ID:      id,
-		Token:   fmt.Sprintf("%s:%x", id, secret),
-		Type:    typ,
-		Created: time.Now(),
+		ID:         id,
+		Token:      fmt.Sprintf("%s:%x", id, secret),
+		Type:       typ,
+		Created:    time.Now(),
+		Permission: *permission,
 	}
 
 	value, err := json.Marshal(token)
@@ -87,21 +202,28 @@ func (cs *CredentialStore) Create(id, typ string) (*Token, error) {
 
 // Check returns whether or not an id-secret pair is a valid access token.
 func (cs *CredentialStore) Check(id string, secret string) error {
+	_, err := cs.Lookup(id, secret)
+	return err
+}
+
+// Lookup returns the access token of the id-secret pair, the permission of the token
+// is not checked.
+func (cs *CredentialStore) Lookup(id string, secret string) (*Token, error) {
 	value := cs.DB.Get([]byte(id))
 	if value == nil {
-		return errors.WithDetailf(ErrNoMatchID, "check id %q nonexisting", id)
+		return nil, errors.WithDetailf(ErrNoMatchID, "check id %q nonexisting", id)
 	}
 
 	token := &Token{}
 	if err := json.Unmarshal(value, token); err != nil {
-		return err
+		return nil, err
 	}
 
 	if splitStrings := strings.Split(token.Token, ":"); len(splitStrings) != 2 || splitStrings[1] != secret {
-		return ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	return nil
+	return token, nil
 }
 
 // List lists all access tokens.
@@ -2,9 +2,11 @@ package accesstoken
 
 import (
 	"context"
+	"net"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	dbm "coingod/database/leveldb"
 	"coingod/errors"
@@ -79,6 +81,53 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestCreateWithPermission(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+	cs := NewStore(testDB)
+
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	cases := []struct {
+		id         string
+		permission *Permission
+		want       error
+	}{
+		{"a", &Permission{Scopes: []string{ScopeChainRead, ScopeWalletRead}, ExpiresAt: &future, AllowedIPs: []string{"127.0.0.1", "10.0.0.0/8"}}, nil},
+		{"b", &Permission{Scopes: []string{"root"}}, ErrBadScope},
+		{"c", &Permission{AllowedIPs: []string{"10.0.0.300"}}, ErrBadAllowedIP},
+		{"d", &Permission{ExpiresAt: &past}, ErrBadExpiry},
+	}
+
+	for _, c := range cases {
+		_, err := cs.CreateWithPermission(c.id, "client", c.permission)
+		if errors.Root(err) != c.want {
+			t.Errorf("CreateWithPermission(%s) error = %s want %s", c.id, err, c.want)
+		}
+	}
+
+	tokens, err := cs.List()
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("got tokens %v, err %v", tokens, err)
+	}
+
+	token, err := cs.Lookup("a", strings.Split(tokens[0].Token, ":")[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !token.HasScope(ScopeWalletRead) || token.HasScope(ScopeSign) {
+		t.Errorf("got scopes %v", token.Scopes)
+	}
+
+	if token.Expired(time.Now()) || !token.Expired(future) {
+		t.Errorf("got expiry %v", token.ExpiresAt)
+	}
+
+	if !token.AllowIP(net.ParseIP("10.1.1.1")) || token.AllowIP(net.ParseIP("192.168.1.1")) {
+		t.Errorf("got allowed ips %v", token.AllowedIPs)
+	}
+}
+
 func mustCreateToken(ctx context.Context, t *testing.T, cs *CredentialStore, id, typ string) *Token {
 	token, err := cs.Create(id, typ)
 	if err != nil {
@@ -20,7 +20,6 @@ import (
 	"coingod/crypto/sha3pool"
 	dbm "coingod/database/leveldb"
 	"coingod/errors"
-	"coingod/protocol"
 	"coingod/protocol/bc"
 	"coingod/protocol/vm/vmutil"
 )
@@ -98,10 +97,15 @@ type CtrlProgram struct {
 	Change         bool // Mark whether this control program is for UTXO change
 }
 
+// Chain is the block source of the account manager, it's the light chain in the light mode
+type Chain interface {
+	BestBlockHeight() uint64
+}
+
 // Manager stores accounts and their associated control programs.
 type Manager struct {
 	db         dbm.DB
-	chain      *protocol.Chain
+	chain      Chain
 	utxoKeeper *utxoKeeper
 
 	addressMu sync.Mutex
@@ -109,11 +113,11 @@ type Manager struct {
 }
 
 // NewManager creates a new account manager
-func NewManager(walletDB dbm.DB, chain *protocol.Chain) *Manager {
+func NewManager(walletDB dbm.DB, chain Chain) *Manager {
 	return &Manager{
 		db:         walletDB,
 		chain:      chain,
-		utxoKeeper: newUtxoKeeper(chain.BestBlockHeight, walletDB),
+		utxoKeeper: newUtxoKeeper(func() uint64 { return chain.BestBlockHeight() }, walletDB),
 	}
 }
 
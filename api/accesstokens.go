@@ -2,15 +2,25 @@ package api
 
 import (
 	"context"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"coingod/accesstoken"
 )
 
 func (a *API) createAccessToken(ctx context.Context, x struct {
-	ID   string `json:"id"`
-	Type string `json:"type"`
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	AllowedIPs []string   `json:"allowed_ips"`
 }) Response {
-	token, err := a.accessTokens.Create(x.ID, x.Type)
+	token, err := a.accessTokens.CreateWithPermission(x.ID, x.Type, &accesstoken.Permission{
+		Scopes:     x.Scopes,
+		ExpiresAt:  x.ExpiresAt,
+		AllowedIPs: x.AllowedIPs,
+	})
 	if err != nil {
 		return NewErrorResponse(err)
 	}
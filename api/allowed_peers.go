@@ -0,0 +1,23 @@
+package api
+
+// AllowedPeers is the allow list of the node public keys
+type AllowedPeers struct {
+	Enabled bool     `json:"enabled"`
+	PubKeys []string `json:"pub_keys"`
+}
+
+// return the node public keys which are allowed to connect
+func (a *API) listAllowedPeers() Response {
+	enabled, pubKeys := a.sync.AllowedPeers()
+	return NewSuccessResponse(&AllowedPeers{Enabled: enabled, PubKeys: pubKeys})
+}
+
+// reload the allowed peers from the config, the connected peers which are no longer
+// allowed are disconnected
+func (a *API) reloadAllowedPeers() Response {
+	if err := a.sync.ReloadAllowedPeers(); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return a.listAllowedPeers()
+}
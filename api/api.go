@@ -18,6 +18,8 @@ import (
 	"coingod/dashboard/equity"
 	"coingod/errors"
 	"coingod/event"
+	"coingod/explorer"
+	"coingod/metrics"
 	"coingod/net/http/authn"
 	"coingod/net/http/gzip"
 	"coingod/net/http/httpjson"
@@ -25,15 +27,22 @@ import (
 	"coingod/net/websocket"
 	"coingod/netsync/peers"
 	"coingod/p2p"
+	"coingod/p2p/security"
 	"coingod/proposal/blockproposer"
 	"coingod/protocol"
+	"coingod/protocol/bc/types"
+	"coingod/validator"
+	"coingod/validator/reward"
 	"coingod/wallet"
 )
 
 var (
 	errNotAuthenticated = errors.New("not authenticated")
+	errPermissionDenied = errors.New("permission denied")
 	httpReadTimeout     = 2 * time.Minute
 	httpWriteTimeout    = time.Hour
+
+	requestLatency = metrics.NewHistogramVec("coingod_api_request_duration_seconds", "Latency of the api requests by the route", metrics.DefaultBuckets, "route")
 )
 
 const (
@@ -110,7 +119,11 @@ type API struct {
 	wallet          *wallet.Wallet
 	accessTokens    *accesstoken.CredentialStore
 	chain           *protocol.Chain
+	lightChain      LightChain
 	contractTracer  *contract.TraceService
+	validatorStats  *validator.Tracker
+	voteReward      *reward.Distributor
+	explorer        *explorer.Indexer
 	server          *http.Server
 	handler         http.Handler
 	blockProposer   *blockproposer.BlockProposer
@@ -177,15 +190,32 @@ type NetSync interface {
 	DialPeerWithAddress(addr *p2p.NetAddress) error
 	GetPeerInfos() []*peers.PeerInfo
 	StopPeer(peerID string) error
+	BanPeer(ip string, duration time.Duration, reason string) error
+	UnbanPeer(ip string) error
+	ListBannedPeers() []*security.BannedPeer
+	ListPeerScores() []*security.PeerScore
+	ReloadAllowedPeers() error
+	AllowedPeers() (bool, []string)
+	BroadcastTx(tx *types.Tx) error
+}
+
+// LightChain is the block source of the light node, which only keeps the block headers and the
+// wallet related transactions
+type LightChain interface {
+	BestBlockHeader() *types.BlockHeader
+	BaseBlockHeight() uint64
 }
 
 // NewAPI create and initialize the API
-func NewAPI(sync NetSync, wallet *wallet.Wallet, blockProposer *blockproposer.BlockProposer, chain *protocol.Chain, traceService *contract.TraceService, config *cfg.Config, token *accesstoken.CredentialStore, dispatcher *event.Dispatcher, notificationMgr *websocket.WSNotificationManager) *API {
+func NewAPI(sync NetSync, wallet *wallet.Wallet, blockProposer *blockproposer.BlockProposer, chain *protocol.Chain, traceService *contract.TraceService, validatorStats *validator.Tracker, voteReward *reward.Distributor, explorerIndexer *explorer.Indexer, config *cfg.Config, token *accesstoken.CredentialStore, dispatcher *event.Dispatcher, notificationMgr *websocket.WSNotificationManager) *API {
 	api := &API{
 		sync:            sync,
 		wallet:          wallet,
 		chain:           chain,
 		contractTracer:  traceService,
+		validatorStats:  validatorStats,
+		voteReward:      voteReward,
+		explorer:        explorerIndexer,
 		accessTokens:    token,
 		blockProposer:   blockProposer,
 		eventDispatcher: dispatcher,
@@ -197,6 +227,30 @@ func NewAPI(sync NetSync, wallet *wallet.Wallet, blockProposer *blockproposer.Bl
 	return api
 }
 
+// EnableLightMode make the API follow the light chain, the requests which need the full chain
+// are rejected and the submitted transactions are sent to the peers directly. It must be called
+// before StartServer.
+func (a *API) EnableLightMode(chain LightChain) {
+	a.lightChain = chain
+	a.buildHandler()
+}
+
+// bestBlockHeader return the tail of the chain followed by the node
+func (a *API) bestBlockHeader() *types.BlockHeader {
+	if a.lightChain != nil {
+		return a.lightChain.BestBlockHeader()
+	}
+	return a.chain.BestBlockHeader()
+}
+
+// baseBlockHeight return the lowest height of the blocks kept by the node
+func (a *API) baseBlockHeight() uint64 {
+	if a.lightChain != nil {
+		return a.lightChain.BaseBlockHeight()
+	}
+	return a.chain.BaseBlockHeight()
+}
+
 func (a *API) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	a.handler.ServeHTTP(rw, req)
 }
@@ -234,16 +288,27 @@ func (a *API) buildHandler() {
 		m.Handle("/sign-message", jsonHandler(a.signMessage))
 
 		m.Handle("/build-transaction", jsonHandler(a.build))
-		m.Handle("/build-chain-transactions", jsonHandler(a.buildChainTxs))
+		m.Handle("/build-chain-transactions", a.fullChainHandler(a.buildChainTxs))
 		m.Handle("/sign-transaction", jsonHandler(a.signTemplate))
 		m.Handle("/sign-transactions", jsonHandler(a.signTemplates))
 
 		m.Handle("/get-transaction", jsonHandler(a.getTransaction))
 		m.Handle("/list-transactions", jsonHandler(a.listTransactions))
+		m.Handle("/list-account-transactions", jsonHandler(a.listAccountTransactions))
+
+		m.Handle("/create-transaction-feed", jsonHandler(a.createTxFeed))
+		m.Handle("/get-transaction-feed", jsonHandler(a.getTxFeed))
+		m.Handle("/list-transaction-feeds", jsonHandler(a.listTxFeeds))
+		m.Handle("/update-transaction-feed", jsonHandler(a.updateTxFeed))
+		m.Handle("/delete-transaction-feed", jsonHandler(a.deleteTxFeed))
 
 		m.Handle("/list-balances", jsonHandler(a.listBalances))
 		m.Handle("/list-unspent-outputs", jsonHandler(a.listUnspentOutputs))
 		m.Handle("/list-account-votes", jsonHandler(a.listAccountVotes))
+		m.Handle("/list-vote-utxos", jsonHandler(a.listVoteUTXOs))
+		m.Handle("/list-account-vote-shares", a.fullChainHandler(a.listAccountVoteShares))
+		m.Handle("/build-move-votes", jsonHandler(a.buildMoveVotes))
+		m.Handle("/list-vote-reward-settlements", jsonHandler(a.listVoteRewardSettlements))
 
 		m.Handle("/decode-program", jsonHandler(a.decodeProgram))
 
@@ -272,16 +337,17 @@ func (a *API) buildHandler() {
 	m.Handle("/submit-transaction", jsonHandler(a.submit))
 	m.Handle("/submit-transactions", jsonHandler(a.submitTxs))
 	m.Handle("/estimate-transaction-gas", jsonHandler(a.estimateTxGas))
-	m.Handle("/estimate-chain-transaction-gas", jsonHandler(a.estimateChainTxGas))
+	m.Handle("/estimate-chain-transaction-gas", a.fullChainHandler(a.estimateChainTxGas))
 
-	m.Handle("/get-unconfirmed-transaction", jsonHandler(a.getUnconfirmedTx))
-	m.Handle("/list-unconfirmed-transactions", jsonHandler(a.listUnconfirmedTxs))
+	m.Handle("/get-unconfirmed-transaction", a.fullChainHandler(a.getUnconfirmedTx))
+	m.Handle("/list-unconfirmed-transactions", a.fullChainHandler(a.listUnconfirmedTxs))
 	m.Handle("/decode-raw-transaction", jsonHandler(a.decodeRawTransaction))
+	m.Handle("/debug-transaction", a.fullChainHandler(a.debugTransaction))
 
-	m.Handle("/get-block", jsonHandler(a.getBlock))
-	m.Handle("/get-raw-block", jsonHandler(a.getRawBlock))
+	m.Handle("/get-block", a.fullChainHandler(a.getBlock))
+	m.Handle("/get-raw-block", a.fullChainHandler(a.getRawBlock))
 	m.Handle("/get-block-hash", jsonHandler(a.getBestBlockHash))
-	m.Handle("/get-block-header", jsonHandler(a.getBlockHeader))
+	m.Handle("/get-block-header", a.fullChainHandler(a.getBlockHeader))
 	m.Handle("/get-block-count", jsonHandler(a.getBlockCount))
 
 	m.Handle("/is-mining", jsonHandler(a.isMining))
@@ -291,22 +357,41 @@ func (a *API) buildHandler() {
 
 	m.Handle("/gas-rate", jsonHandler(a.gasRate))
 	m.Handle("/net-info", jsonHandler(a.getNetInfo))
-	m.Handle("/chain-status", jsonHandler(a.getChainStatus))
+	m.Handle("/chain-status", a.fullChainHandler(a.getChainStatus))
 
 	m.Handle("/list-peers", jsonHandler(a.listPeers))
 	m.Handle("/disconnect-peer", jsonHandler(a.disconnectPeer))
 	m.Handle("/connect-peer", jsonHandler(a.connectPeer))
-
-	m.Handle("/get-merkle-proof", jsonHandler(a.getMerkleProof))
-	m.Handle("/get-vote-result", jsonHandler(a.getVoteResult))
-
-	m.Handle("/get-contract-instance", jsonHandler(a.getContractInstance))
-	m.Handle("/create-contract-instance", jsonHandler(a.createContractInstance))
-	m.Handle("/remove-contract-instance", jsonHandler(a.removeContractInstance))
+	m.Handle("/list-banned-peers", jsonHandler(a.listBannedPeers))
+	m.Handle("/ban-peer", jsonHandler(a.banPeer))
+	m.Handle("/unban-peer", jsonHandler(a.unbanPeer))
+	m.Handle("/list-peer-scores", jsonHandler(a.listPeerScores))
+	m.Handle("/list-allowed-peers", jsonHandler(a.listAllowedPeers))
+	m.Handle("/reload-allowed-peers", jsonHandler(a.reloadAllowedPeers))
+
+	m.Handle("/get-merkle-proof", a.fullChainHandler(a.getMerkleProof))
+	m.Handle("/get-vote-result", a.fullChainHandler(a.getVoteResult))
+	m.Handle("/list-slashing-evidence", a.fullChainHandler(a.listSlashingEvidences))
+	m.Handle("/list-validators", a.fullChainHandler(a.listValidators))
+	m.Handle("/get-validator-stats", a.fullChainHandler(a.getValidatorStats))
+	m.Handle("/get-validator-votes", a.fullChainHandler(a.getValidatorVotes))
+
+	m.Handle("/get-address-balance", a.fullChainHandler(a.getAddressBalance))
+	m.Handle("/list-address-transactions", a.fullChainHandler(a.listAddressTransactions))
+	m.Handle("/list-asset-holders", a.fullChainHandler(a.listAssetHolders))
+
+	m.Handle("/get-contract-instance", a.fullChainHandler(a.getContractInstance))
+	m.Handle("/create-contract-instance", a.fullChainHandler(a.createContractInstance))
+	m.Handle("/remove-contract-instance", a.fullChainHandler(a.removeContractInstance))
+	m.Handle("/register-contract-callback", a.fullChainHandler(a.registerContractCallback))
+	m.Handle("/list-contract-callbacks", a.fullChainHandler(a.listContractCallbacks))
+	m.Handle("/remove-contract-callback", a.fullChainHandler(a.removeContractCallback))
 
 	m.HandleFunc("/websocket-subscribe", a.websocketHandler)
+	m.Handle("/metrics", metrics.Handler())
 
 	handler := walletHandler(m, walletEnable)
+	handler = latencyHandler(m, handler)
 	handler = webAssetsHandler(handler)
 	handler = gzip.Handler{Handler: handler}
 	a.handler = handler
@@ -321,6 +406,15 @@ func jsonHandler(f interface{}) http.Handler {
 	return h
 }
 
+// fullChainHandler is the json Handler of the request which needs the full chain, the request
+// is rejected in the light mode
+func (a *API) fullChainHandler(f interface{}) http.Handler {
+	if a.lightChain != nil {
+		return jsonHandler(func() Response { return NewErrorResponse(ErrLightMode) })
+	}
+	return jsonHandler(f)
+}
+
 // error Handler
 func alwaysError(err error) http.Handler {
 	return jsonHandler(func() error { return err })
@@ -343,14 +437,18 @@ func webAssetsHandler(next http.Handler) http.Handler {
 
 // AuthHandler access token auth Handler
 func AuthHandler(handler http.Handler, accessTokens *accesstoken.CredentialStore, authDisable bool) http.Handler {
-	authenticator := authn.NewAPI(accessTokens, authDisable)
+	authenticator := authn.NewAPI(accessTokens, routeScope, authDisable)
 
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// TODO(tessr): check that this path exists; return early if this path isn't legit
 		req, err := authenticator.Authenticate(req)
 		if err != nil {
 			log.WithFields(log.Fields{"module": logModule, "error": errors.Wrap(err, "Serve")}).Error("Authenticate fail")
-			err = errors.WithDetail(errNotAuthenticated, err.Error())
+			if errors.Root(err) == authn.ErrPermissionDenied {
+				err = errors.WithDetail(errPermissionDenied, err.Error())
+			} else {
+				err = errors.WithDetail(errNotAuthenticated, err.Error())
+			}
 			errorFormatter.Write(req.Context(), rw, err)
 			return
 		}
@@ -383,6 +481,17 @@ func walletHandler(m *http.ServeMux, walletEnable bool) http.Handler {
 	})
 }
 
+// latencyHandler record the latency of the request by the registered route, the unknown
+// paths are recorded as the "/" route
+func latencyHandler(m *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, route := m.Handler(req)
+		start := time.Now()
+		next.ServeHTTP(w, req)
+		requestLatency.Observe(time.Since(start).Seconds(), route)
+	})
+}
+
 // walletRedirectHandler redirect to error when the wallet is closed
 func walletRedirectHandler(w http.ResponseWriter, req *http.Request) {
 	h := http.RedirectHandler(req.URL.String(), http.StatusMovedPermanently)
@@ -2,13 +2,18 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http/httptest"
 	"os"
 	"testing"
 
 	"coingod/accesstoken"
 	"coingod/blockchain/rpc"
+	cfg "coingod/config"
+	"coingod/consensus"
 	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
 	"coingod/testutil"
 )
 
@@ -95,3 +100,69 @@ func TestAPIHandler(t *testing.T) {
 		}
 	}
 }
+
+type mockLightChain struct {
+	bestHeader *types.BlockHeader
+}
+
+func (c *mockLightChain) BestBlockHeader() *types.BlockHeader { return c.bestHeader }
+func (c *mockLightChain) BaseBlockHeight() uint64             { return 0 }
+
+type mockLightSync struct {
+	NetSync
+	txs []*types.Tx
+}
+
+func (s *mockLightSync) BroadcastTx(tx *types.Tx) error {
+	s.txs = append(s.txs, tx)
+	return nil
+}
+
+func TestLightModeHandler(t *testing.T) {
+	commonConfig := cfg.CommonConfig
+	cfg.CommonConfig = cfg.DefaultConfig()
+	defer func() { cfg.CommonConfig = commonConfig }()
+
+	sync := &mockLightSync{}
+	a := &API{sync: sync}
+	a.EnableLightMode(&mockLightChain{bestHeader: &types.BlockHeader{Height: 100}})
+	server := httptest.NewServer(a.handler)
+	defer server.Close()
+
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+	a.accessTokens = accesstoken.NewStore(testDB)
+	client := &rpc.Client{BaseURL: server.URL, AccessToken: "test-user:test-secret"}
+
+	response := &Response{}
+	client.Call(context.Background(), "/get-block-count", nil, &response)
+	if response.Status != SUCCESS || fmt.Sprint(response.Data.(map[string]interface{})["block_count"]) != "100" {
+		t.Fatalf("got block count response %v, want the light chain height 100", response)
+	}
+
+	response = &Response{}
+	client.Call(context.Background(), "/get-block", struct {
+		BlockHeight uint64 `json:"block_height"`
+	}{BlockHeight: 1}, &response)
+	if response.Status != FAIL || response.Code != "CG113" {
+		t.Fatalf("got get block response %v, want the light mode error", response)
+	}
+
+	tx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, *consensus.CGAssetID, 100, 0, []byte{0x51}, nil)},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 90, []byte{0x51}, nil)},
+	})
+
+	response = &Response{}
+	client.Call(context.Background(), "/submit-transaction", struct {
+		Tx *types.Tx `json:"raw_transaction"`
+	}{Tx: tx}, &response)
+	if response.Status != SUCCESS {
+		t.Fatalf("got submit response %v, want success", response)
+	}
+
+	if len(sync.txs) != 1 || sync.txs[0].ID != tx.ID {
+		t.Fatalf("got broadcast txs %v, want the submitted tx %v", sync.txs, tx.ID)
+	}
+}
@@ -11,13 +11,14 @@ import (
 
 // return best block hash
 func (a *API) getBestBlockHash() Response {
-	blockHash := map[string]string{"block_hash": a.chain.BestBlockHash().String()}
+	hash := a.bestBlockHeader().Hash()
+	blockHash := map[string]string{"block_hash": hash.String()}
 	return NewSuccessResponse(blockHash)
 }
 
 // return current block count
 func (a *API) getBlockCount() Response {
-	blockHeight := map[string]uint64{"block_count": a.chain.BestBlockHeight()}
+	blockHeight := map[string]uint64{"block_count": a.bestBlockHeader().Height}
 	return NewSuccessResponse(blockHeight)
 }
 
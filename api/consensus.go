@@ -1,5 +1,9 @@
 package api
 
+import (
+	"coingod/protocol/state"
+)
+
 type VoteInfo struct {
 	Vote    string `json:"vote"`
 	VoteNum uint64 `json:"vote_number"`
@@ -30,3 +34,21 @@ func (a *API) getVoteResult(req BlockReq) Response {
 	}
 	return NewSuccessResponse(voteInfos)
 }
+
+// return the slashing evidences of the validators which break the casper rules
+func (a *API) listSlashingEvidences(ins struct {
+	PubKey string `json:"pub_key"`
+}) Response {
+	evidences, err := a.chain.SlashingEvidences()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	result := []*state.SlashingEvidence{}
+	for _, evidence := range evidences {
+		if ins.PubKey == "" || evidence.PubKey == ins.PubKey {
+			result = append(result, evidence)
+		}
+	}
+	return NewSuccessResponse(result)
+}
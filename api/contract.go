@@ -155,3 +155,34 @@ func (a *API) removeContractInstance(_ context.Context, ins struct {
 
 	return NewSuccessResponse(nil)
 }
+
+func (a *API) registerContractCallback(_ context.Context, ins struct {
+	TraceID string `json:"trace_id"`
+	URL     string `json:"url"`
+}) Response {
+	callback, err := a.contractTracer.RegisterCallback(ins.TraceID, ins.URL)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(callback)
+}
+
+func (a *API) listContractCallbacks(_ context.Context) Response {
+	callbacks, err := a.contractTracer.ListCallbacks()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(callbacks)
+}
+
+func (a *API) removeContractCallback(_ context.Context, ins struct {
+	ID string `json:"id"`
+}) Response {
+	if err := a.contractTracer.RemoveCallback(ins.ID); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(nil)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/validation"
+	"coingod/protocol/vm"
+)
+
+type debugInputResp struct {
+	InputIndex int             `json:"input_index"`
+	InputID    bc.Hash         `json:"input_id"`
+	Steps      []*vm.TraceStep `json:"steps"`
+}
+
+type debugTxResp struct {
+	TxID    bc.Hash           `json:"tx_id"`
+	GasUsed int64             `json:"gas_used"`
+	Error   string            `json:"error,omitempty"`
+	Inputs  []*debugInputResp `json:"inputs"`
+}
+
+// POST /debug-transaction
+// validate the raw transaction on the best block, and return the vm execution steps of each input
+func (a *API) debugTransaction(ctx context.Context, ins struct {
+	Tx types.Tx `json:"raw_transaction"`
+}) Response {
+	// the serialized size is required by the validation, calculate it like the submit does
+	data, err := ins.Tx.TxData.MarshalText()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	ins.Tx.Tx.SerializedSize = uint64(len(data) / 2)
+
+	bh := a.chain.BestBlockHeader()
+	gasState, recorders, err := validation.TraceTx(ins.Tx.Tx, types.MapBlock(&types.Block{BlockHeader: *bh}), a.chain.ProgramConverter)
+	resp := &debugTxResp{TxID: ins.Tx.ID, Inputs: []*debugInputResp{}}
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.GasUsed = gasState.GasUsed
+	}
+
+	for i, recorder := range recorders {
+		steps := recorder.Steps
+		if steps == nil {
+			steps = []*vm.TraceStep{}
+		}
+		resp.Inputs = append(resp.Inputs, &debugInputResp{InputIndex: i, InputID: ins.Tx.InputIDs[i], Steps: steps})
+	}
+	return NewSuccessResponse(resp)
+}
@@ -3,23 +3,31 @@ package api
 import (
 	"context"
 
+	"coingod/accesstoken"
 	"coingod/account"
 	"coingod/asset"
 	"coingod/blockchain/pseudohsm"
 	"coingod/blockchain/rpc"
 	"coingod/blockchain/signers"
 	"coingod/blockchain/txbuilder"
+	"coingod/blockchain/txfeed"
 	"coingod/contract"
 	"coingod/errors"
+	"coingod/explorer"
 	"coingod/net/http/httperror"
 	"coingod/net/http/httpjson"
+	"coingod/p2p/security"
+	"coingod/protocol"
 	"coingod/protocol/validation"
 	"coingod/protocol/vm"
+	"coingod/wallet"
 )
 
 var (
 	// ErrDefault is default Coingod API Error
 	ErrDefault = errors.New("Coingod API Error")
+	// ErrLightMode means the request needs the full chain which isn't synced by the light node
+	ErrLightMode = errors.New("the request isn't supported in the light mode")
 )
 
 func isTemporary(info httperror.Info, err error) bool {
@@ -45,6 +53,12 @@ func isTemporary(info httperror.Info, err error) bool {
 var respErrFormatter = map[error]httperror.Info{
 	ErrDefault: {500, "CG000", "Coingod API Error"},
 
+	// Network error namespace (1xx)
+	security.ErrPeerNotBanned:     {400, "CG110", "The peer is not banned"},
+	security.ErrBadAllowedPeer:    {400, "CG111", "Invalid node public key in the allowed peers"},
+	security.ErrAllowListDisabled: {400, "CG112", "The allowed peers are not configured"},
+	ErrLightMode:                  {400, "CG113", "The request needs the full chain, it isn't supported in the light mode"},
+
 	// Signers error namespace (2xx)
 	signers.ErrBadQuorum: {400, "CG200", "Quorum must be greater than or equal to 1, and must be less than or equal to the length of xpubs"},
 	signers.ErrBadXPub:   {400, "CG201", "Invalid xpub format"},
@@ -54,6 +68,25 @@ var respErrFormatter = map[error]httperror.Info{
 	// Contract error namespace (3xx)
 	contract.ErrContractDuplicated: {400, "CG302", "Contract is duplicated"},
 	contract.ErrContractNotFound:   {400, "CG303", "Contract not found"},
+	contract.ErrBadCallbackURL:     {400, "CG304", "Invalid contract callback url"},
+	contract.ErrCallbackNotFound:   {400, "CG305", "Contract callback not found"},
+	contract.ErrNotifierDisabled:   {400, "CG306", "Contract callback is disabled"},
+
+	// Txfeed error namespace (4xx)
+	txfeed.ErrDuplicateAlias: {400, "CG400", "Txfeed alias already exists"},
+	txfeed.ErrEmptyAlias:     {400, "CG401", "Txfeed alias is empty"},
+	txfeed.ErrNotFound:       {400, "CG402", "Txfeed not found"},
+	txfeed.ErrBadFilter:      {400, "CG403", "Invalid txfeed filter"},
+	txfeed.ErrBadCursor:      {400, "CG404", "Invalid txfeed cursor"},
+
+	// Wallet error namespace (5xx)
+	wallet.ErrBadTxCursor:    {400, "CG500", "Invalid transaction cursor"},
+	wallet.ErrMissingHistory: {400, "CG501", "Wallet history before the base block is missing"},
+
+	// Explorer error namespace (6xx)
+	ErrExplorerDisabled:   {400, "CG600", "Explorer is disabled"},
+	ErrBadExplorerAddress: {400, "CG601", "Invalid address or control program"},
+	explorer.ErrBadCursor: {400, "CG602", "Invalid explorer cursor"},
 
 	// Transaction error namespace (7xx)
 	// Build transaction error namespace (70x ~ 72x)
@@ -72,6 +105,9 @@ var respErrFormatter = map[error]httperror.Info{
 	txbuilder.ErrOrphanTx:           {400, "CG712", "Transaction input UTXO not found"},
 	txbuilder.ErrExtTxFee:           {400, "CG713", "Transaction fee exceeded max limit"},
 	txbuilder.ErrNoGasInput:         {400, "CG714", "Transaction has no gas input"},
+	ErrNoVotes:                      {400, "CG715", "Account has no unlocked votes for the validator"},
+	ErrBadMoveVote:                  {400, "CG716", "Invalid vote movement"},
+	ErrVoteRewardDisabled:           {400, "CG717", "Vote reward distribution is disabled"},
 
 	// Submit transaction error namespace (73x ~ 79x)
 	// Validation error (73x ~ 75x)
@@ -113,10 +149,20 @@ var respErrFormatter = map[error]httperror.Info{
 	vm.ErrUnsupportedVM:      {400, "CG774", "Unsupported VM because the version of VM is mismatched"},
 	vm.ErrVerifyFailed:       {400, "CG775", "VERIFY failed"},
 
+	// Mempool error (79x)
+	protocol.ErrDoubleSpend:         {400, "CG790", "Transaction conflicts with the transaction in the pool"},
+	protocol.ErrInsufficientFeeBump: {400, "CG791", "Replacement transaction fee is insufficient"},
+	protocol.ErrReplaceTooMany:      {400, "CG792", "Replacement transaction evicts too many transactions"},
+
 	// Mock HSM error namespace (8xx)
 	pseudohsm.ErrDuplicateKeyAlias: {400, "CG800", "Key Alias already exists"},
 	pseudohsm.ErrLoadKey:           {400, "CG801", "Key not found or wrong password"},
 	pseudohsm.ErrDecrypt:           {400, "CG802", "Could not decrypt key with given passphrase"},
+
+	// Access token error namespace (86x)
+	accesstoken.ErrBadScope:     {400, "CG862", "Invalid access token scope"},
+	accesstoken.ErrBadAllowedIP: {400, "CG863", "Invalid access token allowed ip"},
+	accesstoken.ErrBadExpiry:    {400, "CG864", "Access token expiry time has passed"},
 }
 
 // Map error values to standard coingod error codes. Missing entries
@@ -135,5 +181,6 @@ var errorFormatter = httperror.Formatter{
 
 		//accesstoken authz err namespace (86x)
 		errNotAuthenticated: {401, "CG860", "Request could not be authenticated"},
+		errPermissionDenied: {403, "CG861", "Access token has no permission for the request"},
 	},
 }
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+
+	"coingod/common"
+	"coingod/consensus"
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+	"coingod/explorer"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm/vmutil"
+)
+
+var (
+	// ErrExplorerDisabled means the explorer indexes are not enabled by the config
+	ErrExplorerDisabled = errors.New("explorer is disabled")
+	// ErrBadExplorerAddress means neither a valid address nor a control program is specified
+	ErrBadExplorerAddress = errors.New("invalid address or control program")
+)
+
+// explorerProgram return the control program of the request, the address is decoded
+// when the control program is not specified
+func explorerProgram(address string, program chainjson.HexBytes) ([]byte, error) {
+	if len(program) != 0 {
+		return program, nil
+	}
+
+	addr, err := common.DecodeAddress(address, &consensus.ActiveNetParams)
+	if err != nil {
+		return nil, errors.WithDetail(ErrBadExplorerAddress, err.Error())
+	}
+
+	switch addr.(type) {
+	case *common.AddressWitnessPubKeyHash:
+		return vmutil.P2WPKHProgram(addr.ScriptAddress())
+	case *common.AddressWitnessScriptHash:
+		return vmutil.P2WSHProgram(addr.ScriptAddress())
+	}
+	return nil, ErrBadExplorerAddress
+}
+
+// POST /get-address-balance
+func (a *API) getAddressBalance(ctx context.Context, ins struct {
+	Address        string             `json:"address"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+}) Response {
+	if a.explorer == nil {
+		return NewErrorResponse(ErrExplorerDisabled)
+	}
+
+	program, err := explorerProgram(ins.Address, ins.ControlProgram)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	balances, err := a.explorer.GetBalances(program)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(balances)
+}
+
+// AddressTx is the transaction of the address with the raw transaction
+type AddressTx struct {
+	*explorer.AddressTx
+	RawTransaction *types.Tx `json:"raw_transaction,omitempty"`
+}
+
+// POST /list-address-transactions
+func (a *API) listAddressTransactions(ctx context.Context, ins struct {
+	Address        string             `json:"address"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	Detail         bool               `json:"detail"`
+	After          string             `json:"after"`
+	Limit          int                `json:"limit"`
+}) Response {
+	if a.explorer == nil {
+		return NewErrorResponse(ErrExplorerDisabled)
+	}
+
+	program, err := explorerProgram(ins.Address, ins.ControlProgram)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	txs, next, err := a.explorer.ListTransactions(program, ins.After, ins.Limit)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	result := []*AddressTx{}
+	for _, tx := range txs {
+		item := &AddressTx{AddressTx: tx}
+		if ins.Detail {
+			block, err := a.chain.GetBlockByHash(&tx.BlockHash)
+			if err != nil {
+				return NewErrorResponse(err)
+			}
+
+			if int(tx.Position) < len(block.Transactions) {
+				item.RawTransaction = block.Transactions[tx.Position]
+			}
+		}
+		result = append(result, item)
+	}
+
+	return NewSuccessResponse(struct {
+		Transactions []*AddressTx `json:"transactions"`
+		Next         string       `json:"next"`
+	}{
+		Transactions: result,
+		Next:         next,
+	})
+}
+
+// POST /list-asset-holders
+func (a *API) listAssetHolders(ctx context.Context, ins struct {
+	AssetID bc.AssetID `json:"asset_id"`
+	After   string     `json:"after"`
+	Limit   int        `json:"limit"`
+}) Response {
+	if a.explorer == nil {
+		return NewErrorResponse(ErrExplorerDisabled)
+	}
+
+	holders, next, err := a.explorer.ListHolders(ins.AssetID, ins.After, ins.Limit)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(struct {
+		Holders []*explorer.Balance `json:"holders"`
+		Next    string              `json:"next"`
+	}{
+		Holders: holders,
+		Next:    next,
+	})
+}
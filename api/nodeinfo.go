@@ -31,7 +31,7 @@ type NetInfo struct {
 
 // getNetInfo return network information
 func (a *API) getNetInfo() Response {
-	highestBlockHeight := a.chain.BestBlockHeight()
+	highestBlockHeight := a.bestBlockHeader().Height
 	if bestPeer := a.sync.BestPeer(); bestPeer != nil {
 		if bestPeer.Height > highestBlockHeight {
 			highestBlockHeight = bestPeer.Height
@@ -41,7 +41,7 @@ func (a *API) getNetInfo() Response {
 		Listening:     a.sync.IsListening(),
 		Syncing:       !a.sync.IsCaughtUp(),
 		Mining:        a.blockProposer.IsProposing(),
-		NodeXPub:      config.CommonConfig.PrivateKey().XPub().String(),
+		NodeXPub:      config.CommonConfig.ValidatorSigner().XPub().String(),
 		PeerCount:     a.sync.PeerCount(),
 		HighestHeight: highestBlockHeight,
 		NetWorkID:     a.sync.GetNetwork(),
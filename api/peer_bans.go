@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net"
+
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+)
+
+var errInvalidIP = errors.New("invalid ip address")
+
+// return the peers in the blacklist
+func (a *API) listBannedPeers() Response {
+	return NewSuccessResponse(a.sync.ListBannedPeers())
+}
+
+// ban the ip for the duration and disconnect the connected peers of it, the default
+// ban duration is used when the duration is empty
+func (a *API) banPeer(ctx context.Context, ins struct {
+	Ip       string             `json:"ip"`
+	Duration chainjson.Duration `json:"duration"`
+	Reason   string             `json:"reason"`
+}) Response {
+	if net.ParseIP(ins.Ip) == nil {
+		return NewErrorResponse(errInvalidIP)
+	}
+
+	if err := a.sync.BanPeer(ins.Ip, ins.Duration.Duration, ins.Reason); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// remove the ip from the blacklist
+func (a *API) unbanPeer(ctx context.Context, ins struct {
+	Ip string `json:"ip"`
+}) Response {
+	if err := a.sync.UnbanPeer(ins.Ip); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// return the ban scores of the misbehaving peers
+func (a *API) listPeerScores() Response {
+	return NewSuccessResponse(a.sync.ListPeerScores())
+}
@@ -18,6 +18,7 @@ import (
 	"coingod/errors"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
+	"coingod/wallet"
 )
 
 // POST /list-accounts
@@ -173,6 +174,56 @@ func (a *API) listTransactions(ctx context.Context, filter struct {
 	return NewSuccessResponse(transactions[start:end])
 }
 
+// POST /list-account-transactions
+func (a *API) listAccountTransactions(ctx context.Context, filter struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+	AssetID      string `json:"asset_id"`
+	StartHeight  uint64 `json:"start_height"`
+	EndHeight    uint64 `json:"end_height"`
+	StartTime    uint64 `json:"start_time"`
+	EndTime      uint64 `json:"end_time"`
+	Detail       bool   `json:"detail"`
+	After        string `json:"after"`
+	Limit        int    `json:"limit"`
+}) Response {
+	accountID := filter.AccountID
+	if filter.AccountAlias != "" {
+		acc, err := a.wallet.AccountMgr.FindByAlias(filter.AccountAlias)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		accountID = acc.ID
+	}
+
+	transactions, next, err := a.wallet.ListTransactions(&wallet.TxFilter{
+		AccountID:   accountID,
+		AssetID:     filter.AssetID,
+		StartHeight: filter.StartHeight,
+		EndHeight:   filter.EndHeight,
+		StartTime:   filter.StartTime,
+		EndTime:     filter.EndTime,
+		After:       filter.After,
+		Limit:       filter.Limit,
+	})
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	var result interface{} = transactions
+	if !filter.Detail {
+		result = a.wallet.GetTransactionsSummary(transactions)
+	}
+
+	return NewSuccessResponse(struct {
+		Transactions interface{} `json:"transactions"`
+		Next         string      `json:"next"`
+	}{
+		Transactions: result,
+		Next:         next,
+	})
+}
+
 // POST /get-unconfirmed-transaction
 func (a *API) getUnconfirmedTx(ctx context.Context, filter struct {
 	TxID chainjson.HexBytes `json:"tx_id"`
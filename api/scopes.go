@@ -0,0 +1,132 @@
+package api
+
+import (
+	"coingod/accesstoken"
+)
+
+// routeScopes is the scope required by each route, the routes not in the map (e.g. the access
+// token management) can only be called by the token without scope restriction.
+var routeScopes = map[string]string{
+	"/create-account":          accesstoken.ScopeWalletWrite,
+	"/update-account-alias":    accesstoken.ScopeWalletWrite,
+	"/list-accounts":           accesstoken.ScopeWalletRead,
+	"/delete-account":          accesstoken.ScopeWalletWrite,
+	"/create-account-receiver": accesstoken.ScopeWalletWrite,
+	"/list-addresses":          accesstoken.ScopeWalletRead,
+	"/validate-address":        accesstoken.ScopeWalletRead,
+	"/list-pubkeys":            accesstoken.ScopeWalletRead,
+	"/get-mining-address":      accesstoken.ScopeWalletRead,
+	"/set-mining-address":      accesstoken.ScopeWalletWrite,
+
+	"/create-asset":       accesstoken.ScopeWalletWrite,
+	"/update-asset-alias": accesstoken.ScopeWalletWrite,
+	"/get-asset":          accesstoken.ScopeWalletRead,
+	"/list-assets":        accesstoken.ScopeWalletRead,
+
+	"/create-key":         accesstoken.ScopeSign,
+	"/update-key-alias":   accesstoken.ScopeSign,
+	"/list-keys":          accesstoken.ScopeWalletRead,
+	"/delete-key":         accesstoken.ScopeSign,
+	"/reset-key-password": accesstoken.ScopeSign,
+	"/check-key-password": accesstoken.ScopeSign,
+	"/sign-message":       accesstoken.ScopeSign,
+
+	"/build-transaction":        accesstoken.ScopeWalletWrite,
+	"/build-chain-transactions": accesstoken.ScopeWalletWrite,
+	"/sign-transaction":         accesstoken.ScopeSign,
+	"/sign-transactions":        accesstoken.ScopeSign,
+
+	"/get-transaction":           accesstoken.ScopeWalletRead,
+	"/list-transactions":         accesstoken.ScopeWalletRead,
+	"/list-account-transactions": accesstoken.ScopeWalletRead,
+
+	"/create-transaction-feed": accesstoken.ScopeWalletWrite,
+	"/get-transaction-feed":    accesstoken.ScopeWalletRead,
+	"/list-transaction-feeds":  accesstoken.ScopeWalletRead,
+	"/update-transaction-feed": accesstoken.ScopeWalletWrite,
+	"/delete-transaction-feed": accesstoken.ScopeWalletWrite,
+
+	"/list-balances":            accesstoken.ScopeWalletRead,
+	"/list-unspent-outputs":     accesstoken.ScopeWalletRead,
+	"/list-account-votes":       accesstoken.ScopeWalletRead,
+	"/list-vote-utxos":          accesstoken.ScopeWalletRead,
+	"/list-account-vote-shares": accesstoken.ScopeWalletRead,
+	"/build-move-votes":         accesstoken.ScopeWalletWrite,
+
+	"/list-vote-reward-settlements": accesstoken.ScopeWalletRead,
+
+	"/decode-program": accesstoken.ScopeChainRead,
+
+	"/backup-wallet":   accesstoken.ScopeSign,
+	"/restore-wallet":  accesstoken.ScopeSign,
+	"/rescan-wallet":   accesstoken.ScopeWalletWrite,
+	"/wallet-info":     accesstoken.ScopeWalletRead,
+	"/recovery-wallet": accesstoken.ScopeSign,
+
+	"/create-contract":       accesstoken.ScopeWalletWrite,
+	"/update-contract-alias": accesstoken.ScopeWalletWrite,
+	"/get-contract":          accesstoken.ScopeWalletRead,
+	"/list-contracts":        accesstoken.ScopeWalletRead,
+
+	"/submit-transaction":             accesstoken.ScopeChainWrite,
+	"/submit-transactions":            accesstoken.ScopeChainWrite,
+	"/estimate-transaction-gas":       accesstoken.ScopeChainRead,
+	"/estimate-chain-transaction-gas": accesstoken.ScopeChainRead,
+
+	"/get-unconfirmed-transaction":   accesstoken.ScopeChainRead,
+	"/list-unconfirmed-transactions": accesstoken.ScopeChainRead,
+	"/decode-raw-transaction":        accesstoken.ScopeChainRead,
+	"/debug-transaction":             accesstoken.ScopeChainRead,
+
+	"/get-block":        accesstoken.ScopeChainRead,
+	"/get-raw-block":    accesstoken.ScopeChainRead,
+	"/get-block-hash":   accesstoken.ScopeChainRead,
+	"/get-block-header": accesstoken.ScopeChainRead,
+	"/get-block-count":  accesstoken.ScopeChainRead,
+
+	"/is-mining":  accesstoken.ScopeChainRead,
+	"/set-mining": accesstoken.ScopePeerAdmin,
+
+	"/verify-message": accesstoken.ScopeChainRead,
+
+	"/gas-rate":     accesstoken.ScopeChainRead,
+	"/net-info":     accesstoken.ScopeChainRead,
+	"/chain-status": accesstoken.ScopeChainRead,
+
+	"/list-peers":           accesstoken.ScopePeerAdmin,
+	"/disconnect-peer":      accesstoken.ScopePeerAdmin,
+	"/connect-peer":         accesstoken.ScopePeerAdmin,
+	"/list-banned-peers":    accesstoken.ScopePeerAdmin,
+	"/ban-peer":             accesstoken.ScopePeerAdmin,
+	"/unban-peer":           accesstoken.ScopePeerAdmin,
+	"/list-peer-scores":     accesstoken.ScopePeerAdmin,
+	"/list-allowed-peers":   accesstoken.ScopePeerAdmin,
+	"/reload-allowed-peers": accesstoken.ScopePeerAdmin,
+
+	"/get-merkle-proof":       accesstoken.ScopeChainRead,
+	"/get-vote-result":        accesstoken.ScopeChainRead,
+	"/list-slashing-evidence": accesstoken.ScopeChainRead,
+	"/list-validators":        accesstoken.ScopeChainRead,
+	"/get-validator-stats":    accesstoken.ScopeChainRead,
+	"/get-validator-votes":    accesstoken.ScopeChainRead,
+
+	"/get-address-balance":       accesstoken.ScopeChainRead,
+	"/list-address-transactions": accesstoken.ScopeChainRead,
+	"/list-asset-holders":        accesstoken.ScopeChainRead,
+
+	// the node requests the url of the callback, so the register is left to the token without
+	// scope restriction
+	"/get-contract-instance":    accesstoken.ScopeWalletRead,
+	"/create-contract-instance": accesstoken.ScopeWalletWrite,
+	"/remove-contract-instance": accesstoken.ScopeWalletWrite,
+	"/list-contract-callbacks":  accesstoken.ScopeWalletRead,
+	"/remove-contract-callback": accesstoken.ScopeWalletWrite,
+
+	"/websocket-subscribe": accesstoken.ScopeChainRead,
+	"/metrics":             accesstoken.ScopeChainRead,
+}
+
+// routeScope return the scope required by the request path
+func routeScope(path string) string {
+	return routeScopes[path]
+}
@@ -186,6 +186,19 @@ func (a *API) buildChainTxs(ctx context.Context, buildReqs *BuildRequest) Respon
 	return NewSuccessResponse(tmpls)
 }
 
+// finalizeTx submit the transaction to the transaction pool, the light node can't validate the
+// transaction without the UTXO set, it sends the transaction to the peers directly
+func (a *API) finalizeTx(ctx context.Context, tx *types.Tx) error {
+	if a.lightChain == nil {
+		return txbuilder.FinalizeTx(ctx, a.chain, tx)
+	}
+
+	if err := txbuilder.CheckTx(tx); err != nil {
+		return err
+	}
+	return a.sync.BroadcastTx(tx)
+}
+
 type submitTxResp struct {
 	TxID *bc.Hash `json:"tx_id"`
 }
@@ -194,7 +207,7 @@ type submitTxResp struct {
 func (a *API) submit(ctx context.Context, ins struct {
 	Tx types.Tx `json:"raw_transaction"`
 }) Response {
-	if err := txbuilder.FinalizeTx(ctx, a.chain, &ins.Tx); err != nil {
+	if err := a.finalizeTx(ctx, &ins.Tx); err != nil {
 		return NewErrorResponse(err)
 	}
 
@@ -212,7 +225,7 @@ func (a *API) submitTxs(ctx context.Context, ins struct {
 }) Response {
 	txHashs := []*bc.Hash{}
 	for i := range ins.Tx {
-		if err := txbuilder.FinalizeTx(ctx, a.chain, &ins.Tx[i]); err != nil {
+		if err := a.finalizeTx(ctx, &ins.Tx[i]); err != nil {
 			return NewErrorResponse(err)
 		}
 		log.WithField("tx_id", ins.Tx[i].ID.String()).Info("submit single tx")
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+
+	"coingod/blockchain/query"
+	"coingod/blockchain/txfeed"
+)
+
+type txFeedReq struct {
+	Alias  string `json:"alias"`
+	Filter string `json:"filter,omitempty"`
+}
+
+// POST /create-transaction-feed
+func (a *API) createTxFeed(ctx context.Context, in txFeedReq) Response {
+	feed, err := a.wallet.TxFeedTracker.Create(in.Alias, in.Filter)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(feed)
+}
+
+// POST /get-transaction-feed
+func (a *API) getTxFeed(ctx context.Context, in struct {
+	Alias string `json:"alias"`
+	After string `json:"after"`
+	Limit int    `json:"limit"`
+}) Response {
+	feed, err := a.wallet.TxFeedTracker.Get(in.Alias)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	txs, next, err := a.wallet.TxFeedTracker.Transactions(in.Alias, in.After, in.Limit)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(struct {
+		*txfeed.TxFeed
+		Transactions []*query.AnnotatedTx `json:"transactions"`
+		Next         string               `json:"next"`
+	}{
+		TxFeed:       feed,
+		Transactions: txs,
+		Next:         next,
+	})
+}
+
+// POST /list-transaction-feeds
+func (a *API) listTxFeeds(ctx context.Context) Response {
+	return NewSuccessResponse(a.wallet.TxFeedTracker.List())
+}
+
+// POST /update-transaction-feed
+func (a *API) updateTxFeed(ctx context.Context, in txFeedReq) Response {
+	feed, err := a.wallet.TxFeedTracker.Update(in.Alias, in.Filter)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(feed)
+}
+
+// POST /delete-transaction-feed
+func (a *API) deleteTxFeed(ctx context.Context, in txFeedReq) Response {
+	if err := a.wallet.TxFeedTracker.Delete(in.Alias); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(nil)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"sort"
+
+	"coingod/validator"
+)
+
+// defaultStatsEpochs is the num of the latest epochs returned when it's not specified
+const defaultStatsEpochs = 10
+
+// ValidatorStats is the performance of the validator
+type ValidatorStats struct {
+	PubKey    string `json:"pub_key"`
+	Effective bool   `json:"effective"`
+	Order     int    `json:"order"`
+	VoteNum   uint64 `json:"vote_num"`
+	validator.Record
+	ProposalRate      float64                  `json:"proposal_rate"`
+	ParticipationRate float64                  `json:"participation_rate"`
+	Epochs            []*validator.EpochRecord `json:"epochs,omitempty"`
+}
+
+func newValidatorStats(stats *validator.Stats) *ValidatorStats {
+	return &ValidatorStats{
+		PubKey:            stats.PubKey,
+		Order:             -1,
+		Record:            stats.Record,
+		ProposalRate:      stats.ProposalRate(),
+		ParticipationRate: stats.ParticipationRate(),
+	}
+}
+
+// listValidators return the effective validators of the best chain and the validators
+// which have been tracked before, the effective validators come first by order
+func (a *API) listValidators() Response {
+	checkpoint, err := a.chain.PrevCheckpointByPrevHash(a.chain.BestBlockHash())
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	allStats, err := a.validatorStats.ListStats()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	effective := checkpoint.EffectiveValidators()
+	result := []*ValidatorStats{}
+	for _, v := range effective {
+		stats, err := a.validatorStats.Stats(v.PubKey)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		item := newValidatorStats(stats)
+		item.Effective, item.Order, item.VoteNum = true, v.Order, v.VoteNum
+		result = append(result, item)
+	}
+
+	for _, stats := range allStats {
+		if _, ok := effective[stats.PubKey]; !ok {
+			result = append(result, newValidatorStats(stats))
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Effective != result[j].Effective {
+			return result[i].Effective
+		}
+		if result[i].Effective {
+			return result[i].Order < result[j].Order
+		}
+		return result[i].PubKey < result[j].PubKey
+	})
+	return NewSuccessResponse(result)
+}
+
+// getValidatorStats return the total and the latest epochs performance of the validator
+func (a *API) getValidatorStats(ins struct {
+	PubKey string `json:"pub_key"`
+	Epochs int    `json:"epochs"`
+}) Response {
+	if ins.Epochs <= 0 {
+		ins.Epochs = defaultStatsEpochs
+	}
+
+	stats, err := a.validatorStats.Stats(ins.PubKey)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	result := newValidatorStats(stats)
+	result.Epochs = stats.LatestEpochs(ins.Epochs)
+	checkpoint, err := a.chain.PrevCheckpointByPrevHash(a.chain.BestBlockHash())
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	if v, ok := checkpoint.EffectiveValidators()[ins.PubKey]; ok {
+		result.Effective, result.Order, result.VoteNum = true, v.Order, v.VoteNum
+	}
+	return NewSuccessResponse(result)
+}
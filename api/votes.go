@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sort"
+
+	"coingod/consensus"
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+	"coingod/net/http/reqid"
+)
+
+// vote error
+var (
+	ErrNoVotes            = errors.New("no votes of the account for the validator")
+	ErrBadMoveVote        = errors.New("bad vote movement")
+	ErrVoteRewardDisabled = errors.New("vote reward distribution is disabled")
+)
+
+// defaultMoveVoteFee is the fee paid from the moved votes when it's not specified
+const defaultMoveVoteFee = 20000000
+
+// VoteUTXO is the vote output of the account with the lock status
+type VoteUTXO struct {
+	OutputID       string `json:"id"`
+	AccountID      string `json:"account_id"`
+	AccountAlias   string `json:"account_alias"`
+	Vote           string `json:"vote"`
+	Amount         uint64 `json:"amount"`
+	Address        string `json:"address"`
+	UnlockHeight   uint64 `json:"unlock_height"`
+	Locked         bool   `json:"locked"`
+	BlocksToUnlock uint64 `json:"blocks_to_unlock"`
+}
+
+// VoteShare is the votes of the account for one validator and the estimated reward share
+type VoteShare struct {
+	Vote             string  `json:"vote"`
+	VoteNum          uint64  `json:"vote_number"`
+	LockedVoteNum    uint64  `json:"locked_vote_number"`
+	ValidatorVoteNum uint64  `json:"validator_vote_number"`
+	Share            float64 `json:"share"`
+	ValidatorRewards uint64  `json:"validator_rewards"`
+	EstimatedReward  uint64  `json:"estimated_reward"`
+}
+
+// ValidatorVotes is the votes of the validator at the checkpoint
+type ValidatorVotes struct {
+	Height     uint64 `json:"height"`
+	Hash       string `json:"hash"`
+	VoteNum    uint64 `json:"vote_number"`
+	TotalVotes uint64 `json:"total_vote_number"`
+}
+
+func (a *API) findAccountID(accountID, accountAlias string) (string, error) {
+	if accountAlias == "" {
+		return accountID, nil
+	}
+
+	acc, err := a.wallet.AccountMgr.FindByAlias(accountAlias)
+	if err != nil {
+		return "", err
+	}
+	return acc.ID, nil
+}
+
+func (a *API) voteUTXOs(accountID string, vote string) []*VoteUTXO {
+	bestHeight := a.bestBlockHeader().Height
+	result := []*VoteUTXO{}
+	for _, utxo := range a.wallet.GetAccountUtxos(accountID, "", false, false, true) {
+		if utxo.AssetID != *consensus.CGAssetID {
+			continue
+		}
+
+		if pubKey := hex.EncodeToString(utxo.Vote); vote == "" || pubKey == vote {
+			voteUTXO := &VoteUTXO{
+				OutputID:     utxo.OutputID.String(),
+				AccountID:    utxo.AccountID,
+				AccountAlias: a.wallet.AccountMgr.GetAliasByID(utxo.AccountID),
+				Vote:         pubKey,
+				Amount:       utxo.Amount,
+				Address:      utxo.Address,
+				UnlockHeight: utxo.ValidHeight,
+				Locked:       utxo.ValidHeight > bestHeight,
+			}
+			if voteUTXO.Locked {
+				voteUTXO.BlocksToUnlock = utxo.ValidHeight - bestHeight
+			}
+			result = append(result, voteUTXO)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].UnlockHeight < result[j].UnlockHeight })
+	return result
+}
+
+// POST /list-vote-utxos
+func (a *API) listVoteUTXOs(ins struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+	Vote         string `json:"vote"`
+}) Response {
+	accountID, err := a.findAccountID(ins.AccountID, ins.AccountAlias)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(a.voteUTXOs(accountID, ins.Vote))
+}
+
+// POST /list-account-vote-shares
+// the estimated reward is the share of the validator rewards in the latest settled epoch,
+// it's only a reference since the validator decides how to distribute the rewards
+func (a *API) listAccountVoteShares(ins struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+}) Response {
+	accountID, err := a.findAccountID(ins.AccountID, ins.AccountAlias)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	checkpoint, err := a.chain.PrevCheckpointByPrevHash(a.chain.BestBlockHash())
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	shares := make(map[string]*VoteShare)
+	result := []*VoteShare{}
+	for _, utxo := range a.voteUTXOs(accountID, "") {
+		share, ok := shares[utxo.Vote]
+		if !ok {
+			share = &VoteShare{Vote: utxo.Vote, ValidatorVoteNum: checkpoint.Votes[utxo.Vote]}
+			shares[utxo.Vote] = share
+			result = append(result, share)
+		}
+
+		share.VoteNum += utxo.Amount
+		if utxo.Locked {
+			share.LockedVoteNum += utxo.Amount
+		}
+	}
+
+	for _, share := range result {
+		if share.ValidatorVoteNum != 0 {
+			share.Share = float64(share.VoteNum) / float64(share.ValidatorVoteNum)
+		}
+
+		stats, err := a.validatorStats.Stats(share.Vote)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		for _, epoch := range stats.LatestEpochs(2) {
+			// the rewards of the running epoch are settled at its checkpoint
+			if epoch.Rewards != 0 {
+				share.ValidatorRewards = epoch.Rewards
+				share.EstimatedReward = uint64(float64(epoch.Rewards) * share.Share)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Vote < result[j].Vote })
+	return NewSuccessResponse(result)
+}
+
+// POST /get-validator-votes
+// return the votes of the validator at the latest checkpoints, the newest comes first
+func (a *API) getValidatorVotes(ins struct {
+	PubKey string `json:"pub_key"`
+	Epochs uint64 `json:"epochs"`
+}) Response {
+	if ins.Epochs == 0 {
+		ins.Epochs = defaultStatsEpochs
+	}
+
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	bestHeight := a.chain.BestBlockHeight()
+	result := []*ValidatorVotes{}
+	for i, height := uint64(0), bestHeight-bestHeight%blocksOfEpoch; i < ins.Epochs; i, height = i+1, height-blocksOfEpoch {
+		header, err := a.chain.GetHeaderByHeight(height)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		hash := header.Hash()
+		checkpoint, err := a.chain.PrevCheckpointByPrevHash(&hash)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		votes := &ValidatorVotes{Height: height, Hash: hash.String(), VoteNum: checkpoint.Votes[ins.PubKey]}
+		for _, voteNum := range checkpoint.Votes {
+			votes.TotalVotes += voteNum
+		}
+
+		result = append(result, votes)
+		if height == 0 {
+			break
+		}
+	}
+	return NewSuccessResponse(result)
+}
+
+// POST /build-move-votes
+// build the transaction which vetoes the votes of the validator and votes the other one
+// by the vetoed coins, the fee is paid from the moved votes. All the unlocked votes are
+// moved when the amount is zero, otherwise the rest of the vetoed outputs turn into
+// spendable coins.
+func (a *API) buildMoveVotes(ctx context.Context, ins struct {
+	AccountID    string             `json:"account_id"`
+	AccountAlias string             `json:"account_alias"`
+	From         chainjson.HexBytes `json:"from"`
+	To           chainjson.HexBytes `json:"to"`
+	Amount       uint64             `json:"amount"`
+	Fee          uint64             `json:"fee"`
+	TTL          chainjson.Duration `json:"ttl"`
+}) Response {
+	accountID, err := a.findAccountID(ins.AccountID, ins.AccountAlias)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	if len(ins.From) == 0 || len(ins.To) == 0 || bytes.Equal(ins.From, ins.To) {
+		return NewErrorResponse(errors.WithDetail(ErrBadMoveVote, "from and to must be different validators"))
+	}
+
+	if ins.Fee == 0 {
+		ins.Fee = defaultMoveVoteFee
+	}
+
+	address, unlocked := "", uint64(0)
+	for _, utxo := range a.voteUTXOs(accountID, hex.EncodeToString(ins.From)) {
+		if !utxo.Locked {
+			address = utxo.Address
+			unlocked += utxo.Amount
+		}
+	}
+
+	if unlocked == 0 {
+		return NewErrorResponse(ErrNoVotes)
+	}
+
+	if ins.Amount == 0 {
+		ins.Amount = unlocked
+	}
+
+	if ins.Amount > unlocked {
+		return NewErrorResponse(errors.WithDetailf(ErrBadMoveVote, "amount %d exceeds the unlocked votes %d", ins.Amount, unlocked))
+	}
+
+	if ins.Amount <= ins.Fee {
+		return NewErrorResponse(errors.WithDetailf(ErrBadMoveVote, "amount %d must be greater than the fee %d", ins.Amount, ins.Fee))
+	}
+
+	req := &BuildRequest{
+		Actions: []map[string]interface{}{
+			{
+				"type":       "veto",
+				"account_id": accountID,
+				"asset_id":   consensus.CGAssetID.String(),
+				"amount":     ins.Amount,
+				"vote":       hex.EncodeToString(ins.From),
+			},
+			{
+				"type":     "vote_output",
+				"asset_id": consensus.CGAssetID.String(),
+				"amount":   ins.Amount - ins.Fee,
+				"address":  address,
+				"vote":     hex.EncodeToString(ins.To),
+			},
+		},
+		TTL: ins.TTL,
+	}
+
+	tpl, err := a.buildSingle(reqid.NewSubContext(ctx, reqid.New()), req)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
+
+// POST /list-vote-reward-settlements
+// return the voter reward settlements of the validator with the payout progress
+func (a *API) listVoteRewardSettlements() Response {
+	if a.voteReward == nil {
+		return NewErrorResponse(ErrVoteRewardDisabled)
+	}
+
+	settlements, err := a.voteReward.ListSettlements()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(settlements)
+}
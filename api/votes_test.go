@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"coingod/account"
+	"coingod/consensus"
+	"coingod/database"
+	dbm "coingod/database/leveldb"
+	chainjson "coingod/encoding/json"
+	"coingod/event"
+	"coingod/protocol"
+	"coingod/protocol/bc"
+	"coingod/wallet"
+)
+
+var (
+	testVoteFrom = []byte{0x01, 0x02}
+	testVoteTo   = []byte{0x03, 0x04}
+)
+
+// newVoteTestAPI return the api on the genesis chain saved in the dir, the account has the
+// unlocked vote of 100000000 and the locked vote of 50000000 for the validator testVoteFrom
+func newVoteTestAPI(t *testing.T, dir string) *API {
+	dispatcher := event.NewDispatcher()
+	store := database.NewStore(dbm.NewDB("testdb", "leveldb", dir))
+	chain, err := protocol.NewChain(store, protocol.NewTxPool(store, dispatcher), dispatcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walletDB := dbm.NewMemDB()
+	utxos := []*account.UTXO{
+		{OutputID: bc.Hash{V0: 1}, AssetID: *consensus.CGAssetID, Amount: 50000000, AccountID: "acc", Address: "addr", Vote: testVoteFrom, ValidHeight: 10},
+		{OutputID: bc.Hash{V0: 2}, AssetID: *consensus.CGAssetID, Amount: 100000000, AccountID: "acc", Address: "addr", Vote: testVoteFrom},
+		{OutputID: bc.Hash{V0: 3}, AssetID: *consensus.CGAssetID, Amount: 100000000, AccountID: "acc", Address: "addr"},
+	}
+	for _, utxo := range utxos {
+		data, err := json.Marshal(utxo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		walletDB.Set(account.StandardUTXOKey(utxo.OutputID), data)
+	}
+
+	return &API{
+		chain:  chain,
+		wallet: &wallet.Wallet{DB: walletDB, AccountMgr: account.NewManager(walletDB, chain)},
+	}
+}
+
+func TestListVoteUTXOs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newVoteTestAPI(t, dir)
+	resp := a.listVoteUTXOs(struct {
+		AccountID    string `json:"account_id"`
+		AccountAlias string `json:"account_alias"`
+		Vote         string `json:"vote"`
+	}{AccountID: "acc"})
+	if resp.Status != SUCCESS {
+		t.Fatalf("got response %v", resp)
+	}
+
+	utxos := resp.Data.([]*VoteUTXO)
+	if len(utxos) != 2 {
+		t.Fatalf("got %d vote utxos, want 2", len(utxos))
+	}
+
+	if utxos[0].Locked || utxos[0].Amount != 100000000 || utxos[0].Vote != "0102" {
+		t.Errorf("got the first vote utxo %v, want the unlocked one", utxos[0])
+	}
+
+	if !utxos[1].Locked || utxos[1].BlocksToUnlock != 10 {
+		t.Errorf("got the second vote utxo %v, want locked for 10 blocks", utxos[1])
+	}
+}
+
+func TestBuildMoveVotesCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newVoteTestAPI(t, dir)
+	cases := []struct {
+		desc     string
+		from     []byte
+		to       []byte
+		amount   uint64
+		fee      uint64
+		wantCode string
+	}{
+		{desc: "move to the same validator", from: testVoteFrom, to: testVoteFrom, wantCode: "CG716"},
+		{desc: "no votes for the validator", from: testVoteTo, to: testVoteFrom, wantCode: "CG715"},
+		{desc: "amount exceeds the unlocked votes", from: testVoteFrom, to: testVoteTo, amount: 150000000, wantCode: "CG716"},
+		{desc: "amount doesn't cover the fee", from: testVoteFrom, to: testVoteTo, amount: 1000, fee: 1000, wantCode: "CG716"},
+		{desc: "unlocked votes don't cover the default fee", from: testVoteFrom, to: testVoteTo, fee: 100000000, wantCode: "CG716"},
+	}
+
+	for i, c := range cases {
+		ins := struct {
+			AccountID    string             `json:"account_id"`
+			AccountAlias string             `json:"account_alias"`
+			From         chainjson.HexBytes `json:"from"`
+			To           chainjson.HexBytes `json:"to"`
+			Amount       uint64             `json:"amount"`
+			Fee          uint64             `json:"fee"`
+			TTL          chainjson.Duration `json:"ttl"`
+		}{AccountID: "acc", From: c.from, To: c.to, Amount: c.amount, Fee: c.fee}
+
+		if resp := a.buildMoveVotes(context.Background(), ins); resp.Status != FAIL || resp.Code != c.wantCode {
+			t.Errorf("case %d(%s): got response %v, want code %s", i, c.desc, resp, c.wantCode)
+		}
+	}
+}
+
+func TestGetValidatorVotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newVoteTestAPI(t, dir)
+	resp := a.getValidatorVotes(struct {
+		PubKey string `json:"pub_key"`
+		Epochs uint64 `json:"epochs"`
+	}{PubKey: "0102", Epochs: 3})
+	if resp.Status != SUCCESS {
+		t.Fatalf("got response %v", resp)
+	}
+
+	// the genesis is the only checkpoint of the chain
+	votes := resp.Data.([]*ValidatorVotes)
+	if len(votes) != 1 || votes[0].Height != 0 || votes[0].VoteNum != 0 {
+		t.Errorf("got validator votes %v", votes)
+	}
+}
+
+func TestListVoteRewardSettlementsDisabled(t *testing.T) {
+	a := &API{}
+	if resp := a.listVoteRewardSettlements(); resp.Status != FAIL || resp.Code != "CG717" {
+		t.Errorf("got response %v, want the disabled error", resp)
+	}
+}
@@ -23,6 +23,10 @@ type WalletImage struct {
 }
 
 func (a *API) restoreWalletImage(ctx context.Context, image WalletImage) Response {
+	if err := a.wallet.CheckFullHistory(); err != nil {
+		return NewErrorResponse(err)
+	}
+
 	if err := a.wallet.Hsm.Restore(image.KeyImages); err != nil {
 		return NewErrorResponse(errors.Wrap(err, "restore key images"))
 	}
@@ -86,21 +90,27 @@ func (a *API) rescanWallet() Response {
 type WalletInfo struct {
 	BestBlockHeight uint64 `json:"best_block_height"`
 	WalletHeight    uint64 `json:"wallet_height"`
+	BaseBlockHeight uint64 `json:"base_block_height"`
 }
 
 func (a *API) getWalletInfo() Response {
-	bestBlockHeight := a.chain.BestBlockHeight()
+	bestBlockHeight := a.bestBlockHeader().Height
 	walletStatus := a.wallet.GetWalletStatusInfo()
 
 	return NewSuccessResponse(&WalletInfo{
 		BestBlockHeight: bestBlockHeight,
 		WalletHeight:    walletStatus.WorkHeight,
+		BaseBlockHeight: a.baseBlockHeight(),
 	})
 }
 
 func (a *API) recoveryFromRootXPubs(ctx context.Context, in struct {
 	XPubs []chainkd.XPub `json:"xpubs"`
 }) Response {
+	if err := a.wallet.CheckFullHistory(); err != nil {
+		return NewErrorResponse(err)
+	}
+
 	if err := a.wallet.RecoveryMgr.AcctResurrect(in.XPubs); err != nil {
 		return NewErrorResponse(err)
 	}
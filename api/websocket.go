@@ -6,6 +6,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"coingod/accesstoken"
+	"coingod/net/http/authn"
 	"coingod/net/websocket"
 )
 
@@ -17,7 +19,8 @@ var timeZeroVal time.Time
 func (a *API) websocketHandler(w http.ResponseWriter, r *http.Request) {
 	log.WithField("remoteAddress", r.RemoteAddr).Info("New websocket client")
 
-	client, err := websocket.NewWebsocketClient(w, r, a.notificationMgr)
+	walletRead := authn.HasScope(r.Context(), accesstoken.ScopeWalletRead)
+	client, err := websocket.NewWebsocketClient(w, r, a.notificationMgr, walletRead)
 	if err != nil {
 		log.WithField("error", err).Error("Failed to new websocket client")
 		http.Error(w, "400 Bad Request.", http.StatusBadRequest)
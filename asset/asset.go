@@ -17,7 +17,6 @@ import (
 	dbm "coingod/database/leveldb"
 	chainjson "coingod/encoding/json"
 	"coingod/errors"
-	"coingod/protocol"
 	"coingod/protocol/bc"
 	"coingod/protocol/vm/vmutil"
 )
@@ -77,8 +76,13 @@ var (
 	ErrNullAlias      = errors.New("null asset alias")
 )
 
+// Chain is the block source of the asset registry, it's the light chain in the light mode
+type Chain interface {
+	BestBlockHeight() uint64
+}
+
 //NewRegistry create new registry
-func NewRegistry(db dbm.DB, chain *protocol.Chain) *Registry {
+func NewRegistry(db dbm.DB, chain Chain) *Registry {
 	initNativeAsset()
 	return &Registry{
 		db:         db,
@@ -91,7 +95,7 @@ func NewRegistry(db dbm.DB, chain *protocol.Chain) *Registry {
 // Registry tracks and stores all known assets on a blockchain.
 type Registry struct {
 	db    dbm.DB
-	chain *protocol.Chain
+	chain Chain
 
 	cacheMu    sync.Mutex
 	cache      *lru.Cache
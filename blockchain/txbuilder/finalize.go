@@ -30,6 +30,26 @@ var (
 // assembles a fully signed tx, and stores the effects of
 // its changes on the UTXO set.
 func FinalizeTx(ctx context.Context, c *protocol.Chain, tx *types.Tx) error {
+	if err := CheckTx(tx); err != nil {
+		return err
+	}
+
+	isOrphan, err := c.ValidateTx(tx)
+	if errors.Root(err) == protocol.ErrBadTx {
+		return errors.Sub(ErrRejected, err)
+	}
+	if err != nil {
+		return errors.WithDetail(err, "tx rejected: "+err.Error())
+	}
+	if isOrphan {
+		return ErrOrphanTx
+	}
+	return nil
+}
+
+// CheckTx does the local checks of the transaction which don't need the UTXO set, the light
+// node relays the checked transaction to the peers for the validation
+func CheckTx(tx *types.Tx) error {
 	if tx.Fee() > cfg.CommonConfig.Wallet.MaxTxFee {
 		return ErrExtTxFee
 	}
@@ -45,17 +65,6 @@ func FinalizeTx(ctx context.Context, c *protocol.Chain, tx *types.Tx) error {
 	}
 	tx.TxData.SerializedSize = uint64(len(data) / 2)
 	tx.Tx.SerializedSize = uint64(len(data) / 2)
-
-	isOrphan, err := c.ValidateTx(tx)
-	if errors.Root(err) == protocol.ErrBadTx {
-		return errors.Sub(ErrRejected, err)
-	}
-	if err != nil {
-		return errors.WithDetail(err, "tx rejected: "+err.Error())
-	}
-	if isOrphan {
-		return ErrOrphanTx
-	}
 	return nil
 }
 
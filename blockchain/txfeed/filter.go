@@ -0,0 +1,206 @@
+package txfeed
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"coingod/blockchain/query"
+	"coingod/errors"
+)
+
+// the keys of condition supported by the txfeed filter
+const (
+	keyAccountID    = "account_id"
+	keyAccountAlias = "account_alias"
+	keyAssetID      = "asset_id"
+	keyAddress      = "address"
+	keyAmount       = "amount"
+	keyInputType    = "input_type"
+	keyOutputType   = "output_type"
+)
+
+var (
+	// ErrBadFilter means the filter of txfeed can't be parsed
+	ErrBadFilter = errors.New("invalid txfeed filter")
+
+	conditionSplitter = regexp.MustCompile(`(?i)\s+AND\s+`)
+	conditionPattern  = regexp.MustCompile(`^([a-z_]+)\s*(>=|<=|!=|=|>|<)\s*(.+)$`)
+
+	stringKeys = map[string]bool{
+		keyAccountID:    true,
+		keyAccountAlias: true,
+		keyAssetID:      true,
+		keyAddress:      true,
+		keyInputType:    true,
+		keyOutputType:   true,
+	}
+)
+
+// Condition is a comparison between the field of transaction input/output and the value,
+// e.g. amount>=100 or asset_id='ffff...'
+type Condition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+
+	amount uint64
+}
+
+// Filter is a set of conditions joined by AND, a transaction matches the filter when
+// any one of its inputs or outputs meets all the conditions
+type Filter struct {
+	Conditions []*Condition `json:"conditions"`
+}
+
+// ParseFilter parse the filter expression like "account_alias='alice' AND amount>=100 AND output_type='control'"
+func ParseFilter(expr string) (*Filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.WithDetail(ErrBadFilter, "empty filter")
+	}
+
+	filter := &Filter{}
+	for _, part := range conditionSplitter.Split(expr, -1) {
+		cond, err := parseCondition(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		filter.Conditions = append(filter.Conditions, cond)
+	}
+	return filter, nil
+}
+
+func parseCondition(expr string) (*Condition, error) {
+	matches := conditionPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return nil, errors.WithDetailf(ErrBadFilter, "can't parse condition %s", expr)
+	}
+
+	cond := &Condition{Key: matches[1], Op: matches[2], Value: strings.TrimSpace(matches[3])}
+	if cond.Key == keyAmount {
+		amount, err := strconv.ParseUint(cond.Value, 10, 64)
+		if err != nil {
+			return nil, errors.WithDetailf(ErrBadFilter, "amount %s is not a number", cond.Value)
+		}
+
+		cond.amount = amount
+		return cond, nil
+	}
+
+	if !stringKeys[cond.Key] {
+		return nil, errors.WithDetailf(ErrBadFilter, "unsupported key %s", cond.Key)
+	}
+
+	if cond.Op != "=" && cond.Op != "!=" {
+		return nil, errors.WithDetailf(ErrBadFilter, "operator %s is not supported by %s", cond.Op, cond.Key)
+	}
+
+	if len(cond.Value) >= 2 && cond.Value[0] == '\'' && cond.Value[len(cond.Value)-1] == '\'' {
+		cond.Value = cond.Value[1 : len(cond.Value)-1]
+	}
+	return cond, nil
+}
+
+// entry is the common fields of the annotated input and output which can be filtered
+type entry struct {
+	isInput      bool
+	typ          string
+	accountID    string
+	accountAlias string
+	assetID      string
+	address      string
+	amount       uint64
+}
+
+// Match return whether the transaction has an input or output meets all the conditions
+func (f *Filter) Match(tx *query.AnnotatedTx) bool {
+	for _, input := range tx.Inputs {
+		if f.matchEntry(&entry{
+			isInput:      true,
+			typ:          input.Type,
+			accountID:    input.AccountID,
+			accountAlias: input.AccountAlias,
+			assetID:      input.AssetID.String(),
+			address:      input.Address,
+			amount:       input.Amount,
+		}) {
+			return true
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if f.matchEntry(&entry{
+			typ:          output.Type,
+			accountID:    output.AccountID,
+			accountAlias: output.AccountAlias,
+			assetID:      output.AssetID.String(),
+			address:      output.Address,
+			amount:       output.Amount,
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchEntry(e *entry) bool {
+	for _, cond := range f.Conditions {
+		if !cond.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Condition) match(e *entry) bool {
+	var field string
+	switch c.Key {
+	case keyAmount:
+		return compareAmount(e.amount, c.Op, c.amount)
+	case keyAccountID:
+		field = e.accountID
+	case keyAccountAlias:
+		field = e.accountAlias
+	case keyAssetID:
+		field = e.assetID
+	case keyAddress:
+		field = e.address
+	case keyInputType:
+		if !e.isInput {
+			return false
+		}
+		field = e.typ
+	case keyOutputType:
+		if e.isInput {
+			return false
+		}
+		field = e.typ
+	default:
+		return false
+	}
+
+	if c.Op == "!=" {
+		return field != c.Value
+	}
+	return field == c.Value
+}
+
+func compareAmount(amount uint64, op string, value uint64) bool {
+	switch op {
+	case "=":
+		return amount == value
+	case "!=":
+		return amount != value
+	case ">":
+		return amount > value
+	case ">=":
+		return amount >= value
+	case "<":
+		return amount < value
+	case "<=":
+		return amount <= value
+	}
+	return false
+}
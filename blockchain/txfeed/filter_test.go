@@ -0,0 +1,75 @@
+package txfeed
+
+import (
+	"testing"
+
+	"coingod/blockchain/query"
+	"coingod/protocol/bc"
+)
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		filter  string
+		wantLen int
+		wantErr bool
+	}{
+		{filter: "account_alias='alice'", wantLen: 1},
+		{filter: "account_alias='alice' AND amount>=100", wantLen: 2},
+		{filter: "asset_id='ffff' and output_type='control' AND amount<5", wantLen: 3},
+		{filter: "", wantErr: true},
+		{filter: "amount>=abc", wantErr: true},
+		{filter: "account_alias>'alice'", wantErr: true},
+		{filter: "unknown='alice'", wantErr: true},
+		{filter: "account_alias", wantErr: true},
+	}
+
+	for i, c := range cases {
+		filter, err := ParseFilter(c.filter)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("case %d: got err %v, want err %v", i, err, c.wantErr)
+		}
+
+		if err == nil && len(filter.Conditions) != c.wantLen {
+			t.Errorf("case %d: got %d conditions, want %d", i, len(filter.Conditions), c.wantLen)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	assetID := bc.AssetID{V0: 1}
+	tx := &query.AnnotatedTx{
+		Inputs: []*query.AnnotatedInput{
+			{Type: "spend", AccountAlias: "alice", AssetID: assetID, Amount: 300},
+		},
+		Outputs: []*query.AnnotatedOutput{
+			{Type: "control", AccountAlias: "bob", AssetID: assetID, Amount: 200},
+			{Type: "control", AccountAlias: "alice", AssetID: assetID, Amount: 100},
+		},
+	}
+
+	cases := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: "account_alias='alice'", want: true},
+		{filter: "account_alias='carol'", want: false},
+		{filter: "account_alias='bob' AND amount=200", want: true},
+		{filter: "account_alias='bob' AND amount>200", want: false},
+		{filter: "account_alias='alice' AND input_type='spend'", want: true},
+		{filter: "account_alias='bob' AND input_type='spend'", want: false},
+		{filter: "output_type='control' AND amount<=100", want: true},
+		{filter: "asset_id='" + assetID.String() + "' AND amount>=300", want: true},
+		{filter: "account_alias!='alice' AND account_alias!='bob'", want: false},
+	}
+
+	for i, c := range cases {
+		filter, err := ParseFilter(c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := filter.Match(tx); got != c.want {
+			t.Errorf("case %d(%s): got %v, want %v", i, c.filter, got, c.want)
+		}
+	}
+}
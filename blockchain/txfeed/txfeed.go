@@ -0,0 +1,327 @@
+package txfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"coingod/blockchain/query"
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/event"
+)
+
+const (
+	logModule = "txfeed"
+
+	// TxFeedPrefix is wallet database txfeed prefix
+	TxFeedPrefix = "TxFeed:"
+	// TxFeedEntryPrefix is wallet database prefix of the transactions matched by txfeed
+	TxFeedEntryPrefix = "TFE:"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+var (
+	// ErrDuplicateAlias means the alias of txfeed has been used
+	ErrDuplicateAlias = errors.New("duplicate txfeed alias")
+	// ErrEmptyAlias means the alias of txfeed is empty
+	ErrEmptyAlias = errors.New("empty txfeed alias")
+	// ErrNotFound means the txfeed is not found by the alias
+	ErrNotFound = errors.New("txfeed not found")
+	// ErrBadCursor means the cursor of txfeed is in bad format
+	ErrBadCursor = errors.New("invalid txfeed cursor")
+)
+
+// TxFeed is a named filter, the transactions matched the filter will be recorded
+// and can be consumed by the cursor
+type TxFeed struct {
+	ID     string  `json:"id"`
+	Alias  string  `json:"alias"`
+	Filter string  `json:"filter"`
+	Param  *Filter `json:"param"`
+}
+
+// TxFeedEvent is posted to the event dispatcher when a transaction matched by the txfeed
+// is attached or detached from the wallet
+type TxFeedEvent struct {
+	Alias    string
+	Cursor   string
+	Tx       *query.AnnotatedTx
+	Detached bool
+}
+
+func calcTxFeedKey(alias string) []byte {
+	return []byte(TxFeedPrefix + alias)
+}
+
+func calcEntryPrefix(feedID string) []byte {
+	return []byte(TxFeedEntryPrefix + feedID + ":")
+}
+
+func calcEntryKey(feedID string, cursor string) []byte {
+	return append(calcEntryPrefix(feedID), cursor...)
+}
+
+func formatCursor(height uint64, position uint32) string {
+	return fmt.Sprintf("%016x%08x", height, position)
+}
+
+// Tracker manages the txfeeds and records the matched transactions in the wallet database
+type Tracker struct {
+	mtx        sync.RWMutex
+	db         dbm.DB
+	txFeeds    map[string]*TxFeed
+	dispatcher *event.Dispatcher
+}
+
+// NewTracker create the txfeed tracker and load all the txfeeds from database
+func NewTracker(db dbm.DB, dispatcher *event.Dispatcher) (*Tracker, error) {
+	t := &Tracker{
+		db:         db,
+		txFeeds:    make(map[string]*TxFeed),
+		dispatcher: dispatcher,
+	}
+
+	iter := db.IteratorPrefix([]byte(TxFeedPrefix))
+	defer iter.Release()
+
+	for iter.Next() {
+		feed := &TxFeed{}
+		if err := json.Unmarshal(iter.Value(), feed); err != nil {
+			return nil, err
+		}
+
+		if feed.Param, _ = ParseFilter(feed.Filter); feed.Param == nil {
+			log.WithFields(log.Fields{"module": logModule, "alias": feed.Alias}).Warn("skip txfeed with bad filter")
+			continue
+		}
+
+		t.txFeeds[feed.Alias] = feed
+	}
+	return t, nil
+}
+
+// Create add a new txfeed with the unique alias
+func (t *Tracker) Create(alias, filter string) (*TxFeed, error) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil, ErrEmptyAlias
+	}
+
+	param, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if _, ok := t.txFeeds[alias]; ok {
+		return nil, ErrDuplicateAlias
+	}
+
+	feed := &TxFeed{ID: uuid.New().String(), Alias: alias, Filter: filter, Param: param}
+	if err := t.saveTxFeed(feed); err != nil {
+		return nil, err
+	}
+
+	t.txFeeds[alias] = feed
+	return feed, nil
+}
+
+// Get return the txfeed by alias
+func (t *Tracker) Get(alias string) (*TxFeed, error) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	feed, ok := t.txFeeds[alias]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return feed, nil
+}
+
+// List return all the txfeeds
+func (t *Tracker) List() []*TxFeed {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	feeds := []*TxFeed{}
+	iter := t.db.IteratorPrefix([]byte(TxFeedPrefix))
+	defer iter.Release()
+
+	for iter.Next() {
+		if feed, ok := t.txFeeds[strings.TrimPrefix(string(iter.Key()), TxFeedPrefix)]; ok {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds
+}
+
+// Update replace the filter of txfeed, the recorded transactions will be kept
+func (t *Tracker) Update(alias, filter string) (*TxFeed, error) {
+	param, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	feed, ok := t.txFeeds[alias]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	updated := &TxFeed{ID: feed.ID, Alias: feed.Alias, Filter: filter, Param: param}
+	if err := t.saveTxFeed(updated); err != nil {
+		return nil, err
+	}
+
+	t.txFeeds[alias] = updated
+	return updated, nil
+}
+
+// Delete remove the txfeed and all the transactions recorded by it
+func (t *Tracker) Delete(alias string) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	feed, ok := t.txFeeds[alias]
+	if !ok {
+		return ErrNotFound
+	}
+
+	batch := t.db.NewBatch()
+	iter := t.db.IteratorPrefix(calcEntryPrefix(feed.ID))
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+
+	batch.Delete(calcTxFeedKey(alias))
+	batch.Write()
+	delete(t.txFeeds, alias)
+	return nil
+}
+
+// Transactions return the transactions recorded by the txfeed after the cursor, the empty cursor
+// means consume from the beginning. The returned cursor is the position of the last transaction
+// and should be passed by the next call.
+func (t *Tracker) Transactions(alias, after string, limit int) ([]*query.AnnotatedTx, string, error) {
+	if after != "" && len(after) != len(formatCursor(0, 0)) {
+		return nil, "", ErrBadCursor
+	}
+
+	if limit <= 0 {
+		limit = defaultLimit
+	} else if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	feed, err := t.Get(alias)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix := calcEntryPrefix(feed.ID)
+	iter := t.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	txs := []*query.AnnotatedTx{}
+	next := after
+	startKey := string(calcEntryKey(feed.ID, after))
+	valid := iter.Seek(calcEntryKey(feed.ID, after))
+	if valid && string(iter.Key()) == startKey {
+		valid = iter.Next()
+	}
+
+	for ; valid && len(txs) < limit; valid = iter.Next() {
+		tx := &query.AnnotatedTx{}
+		if err := json.Unmarshal(iter.Value(), tx); err != nil {
+			return nil, "", err
+		}
+
+		txs = append(txs, tx)
+		next = strings.TrimPrefix(string(iter.Key()), string(prefix))
+	}
+	return txs, next, nil
+}
+
+// IndexTransactions record the transactions matched by the txfeeds into the batch, the returned
+// events should be passed to Notify after the batch is written
+func (t *Tracker) IndexTransactions(batch dbm.Batch, height uint64, txs []*query.AnnotatedTx) ([]*TxFeedEvent, error) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	events := []*TxFeedEvent{}
+	for _, feed := range t.txFeeds {
+		for _, tx := range txs {
+			if !feed.Param.Match(tx) {
+				continue
+			}
+
+			rawTx, err := json.Marshal(tx)
+			if err != nil {
+				return nil, err
+			}
+
+			cursor := formatCursor(height, tx.Position)
+			batch.Set(calcEntryKey(feed.ID, cursor), rawTx)
+			events = append(events, &TxFeedEvent{Alias: feed.Alias, Cursor: cursor, Tx: tx})
+		}
+	}
+	return events, nil
+}
+
+// DetachTransactions delete the transactions recorded in the height when the block is rollback
+func (t *Tracker) DetachTransactions(batch dbm.Batch, height uint64) []*TxFeedEvent {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	events := []*TxFeedEvent{}
+	for _, feed := range t.txFeeds {
+		prefix := calcEntryPrefix(feed.ID)
+		iter := t.db.IteratorPrefix(append(prefix, fmt.Sprintf("%016x", height)...))
+		for iter.Next() {
+			tx := &query.AnnotatedTx{}
+			if err := json.Unmarshal(iter.Value(), tx); err == nil {
+				cursor := strings.TrimPrefix(string(iter.Key()), string(prefix))
+				events = append(events, &TxFeedEvent{Alias: feed.Alias, Cursor: cursor, Tx: tx, Detached: true})
+			}
+			batch.Delete(iter.Key())
+		}
+		iter.Release()
+	}
+	return events
+}
+
+// Notify post the txfeed events to the subscribers
+func (t *Tracker) Notify(events []*TxFeedEvent) {
+	if t.dispatcher == nil {
+		return
+	}
+
+	for _, event := range events {
+		if err := t.dispatcher.Post(*event); err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err, "alias": event.Alias}).Error("fail on post txfeed event")
+		}
+	}
+}
+
+func (t *Tracker) saveTxFeed(feed *TxFeed) error {
+	rawFeed, err := json.Marshal(feed)
+	if err != nil {
+		return err
+	}
+
+	t.db.Set(calcTxFeedKey(feed.Alias), rawFeed)
+	return nil
+}
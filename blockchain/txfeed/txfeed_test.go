@@ -0,0 +1,147 @@
+package txfeed
+
+import (
+	"testing"
+
+	"coingod/blockchain/query"
+	dbm "coingod/database/leveldb"
+	"coingod/event"
+	"coingod/protocol/bc"
+)
+
+func newFeedTx(id uint64, position uint32, alias string) *query.AnnotatedTx {
+	return &query.AnnotatedTx{
+		ID:       bc.Hash{V0: id},
+		Position: position,
+		Outputs:  []*query.AnnotatedOutput{{Type: "control", AccountAlias: alias, Amount: 100}},
+	}
+}
+
+func TestTrackerManageTxFeeds(t *testing.T) {
+	db := dbm.NewMemDB()
+	tracker, err := NewTracker(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Create(" ", "account_alias='alice'"); err != ErrEmptyAlias {
+		t.Errorf("got err %v, want %v", err, ErrEmptyAlias)
+	}
+
+	if _, err := tracker.Create("alice", "account_alias"); err == nil {
+		t.Error("create the txfeed with bad filter")
+	}
+
+	if _, err := tracker.Create("alice", "account_alias='alice'"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Create("alice", "account_alias='bob'"); err != ErrDuplicateAlias {
+		t.Errorf("got err %v, want %v", err, ErrDuplicateAlias)
+	}
+
+	if _, err := tracker.Update("alice", "account_alias='alice' AND amount>=100"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Update("bob", "account_alias='bob'"); err != ErrNotFound {
+		t.Errorf("got err %v, want %v", err, ErrNotFound)
+	}
+
+	// the txfeeds are loaded from the database by the new tracker
+	reloaded, err := NewTracker(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := reloaded.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if feed.Filter != "account_alias='alice' AND amount>=100" || len(reloaded.List()) != 1 {
+		t.Errorf("got txfeed %v of %d txfeeds, want the updated one", feed, len(reloaded.List()))
+	}
+
+	if err := reloaded.Delete("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reloaded.Get("alice"); err != ErrNotFound {
+		t.Errorf("got err %v, want %v", err, ErrNotFound)
+	}
+
+	if err := reloaded.Delete("alice"); err != ErrNotFound {
+		t.Errorf("got err %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestTrackerIndexTransactions(t *testing.T) {
+	db := dbm.NewMemDB()
+	dispatcher := event.NewDispatcher()
+	sub, err := dispatcher.Subscribe(TxFeedEvent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	tracker, err := NewTracker(db, dispatcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Create("alice", "account_alias='alice'"); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := map[uint64][]*query.AnnotatedTx{
+		1: {newFeedTx(1, 1, "alice"), newFeedTx(2, 2, "bob")},
+		2: {newFeedTx(3, 1, "alice"), newFeedTx(4, 2, "alice")},
+	}
+
+	for height := uint64(1); height <= 2; height++ {
+		batch := db.NewBatch()
+		events, err := tracker.IndexTransactions(batch, height, blocks[height])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		batch.Write()
+		tracker.Notify(events)
+	}
+
+	for _, wantID := range []uint64{1, 3, 4} {
+		ev := (<-sub.Chan()).Data.(TxFeedEvent)
+		if ev.Tx.ID.V0 != wantID || ev.Detached {
+			t.Errorf("got the event of tx %d detached %v, want the attached tx %d", ev.Tx.ID.V0, ev.Detached, wantID)
+		}
+	}
+
+	txs, cursor, err := tracker.Transactions("alice", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txs) != 2 || txs[0].ID.V0 != 1 || txs[1].ID.V0 != 3 || cursor != formatCursor(2, 1) {
+		t.Fatalf("got %d txs and cursor %s, want tx 1, 3 and the cursor of tx 3", len(txs), cursor)
+	}
+
+	if txs, _, err = tracker.Transactions("alice", cursor, 2); err != nil || len(txs) != 1 || txs[0].ID.V0 != 4 {
+		t.Fatalf("got %d txs err %v after the cursor, want tx 4", len(txs), err)
+	}
+
+	if _, _, err := tracker.Transactions("alice", "bad", 2); err != ErrBadCursor {
+		t.Errorf("got err %v, want %v", err, ErrBadCursor)
+	}
+
+	batch := db.NewBatch()
+	events := tracker.DetachTransactions(batch, 2)
+	batch.Write()
+	if len(events) != 2 || !events[0].Detached {
+		t.Fatalf("got %d detach events, want 2", len(events))
+	}
+
+	if txs, _, err = tracker.Transactions("alice", "", 10); err != nil || len(txs) != 1 || txs[0].ID.V0 != 1 {
+		t.Errorf("got %d txs err %v after detached, want tx 1", len(txs), err)
+	}
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
@@ -9,6 +10,18 @@ import (
 	"coingod/util"
 )
 
+func init() {
+	createAccessTokenCmd.PersistentFlags().StringSliceVar(&tokenScopes, "scopes", nil, "the scopes of the token: chain-read, chain-write, wallet-read, wallet-write, sign, peer-admin. default: full permission")
+	createAccessTokenCmd.PersistentFlags().DurationVar(&tokenExpiresIn, "expires-in", 0, "the token expires after the duration, e.g. 720h. default: never expire")
+	createAccessTokenCmd.PersistentFlags().StringSliceVar(&tokenAllowedIPs, "allowed-ips", nil, "the IPs or CIDRs allowed to use the token. default: any ip")
+}
+
+var (
+	tokenScopes     []string
+	tokenExpiresIn  time.Duration
+	tokenAllowedIPs []string
+)
+
 var createAccessTokenCmd = &cobra.Command{
 	Use:   "create-access-token <tokenID>",
 	Short: "Create a new access token",
@@ -16,6 +29,12 @@ var createAccessTokenCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		var token accessToken
 		token.ID = args[0]
+		token.Scopes = tokenScopes
+		token.AllowedIPs = tokenAllowedIPs
+		if tokenExpiresIn > 0 {
+			expiresAt := time.Now().Add(tokenExpiresIn)
+			token.ExpiresAt = &expiresAt
+		}
 
 		data, exitCode := util.ClientCall("/create-access-token", &token)
 		if exitCode != util.Success {
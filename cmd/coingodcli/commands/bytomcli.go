@@ -127,6 +127,7 @@ func AddCommands() {
 
 	CoingodcliCmd.AddCommand(getTransactionCmd)
 	CoingodcliCmd.AddCommand(listTransactionsCmd)
+	CoingodcliCmd.AddCommand(listAccountTransactionsCmd)
 
 	CoingodcliCmd.AddCommand(getUnconfirmedTransactionCmd)
 	CoingodcliCmd.AddCommand(listUnconfirmedTransactionsCmd)
@@ -135,6 +136,11 @@ func AddCommands() {
 	CoingodcliCmd.AddCommand(listUnspentOutputsCmd)
 	CoingodcliCmd.AddCommand(listBalancesCmd)
 
+	CoingodcliCmd.AddCommand(listVoteUTXOsCmd)
+	CoingodcliCmd.AddCommand(listAccountVoteSharesCmd)
+	CoingodcliCmd.AddCommand(getValidatorVotesCmd)
+	CoingodcliCmd.AddCommand(buildMoveVotesCmd)
+
 	CoingodcliCmd.AddCommand(rescanWalletCmd)
 	CoingodcliCmd.AddCommand(walletInfoCmd)
 
@@ -148,6 +154,10 @@ func AddCommands() {
 	CoingodcliCmd.AddCommand(getBlockCmd)
 	CoingodcliCmd.AddCommand(getBlockHeaderCmd)
 
+	CoingodcliCmd.AddCommand(getAddressBalanceCmd)
+	CoingodcliCmd.AddCommand(listAddressTransactionsCmd)
+	CoingodcliCmd.AddCommand(listAssetHoldersCmd)
+
 	CoingodcliCmd.AddCommand(createKeyCmd)
 	CoingodcliCmd.AddCommand(deleteKeyCmd)
 	CoingodcliCmd.AddCommand(listKeysCmd)
@@ -166,6 +176,12 @@ func AddCommands() {
 	CoingodcliCmd.AddCommand(updateTransactionFeedCmd)
 
 	CoingodcliCmd.AddCommand(netInfoCmd)
+	CoingodcliCmd.AddCommand(listBannedPeersCmd)
+	CoingodcliCmd.AddCommand(banPeerCmd)
+	CoingodcliCmd.AddCommand(unbanPeerCmd)
+	CoingodcliCmd.AddCommand(listPeerScoresCmd)
+	CoingodcliCmd.AddCommand(listAllowedPeersCmd)
+	CoingodcliCmd.AddCommand(reloadAllowedPeersCmd)
 	CoingodcliCmd.AddCommand(gasRateCmd)
 
 	CoingodcliCmd.AddCommand(versionCmd)
@@ -200,6 +216,7 @@ func AddTemplateFunc() {
 
 		getTransactionCmd.Name(),
 		listTransactionsCmd.Name(),
+		listAccountTransactionsCmd.Name(),
 		listUnspentOutputsCmd.Name(),
 		listBalancesCmd.Name(),
 
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"coingod/util"
+)
+
+func init() {
+	listAddressTransactionsCmd.PersistentFlags().StringVar(&explorerAfter, "after", "", "the cursor returned by the previous call")
+	listAddressTransactionsCmd.PersistentFlags().IntVar(&explorerLimit, "limit", 0, "the max number of the transactions")
+	listAddressTransactionsCmd.PersistentFlags().BoolVar(&detail, "detail", false, "list the raw transactions")
+
+	listAssetHoldersCmd.PersistentFlags().StringVar(&explorerAfter, "after", "", "the cursor returned by the previous call")
+	listAssetHoldersCmd.PersistentFlags().IntVar(&explorerLimit, "limit", 0, "the max number of the holders")
+}
+
+var (
+	explorerAfter = ""
+	explorerLimit = 0
+)
+
+var getAddressBalanceCmd = &cobra.Command{
+	Use:   "get-address-balance <address>",
+	Short: "Get the balances of all the assets held by the address",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			Address string `json:"address"`
+		}{Address: args[0]}
+
+		data, exitCode := util.ClientCall("/get-address-balance", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var listAddressTransactionsCmd = &cobra.Command{
+	Use:   "list-address-transactions <address>",
+	Short: "List the transactions of the address by the cursor",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			Address string `json:"address"`
+			Detail  bool   `json:"detail"`
+			After   string `json:"after"`
+			Limit   int    `json:"limit"`
+		}{Address: args[0], Detail: detail, After: explorerAfter, Limit: explorerLimit}
+
+		data, exitCode := util.ClientCall("/list-address-transactions", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
+
+var listAssetHoldersCmd = &cobra.Command{
+	Use:   "list-asset-holders <assetID>",
+	Short: "List the control programs holding the asset by the cursor",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			AssetID string `json:"asset_id"`
+			After   string `json:"after"`
+			Limit   int    `json:"limit"`
+		}{AssetID: args[0], After: explorerAfter, Limit: explorerLimit}
+
+		data, exitCode := util.ClientCall("/list-asset-holders", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
@@ -4,10 +4,21 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
 
 	"coingod/util"
 )
 
+func init() {
+	banPeerCmd.PersistentFlags().StringVar(&banDuration, "duration", "", "ban duration such as 30m or 24h, 1h by default")
+	banPeerCmd.PersistentFlags().StringVar(&banReason, "reason", "", "reason of the ban")
+}
+
+var (
+	banDuration = ""
+	banReason   = ""
+)
+
 var netInfoCmd = &cobra.Command{
 	Use:   "net-info",
 	Short: "Print the summary of network",
@@ -20,3 +31,95 @@ var netInfoCmd = &cobra.Command{
 		printJSON(data)
 	},
 }
+
+var listBannedPeersCmd = &cobra.Command{
+	Use:   "list-banned-peers",
+	Short: "List the peers in the blacklist",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-banned-peers")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var banPeerCmd = &cobra.Command{
+	Use:   "ban-peer <ip>",
+	Short: "Ban the peer ip and disconnect the connected peers of it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			Ip       string `json:"ip"`
+			Duration string `json:"duration,omitempty"`
+			Reason   string `json:"reason"`
+		}{Ip: args[0], Duration: banDuration, Reason: banReason}
+
+		if _, exitCode := util.ClientCall("/ban-peer", &req); exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		jww.FEEDBACK.Println("Successfully ban peer")
+	},
+}
+
+var unbanPeerCmd = &cobra.Command{
+	Use:   "unban-peer <ip>",
+	Short: "Remove the peer ip from the blacklist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			Ip string `json:"ip"`
+		}{Ip: args[0]}
+
+		if _, exitCode := util.ClientCall("/unban-peer", &req); exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		jww.FEEDBACK.Println("Successfully unban peer")
+	},
+}
+
+var listPeerScoresCmd = &cobra.Command{
+	Use:   "list-peer-scores",
+	Short: "List the ban scores of the misbehaving peers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-peer-scores")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var listAllowedPeersCmd = &cobra.Command{
+	Use:   "list-allowed-peers",
+	Short: "List the node public keys which are allowed to connect",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-allowed-peers")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
+
+var reloadAllowedPeersCmd = &cobra.Command{
+	Use:   "reload-allowed-peers",
+	Short: "Reload the allowed peers from the config and disconnect the peers no longer allowed",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/reload-allowed-peers")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
@@ -36,6 +36,13 @@ func init() {
 	listTransactionsCmd.PersistentFlags().StringVar(&account, "account_id", "", "account id")
 	listTransactionsCmd.PersistentFlags().BoolVar(&detail, "detail", false, "list transactions details")
 	listTransactionsCmd.PersistentFlags().BoolVar(&unconfirmed, "unconfirmed", false, "list unconfirmed transactions")
+
+	listAccountTransactionsCmd.PersistentFlags().StringVar(&txAssetID, "asset_id", "", "only list the transactions with the asset")
+	listAccountTransactionsCmd.PersistentFlags().Uint64Var(&txStartHeight, "start_height", 0, "the lowest block height of the transactions")
+	listAccountTransactionsCmd.PersistentFlags().Uint64Var(&txEndHeight, "end_height", 0, "the highest block height of the transactions")
+	listAccountTransactionsCmd.PersistentFlags().StringVar(&txAfter, "after", "", "the cursor returned by the previous call")
+	listAccountTransactionsCmd.PersistentFlags().IntVar(&txLimit, "limit", 0, "the max number of the transactions")
+	listAccountTransactionsCmd.PersistentFlags().BoolVar(&detail, "detail", false, "list transactions details")
 }
 
 var (
@@ -53,6 +60,11 @@ var (
 	arbitrary       = ""
 	program         = ""
 	contractName    = ""
+	txAssetID       = ""
+	txStartHeight   = uint64(0)
+	txEndHeight     = uint64(0)
+	txAfter         = ""
+	txLimit         = 0
 )
 
 var buildIssueReqFmt = `
@@ -373,6 +385,30 @@ var listTransactionsCmd = &cobra.Command{
 	},
 }
 
+var listAccountTransactionsCmd = &cobra.Command{
+	Use:   "list-account-transactions <accountID>",
+	Short: "List the transactions of the account by the cursor",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := struct {
+			AccountID   string `json:"account_id"`
+			AssetID     string `json:"asset_id"`
+			StartHeight uint64 `json:"start_height"`
+			EndHeight   uint64 `json:"end_height"`
+			Detail      bool   `json:"detail"`
+			After       string `json:"after"`
+			Limit       int    `json:"limit"`
+		}{AccountID: args[0], AssetID: txAssetID, StartHeight: txStartHeight, EndHeight: txEndHeight, Detail: detail, After: txAfter, Limit: txLimit}
+
+		data, exitCode := util.ClientCall("/list-account-transactions", &filter)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
+
 var getUnconfirmedTransactionCmd = &cobra.Command{
 	Use:   "get-unconfirmed-transaction <hash>",
 	Short: "get unconfirmed transaction by matching the given transaction hash",
@@ -65,11 +65,14 @@ type respTxFeed struct {
 }
 
 type accessToken struct {
-	ID      string    `json:"id,omitempty"`
-	Token   string    `json:"token,omitempty"`
-	Type    string    `json:"type,omitempty"`
-	Secret  string    `json:"secret,omitempty"`
-	Created time.Time `json:"created_at,omitempty"`
+	ID         string     `json:"id,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	Type       string     `json:"type,omitempty"`
+	Secret     string     `json:"secret,omitempty"`
+	Created    time.Time  `json:"created_at,omitempty"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
 }
 
 func printJSON(data interface{}) {
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"coingod/util"
+)
+
+func init() {
+	listVoteUTXOsCmd.PersistentFlags().StringVar(&accountID, "account_id", "", "account ID")
+	listVoteUTXOsCmd.PersistentFlags().StringVar(&accountAlias, "account_alias", "", "account alias")
+	listVoteUTXOsCmd.PersistentFlags().StringVar(&voteValidator, "vote", "", "public key of the voted validator")
+
+	listAccountVoteSharesCmd.PersistentFlags().StringVar(&accountID, "account_id", "", "account ID")
+	listAccountVoteSharesCmd.PersistentFlags().StringVar(&accountAlias, "account_alias", "", "account alias")
+
+	getValidatorVotesCmd.PersistentFlags().Uint64Var(&voteEpochs, "epochs", 0, "num of the latest epochs")
+
+	buildMoveVotesCmd.PersistentFlags().StringVar(&accountID, "account_id", "", "account ID")
+	buildMoveVotesCmd.PersistentFlags().StringVar(&accountAlias, "account_alias", "", "account alias")
+	buildMoveVotesCmd.PersistentFlags().Uint64Var(&voteAmount, "amount", 0, "amount of the moved votes, all the unlocked votes are moved by default")
+	buildMoveVotesCmd.PersistentFlags().Uint64Var(&voteFee, "fee", 0, "fee paid from the moved votes")
+}
+
+var (
+	voteValidator = ""
+	voteEpochs    = uint64(0)
+	voteAmount    = uint64(0)
+	voteFee       = uint64(0)
+)
+
+var listVoteUTXOsCmd = &cobra.Command{
+	Use:   "list-vote-utxos",
+	Short: "List the vote outputs of the accounts with the lock status",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := struct {
+			AccountID    string `json:"account_id"`
+			AccountAlias string `json:"account_alias"`
+			Vote         string `json:"vote"`
+		}{AccountID: accountID, AccountAlias: accountAlias, Vote: voteValidator}
+
+		data, exitCode := util.ClientCall("/list-vote-utxos", &filter)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var listAccountVoteSharesCmd = &cobra.Command{
+	Use:   "list-account-vote-shares",
+	Short: "List the votes of the account for each validator and the estimated reward share",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := struct {
+			AccountID    string `json:"account_id"`
+			AccountAlias string `json:"account_alias"`
+		}{AccountID: accountID, AccountAlias: accountAlias}
+
+		data, exitCode := util.ClientCall("/list-account-vote-shares", &filter)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var getValidatorVotesCmd = &cobra.Command{
+	Use:   "get-validator-votes <pub_key>",
+	Short: "Get the votes of the validator at the latest checkpoints",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			PubKey string `json:"pub_key"`
+			Epochs uint64 `json:"epochs"`
+		}{PubKey: args[0], Epochs: voteEpochs}
+
+		data, exitCode := util.ClientCall("/get-validator-votes", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSONList(data)
+	},
+}
+
+var buildMoveVotesCmd = &cobra.Command{
+	Use:   "build-move-votes <from_pub_key> <to_pub_key>",
+	Short: "Build the transaction which moves the votes from one validator to another",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := struct {
+			AccountID    string `json:"account_id"`
+			AccountAlias string `json:"account_alias"`
+			From         string `json:"from"`
+			To           string `json:"to"`
+			Amount       uint64 `json:"amount"`
+			Fee          uint64 `json:"fee"`
+		}{AccountID: accountID, AccountAlias: accountAlias, From: args[0], To: args[1], Amount: voteAmount, Fee: voteFee}
+
+		data, exitCode := util.ClientCall("/build-move-votes", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path"
@@ -10,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	cfg "coingod/config"
+	"coingod/consensus"
 	"coingod/crypto/ed25519/chainkd"
 )
 
@@ -19,8 +21,17 @@ var initFilesCmd = &cobra.Command{
 	Run:   initFiles,
 }
 
+var (
+	genesisFile  string
+	snapshotFile string
+	snapshotHash string
+)
+
 func init() {
 	initFilesCmd.Flags().String("chain_id", config.ChainID, "Select [mainnet] or [testnet] or [solonet]")
+	initFilesCmd.Flags().StringVar(&genesisFile, "genesis", "", "Init the custom network by the json or toml genesis file")
+	initFilesCmd.Flags().StringVar(&snapshotFile, "snapshot", "", "Bootstrap the chain from the snapshot file exported by the snapshot export command")
+	initFilesCmd.Flags().StringVar(&snapshotHash, "snapshot_hash", "", "The expected content hash of the snapshot, required by --snapshot")
 
 	RootCmd.AddCommand(initFilesCmd)
 }
@@ -29,11 +40,38 @@ func initFiles(cmd *cobra.Command, args []string) {
 	configFilePath := path.Join(config.RootDir, "config.toml")
 	if _, err := os.Stat(configFilePath); !os.IsNotExist(err) {
 		log.WithFields(log.Fields{"module": logModule, "config": configFilePath}).Info("Already exists config file.")
+	} else {
+		initConfigFiles(configFilePath)
+	}
+
+	if snapshotFile == "" {
 		return
 	}
 
-	switch config.ChainID {
-	case "mainnet", "testnet":
+	if snapshotHash == "" {
+		log.WithFields(log.Fields{"module": logModule}).Fatal("snapshot_hash is required to verify the snapshot")
+	}
+
+	chainID := config.ChainID
+	if genesisFile != "" {
+		def, err := consensus.ReadNetworkDefinition(genesisFile)
+		if err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("fail on read genesis file")
+		}
+		chainID = def.ChainID
+	}
+
+	if err := importSnapshot(chainID, snapshotFile, snapshotHash); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("fail on import snapshot")
+	}
+}
+
+// initConfigFiles create the config file and the node private key in the root dir
+func initConfigFiles(configFilePath string) {
+	switch {
+	case genesisFile != "":
+		initCustomNet(genesisFile)
+	case config.ChainID == "mainnet", config.ChainID == "testnet":
 		cfg.EnsureRoot(config.RootDir, config.ChainID)
 	default:
 		cfg.EnsureRoot(config.RootDir, "solonet")
@@ -56,3 +94,23 @@ func initFiles(cmd *cobra.Command, args []string) {
 
 	log.WithFields(log.Fields{"module": logModule, "config": configFilePath}).Info("Initialized coingod")
 }
+
+// initCustomNet check the network definition and save it to the data dir in json
+func initCustomNet(genesisFile string) {
+	def, err := consensus.ReadNetworkDefinition(genesisFile)
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("fail on read genesis file")
+	}
+
+	if _, err := def.Params(); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("invalid network definition")
+	}
+
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("fail on marshal network definition")
+	}
+
+	cfg.EnsureCustomNetRoot(config.RootDir, def.ChainID, def.DefaultPort, data)
+	log.WithFields(log.Fields{"module": logModule, "chain_id": def.ChainID}).Info("Initialized custom network")
+}
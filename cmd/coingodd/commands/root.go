@@ -23,6 +23,10 @@ var RootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+
+		if err := config.ValidateBasic(); err != nil {
+			return err
+		}
 		pathParts := strings.SplitN(config.RootDir, "/", 2)
 		if len(pathParts) == 2 && (pathParts[0] == "~" || pathParts[0] == "$HOME") {
 			usr, err := user.Current()
@@ -20,6 +20,7 @@ var runNodeCmd = &cobra.Command{
 func init() {
 	runNodeCmd.Flags().String("prof_laddr", config.ProfListenAddress, "Use http to profile coingodd programs")
 	runNodeCmd.Flags().Bool("mining", config.Mining, "Enable mining")
+	runNodeCmd.Flags().String("tx_selection", config.TxSelection, "Transaction selection strategy of block proposal(fee_rate or time)")
 
 	runNodeCmd.Flags().Bool("auth.disable", config.Auth.Disable, "Disable rpc access authenticate")
 
@@ -27,6 +28,7 @@ func init() {
 	runNodeCmd.Flags().Bool("wallet.rescan", config.Wallet.Rescan, "Rescan wallet")
 	runNodeCmd.Flags().Bool("wallet.txindex", config.Wallet.TxIndex, "Save global tx index")
 	runNodeCmd.Flags().Bool("vault_mode", config.VaultMode, "Run in the offline enviroment")
+	runNodeCmd.Flags().Bool("light_mode", config.LightMode, "Only sync the block headers and the wallet related transactions")
 	runNodeCmd.Flags().Bool("web.closed", config.Web.Closed, "Lanch web browser or not")
 	runNodeCmd.Flags().String("chain_id", config.ChainID, "Select network type")
 
@@ -45,6 +47,9 @@ func init() {
 	runNodeCmd.Flags().String("p2p.proxy_username", config.P2P.ProxyUsername, "Username for proxy server")
 	runNodeCmd.Flags().String("p2p.proxy_password", config.P2P.ProxyPassword, "Password for proxy server")
 	runNodeCmd.Flags().String("p2p.keep_dial", config.P2P.KeepDial, "Peers addresses try keeping connecting to, separated by ',' (for example \"1.1.1.1:46657;2.2.2.2:46658\")")
+	runNodeCmd.Flags().String("p2p.allowed_peers", config.P2P.AllowedPeers, "Comma delimited node public keys which are only allowed to connect")
+	runNodeCmd.Flags().String("p2p.allowed_peers_file", config.P2P.AllowedPeersFile, "File of the allowed node public keys, one per line, reloadable by the api")
+	runNodeCmd.Flags().Bool("p2p.private_validator", config.P2P.PrivateValidator, "Only connect the keep dial sentries and disable the node discovery")
 
 	// log flags
 	runNodeCmd.Flags().String("log_file", config.LogFile, "Log output file")
@@ -53,6 +58,27 @@ func init() {
 	runNodeCmd.Flags().Int("ws.max_num_websockets", config.Websocket.MaxNumWebsockets, "Max number of websocket connections")
 	runNodeCmd.Flags().Int("ws.max_num_concurrent_reqs", config.Websocket.MaxNumConcurrentReqs, "Max number of concurrent websocket requests that may be processed concurrently")
 
+	// mempool flags
+	runNodeCmd.Flags().Bool("mempool.replace_by_fee", config.Mempool.ReplaceByFee, "Allow the transaction to replace the conflicting transactions in the mempool by paying more fee")
+	runNodeCmd.Flags().Uint64("mempool.min_fee_bump", config.Mempool.MinFeeBump, "The minimum percent of fee the replacement transaction should pay more")
+
+	// signer flags
+	runNodeCmd.Flags().String("signer.remote_addr", config.Signer.RemoteAddr, "Remote signer address of the validator key, unix:///path/to/socket or tcp://127.0.0.1:port")
+	runNodeCmd.Flags().String("signer.password_file", config.Signer.PasswordFile, "The file contains the password of the encrypted node key")
+
+	// vote reward flags
+	runNodeCmd.Flags().Bool("vote_reward.enable", config.VoteReward.Enable, "Distribute the validator rewards to the voters automatically")
+	runNodeCmd.Flags().String("vote_reward.account_id", config.VoteReward.AccountID, "The account receives the validator rewards and pays the voters")
+	runNodeCmd.Flags().String("vote_reward.password_file", config.VoteReward.PasswordFile, "The file contains the password of the vote reward account key")
+	runNodeCmd.Flags().Uint64("vote_reward.reward_ratio", config.VoteReward.RewardRatio, "The percent of the validator rewards distributed to the voters")
+
+	// explorer flags
+	runNodeCmd.Flags().Bool("explorer.enable", config.Explorer.Enable, "Index the balances and transactions of all the addresses and the holders of all the assets")
+
+	// prune flags
+	runNodeCmd.Flags().Bool("prune.enable", config.Prune.Enable, "Delete the transactions of the old blocks below the finalized checkpoint, keep the headers and the utxo set")
+	runNodeCmd.Flags().Uint64("prune.depth", config.Prune.Depth, "Number of the blocks keeping the transactions below the finalized checkpoint, at least the lock time of the vote outputs")
+
 	RootCmd.AddCommand(runNodeCmd)
 }
 
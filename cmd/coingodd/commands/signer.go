@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"coingod/blockchain/pseudohsm"
+	"coingod/signer"
+)
+
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Run the remote signer of the validator key",
+	RunE:  runSigner,
+}
+
+var encryptKeyCmd = &cobra.Command{
+	Use:   "encrypt_key",
+	Short: "Encrypt the node key file by the password of signer.password_file",
+	RunE:  encryptKey,
+}
+
+var signerListenAddr string
+
+func init() {
+	signerCmd.Flags().StringVar(&signerListenAddr, "laddr", "", "Signer listen address, unix:///path/to/socket or tcp://127.0.0.1:port (default signer.sock in the root dir)")
+	signerCmd.Flags().String("signer.password_file", config.Signer.PasswordFile, "The file contains the password of the encrypted node key")
+	signerCmd.Flags().String("signer.state_file", config.Signer.StateFile, "The file records the signed blocks and votes")
+	encryptKeyCmd.Flags().String("signer.password_file", config.Signer.PasswordFile, "The file contains the password of the encrypted node key")
+
+	RootCmd.AddCommand(signerCmd)
+	RootCmd.AddCommand(encryptKeyCmd)
+}
+
+func runSigner(cmd *cobra.Command, args []string) error {
+	setLogLevel(config.LogLevel)
+
+	guard, err := signer.NewGuard(config.SignerStateFile())
+	if err != nil {
+		return err
+	}
+
+	if signerListenAddr == "" {
+		signerListenAddr = "unix://" + path.Join(config.RootDir, "signer.sock")
+	}
+
+	listener, err := signer.Listen(signerListenAddr)
+	if err != nil {
+		return err
+	}
+
+	xprv := config.PrivateKey()
+	localSigner := signer.NewLocalSigner(*xprv, guard)
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		<-sigCh
+		listener.Close()
+	}()
+
+	log.WithFields(log.Fields{"module": logModule, "laddr": signerListenAddr, "xpub": xprv.XPub().String()}).Info("remote signer started")
+	return signer.Serve(listener, localSigner)
+}
+
+func encryptKey(cmd *cobra.Command, args []string) error {
+	keyFilePath := path.Join(config.RootDir, config.PrivateKeyFile)
+	if err := signer.EncryptKeyFile(keyFilePath, config.KeyPassword(), pseudohsm.StandardScryptN, pseudohsm.StandardScryptP); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "file": keyFilePath}).Info("node key file encrypted")
+	return nil
+}
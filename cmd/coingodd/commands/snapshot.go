@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/prometheus/util/flock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cfg "coingod/config"
+	"coingod/consensus"
+	"coingod/database"
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/protocol/bc"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Manage the snapshot of the chain state at the last finalized checkpoint",
+}
+
+var exportSnapshotCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export the utxo set, the contracts and the checkpoint at the last finalized checkpoint",
+	Args:  cobra.ExactArgs(1),
+	RunE:  exportSnapshot,
+}
+
+func init() {
+	exportSnapshotCmd.Flags().String("chain_id", config.ChainID, "Select network type")
+
+	snapshotCmd.AddCommand(exportSnapshotCmd)
+	RootCmd.AddCommand(snapshotCmd)
+}
+
+// openCoreDB lock the data directory like the running node, so the snapshot is never
+// exported or imported while the node is writing the chain
+func openCoreDB(chainID string) (dbm.DB, error) {
+	if _, _, err := flock.New(filepath.Join(config.RootDir, "LOCK")); err != nil {
+		return nil, errors.New("datadir already used by another process")
+	}
+
+	if err := consensus.InitActiveNetParams(chainID, config.GenesisPath()); err != nil {
+		return nil, err
+	}
+	return dbm.NewDB("core", config.DBBackend, config.DBDir()), nil
+}
+
+func exportSnapshot(cmd *cobra.Command, args []string) error {
+	coreDB, err := openCoreDB(config.ChainID)
+	if err != nil {
+		return err
+	}
+	defer coreDB.Close()
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, contentHash, err := database.NewStore(coreDB).ExportSnapshot(file)
+	if err != nil {
+		os.Remove(args[0])
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"module":       logModule,
+		"file":         args[0],
+		"height":       info.Height,
+		"hash":         info.Hash.String(),
+		"content_hash": hex.EncodeToString(contentHash.Bytes()),
+	}).Info("snapshot exported, pass the content hash to init --snapshot_hash of the new node")
+	return nil
+}
+
+// importSnapshot load the snapshot into the empty core database of the node, the content
+// hash must be the value configured or agreed by the validators
+func importSnapshot(chainID, snapshotFile, snapshotHash string) error {
+	contentHash := &bc.Hash{}
+	if err := contentHash.UnmarshalText([]byte(snapshotHash)); err != nil {
+		return errors.Wrap(err, "invalid snapshot_hash")
+	}
+
+	coreDB, err := openCoreDB(chainID)
+	if err != nil {
+		return err
+	}
+	defer coreDB.Close()
+
+	file, err := os.Open(snapshotFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	genesisHash := cfg.GenesisBlock().Hash()
+	info, err := database.NewStore(coreDB).ImportSnapshot(file, &genesisHash, contentHash)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "height": info.Height, "hash": info.Hash.String()}).Info("snapshot imported, the node will sync the blocks after it")
+	return nil
+}
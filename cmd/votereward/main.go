@@ -10,6 +10,7 @@ import (
 	"coingod/consensus"
 	"coingod/toolbar/common"
 	cfg "coingod/toolbar/vote_reward/config"
+	"coingod/toolbar/vote_reward/database/orm"
 	"coingod/toolbar/vote_reward/settlementvotereward"
 	"coingod/toolbar/vote_reward/synchron"
 )
@@ -42,17 +43,18 @@ func runReward(cmd *cobra.Command, args []string) error {
 		log.WithFields(log.Fields{"module": logModule, "config": configFile, "error": err}).Fatal("Failded to load config file.")
 	}
 
-	if err := consensus.InitActiveNetParams(config.ChainID); err != nil {
+	if err := consensus.InitActiveNetParams(config.ChainID, config.GenesisFile); err != nil {
 		log.WithFields(log.Fields{"module": logModule, "error": err}).Fatal("Init ActiveNetParams.")
 	}
 	if rewardStartHeight >= rewardEndHeight || rewardStartHeight%consensus.ActiveNetParams.BlocksOfEpoch != 0 || rewardEndHeight%consensus.ActiveNetParams.BlocksOfEpoch != 0 {
 		log.Fatal("Please check the height range, which must be multiple of the number of block rounds.")
 	}
 
-	db, err := common.NewMySQLDB(config.MySQLConfig)
+	db, err := common.NewDB(config.DBConfig(), &orm.ChainStatus{}, &orm.Utxo{})
 	if err != nil {
-		log.WithFields(log.Fields{"module": logModule, "error": err}).Fatal("Failded to initialize mysql db.")
+		log.WithFields(log.Fields{"module": logModule, "driver": config.DBDriver, "error": err}).Fatal("Failded to initialize db.")
 	}
+	defer db.Close()
 
 	db.LogMode(true)
 
@@ -1,32 +1,53 @@
 package config
 
 import (
-	"encoding/hex"
-	"io"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	"coingod/crypto/ed25519/chainkd"
+	"coingod/signer"
+)
+
+const (
+	// TxSelectionTime packs the transactions in the order they are added to the pool
+	TxSelectionTime = "time"
+	// TxSelectionFeeRate packs the transactions with the highest fee per weight first,
+	// the in-pool parents are packed together with the child which pays for them
+	TxSelectionFeeRate = "fee_rate"
 )
 
 var (
 	// CommonConfig means config object
 	CommonConfig *Config
+
+	signerMu sync.Mutex
 )
 
 type Config struct {
 	// Top level options use an anonymous struct
 	BaseConfig `mapstructure:",squash"`
 	// Options for services
-	P2P       *P2PConfig       `mapstructure:"p2p"`
-	Wallet    *WalletConfig    `mapstructure:"wallet"`
-	Auth      *RPCAuthConfig   `mapstructure:"auth"`
-	Web       *WebConfig       `mapstructure:"web"`
-	Websocket *WebsocketConfig `mapstructure:"ws"`
+	P2P        *P2PConfig        `mapstructure:"p2p"`
+	Wallet     *WalletConfig     `mapstructure:"wallet"`
+	Auth       *RPCAuthConfig    `mapstructure:"auth"`
+	Web        *WebConfig        `mapstructure:"web"`
+	Websocket  *WebsocketConfig  `mapstructure:"ws"`
+	Mempool    *MempoolConfig    `mapstructure:"mempool"`
+	Signer     *SignerConfig     `mapstructure:"signer"`
+	VoteReward *VoteRewardConfig `mapstructure:"vote_reward"`
+	Explorer   *ExplorerConfig   `mapstructure:"explorer"`
+	Prune      *PruneConfig      `mapstructure:"prune"`
+
+	validatorSigner signer.Signer
+	nodeKey         *chainkd.XPrv
 }
 
 // Default configurable parameters.
@@ -38,7 +59,20 @@ func DefaultConfig() *Config {
 		Auth:       DefaultRPCAuthConfig(),
 		Web:        DefaultWebConfig(),
 		Websocket:  DefaultWebsocketConfig(),
+		Mempool:    DefaultMempoolConfig(),
+		Signer:     DefaultSignerConfig(),
+		VoteReward: DefaultVoteRewardConfig(),
+		Explorer:   DefaultExplorerConfig(),
+		Prune:      DefaultPruneConfig(),
+	}
+}
+
+// ValidateBasic check the values which can't be corrected at runtime
+func (cfg *Config) ValidateBasic() error {
+	if cfg.TxSelection != TxSelectionTime && cfg.TxSelection != TxSelectionFeeRate {
+		return fmt.Errorf("invalid tx_selection %q, use %s or %s", cfg.TxSelection, TxSelectionTime, TxSelectionFeeRate)
 	}
+	return nil
 }
 
 // Set the RootDir for all Config structs
@@ -56,26 +90,133 @@ func (cfg *Config) PrivateKey() *chainkd.XPrv {
 	}
 
 	filePath := rootify(cfg.PrivateKeyFile, cfg.BaseConfig.RootDir)
-	fildReader, err := os.Open(filePath)
+	xprv, err := signer.LoadKeyFile(filePath, cfg.KeyPassword())
 	if err != nil {
-		log.WithField("err", err).Panic("fail on open private key file")
+		log.WithField("err", err).Panic("fail on load private key file")
 	}
 
-	defer fildReader.Close()
-	buf := make([]byte, 128)
-	if _, err = io.ReadFull(fildReader, buf); err != nil {
-		log.WithField("err", err).Panic("fail on read private key file")
+	cfg.XPrv = xprv
+	xpub := cfg.XPrv.XPub()
+	cfg.XPub = &xpub
+	return cfg.XPrv
+}
+
+// NodeKey return the key of the p2p identity, it's the private key of the node when the
+// validator signs in process. The validator key stays on the remote signer when the
+// remote address is configured, so the node uses the separate p2p key file instead.
+func (cfg *Config) NodeKey() *chainkd.XPrv {
+	if cfg.Signer == nil || cfg.Signer.RemoteAddr == "" {
+		return cfg.PrivateKey()
 	}
 
-	var xprv chainkd.XPrv
-	if _, err := hex.Decode(xprv[:], buf); err != nil {
-		log.WithField("err", err).Panic("fail on decode private key")
+	if cfg.nodeKey != nil {
+		return cfg.nodeKey
 	}
 
-	cfg.XPrv = &xprv
-	xpub := cfg.XPrv.XPub()
-	cfg.XPub = &xpub
-	return cfg.XPrv
+	xprv, err := signer.LoadOrCreateKeyFile(rootify(cfg.Signer.P2PKeyFile, cfg.BaseConfig.RootDir))
+	if err != nil {
+		log.WithField("err", err).Panic("fail on load p2p key file")
+	}
+
+	cfg.nodeKey = xprv
+	return cfg.nodeKey
+}
+
+// KeyPassword read the password of the encrypted private key from the password file,
+// it returns the empty string when the password file is not configured
+func (cfg *Config) KeyPassword() string {
+	if cfg.Signer == nil || cfg.Signer.PasswordFile == "" {
+		return ""
+	}
+
+	password, err := ioutil.ReadFile(rootify(cfg.Signer.PasswordFile, cfg.BaseConfig.RootDir))
+	if err != nil {
+		log.WithField("err", err).Panic("fail on read private key password file")
+	}
+	return strings.TrimRight(string(password), "\r\n")
+}
+
+// ValidatorSigner return the signer of the blocks and the casper votes, it's the remote
+// signer when the remote address is configured, otherwise the private key of the node
+// signs in process with the double sign protection.
+func (cfg *Config) ValidatorSigner() signer.Signer {
+	signerMu.Lock()
+	defer signerMu.Unlock()
+
+	if cfg.validatorSigner != nil {
+		return cfg.validatorSigner
+	}
+
+	signerConfig := cfg.Signer
+	if signerConfig == nil {
+		signerConfig = DefaultSignerConfig()
+	}
+
+	if signerConfig.RemoteAddr != "" {
+		remoteSigner, err := signer.NewRemoteSigner(signerConfig.RemoteAddr)
+		if err != nil {
+			log.WithField("err", err).Panic("fail on connect remote signer")
+		}
+
+		cfg.validatorSigner = remoteSigner
+		return cfg.validatorSigner
+	}
+
+	guard, err := signer.NewGuard(cfg.SignerStateFile())
+	if err != nil {
+		log.WithField("err", err).Panic("fail on load signer state")
+	}
+
+	cfg.validatorSigner = signer.NewLocalSigner(*cfg.PrivateKey(), guard)
+	return cfg.validatorSigner
+}
+
+// VoteRewardPassword read the password of the account key which pays the voter rewards
+func (cfg *Config) VoteRewardPassword() string {
+	if cfg.VoteReward == nil || cfg.VoteReward.PasswordFile == "" {
+		return ""
+	}
+
+	password, err := ioutil.ReadFile(rootify(cfg.VoteReward.PasswordFile, cfg.BaseConfig.RootDir))
+	if err != nil {
+		log.WithField("err", err).Panic("fail on read vote reward password file")
+	}
+	return strings.TrimRight(string(password), "\r\n")
+}
+
+// AllowedPeers return the node public keys merged from the allowed_peers and the
+// allowed_peers_file, the file is read on every call so it can be reloaded
+func (cfg *Config) AllowedPeers() ([]string, error) {
+	pubKeys := []string{}
+	for _, pubKey := range strings.Split(cfg.P2P.AllowedPeers, ",") {
+		if pubKey = strings.TrimSpace(pubKey); pubKey != "" {
+			pubKeys = append(pubKeys, pubKey)
+		}
+	}
+
+	if cfg.P2P.AllowedPeersFile == "" {
+		return pubKeys, nil
+	}
+
+	data, err := ioutil.ReadFile(rootify(cfg.P2P.AllowedPeersFile, cfg.BaseConfig.RootDir))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			pubKeys = append(pubKeys, line)
+		}
+	}
+	return pubKeys, nil
+}
+
+// SignerStateFile return the path of the file which records the signed blocks and votes
+func (cfg *Config) SignerStateFile() string {
+	if cfg.Signer == nil || cfg.Signer.StateFile == "" {
+		return rootify(DefaultSignerConfig().StateFile, cfg.BaseConfig.RootDir)
+	}
+	return rootify(cfg.Signer.StateFile, cfg.BaseConfig.RootDir)
 }
 
 // -----------------------------------------------------------------------------
@@ -102,6 +243,9 @@ type BaseConfig struct {
 
 	Mining bool `mapstructure:"mining"`
 
+	// Transaction selection strategy of block proposal: fee_rate | time
+	TxSelection string `mapstructure:"tx_selection"`
+
 	// Database backend: leveldb | memdb
 	DBBackend string `mapstructure:"db_backend"`
 
@@ -115,9 +259,15 @@ type BaseConfig struct {
 
 	VaultMode bool `mapstructure:"vault_mode"`
 
+	// Light node only syncs the block headers and the wallet related transactions
+	LightMode bool `mapstructure:"light_mode"`
+
 	// log file name
 	LogFile string `mapstructure:"log_file"`
 
+	// The genesis file of the custom network, it's written by the init command
+	GenesisFile string `mapstructure:"genesis_file"`
+
 	PrivateKeyFile string `mapstructure:"private_key_file"`
 	XPrv           *chainkd.XPrv
 	XPub           *chainkd.XPub
@@ -129,12 +279,14 @@ func DefaultBaseConfig() BaseConfig {
 		Moniker:           "anonymous",
 		ProfListenAddress: "",
 		Mining:            false,
+		TxSelection:       TxSelectionTime,
 		DBBackend:         "leveldb",
 		DBPath:            "data",
 		KeysPath:          "keystore",
 		NodeAlias:         "",
 		LogFile:           "log",
 		PrivateKeyFile:    "node_key.txt",
+		GenesisFile:       "genesis.json",
 	}
 }
 
@@ -150,6 +302,10 @@ func (b BaseConfig) KeysDir() string {
 	return rootify(b.KeysPath, b.RootDir)
 }
 
+func (b BaseConfig) GenesisPath() string {
+	return rootify(b.GenesisFile, b.RootDir)
+}
+
 // P2PConfig
 type P2PConfig struct {
 	ListenAddress    string `mapstructure:"laddr"`
@@ -163,6 +319,14 @@ type P2PConfig struct {
 	ProxyUsername    string `mapstructure:"proxy_username"`
 	ProxyPassword    string `mapstructure:"proxy_password"`
 	KeepDial         string `mapstructure:"keep_dial"`
+	AllowedPeers     string `mapstructure:"allowed_peers"`
+	AllowedPeersFile string `mapstructure:"allowed_peers_file"`
+	PrivateValidator bool   `mapstructure:"private_validator"`
+}
+
+// AllowListEnabled return whether only the allowed node public keys may connect
+func (p *P2PConfig) AllowListEnabled() bool {
+	return p.AllowedPeers != "" || p.AllowedPeersFile != ""
 }
 
 // Default configurable p2p parameters.
@@ -201,6 +365,53 @@ type WebsocketConfig struct {
 	MaxNumConcurrentReqs int `mapstructure:"max_num_concurrent_reqs"`
 }
 
+type SignerConfig struct {
+	// The address of the remote signer, unix:///path/to/socket or tcp://127.0.0.1:port.
+	// The node key signs in process when it's empty
+	RemoteAddr string `mapstructure:"remote_addr"`
+	// The file contains the password of the encrypted node key
+	PasswordFile string `mapstructure:"password_file"`
+	// The file records the signed blocks and votes for the double sign protection
+	StateFile string `mapstructure:"state_file"`
+	// The file contains the p2p identity key when the node key is on the remote signer,
+	// it's generated at the first start
+	P2PKeyFile string `mapstructure:"p2p_key_file"`
+}
+
+// VoteRewardConfig is the config of the voter reward distribution by the validator node
+type VoteRewardConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// The account receives the validator rewards and pays the voters
+	AccountID string `mapstructure:"account_id"`
+	// The file contains the password of the account key
+	PasswordFile string `mapstructure:"password_file"`
+	// The percent of the validator rewards distributed to the voters
+	RewardRatio uint64 `mapstructure:"reward_ratio"`
+	// The max num of the voters paid by one transaction
+	BatchSize int `mapstructure:"batch_size"`
+	// The fee of each payout transaction
+	Fee uint64 `mapstructure:"fee"`
+}
+
+// ExplorerConfig is the config of the address and asset indexes of the full chain
+type ExplorerConfig struct {
+	Enable bool `mapstructure:"enable"`
+}
+
+// PruneConfig is the config of deleting the old block transactions
+type PruneConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// The number of the blocks keeping the transactions below the last finalized checkpoint
+	Depth uint64 `mapstructure:"depth"`
+}
+
+type MempoolConfig struct {
+	// Allow the transaction to replace the conflicting transactions by paying more fee
+	ReplaceByFee bool `mapstructure:"replace_by_fee"`
+	// The minimum percent of fee the replacement should pay more than the replaced transactions
+	MinFeeBump uint64 `mapstructure:"min_fee_bump"`
+}
+
 // Default configurable rpc's auth parameters.
 func DefaultRPCAuthConfig() *RPCAuthConfig {
 	return &RPCAuthConfig{
@@ -232,6 +443,50 @@ func DefaultWebsocketConfig() *WebsocketConfig {
 	}
 }
 
+// Default configurable signer parameters.
+func DefaultSignerConfig() *SignerConfig {
+	return &SignerConfig{
+		RemoteAddr:   "",
+		PasswordFile: "",
+		StateFile:    "signer_state.json",
+		P2PKeyFile:   "p2p_key.txt",
+	}
+}
+
+// Default configurable vote reward parameters.
+func DefaultVoteRewardConfig() *VoteRewardConfig {
+	return &VoteRewardConfig{
+		Enable:      false,
+		RewardRatio: 50,
+		BatchSize:   100,
+		Fee:         20000000,
+	}
+}
+
+// Default configurable explorer parameters.
+func DefaultExplorerConfig() *ExplorerConfig {
+	return &ExplorerConfig{
+		Enable: false,
+	}
+}
+
+// Default configurable prune parameters, the depth is raised to the lock time of the vote
+// outputs when it's shorter.
+func DefaultPruneConfig() *PruneConfig {
+	return &PruneConfig{
+		Enable: false,
+		Depth:  0,
+	}
+}
+
+// Default configurable mempool parameters.
+func DefaultMempoolConfig() *MempoolConfig {
+	return &MempoolConfig{
+		ReplaceByFee: false,
+		MinFeeBump:   10,
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Utils
 
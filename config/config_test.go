@@ -1,6 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,3 +23,42 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal("/opt/data", cfg.DBDir())
 
 }
+
+func TestValidateBasic(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.ValidateBasic(); err != nil || cfg.TxSelection != TxSelectionTime {
+		t.Fatalf("the default config is invalid: %v", err)
+	}
+
+	cfg.TxSelection = TxSelectionFeeRate
+	if err := cfg.ValidateBasic(); err != nil {
+		t.Errorf("got err %v of %s", err, cfg.TxSelection)
+	}
+
+	cfg.TxSelection = "fee"
+	if err := cfg.ValidateBasic(); err == nil {
+		t.Error("accept the unknown tx_selection")
+	}
+}
+
+func TestNodeKeyOfRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := DefaultConfig()
+	cfg.SetRoot(dir)
+	cfg.Signer.RemoteAddr = "unix://" + filepath.Join(dir, "signer.sock")
+
+	nodeKey := cfg.NodeKey()
+	if _, err := os.Stat(filepath.Join(dir, cfg.PrivateKeyFile)); !os.IsNotExist(err) {
+		t.Errorf("the validator key file is touched by the remote signer node: %v", err)
+	}
+
+	cfg.nodeKey = nil
+	if got := cfg.NodeKey(); *got != *nodeKey {
+		t.Errorf("the p2p key is not persisted, got %s, want %s", got.XPub().String(), nodeKey.XPub().String())
+	}
+}
@@ -79,8 +79,44 @@ func soloNetGenesisBlock() *types.Block {
 	return block
 }
 
+// customNetGenesisBlock build the genesis block by the allocations of the custom network
+func customNetGenesisBlock(genesis *consensus.GenesisConfig) *types.Block {
+	outputs := []*types.TxOutput{}
+	for _, allocation := range genesis.Allocations {
+		outputs = append(outputs, types.NewOriginalTxOutput(*consensus.CGAssetID, allocation.Amount, allocation.ControlProgram, nil))
+	}
+
+	txData := types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte("Genesis of " + consensus.ActiveNetParams.Name))},
+		Outputs: outputs,
+	}
+	txs := []*types.Tx{types.NewTx(txData)}
+	merkleRoot, err := types.TxMerkleRoot(toBCTxs(txs))
+	if err != nil {
+		log.Panicf("fail on calc genesis tx merkel root")
+	}
+
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Version:   1,
+			Height:    0,
+			Timestamp: genesis.Timestamp,
+			BlockCommitment: types.BlockCommitment{
+				TransactionsMerkleRoot: merkleRoot,
+			},
+		},
+		Transactions: txs,
+	}
+	return block
+}
+
 // GenesisBlock will return genesis block
 func GenesisBlock() *types.Block {
+	if genesis := consensus.ActiveNetParams.Genesis; genesis != nil {
+		return customNetGenesisBlock(genesis)
+	}
+
 	return map[string]func() *types.Block{
 		"main": mainNetGenesisBlock,
 		"test": testNetGenesisBlock,
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"path"
 
 	cmn "github.com/tendermint/tmlibs/common"
@@ -19,6 +20,27 @@ func EnsureRoot(rootDir string, network string) {
 	}
 }
 
+// EnsureCustomNetRoot init the root dir of the custom network, the network definition
+// is saved as the genesis file which is loaded on every start
+func EnsureCustomNetRoot(rootDir string, chainID string, port string, genesis []byte) {
+	cmn.EnsureDir(rootDir, 0700)
+	cmn.EnsureDir(rootDir+"/data", 0700)
+
+	if port == "" {
+		port = "46658"
+	}
+
+	configFilePath := path.Join(rootDir, "config.toml")
+	if !cmn.FileExists(configFilePath) {
+		cmn.MustWriteFile(configFilePath, []byte(defaultConfigTmpl+fmt.Sprintf(customNetConfigTmpl, chainID, port)), 0644)
+	}
+
+	genesisFilePath := path.Join(rootDir, DefaultBaseConfig().GenesisFile)
+	if !cmn.FileExists(genesisFilePath) {
+		cmn.MustWriteFile(genesisFilePath, genesis, 0644)
+	}
+}
+
 var defaultConfigTmpl = `# This is a TOML config file.
 # For more information, see https://github.com/toml-lang/toml
 fast_sync = true
@@ -45,6 +67,12 @@ laddr = "tcp://0.0.0.0:46658"
 seeds = ""
 `
 
+var customNetConfigTmpl = `chain_id = "%s"
+[p2p]
+laddr = "tcp://0.0.0.0:%s"
+seeds = ""
+`
+
 // Select network seeds to merge a new string.
 func selectNetwork(network string) string {
 	switch network {
@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"fmt"
+)
+
+// ChainRules is the consensus rules which could be changed by the protocol upgrade
+type ChainRules struct {
+	// MaxBlockGas is the max gas that one block contains
+	MaxBlockGas uint64 `json:"max_block_gas,omitempty" toml:"max_block_gas"`
+
+	// MaxGasAmount is the max gas that one transaction could use
+	MaxGasAmount int64 `json:"max_gas_amount,omitempty" toml:"max_gas_amount"`
+
+	// MaxNumOfValidators is the num of the validators in one epoch, it can't exceed the
+	// signature slots of the sup link
+	MaxNumOfValidators int `json:"max_num_of_validators,omitempty" toml:"max_num_of_validators"`
+
+	// RewardThreshold is the pledge rate below which the block reward is reduced
+	RewardThreshold float64 `json:"reward_threshold,omitempty" toml:"reward_threshold"`
+
+	// BlockReward is the reward of the validator for each block
+	BlockReward uint64 `json:"block_reward,omitempty" toml:"block_reward"`
+
+	// MaxCheckPredicateDepth is the max nesting level of CHECKPREDICATE in the vm, zero means no limit
+	MaxCheckPredicateDepth int `json:"max_check_predicate_depth,omitempty" toml:"max_check_predicate_depth"`
+}
+
+func defaultChainRules() *ChainRules {
+	return &ChainRules{
+		MaxBlockGas:        MaxBlockGas,
+		MaxGasAmount:       MaxGasAmount,
+		MaxNumOfValidators: MaxNumOfValidators,
+		RewardThreshold:    RewardThreshold,
+		BlockReward:        BlockReward,
+	}
+}
+
+// override replace the rules by the non-zero fields of the upgrade
+func (r *ChainRules) override(upgrade *ChainRules) {
+	if upgrade.MaxBlockGas != 0 {
+		r.MaxBlockGas = upgrade.MaxBlockGas
+	}
+	if upgrade.MaxGasAmount != 0 {
+		r.MaxGasAmount = upgrade.MaxGasAmount
+	}
+	if upgrade.MaxNumOfValidators != 0 {
+		r.MaxNumOfValidators = upgrade.MaxNumOfValidators
+	}
+	if upgrade.RewardThreshold != 0 {
+		r.RewardThreshold = upgrade.RewardThreshold
+	}
+	if upgrade.BlockReward != 0 {
+		r.BlockReward = upgrade.BlockReward
+	}
+	if upgrade.MaxCheckPredicateDepth != 0 {
+		r.MaxCheckPredicateDepth = upgrade.MaxCheckPredicateDepth
+	}
+}
+
+// Fork is a named protocol upgrade which is activated at the height, or at the checkpoint
+// of the epoch when the epoch is set. The non-zero rules of the fork override the rules
+// of the previous forks.
+type Fork struct {
+	Name   string     `json:"name" toml:"name"`
+	Height uint64     `json:"height,omitempty" toml:"height"`
+	Epoch  uint64     `json:"epoch,omitempty" toml:"epoch"`
+	Rules  ChainRules `json:"rules" toml:"rules"`
+}
+
+// ActivationHeight return the first height which the fork is active
+func (f *Fork) ActivationHeight(blocksOfEpoch uint64) uint64 {
+	if f.Epoch != 0 {
+		return f.Epoch * blocksOfEpoch
+	}
+	return f.Height
+}
+
+// checkForks make sure the forks are named uniquely, activated in order and the
+// rules are in the valid range
+func checkForks(forks []Fork, blocksOfEpoch uint64) error {
+	names := make(map[string]bool)
+	var lastHeight uint64
+	for i, fork := range forks {
+		if fork.Name == "" || names[fork.Name] {
+			return fmt.Errorf("fork %d has empty or duplicate name %q", i, fork.Name)
+		}
+		names[fork.Name] = true
+
+		height := fork.ActivationHeight(blocksOfEpoch)
+		if height < lastHeight {
+			return fmt.Errorf("fork %s is activated at %d before the previous fork", fork.Name, height)
+		}
+		lastHeight = height
+
+		if fork.Rules.MaxNumOfValidators < 0 || fork.Rules.MaxNumOfValidators > MaxNumOfValidators {
+			return fmt.Errorf("fork %s has max num of validators exceeds %d", fork.Name, MaxNumOfValidators)
+		}
+
+		if fork.Rules.MaxGasAmount < 0 || fork.Rules.MaxCheckPredicateDepth < 0 || fork.Rules.RewardThreshold < 0 || fork.Rules.RewardThreshold > 1 {
+			return fmt.Errorf("fork %s has negative rules or reward threshold out of range", fork.Name)
+		}
+	}
+	return nil
+}
+
+// RulesAt return the consensus rules of the network at the height
+func (p *Params) RulesAt(height uint64) *ChainRules {
+	rules := defaultChainRules()
+	for i := range p.Forks {
+		if height >= p.Forks[i].ActivationHeight(p.BlocksOfEpoch) {
+			rules.override(&p.Forks[i].Rules)
+		}
+	}
+	return rules
+}
+
+// IsForkActive return whether the named fork of the network is activated at the height
+func (p *Params) IsForkActive(name string, height uint64) bool {
+	for i := range p.Forks {
+		if p.Forks[i].Name == name {
+			return height >= p.Forks[i].ActivationHeight(p.BlocksOfEpoch)
+		}
+	}
+	return false
+}
+
+// Rules return the consensus rules of the active network at the height
+func Rules(height uint64) *ChainRules {
+	return ActiveNetParams.RulesAt(height)
+}
+
+// IsForkActive return whether the named fork of the active network is activated at the height
+func IsForkActive(name string, height uint64) bool {
+	return ActiveNetParams.IsForkActive(name, height)
+}
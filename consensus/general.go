@@ -62,9 +62,9 @@ type CasperConfig struct {
 }
 
 type VotePendingBlockNum struct {
-	BeginBlock uint64
-	EndBlock   uint64
-	Num        uint64
+	BeginBlock uint64 `json:"begin_block" toml:"begin_block"`
+	EndBlock   uint64 `json:"end_block" toml:"end_block"`
+	Num        uint64 `json:"num" toml:"num"`
 }
 
 // CGAssetID is CG's asset id, the soul asset of Coingod
@@ -105,6 +105,13 @@ type Params struct {
 
 	// CasperConfig defines the casper consensus parameters
 	CasperConfig
+
+	// Forks defines the protocol upgrades in the order of activation
+	Forks []Fork
+
+	// Genesis defines the genesis block of the custom network, it's nil for the
+	// built-in networks which use the hardcoded genesis block
+	Genesis *GenesisConfig
 }
 
 // ActiveNetParams is ...
@@ -178,8 +185,15 @@ func VotePendingBlockNums(height uint64) uint64 {
 	return defaultVotePendingNum
 }
 
-// InitActiveNetParams load the config by chain ID
-func InitActiveNetParams(chainID string) error {
+// InitActiveNetParams load the config by chain ID, the custom network defined by the
+// genesis file is registered first if the file exists
+func InitActiveNetParams(chainID string, genesisFile string) error {
+	if genesisFile != "" && fileExists(genesisFile) {
+		if err := loadNetworkDefinition(genesisFile); err != nil {
+			return err
+		}
+	}
+
 	var exist bool
 	if ActiveNetParams, exist = NetParams[chainID]; !exist {
 		return fmt.Errorf("chain_id[%v] don't exist", chainID)
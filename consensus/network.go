@@ -0,0 +1,189 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"coingod/crypto/ed25519/chainkd"
+)
+
+var (
+	errEmptyChainID     = errors.New("chain_id of the custom network is empty")
+	errBuiltinChainID   = errors.New("chain_id of the custom network conflicts with the built-in network")
+	errEmptyBech32HRP   = errors.New("bech32_hrp of the custom network is empty")
+	errBadCasperConfig  = errors.New("block_time_interval and blocks_of_epoch must be greater than 0")
+	errEmptyAllocations = errors.New("the custom network has no initial allocation")
+	errEmptyFederation  = errors.New("the custom network has no federation xpub to propose the blocks")
+)
+
+// GenesisAllocation is the initial CG output of the custom network genesis block
+type GenesisAllocation struct {
+	ControlProgram []byte
+	Amount         uint64
+}
+
+// GenesisConfig defines the genesis block of the custom network
+type GenesisConfig struct {
+	Timestamp   uint64
+	Allocations []GenesisAllocation
+}
+
+// NetworkDefinition is the custom network loaded from the genesis file, which could be
+// written in json or toml
+type NetworkDefinition struct {
+	ChainID              string                 `json:"chain_id" toml:"chain_id"`
+	Bech32HRPSegwit      string                 `json:"bech32_hrp" toml:"bech32_hrp"`
+	DefaultPort          string                 `json:"default_port" toml:"default_port"`
+	DNSSeeds             []string               `json:"dns_seeds" toml:"dns_seeds"`
+	BlockTimeInterval    uint64                 `json:"block_time_interval" toml:"block_time_interval"`
+	MaxTimeOffsetMs      uint64                 `json:"max_time_offset_ms" toml:"max_time_offset_ms"`
+	BlocksOfEpoch        uint64                 `json:"blocks_of_epoch" toml:"blocks_of_epoch"`
+	MinValidatorVoteNum  uint64                 `json:"min_validator_vote_num" toml:"min_validator_vote_num"`
+	VotePendingBlockNums []VotePendingBlockNum  `json:"vote_pending_block_nums" toml:"vote_pending_block_nums"`
+	FederationXpubs      []string               `json:"federation_xpubs" toml:"federation_xpubs"`
+	GenesisTimestamp     uint64                 `json:"genesis_timestamp" toml:"genesis_timestamp"`
+	Allocations          []AllocationDefinition `json:"allocations" toml:"allocations"`
+	Forks                []Fork                 `json:"forks" toml:"forks"`
+}
+
+// AllocationDefinition is the initial allocation in the genesis file, the control program is hex encoded
+type AllocationDefinition struct {
+	ControlProgram string `json:"control_program" toml:"control_program"`
+	Amount         uint64 `json:"amount" toml:"amount"`
+}
+
+// ReadNetworkDefinition parse the genesis file, the toml format is selected by the .toml extension
+func ReadNetworkDefinition(path string) (*NetworkDefinition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	def := &NetworkDefinition{}
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, def)
+	} else {
+		err = json.Unmarshal(data, def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail on parse genesis file %s: %v", path, err)
+	}
+	return def, nil
+}
+
+// Params check the definition and convert it to the network params
+func (d *NetworkDefinition) Params() (*Params, error) {
+	if d.ChainID == "" {
+		return nil, errEmptyChainID
+	}
+
+	for chainID, params := range NetParams {
+		if params.Genesis == nil && (chainID == d.ChainID || params.Name == d.ChainID) {
+			return nil, errBuiltinChainID
+		}
+	}
+
+	if d.Bech32HRPSegwit == "" {
+		return nil, errEmptyBech32HRP
+	}
+
+	if d.BlockTimeInterval == 0 || d.BlocksOfEpoch == 0 {
+		return nil, errBadCasperConfig
+	}
+
+	if len(d.Allocations) == 0 {
+		return nil, errEmptyAllocations
+	}
+
+	// the federation proposes the blocks until the validators are elected by the votes
+	if len(d.FederationXpubs) == 0 {
+		return nil, errEmptyFederation
+	}
+
+	params := &Params{
+		Name:            d.ChainID,
+		Bech32HRPSegwit: d.Bech32HRPSegwit,
+		DefaultPort:     d.DefaultPort,
+		DNSSeeds:        d.DNSSeeds,
+		CasperConfig: CasperConfig{
+			BlockTimeInterval:   d.BlockTimeInterval,
+			MaxTimeOffsetMs:     d.MaxTimeOffsetMs,
+			BlocksOfEpoch:       d.BlocksOfEpoch,
+			MinValidatorVoteNum: d.MinValidatorVoteNum,
+			FederationXpubs:     []chainkd.XPub{},
+		},
+		Genesis: &GenesisConfig{Timestamp: d.GenesisTimestamp},
+	}
+
+	for _, pendingNum := range d.VotePendingBlockNums {
+		// the zero end block means the schedule never ends
+		if pendingNum.EndBlock == 0 {
+			pendingNum.EndBlock = math.MaxUint64
+		}
+
+		if pendingNum.EndBlock <= pendingNum.BeginBlock {
+			return nil, fmt.Errorf("vote pending schedule from %d to %d is empty", pendingNum.BeginBlock, pendingNum.EndBlock)
+		}
+		params.VotePendingBlockNums = append(params.VotePendingBlockNums, pendingNum)
+	}
+
+	if len(params.VotePendingBlockNums) == 0 {
+		params.VotePendingBlockNums = []VotePendingBlockNum{{BeginBlock: 0, EndBlock: math.MaxUint64, Num: defaultVotePendingNum}}
+	}
+
+	if err := checkForks(d.Forks, d.BlocksOfEpoch); err != nil {
+		return nil, err
+	}
+	params.Forks = d.Forks
+
+	for _, str := range d.FederationXpubs {
+		var xpub chainkd.XPub
+		if err := xpub.UnmarshalText([]byte(str)); err != nil {
+			return nil, fmt.Errorf("invalid federation xpub %s: %v", str, err)
+		}
+		params.FederationXpubs = append(params.FederationXpubs, xpub)
+	}
+
+	for _, allocation := range d.Allocations {
+		program, err := hex.DecodeString(allocation.ControlProgram)
+		if err != nil || len(program) == 0 {
+			return nil, fmt.Errorf("invalid allocation control program %s", allocation.ControlProgram)
+		}
+
+		if allocation.Amount == 0 {
+			return nil, fmt.Errorf("allocation to %s has zero amount", allocation.ControlProgram)
+		}
+		params.Genesis.Allocations = append(params.Genesis.Allocations, GenesisAllocation{ControlProgram: program, Amount: allocation.Amount})
+	}
+	return params, nil
+}
+
+// loadNetworkDefinition register the custom network of the genesis file to the NetParams
+func loadNetworkDefinition(path string) error {
+	def, err := ReadNetworkDefinition(path)
+	if err != nil {
+		return err
+	}
+
+	params, err := def.Params()
+	if err != nil {
+		return err
+	}
+
+	NetParams[def.ChainID] = *params
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,146 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const jsonNetworkDefinition = `{
+	"chain_id": "privnet",
+	"bech32_hrp": "pc",
+	"default_port": "46700",
+	"block_time_interval": 3000,
+	"max_time_offset_ms": 6000,
+	"blocks_of_epoch": 20,
+	"min_validator_vote_num": 100000000,
+	"vote_pending_block_nums": [{"begin_block": 0, "end_block": 1000, "num": 5}, {"begin_block": 1000, "num": 50}],
+	"federation_xpubs": ["8c675cc0d0de07618dedd702fe54321f3dd0ab46b4b50deac4b87940ac0a974f79b9e33ca3161bf8cbd8d64b8214bd85db2e9bb04be0393f41041278278530c3"],
+	"genesis_timestamp": 1736233200000,
+	"allocations": [{"control_program": "0014f09582056ca6dea02c11c136a831fd25d090927e", "amount": 100000000000}]
+}`
+
+const tomlNetworkDefinition = `
+chain_id = "privnet"
+bech32_hrp = "pc"
+block_time_interval = 3000
+blocks_of_epoch = 20
+federation_xpubs = ["8c675cc0d0de07618dedd702fe54321f3dd0ab46b4b50deac4b87940ac0a974f79b9e33ca3161bf8cbd8d64b8214bd85db2e9bb04be0393f41041278278530c3"]
+genesis_timestamp = 1736233200000
+
+[[allocations]]
+control_program = "0014f09582056ca6dea02c11c136a831fd25d090927e"
+amount = 100000000000
+`
+
+func writeGenesisFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInitCustomNetParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(active Params) {
+		ActiveNetParams = active
+		delete(NetParams, "privnet")
+	}(ActiveNetParams)
+
+	for _, path := range []string{writeGenesisFile(t, dir, "genesis.json", jsonNetworkDefinition), writeGenesisFile(t, dir, "genesis.toml", tomlNetworkDefinition)} {
+		delete(NetParams, "privnet")
+		if err := InitActiveNetParams("privnet", path); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		if ActiveNetParams.Name != "privnet" || ActiveNetParams.Bech32HRPSegwit != "pc" || ActiveNetParams.BlocksOfEpoch != 20 {
+			t.Errorf("%s: got params %v", path, ActiveNetParams)
+		}
+
+		if genesis := ActiveNetParams.Genesis; genesis == nil || genesis.Timestamp != 1736233200000 || len(genesis.Allocations) != 1 || genesis.Allocations[0].Amount != 100000000000 {
+			t.Errorf("%s: got genesis %v", path, genesis)
+		}
+	}
+
+	// the last loaded toml definition uses the default vote pending schedule
+	if got := VotePendingBlockNums(100); got != defaultVotePendingNum {
+		t.Errorf("got vote pending num %d, want %d", got, defaultVotePendingNum)
+	}
+
+	delete(NetParams, "privnet")
+	if err := InitActiveNetParams("privnet", filepath.Join(dir, "genesis.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ActiveNetParams.FederationXpubs) != 1 {
+		t.Errorf("got %d federation xpubs, want 1", len(ActiveNetParams.FederationXpubs))
+	}
+
+	pendingCases := map[uint64]uint64{0: 5, 999: 5, 1000: 50, math.MaxUint64 - 1: 50}
+	for height, want := range pendingCases {
+		if got := VotePendingBlockNums(height); got != want {
+			t.Errorf("height %d: got vote pending num %d, want %d", height, got, want)
+		}
+	}
+
+	// the missing genesis file doesn't affect the built-in networks
+	if err := InitActiveNetParams("solonet", filepath.Join(dir, "missing.json")); err != nil || ActiveNetParams.Genesis != nil {
+		t.Errorf("got err %v and genesis %v for the built-in network", err, ActiveNetParams.Genesis)
+	}
+}
+
+func TestNetworkDefinitionParams(t *testing.T) {
+	valid := func() *NetworkDefinition {
+		return &NetworkDefinition{
+			ChainID:           "privnet",
+			Bech32HRPSegwit:   "pc",
+			BlockTimeInterval: 3000,
+			BlocksOfEpoch:     20,
+			FederationXpubs:   []string{"8c675cc0d0de07618dedd702fe54321f3dd0ab46b4b50deac4b87940ac0a974f79b9e33ca3161bf8cbd8d64b8214bd85db2e9bb04be0393f41041278278530c3"},
+			Allocations:       []AllocationDefinition{{ControlProgram: "0014f09582056ca6dea02c11c136a831fd25d090927e", Amount: 1}},
+		}
+	}
+
+	cases := []struct {
+		desc   string
+		modify func(*NetworkDefinition)
+	}{
+		{desc: "empty chain id", modify: func(d *NetworkDefinition) { d.ChainID = "" }},
+		{desc: "built-in chain id", modify: func(d *NetworkDefinition) { d.ChainID = "mainnet" }},
+		{desc: "empty bech32 hrp", modify: func(d *NetworkDefinition) { d.Bech32HRPSegwit = "" }},
+		{desc: "zero epoch", modify: func(d *NetworkDefinition) { d.BlocksOfEpoch = 0 }},
+		{desc: "bad xpub", modify: func(d *NetworkDefinition) { d.FederationXpubs = []string{"00"} }},
+		{desc: "no federation xpub", modify: func(d *NetworkDefinition) { d.FederationXpubs = nil }},
+		{desc: "bad program", modify: func(d *NetworkDefinition) { d.Allocations[0].ControlProgram = "zz" }},
+		{desc: "zero amount", modify: func(d *NetworkDefinition) { d.Allocations[0].Amount = 0 }},
+		{desc: "no allocation", modify: func(d *NetworkDefinition) { d.Allocations = nil }},
+		{desc: "duplicate fork", modify: func(d *NetworkDefinition) { d.Forks = []Fork{{Name: "a", Height: 1}, {Name: "a", Height: 2}} }},
+		{desc: "fork out of order", modify: func(d *NetworkDefinition) { d.Forks = []Fork{{Name: "a", Epoch: 2}, {Name: "b", Height: 39}} }},
+		{desc: "too many validators", modify: func(d *NetworkDefinition) {
+			d.Forks = []Fork{{Name: "a", Rules: ChainRules{MaxNumOfValidators: MaxNumOfValidators + 1}}}
+		}},
+		{desc: "empty pending schedule", modify: func(d *NetworkDefinition) {
+			d.VotePendingBlockNums = []VotePendingBlockNum{{BeginBlock: 10, EndBlock: 10, Num: 1}}
+		}},
+	}
+
+	if _, err := valid().Params(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range cases {
+		def := valid()
+		c.modify(def)
+		if _, err := def.Params(); err == nil {
+			t.Errorf("case %d(%s): got nil error", i, c.desc)
+		}
+	}
+}
@@ -11,8 +11,14 @@ const (
 	SFFastSync
 	// SFSPV indicate peer support spv mode
 	SFSPV
+	// SFPruned indicate peer only keeps the transactions of the recent blocks
+	SFPruned
 	// DefaultServices is the server that this node support
 	DefaultServices = SFFullNode | SFFastSync | SFSPV
+	// PrunedServices is the server that the pruned node support, it can't serve the fast sync
+	PrunedServices = SFFullNode | SFSPV | SFPruned
+	// LightServices is the server that the light node support, it can't serve any data to the peers
+	LightServices ServiceFlag = 0
 )
 
 // IsEnable check does the flag support the input flag function
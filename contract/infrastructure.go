@@ -1,6 +1,7 @@
 package contract
 
 import (
+	"coingod/event"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 )
@@ -8,13 +9,17 @@ import (
 type Infrastructure struct {
 	Chain      ChainService
 	Repository Repository
+	Dispatcher *event.Dispatcher
 }
 
-func NewInfrastructure(chain ChainService, repository Repository) *Infrastructure {
-	return &Infrastructure{Chain: chain, Repository: repository}
+// NewInfrastructure create the infrastructure of the trace service, the instance events
+// and the callbacks are disabled when the dispatcher is nil
+func NewInfrastructure(chain ChainService, repository Repository, dispatcher *event.Dispatcher) *Infrastructure {
+	return &Infrastructure{Chain: chain, Repository: repository, Dispatcher: dispatcher}
 }
 
 type ChainService interface {
+	BaseBlockHeight() uint64
 	BestChain() (uint64, bc.Hash)
 	FinalizedHeight() uint64
 	GetBlockByHash(*bc.Hash) (*types.Block, error)
@@ -35,4 +40,7 @@ type Repository interface {
 	RemoveInstance(traceID string)
 	GetChainStatus() *ChainStatus
 	SaveChainStatus(status *ChainStatus) error
+	LoadCallbacks() ([]*Callback, error)
+	SaveCallback(callback *Callback) error
+	RemoveCallback(id string)
 }
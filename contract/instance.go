@@ -1,6 +1,8 @@
 package contract
 
 import (
+	"sort"
+
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"github.com/google/uuid"
@@ -30,6 +32,20 @@ type Instance struct {
 	ScannedHash   bc.Hash  `json:"scanned_hash"`
 	ScannedHeight uint64   `json:"scanned_height"`
 	Unconfirmed   []*TreeNode
+	Deltas        []*Delta `json:"deltas"`
+
+	// prevStatus is the status before the last transfer or rollback, it's only used for the notification
+	prevStatus Status
+}
+
+// Delta is the state of the instance before a transfer, the rollback of the transfer restores
+// it exactly since the instances merged by the transfer own the different utxos before it
+type Delta struct {
+	TxHash      bc.Hash  `json:"tx_hash"`
+	BlockHeight uint64   `json:"block_height"`
+	UTXOs       []*UTXO  `json:"utxos"`
+	PrevTxHash  *bc.Hash `json:"prev_tx_hash"`
+	Status      Status   `json:"status"`
 }
 
 func newInstance(t *transfer, block *types.Block) *Instance {
@@ -48,15 +64,19 @@ func newInstance(t *transfer, block *types.Block) *Instance {
 	return inst
 }
 
+// transferTo replace the spent utxos of the instance by the contract outputs of the transfer, the
+// instance is ended when all the utxos are spent without any contract output
 func (i *Instance) transferTo(t *transfer, blockHeight uint64) *Instance {
 	inst := &Instance{
 		TraceID:     i.TraceID,
 		Status:      i.Status,
 		Unconfirmed: i.Unconfirmed,
-		UTXOs:       t.outUTXOs,
+		UTXOs:       append(excludeUTXOs(i.UTXOs, t.inUTXOs), t.outUTXOs...),
 		TxHash:      &t.txHash,
+		Deltas:      append(append([]*Delta{}, i.Deltas...), &Delta{TxHash: t.txHash, BlockHeight: blockHeight, UTXOs: i.UTXOs, PrevTxHash: i.TxHash, Status: i.Status}),
+		prevStatus:  i.Status,
 	}
-	if len(t.outUTXOs) == 0 {
+	if len(inst.UTXOs) == 0 {
 		inst.Status = Ended
 		inst.EndedHeight = blockHeight
 		inst.UTXOs = t.inUTXOs
@@ -65,35 +85,66 @@ func (i *Instance) transferTo(t *transfer, blockHeight uint64) *Instance {
 	return inst
 }
 
+// rollbackTo restore the state of the instance before the transfer from its delta, the instance
+// without the delta of the transfer follows all the utxos spent by the transfer
 func (i *Instance) rollbackTo(t *transfer) *Instance {
-	return &Instance{
-		TraceID:     i.TraceID,
-		Status:      InSync,
-		UTXOs:       t.inUTXOs,
-		TxHash:      nil,
-		Unconfirmed: nil,
+	inst := &Instance{
+		TraceID:    i.TraceID,
+		Status:     InSync,
+		Deltas:     i.Deltas,
+		prevStatus: i.Status,
 	}
+	for j := len(i.Deltas) - 1; j >= 0; j-- {
+		if delta := i.Deltas[j]; delta.TxHash == t.txHash {
+			inst.UTXOs, inst.TxHash, inst.Status = delta.UTXOs, delta.PrevTxHash, delta.Status
+			inst.Deltas = append(append([]*Delta{}, i.Deltas[:j]...), i.Deltas[j+1:]...)
+			return inst
+		}
+	}
+
+	inst.UTXOs = t.inUTXOs
+	if i.Status != Ended {
+		inst.UTXOs = append(excludeUTXOs(i.UTXOs, t.outUTXOs), t.inUTXOs...)
+	}
+	return inst
 }
 
+// pruneDeltas drop the deltas of the finalized blocks which can't be rolled back
+func (i *Instance) pruneDeltas(finalizedHeight uint64) {
+	var deltas []*Delta
+	for _, delta := range i.Deltas {
+		if delta.BlockHeight > finalizedHeight {
+			deltas = append(deltas, delta)
+		}
+	}
+	i.Deltas = deltas
+}
+
+// confirmTx replace the confirmed transfer by its children in the unconfirmed tree, the other
+// transfers are kept until they leave the tx pool
 func (i *Instance) confirmTx(txHash bc.Hash) {
+	var nodes []*TreeNode
 	for _, node := range i.Unconfirmed {
 		if node.TxHash == txHash {
-			i.Unconfirmed = node.Children
-			return
+			nodes = append(nodes, node.Children...)
+		} else {
+			nodes = append(nodes, node)
 		}
 	}
-	i.Unconfirmed = nil
+	i.Unconfirmed = nodes
 }
 
+// instanceIndex index the instances by the trace id and each of their utxos, a utxo may
+// belong to several instances after their contract utxos are merged
 type instanceIndex struct {
 	traceIdToInst  map[string]*Instance
-	utxoHashToInst map[bc.Hash]*Instance
+	utxoHashToInst map[bc.Hash]map[string]*Instance
 }
 
 func newInstanceIndex() *instanceIndex {
 	return &instanceIndex{
 		traceIdToInst:  make(map[string]*Instance),
-		utxoHashToInst: make(map[bc.Hash]*Instance),
+		utxoHashToInst: make(map[bc.Hash]map[string]*Instance),
 	}
 }
 
@@ -109,14 +160,35 @@ func (i *instanceIndex) getByID(traceID string) *Instance {
 	return i.traceIdToInst[traceID]
 }
 
-func (i *instanceIndex) getByUTXO(utxoHash bc.Hash) *Instance {
-	return i.utxoHashToInst[utxoHash]
+// getByUTXOs return the distinct instances own any of the utxos, in the order of the utxos
+func (i *instanceIndex) getByUTXOs(utxos []*UTXO) []*Instance {
+	var instances []*Instance
+	seen := make(map[string]bool)
+	for _, utxo := range utxos {
+		insts := i.utxoHashToInst[utxo.Hash]
+		traceIDs := make([]string, 0, len(insts))
+		for traceID := range insts {
+			traceIDs = append(traceIDs, traceID)
+		}
+		sort.Strings(traceIDs)
+
+		for _, traceID := range traceIDs {
+			if !seen[traceID] {
+				seen[traceID] = true
+				instances = append(instances, insts[traceID])
+			}
+		}
+	}
+	return instances
 }
 
 func (i *instanceIndex) add(instance *Instance) {
 	i.traceIdToInst[instance.TraceID] = instance
 	for _, utxo := range instance.UTXOs {
-		i.utxoHashToInst[utxo.Hash] = instance
+		if _, ok := i.utxoHashToInst[utxo.Hash]; !ok {
+			i.utxoHashToInst[utxo.Hash] = make(map[string]*Instance)
+		}
+		i.utxoHashToInst[utxo.Hash][instance.TraceID] = instance
 	}
 }
 
@@ -129,7 +201,10 @@ func (i *instanceIndex) remove(id string) {
 	if inst, ok := i.traceIdToInst[id]; ok {
 		delete(i.traceIdToInst, id)
 		for _, utxo := range inst.UTXOs {
-			delete(i.utxoHashToInst, utxo.Hash)
+			delete(i.utxoHashToInst[utxo.Hash], id)
+			if len(i.utxoHashToInst[utxo.Hash]) == 0 {
+				delete(i.utxoHashToInst, utxo.Hash)
+			}
 		}
 	}
 }
@@ -144,6 +219,22 @@ type UTXO struct {
 	StateData [][]byte   `json:"state_data"`
 }
 
+// excludeUTXOs return the utxos which are not in the excluded list
+func excludeUTXOs(utxos, excluded []*UTXO) []*UTXO {
+	excludedHashes := make(map[bc.Hash]bool)
+	for _, utxo := range excluded {
+		excludedHashes[utxo.Hash] = true
+	}
+
+	result := []*UTXO{}
+	for _, utxo := range utxos {
+		if !excludedHashes[utxo.Hash] {
+			result = append(result, utxo)
+		}
+	}
+	return result
+}
+
 func inputToUTXO(tx *types.Tx, index int) *UTXO {
 	input := tx.Inputs[index]
 	outputID, _ := input.SpentOutputID()
@@ -0,0 +1,244 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"coingod/errors"
+	"coingod/event"
+	"coingod/protocol/bc"
+)
+
+const (
+	callbackQueueSize  = 1024
+	maxCallbackRetry   = 5
+	callbackTimeout    = 10 * time.Second
+	callbackRetryDelay = time.Second
+)
+
+var (
+	ErrNotifierDisabled  = errors.New("trace service is running without event dispatcher")
+	ErrBadCallbackURL    = errors.New("callback url must be http or https")
+	ErrCallbackNotFound  = errors.New("callback not found")
+	errCallbackRejected  = errors.New("callback response status is not 2xx")
+	errCallbackQueueFull = errors.New("callback queue is full")
+)
+
+// InstanceEvent is posted to the event dispatcher when the trace service changes the instance,
+// include the status transition, the transfer of the contract utxos, the rollback of the block
+// and the unconfirmed transaction spends the contract
+type InstanceEvent struct {
+	TraceID     string   `json:"trace_id"`
+	PrevStatus  Status   `json:"prev_status"`
+	Status      Status   `json:"status"`
+	Rollback    bool     `json:"rollback"`
+	Unconfirmed bool     `json:"unconfirmed"`
+	BlockHeight uint64   `json:"block_height"`
+	BlockHash   bc.Hash  `json:"block_hash"`
+	TxHash      *bc.Hash `json:"tx_hash"`
+	UTXOs       []*UTXO  `json:"utxos"`
+}
+
+// Callback is the http url registered to receive the instance events, the events of all
+// the instances are delivered when the trace id is empty
+type Callback struct {
+	ID      string `json:"id"`
+	TraceID string `json:"trace_id,omitempty"`
+	URL     string `json:"url"`
+}
+
+func (c *Callback) match(ev *InstanceEvent) bool {
+	return c.TraceID == "" || c.TraceID == ev.TraceID
+}
+
+// callbackWorker deliver the events to a callback in order, the failed delivery is retried
+// with exponential backoff before the next event
+type callbackWorker struct {
+	callback *Callback
+	client   *http.Client
+	eventCh  chan *InstanceEvent
+	quit     chan struct{}
+}
+
+func newCallbackWorker(callback *Callback, client *http.Client) *callbackWorker {
+	w := &callbackWorker{
+		callback: callback,
+		client:   client,
+		eventCh:  make(chan *InstanceEvent, callbackQueueSize),
+		quit:     make(chan struct{}),
+	}
+	go w.deliverLoop()
+	return w
+}
+
+func (w *callbackWorker) enqueue(ev *InstanceEvent) error {
+	select {
+	case w.eventCh <- ev:
+		return nil
+	default:
+		return errCallbackQueueFull
+	}
+}
+
+func (w *callbackWorker) stop() {
+	close(w.quit)
+}
+
+func (w *callbackWorker) deliverLoop() {
+	for {
+		select {
+		case ev := <-w.eventCh:
+			w.deliver(ev)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func (w *callbackWorker) deliver(ev *InstanceEvent) {
+	delay := callbackRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := w.post(ev)
+		if err == nil {
+			return
+		}
+
+		if attempt >= maxCallbackRetry {
+			log.WithFields(log.Fields{"module": logModule, "err": err, "url": w.callback.URL, "trace_id": ev.TraceID}).Error("give up delivering instance event")
+			return
+		}
+
+		log.WithFields(log.Fields{"module": logModule, "err": err, "url": w.callback.URL, "attempt": attempt}).Warn("fail on deliver instance event")
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func (w *callbackWorker) post(ev *InstanceEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.callback.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.WithDetailf(errCallbackRejected, "status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// callbackNotifier subscribe the instance events from the dispatcher and dispatch them
+// to the workers of the matched callbacks
+type callbackNotifier struct {
+	sync.RWMutex
+	repository Repository
+	client     *http.Client
+	workers    map[string]*callbackWorker
+}
+
+func newCallbackNotifier(repository Repository, dispatcher *event.Dispatcher) (*callbackNotifier, error) {
+	n := &callbackNotifier{
+		repository: repository,
+		client:     &http.Client{Timeout: callbackTimeout},
+		workers:    make(map[string]*callbackWorker),
+	}
+
+	callbacks, err := repository.LoadCallbacks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, callback := range callbacks {
+		n.workers[callback.ID] = newCallbackWorker(callback, n.client)
+	}
+
+	sub, err := dispatcher.Subscribe(InstanceEvent{})
+	if err != nil {
+		return nil, err
+	}
+
+	go n.eventLoop(sub)
+	return n, nil
+}
+
+func (n *callbackNotifier) eventLoop(sub *event.Subscription) {
+	for obj := range sub.Chan() {
+		ev, ok := obj.Data.(InstanceEvent)
+		if !ok {
+			log.WithFields(log.Fields{"module": logModule}).Error("event type error")
+			continue
+		}
+
+		n.RLock()
+		for _, worker := range n.workers {
+			if !worker.callback.match(&ev) {
+				continue
+			}
+
+			if err := worker.enqueue(&ev); err != nil {
+				log.WithFields(log.Fields{"module": logModule, "err": err, "url": worker.callback.URL}).Warn("drop instance event")
+			}
+		}
+		n.RUnlock()
+	}
+}
+
+func (n *callbackNotifier) register(traceID, rawURL string) (*Callback, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.WithDetailf(ErrBadCallbackURL, "url %q", rawURL)
+	}
+
+	callback := &Callback{ID: uuid.New().String(), TraceID: traceID, URL: rawURL}
+	if err := n.repository.SaveCallback(callback); err != nil {
+		return nil, err
+	}
+
+	n.Lock()
+	defer n.Unlock()
+	n.workers[callback.ID] = newCallbackWorker(callback, n.client)
+	return callback, nil
+}
+
+func (n *callbackNotifier) list() []*Callback {
+	n.RLock()
+	defer n.RUnlock()
+
+	callbacks := []*Callback{}
+	for _, worker := range n.workers {
+		callbacks = append(callbacks, worker.callback)
+	}
+	return callbacks
+}
+
+func (n *callbackNotifier) remove(id string) error {
+	n.Lock()
+	defer n.Unlock()
+
+	worker, ok := n.workers[id]
+	if !ok {
+		return errors.WithDetail(ErrCallbackNotFound, fmt.Sprintf("callback %s", id))
+	}
+
+	n.repository.RemoveCallback(id)
+	worker.stop()
+	delete(n.workers, id)
+	return nil
+}
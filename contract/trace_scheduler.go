@@ -146,6 +146,7 @@ func (t *traceScheduler) finishJobs(jobs map[bc.Hash][]*Instance) error {
 	for _, instances := range jobs {
 		for _, inst := range instances {
 			if _, ok := inSyncMap[inst.TraceID]; !ok {
+				inst.prevStatus = inst.Status
 				inst.Status = OffChain
 				offChainInstances = append(offChainInstances, inst)
 			}
@@ -156,6 +157,7 @@ func (t *traceScheduler) finishJobs(jobs map[bc.Hash][]*Instance) error {
 		return err
 	}
 
+	t.tracerService.postInstanceEvents(offChainInstances, &InstanceEvent{BlockHeight: t.currentHeight, BlockHash: t.currentHash})
 	t.releaseInstances(offChainInstances)
 
 	if len(inSyncInstances) != 0 {
@@ -6,6 +6,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"coingod/event"
+	"coingod/protocol"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 )
@@ -23,21 +25,29 @@ type TraceService struct {
 	tracer           *tracer
 	infra            *Infrastructure
 	scheduler        *traceScheduler
+	notifier         *callbackNotifier
 	unconfirmedIndex map[bc.Hash]*TreeNode
 	endedInstances   map[string]bool
 	bestHeight       uint64
 	bestHash         bc.Hash
 }
 
-func NewTraceService(infra *Infrastructure) *TraceService {
+func NewTraceService(infra *Infrastructure) (*TraceService, error) {
 	allInstances, err := infra.Repository.LoadInstances()
 	if err != nil {
-		log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("load instances from db")
+		return nil, err
+	}
+
+	chainStatus, err := initChainStatus(infra)
+	if err != nil {
+		return nil, err
 	}
 
-	chainStatus := initChainStatus(infra)
 	scheduler := newTraceScheduler(infra)
-	inSyncInstances := dispatchInstances(allInstances, scheduler, infra.Chain.FinalizedHeight())
+	inSyncInstances, err := dispatchInstances(allInstances, scheduler, infra.Chain.FinalizedHeight())
+	if err != nil {
+		return nil, err
+	}
 
 	service := &TraceService{
 		infra:            infra,
@@ -48,23 +58,53 @@ func NewTraceService(infra *Infrastructure) *TraceService {
 		bestHeight:       chainStatus.BlockHeight,
 		bestHash:         chainStatus.BlockHash,
 	}
+	if infra.Dispatcher != nil {
+		if service.notifier, err = newCallbackNotifier(infra.Repository, infra.Dispatcher); err != nil {
+			return nil, err
+		}
+
+		txMsgSub, err := infra.Dispatcher.Subscribe(protocol.TxMsgEvent{})
+		if err != nil {
+			return nil, err
+		}
+		go service.memPoolTxLoop(txMsgSub)
+	}
+
 	scheduler.start(service)
-	return service
+	return service, nil
+}
+
+// memPoolTxLoop follow the contract utxos spent by the transactions accepted or removed by the tx pool
+func (t *TraceService) memPoolTxLoop(sub *event.Subscription) {
+	for obj := range sub.Chan() {
+		ev, ok := obj.Data.(protocol.TxMsgEvent)
+		if !ok {
+			log.WithFields(log.Fields{"module": logModule}).Error("event type error")
+			continue
+		}
+
+		switch ev.TxMsg.MsgType {
+		case protocol.MsgNewTx:
+			t.AddUnconfirmedTx(ev.TxMsg.TxDesc.Tx)
+		case protocol.MsgRemoveTx:
+			t.RemoveUnconfirmedTx(ev.TxMsg.TxDesc.Tx)
+		}
+	}
 }
 
-func initChainStatus(infra *Infrastructure) *ChainStatus {
+func initChainStatus(infra *Infrastructure) (*ChainStatus, error) {
 	chainStatus := infra.Repository.GetChainStatus()
 	if chainStatus == nil {
 		bestHeight, bestHash := infra.Chain.BestChain()
 		chainStatus = &ChainStatus{BlockHeight: bestHeight, BlockHash: bestHash}
 		if err := infra.Repository.SaveChainStatus(chainStatus); err != nil {
-			log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("init chain status for trace service")
+			return nil, err
 		}
 	}
-	return chainStatus
+	return chainStatus, nil
 }
 
-func dispatchInstances(instances []*Instance, scheduler *traceScheduler, finalizedHeight uint64) []*Instance {
+func dispatchInstances(instances []*Instance, scheduler *traceScheduler, finalizedHeight uint64) ([]*Instance, error) {
 	var result []*Instance
 	for _, inst := range instances {
 		if inst.Status == InSync {
@@ -75,11 +115,11 @@ func dispatchInstances(instances []*Instance, scheduler *traceScheduler, finaliz
 			}
 		} else if inst.Status == Lagging {
 			if err := scheduler.addNewJob(inst); err != nil {
-				log.WithFields(log.Fields{"module": logModule, "err": err}).Fatal("add new job when init tracer")
+				return nil, err
 			}
 		}
 	}
-	return result
+	return result, nil
 }
 
 func (t *TraceService) BestHeight() uint64 {
@@ -99,10 +139,25 @@ func (t *TraceService) ApplyBlock(block *types.Block) error {
 	defer t.Unlock()
 
 	newInstances := t.tracer.applyBlock(block)
+	finalizedHeight := t.infra.Chain.FinalizedHeight()
+	for _, inst := range newInstances {
+		inst.pruneDeltas(finalizedHeight)
+	}
 	t.processEndedInstances(newInstances)
+	for _, tx := range block.Transactions {
+		for _, outputID := range tx.ResultIds {
+			delete(t.unconfirmedIndex, *outputID)
+		}
+	}
+
 	t.bestHeight++
 	t.bestHash = block.Hash()
-	return t.infra.Repository.SaveInstancesWithStatus(newInstances, t.bestHeight, t.bestHash)
+	if err := t.infra.Repository.SaveInstancesWithStatus(newInstances, t.bestHeight, t.bestHash); err != nil {
+		return err
+	}
+
+	t.postInstanceEvents(newInstances, &InstanceEvent{BlockHeight: block.Height, BlockHash: block.Hash()})
+	return nil
 }
 
 func (t *TraceService) DetachBlock(block *types.Block) error {
@@ -113,27 +168,72 @@ func (t *TraceService) DetachBlock(block *types.Block) error {
 	t.processEndedInstances(nil)
 	t.bestHeight--
 	t.bestHash = block.PreviousBlockHash
-	return t.infra.Repository.SaveInstancesWithStatus(newInstances, t.bestHeight, t.bestHash)
+	if err := t.infra.Repository.SaveInstancesWithStatus(newInstances, t.bestHeight, t.bestHash); err != nil {
+		return err
+	}
+
+	t.postInstanceEvents(newInstances, &InstanceEvent{Rollback: true, BlockHeight: block.Height, BlockHash: block.Hash()})
+	return nil
 }
 
+// AddUnconfirmedTx append the transfer to the unconfirmed tree of each instance whose utxo is spent,
+// or to the unconfirmed transfer which creates the spent utxo
 func (t *TraceService) AddUnconfirmedTx(tx *types.Tx) {
+	t.Lock()
+	defer t.Unlock()
+
 	transfers := parseTransfers(tx)
 	for _, transfer := range transfers {
 		inUTXOs, outUTXOs := transfer.inUTXOs, transfer.outUTXOs
 		if len(inUTXOs) == 0 || len(outUTXOs) == 0 {
-			return
+			continue
 		}
 
 		treeNode := &TreeNode{TxHash: tx.ID, UTXOs: outUTXOs}
-		if inst := t.tracer.index.getByUTXO(inUTXOs[0].Hash); inst != nil {
-			inst.Unconfirmed = append(inst.Unconfirmed, treeNode)
+		if instances := t.tracer.index.getByUTXOs(inUTXOs); len(instances) != 0 {
+			for _, inst := range instances {
+				inst.Unconfirmed = append(inst.Unconfirmed, treeNode)
+				t.postInstanceEvent(&InstanceEvent{TraceID: inst.TraceID, PrevStatus: inst.Status, Status: inst.Status, Unconfirmed: true, TxHash: &tx.ID, UTXOs: outUTXOs})
+			}
 			t.addToUnconfirmedIndex(treeNode, outUTXOs)
-			return
+			continue
 		}
 
-		if parent, ok := t.unconfirmedIndex[inUTXOs[0].Hash]; ok {
-			parent.Children = append(parent.Children, treeNode)
-			t.addToUnconfirmedIndex(treeNode, outUTXOs)
+		for _, utxo := range inUTXOs {
+			if parent, ok := t.unconfirmedIndex[utxo.Hash]; ok {
+				parent.Children = append(parent.Children, treeNode)
+				t.addToUnconfirmedIndex(treeNode, outUTXOs)
+				break
+			}
+		}
+	}
+}
+
+// RemoveUnconfirmedTx drop the transfer which left the tx pool from the unconfirmed trees, its
+// children take its place since they leave the tx pool by themselves unless it's confirmed
+func (t *TraceService) RemoveUnconfirmedTx(tx *types.Tx) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, transfer := range parseTransfers(tx) {
+		var treeNode *TreeNode
+		for _, utxo := range transfer.outUTXOs {
+			if node, ok := t.unconfirmedIndex[utxo.Hash]; ok && node.TxHash == tx.ID {
+				treeNode = node
+				delete(t.unconfirmedIndex, utxo.Hash)
+			}
+		}
+		if treeNode == nil {
+			continue
+		}
+
+		for _, inst := range t.tracer.index.getByUTXOs(transfer.inUTXOs) {
+			inst.Unconfirmed = replaceTreeNode(inst.Unconfirmed, treeNode)
+		}
+		for _, utxo := range transfer.inUTXOs {
+			if parent, ok := t.unconfirmedIndex[utxo.Hash]; ok {
+				parent.Children = replaceTreeNode(parent.Children, treeNode)
+			}
 		}
 	}
 }
@@ -165,6 +265,8 @@ func (t *TraceService) CreateInstance(txHash, blockHash bc.Hash) ([]string, erro
 		if err := t.addNewTraceJob(inst); err != nil {
 			return nil, err
 		}
+
+		t.postInstanceEvents([]*Instance{inst}, &InstanceEvent{BlockHeight: block.Height, BlockHash: block.Hash()})
 	}
 	return traceIDs, nil
 }
@@ -191,6 +293,7 @@ func (t *TraceService) takeOverInstances(instances []*Instance, blockHash bc.Has
 	}
 
 	for _, inst := range instances {
+		inst.prevStatus = Lagging
 		if inst.Status != Ended {
 			inst.Status = InSync
 		}
@@ -201,6 +304,8 @@ func (t *TraceService) takeOverInstances(instances []*Instance, blockHash bc.Has
 		return false
 	}
 
+	t.postInstanceEvents(instances, &InstanceEvent{BlockHash: blockHash, BlockHeight: t.bestHeight})
+
 	t.tracer.addInstances(instances)
 	t.processEndedInstances(instances)
 	return true
@@ -238,12 +343,67 @@ func (t *TraceService) addNewTraceJob(inst *Instance) error {
 	return nil
 }
 
+// RegisterCallback register the http url to receive the events of the instance, the events of
+// all the instances are delivered when the trace id is empty
+func (t *TraceService) RegisterCallback(traceID, url string) (*Callback, error) {
+	if t.notifier == nil {
+		return nil, ErrNotifierDisabled
+	}
+	return t.notifier.register(traceID, url)
+}
+
+func (t *TraceService) ListCallbacks() ([]*Callback, error) {
+	if t.notifier == nil {
+		return nil, ErrNotifierDisabled
+	}
+	return t.notifier.list(), nil
+}
+
+func (t *TraceService) RemoveCallback(id string) error {
+	if t.notifier == nil {
+		return ErrNotifierDisabled
+	}
+	return t.notifier.remove(id)
+}
+
+// postInstanceEvents post the change of each instance, the block info is taken from the template
+func (t *TraceService) postInstanceEvents(instances []*Instance, template *InstanceEvent) {
+	for _, inst := range instances {
+		ev := *template
+		ev.TraceID, ev.PrevStatus, ev.Status, ev.TxHash, ev.UTXOs = inst.TraceID, inst.prevStatus, inst.Status, inst.TxHash, inst.UTXOs
+		t.postInstanceEvent(&ev)
+	}
+}
+
+func (t *TraceService) postInstanceEvent(ev *InstanceEvent) {
+	if t.infra.Dispatcher == nil {
+		return
+	}
+
+	if err := t.infra.Dispatcher.Post(*ev); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err, "trace_id": ev.TraceID}).Error("post instance event")
+	}
+}
+
 func (t *TraceService) addToUnconfirmedIndex(treeNode *TreeNode, utxos []*UTXO) {
 	for _, utxo := range utxos {
 		t.unconfirmedIndex[utxo.Hash] = treeNode
 	}
 }
 
+// replaceTreeNode replace the tree node by its children
+func replaceTreeNode(nodes []*TreeNode, treeNode *TreeNode) []*TreeNode {
+	var result []*TreeNode
+	for _, node := range nodes {
+		if node == treeNode {
+			result = append(result, node.Children...)
+		} else {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
 func findTx(block *types.Block, txHash bc.Hash) *types.Tx {
 	for _, tx := range block.Transactions {
 		if tx.ID == txHash {
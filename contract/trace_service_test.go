@@ -0,0 +1,50 @@
+package contract
+
+import (
+	"testing"
+
+	"coingod/consensus"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+func TestTraceServiceRemoveUnconfirmedTx(t *testing.T) {
+	createTx := mockTx([]*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, *consensus.CGAssetID, 100, 0, mockP2WPKHProgram, nil)}, []uint64{100})
+	inst := newInstance(parseTransfers(createTx)[0], mockBlock(1, createTx))
+	inst.Status = InSync
+	service := &TraceService{
+		infra:            &Infrastructure{},
+		tracer:           newTracer([]*Instance{inst}),
+		unconfirmedIndex: make(map[bc.Hash]*TreeNode),
+	}
+
+	// the child spends the output of the parent in the tx pool
+	parentTx := mockTx([]*types.TxInput{spendUTXO(inst.UTXOs[0])}, []uint64{100})
+	childTx := mockTx([]*types.TxInput{spendUTXO(parseTransfers(parentTx)[0].outUTXOs[0])}, []uint64{100})
+	service.AddUnconfirmedTx(parentTx)
+	service.AddUnconfirmedTx(childTx)
+	if unconfirmed := service.tracer.getInstance(inst.TraceID).Unconfirmed; len(unconfirmed) != 1 || unconfirmed[0].TxHash != parentTx.ID || len(unconfirmed[0].Children) != 1 {
+		t.Fatalf("got unconfirmed tree %v, want the parent with the child", unconfirmed)
+	}
+
+	// the child takes the place of the parent which is confirmed before the block is traced
+	service.RemoveUnconfirmedTx(parentTx)
+	unconfirmed := service.tracer.getInstance(inst.TraceID).Unconfirmed
+	if len(unconfirmed) != 1 || unconfirmed[0].TxHash != childTx.ID {
+		t.Fatalf("got unconfirmed tree %v after remove the parent, want the child", unconfirmed)
+	}
+
+	if len(service.unconfirmedIndex) != 1 {
+		t.Errorf("got %d indexed unconfirmed utxos after remove the parent, want 1", len(service.unconfirmedIndex))
+	}
+
+	service.tracer.applyBlock(mockBlock(2, parentTx))
+	if unconfirmed := service.tracer.getInstance(inst.TraceID).Unconfirmed; len(unconfirmed) != 1 || unconfirmed[0].TxHash != childTx.ID {
+		t.Fatalf("got unconfirmed tree %v after confirm the parent, want the child", unconfirmed)
+	}
+
+	service.RemoveUnconfirmedTx(childTx)
+	if unconfirmed := service.tracer.getInstance(inst.TraceID).Unconfirmed; len(unconfirmed) != 0 || len(service.unconfirmedIndex) != 0 {
+		t.Errorf("got unconfirmed tree %v and %d indexed utxos after remove the child, want empty", unconfirmed, len(service.unconfirmedIndex))
+	}
+}
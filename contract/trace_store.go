@@ -14,11 +14,13 @@ const (
 
 	instance byte = iota + 1
 	chainStatus
+	callback
 )
 
 var (
 	instancePrefixKey    = []byte{instance, colon}
 	chainStatusPrefixKey = []byte{chainStatus, colon}
+	callbackPrefixKey    = []byte{callback, colon}
 )
 
 func instanceKey(traceID string) []byte {
@@ -29,6 +31,10 @@ func chainStatusKey() []byte {
 	return chainStatusPrefixKey
 }
 
+func callbackKey(id string) []byte {
+	return append(callbackPrefixKey, []byte(id)...)
+}
+
 
 type TraceStore struct {
 	db dbm.DB
@@ -127,6 +133,39 @@ func (t *TraceStore) SaveChainStatus(chainStatus *ChainStatus) error {
 	return nil
 }
 
+// LoadCallbacks used to load all callbacks in db
+func (t *TraceStore) LoadCallbacks() ([]*Callback, error) {
+	iter := t.db.IteratorPrefix(callbackPrefixKey)
+	defer iter.Release()
+
+	var callbacks []*Callback
+	for iter.Next() {
+		callback := &Callback{}
+		if err := json.Unmarshal(iter.Value(), callback); err != nil {
+			return nil, err
+		}
+
+		callbacks = append(callbacks, callback)
+	}
+	return callbacks, nil
+}
+
+// SaveCallback save the callback
+func (t *TraceStore) SaveCallback(callback *Callback) error {
+	data, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+
+	t.db.Set(callbackKey(callback.ID), data)
+	return nil
+}
+
+// RemoveCallback delete a callback by given id
+func (t *TraceStore) RemoveCallback(id string) {
+	t.db.Delete(callbackKey(id))
+}
+
 func (t *TraceStore) saveInstances(instances []*Instance, batch dbm.Batch) error {
 	for _, inst := range instances {
 		key := instanceKey(inst.TraceID)
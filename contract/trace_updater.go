@@ -1,7 +1,8 @@
 package contract
 
 import (
-	log "github.com/sirupsen/logrus"
+	"coingod/errors"
+	"coingod/follower"
 )
 
 var logModule = "tracer"
@@ -18,8 +19,14 @@ func NewTraceUpdater(traceService *TraceService, chain ChainService) *TraceUpdat
 	}
 }
 
-func (t *TraceUpdater) Sync() {
+// Sync follow the chain until the blocks to apply are not available, e.g. they are pruned
+// by the chain, or the block fails to be traced. It must be run as a goroutine.
+func (t *TraceUpdater) Sync() error {
 	for {
+		if baseHeight := t.chain.BaseBlockHeight(); t.BestHeight()+1 < baseHeight {
+			return errors.WithDetailf(follower.ErrPrunedHistory, "trace updater at height %d, the chain keeps the blocks from height %d", t.BestHeight(), baseHeight)
+		}
+
 		block, _ := t.chain.GetBlockByHeight(t.BestHeight() + 1)
 		if block == nil {
 			t.walletBlockWaiter()
@@ -29,18 +36,15 @@ func (t *TraceUpdater) Sync() {
 		if bestHash := t.BestHash(); block.PreviousBlockHash != bestHash {
 			block, err := t.chain.GetBlockByHash(&bestHash)
 			if err != nil {
-				log.WithFields(log.Fields{"module": logModule, "err": err, "block_hash": bestHash.String()}).Errorf("trace updater get block")
-				break
+				return errors.Wrap(err, "trace updater get block")
 			}
 
 			if err := t.DetachBlock(block); err != nil {
-				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("trace updater detach block")
-				break
+				return errors.Wrap(err, "trace updater detach block")
 			}
 		} else {
 			if err := t.ApplyBlock(block); err != nil {
-				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("trace updater attach block")
-				break
+				return errors.Wrap(err, "trace updater attach block")
 			}
 		}
 	}
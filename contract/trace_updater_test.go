@@ -0,0 +1,47 @@
+package contract
+
+import (
+	"testing"
+
+	"coingod/errors"
+	"coingod/follower"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+// mockPrunedChain keeps the blocks from the base height only
+type mockPrunedChain struct {
+	baseHeight uint64
+}
+
+func (c *mockPrunedChain) BaseBlockHeight() uint64 {
+	return c.baseHeight
+}
+
+func (c *mockPrunedChain) BestChain() (uint64, bc.Hash) {
+	return c.baseHeight, bc.Hash{}
+}
+
+func (c *mockPrunedChain) FinalizedHeight() uint64 {
+	return 0
+}
+
+func (c *mockPrunedChain) BlockWaiter(uint64) <-chan struct{} {
+	return nil
+}
+
+func (c *mockPrunedChain) GetBlockByHash(*bc.Hash) (*types.Block, error) {
+	return nil, errors.New("block is pruned")
+}
+
+func (c *mockPrunedChain) GetBlockByHeight(uint64) (*types.Block, error) {
+	return nil, errors.New("block is pruned")
+}
+
+func TestTraceUpdaterPrunedHistory(t *testing.T) {
+	chain := &mockPrunedChain{baseHeight: 10}
+	service := &TraceService{tracer: newTracer(nil), bestHeight: 5}
+	if err := NewTraceUpdater(service, chain).Sync(); errors.Root(err) != follower.ErrPrunedHistory {
+		t.Errorf("got error %v, want %v", err, follower.ErrPrunedHistory)
+	}
+}
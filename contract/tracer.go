@@ -39,6 +39,9 @@ func (t *tracer) removeInstance(traceID string) {
 	t.index.remove(traceID)
 }
 
+// applyBlock follow every contract utxo spent by the transfers of the block, the instance
+// is saved to the index right after each transfer since the later transactions of the
+// block may spend its new utxos
 func (t *tracer) applyBlock(block *types.Block) []*Instance {
 	var newInstances []*Instance
 	for _, tx := range block.Transactions {
@@ -48,13 +51,13 @@ func (t *tracer) applyBlock(block *types.Block) []*Instance {
 				continue
 			}
 
-			if inst := t.index.getByUTXO(transfer.inUTXOs[0].Hash); inst != nil {
+			for _, inst := range t.index.getByUTXOs(transfer.inUTXOs) {
 				newInst := inst.transferTo(transfer, block.Height)
+				t.index.save(newInst)
 				newInstances = append(newInstances, newInst)
 			}
 		}
 	}
-	t.saveInstances(newInstances)
 	return newInstances
 }
 
@@ -64,14 +67,18 @@ func (t *tracer) detachBlock(block *types.Block) []*Instance {
 		tx := block.Transactions[i]
 		transfers := parseTransfers(tx)
 		for _, transfer := range transfers {
-			utxos := append(transfer.outUTXOs, transfer.inUTXOs...)
-			if inst := t.index.getByUTXO(utxos[0].Hash); inst != nil {
+			utxos := append(append([]*UTXO{}, transfer.outUTXOs...), transfer.inUTXOs...)
+			for _, inst := range t.index.getByUTXOs(utxos) {
+				if inst.Status == Ended && (inst.TxHash == nil || *inst.TxHash != transfer.txHash) {
+					continue
+				}
+
 				newInst := inst.rollbackTo(transfer)
+				t.index.save(newInst)
 				newInstances = append(newInstances, newInst)
 			}
 		}
 	}
-	t.saveInstances(newInstances)
 	return newInstances
 }
 
@@ -127,9 +134,3 @@ func parseContractUTXOs(tx *types.Tx) ([]*UTXO, []*UTXO) {
 func isContract(program []byte) bool {
 	return !(segwit.IsP2WScript(program) || vmutil.IsUnspendable(program))
 }
-
-func (t *tracer) saveInstances(instances []*Instance) {
-	for _, inst := range instances {
-		t.index.save(inst)
-	}
-}
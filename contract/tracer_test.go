@@ -0,0 +1,154 @@
+package contract
+
+import (
+	"testing"
+
+	"coingod/consensus"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm/vmutil"
+)
+
+var (
+	// the pay to public key hash program is traced as the contract since it isn't the witness program
+	mockContractProgram, _ = vmutil.P2PKHSigProgram(make([]byte, 20))
+	mockP2WPKHProgram      = append([]byte{0x00, 0x14}, make([]byte, 20)...)
+)
+
+func mockTx(inputs []*types.TxInput, outputAmounts []uint64) *types.Tx {
+	txData := types.TxData{Version: 1, Inputs: inputs}
+	for _, amount := range outputAmounts {
+		txData.Outputs = append(txData.Outputs, types.NewOriginalTxOutput(*consensus.CGAssetID, amount, mockContractProgram, nil))
+	}
+	return types.NewTx(txData)
+}
+
+func spendUTXO(utxo *UTXO) *types.TxInput {
+	return types.NewSpendInput(nil, utxo.SourceID, utxo.AssetID, utxo.Amount, utxo.SourcePos, utxo.Program, utxo.StateData)
+}
+
+func mockBlock(height uint64, txs ...*types.Tx) *types.Block {
+	return &types.Block{BlockHeader: types.BlockHeader{Height: height}, Transactions: txs}
+}
+
+func utxoHashes(utxos []*UTXO) map[bc.Hash]bool {
+	hashes := make(map[bc.Hash]bool)
+	for _, utxo := range utxos {
+		hashes[utxo.Hash] = true
+	}
+	return hashes
+}
+
+func checkInstance(t *testing.T, desc string, inst *Instance, status Status, utxos ...*UTXO) {
+	if inst.Status != status {
+		t.Errorf("%s: got status %d, want %d", desc, inst.Status, status)
+	}
+
+	got, want := utxoHashes(inst.UTXOs), utxoHashes(utxos)
+	if len(inst.UTXOs) != len(utxos) || len(got) != len(want) {
+		t.Fatalf("%s: got %d utxos, want %d", desc, len(inst.UTXOs), len(utxos))
+	}
+
+	for hash := range want {
+		if !got[hash] {
+			t.Errorf("%s: utxo %s is missing", desc, hash.String())
+		}
+	}
+}
+
+func TestTracerSplitAndMerge(t *testing.T) {
+	// the contract is created with two utxos
+	createTx := mockTx([]*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, *consensus.CGAssetID, 300, 0, mockP2WPKHProgram, nil)}, []uint64{100, 200})
+	createTransfers := parseTransfers(createTx)
+	if len(createTransfers) != 1 {
+		t.Fatalf("got %d transfers, want 1", len(createTransfers))
+	}
+
+	inst := newInstance(createTransfers[0], mockBlock(1, createTx))
+	inst.Status = InSync
+	utxoA, utxoB := createTransfers[0].outUTXOs[0], createTransfers[0].outUTXOs[1]
+	tracer := newTracer([]*Instance{inst})
+
+	// spend part of the utxos
+	splitTx := mockTx([]*types.TxInput{spendUTXO(utxoA)}, []uint64{60, 40})
+	splitBlock := mockBlock(2, splitTx)
+	newInstances := tracer.applyBlock(splitBlock)
+	if len(newInstances) != 1 {
+		t.Fatalf("got %d changed instances, want 1", len(newInstances))
+	}
+
+	splitOutputs := parseTransfers(splitTx)[0].outUTXOs
+	checkInstance(t, "split", tracer.getInstance(inst.TraceID), InSync, utxoB, splitOutputs[0], splitOutputs[1])
+
+	// merge all the utxos into one and spend it in the same block
+	mergeTx := mockTx([]*types.TxInput{spendUTXO(utxoB), spendUTXO(splitOutputs[0]), spendUTXO(splitOutputs[1])}, []uint64{300})
+	mergeOutput := parseTransfers(mergeTx)[0].outUTXOs[0]
+	endTx := mockTx([]*types.TxInput{spendUTXO(mergeOutput)}, nil)
+	endBlock := mockBlock(3, mergeTx, endTx)
+	if newInstances := tracer.applyBlock(endBlock); len(newInstances) != 2 {
+		t.Fatalf("got %d changed instances, want 2", len(newInstances))
+	}
+
+	endInst := tracer.getInstance(inst.TraceID)
+	checkInstance(t, "end", endInst, Ended, mergeOutput)
+	if endInst.EndedHeight != 3 || endInst.prevStatus != InSync {
+		t.Errorf("got ended height %d prev status %d", endInst.EndedHeight, endInst.prevStatus)
+	}
+
+	// rollback the blocks one by one
+	tracer.detachBlock(endBlock)
+	checkInstance(t, "detach end block", tracer.getInstance(inst.TraceID), InSync, utxoB, splitOutputs[0], splitOutputs[1])
+
+	tracer.detachBlock(splitBlock)
+	checkInstance(t, "detach split block", tracer.getInstance(inst.TraceID), InSync, utxoA, utxoB)
+}
+
+func TestTracerMergeInstances(t *testing.T) {
+	var instances []*Instance
+	var utxos []*UTXO
+	for i := uint64(1); i <= 2; i++ {
+		createTx := mockTx([]*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: i}, *consensus.CGAssetID, 100, 0, mockP2WPKHProgram, nil)}, []uint64{100})
+		inst := newInstance(parseTransfers(createTx)[0], mockBlock(1, createTx))
+		inst.Status = InSync
+		instances = append(instances, inst)
+		utxos = append(utxos, inst.UTXOs[0])
+	}
+
+	tracer := newTracer(instances)
+	mergeTx := mockTx([]*types.TxInput{spendUTXO(utxos[0]), spendUTXO(utxos[1])}, []uint64{200})
+	mergeBlock := mockBlock(2, mergeTx)
+	if newInstances := tracer.applyBlock(mergeBlock); len(newInstances) != 2 {
+		t.Fatalf("got %d changed instances, want 2", len(newInstances))
+	}
+
+	mergeOutput := parseTransfers(mergeTx)[0].outUTXOs[0]
+	if got := tracer.index.getByUTXOs([]*UTXO{mergeOutput}); len(got) != 2 {
+		t.Fatalf("got %d instances own the merged utxo, want 2", len(got))
+	}
+
+	// each instance is back to its own utxo rather than all the merged ones
+	tracer.detachBlock(mergeBlock)
+	for i, inst := range instances {
+		rollbackInst := tracer.getInstance(inst.TraceID)
+		checkInstance(t, "detach merge block", rollbackInst, InSync, utxos[i])
+		if rollbackInst.TxHash == nil || *rollbackInst.TxHash != *inst.TxHash || len(rollbackInst.Deltas) != 0 {
+			t.Errorf("got tx hash %v and %d deltas after rollback, want the create tx", rollbackInst.TxHash, len(rollbackInst.Deltas))
+		}
+
+		if got := tracer.index.getByUTXOs([]*UTXO{utxos[i]}); len(got) != 1 || got[0].TraceID != inst.TraceID {
+			t.Errorf("got %d instances own the utxo %d after rollback, want 1", len(got), i)
+		}
+	}
+
+	if got := tracer.index.getByUTXOs([]*UTXO{mergeOutput}); len(got) != 0 {
+		t.Errorf("got %d instances own the merged utxo after rollback, want 0", len(got))
+	}
+}
+
+func TestInstancePruneDeltas(t *testing.T) {
+	inst := &Instance{Deltas: []*Delta{{BlockHeight: 10}, {BlockHeight: 11}, {BlockHeight: 12}}}
+	inst.pruneDeltas(11)
+	if len(inst.Deltas) != 1 || inst.Deltas[0].BlockHeight != 12 {
+		t.Errorf("got %d deltas after prune, want the delta of height 12", len(inst.Deltas))
+	}
+}
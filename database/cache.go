@@ -128,6 +128,10 @@ func (c *cache) lookupBlockTxs(hash *bc.Hash) ([]*types.Tx, error) {
 	return blockTxs.([]*types.Tx), nil
 }
 
+func (c *cache) removeBlockTxs(hash *bc.Hash) {
+	c.lruBlockTxs.Remove(*hash)
+}
+
 func (c *cache) lookupMainChainHash(height uint64) (*bc.Hash, error) {
 	if hash, ok := c.lruMainChainHashes.Get(height); ok {
 		return hash.(*bc.Hash), nil
@@ -0,0 +1,720 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/sha3"
+
+	"coingod/consensus"
+	"coingod/crypto/sha3pool"
+	dbm "coingod/database/leveldb"
+	"coingod/database/storage"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+const (
+	snapshotVersion = 1
+
+	// maxSnapshotFieldSize limit the size of the key or the value of a snapshot record
+	maxSnapshotFieldSize = 64 << 20
+	snapshotBatchSize    = 10000
+)
+
+// the records of the snapshot must be written in the order of the types
+const (
+	snapshotInfoRecord byte = iota + 1
+	snapshotHeaderRecord
+	snapshotBlockRecord
+	snapshotCheckpointRecord
+	snapshotUtxoRecord
+	snapshotContractRecord
+	snapshotEndRecord
+)
+
+var (
+	// ErrSnapshotFormat means the snapshot file is broken or created by another version
+	ErrSnapshotFormat = errors.New("invalid snapshot format")
+	// ErrSnapshotHash means the content hash of the snapshot is not the expected one
+	ErrSnapshotHash = errors.New("snapshot content hash mismatch")
+	// ErrSnapshotChain means the blocks of the snapshot don't belong to the network
+	ErrSnapshotChain = errors.New("snapshot doesn't belong to the chain")
+
+	errSnapshotEmptyStore = errors.New("can't export the snapshot of the empty store")
+	errSnapshotStoreInit  = errors.New("the snapshot can only be imported to the empty store")
+)
+
+// SnapshotInfo describe the chain state contained in the snapshot, which is the state at
+// the last finalized checkpoint of the exporting node
+type SnapshotInfo struct {
+	Version uint32  `json:"version"`
+	Height  uint64  `json:"height"`
+	Hash    bc.Hash `json:"hash"`
+}
+
+// encodeSnapshotRecord format the record as type + key length + key + value length + value
+func encodeSnapshotRecord(recordType byte, key, value []byte) []byte {
+	buf := make([]byte, 9+len(key)+len(value))
+	buf[0] = recordType
+	binary.BigEndian.PutUint32(buf[1:5], uint32(len(key)))
+	copy(buf[5:], key)
+	binary.BigEndian.PutUint32(buf[5+len(key):], uint32(len(value)))
+	copy(buf[9+len(key):], value)
+	return buf
+}
+
+// snapshotWriter write the records and sum the content hash, the hash is appended as
+// the end record
+type snapshotWriter struct {
+	w      *bufio.Writer
+	hasher sha3.ShakeHash
+}
+
+func newSnapshotWriter(w io.Writer) *snapshotWriter {
+	return &snapshotWriter{w: bufio.NewWriter(w), hasher: sha3pool.Get256()}
+}
+
+func (sw *snapshotWriter) write(recordType byte, key, value []byte) error {
+	record := encodeSnapshotRecord(recordType, key, value)
+	sw.hasher.Write(record)
+	_, err := sw.w.Write(record)
+	return err
+}
+
+func (sw *snapshotWriter) close() (*bc.Hash, error) {
+	defer sha3pool.Put256(sw.hasher)
+
+	var b32 [32]byte
+	sw.hasher.Read(b32[:])
+	contentHash := bc.NewHash(b32)
+	if _, err := sw.w.Write(encodeSnapshotRecord(snapshotEndRecord, nil, contentHash.Bytes())); err != nil {
+		return nil, err
+	}
+	return &contentHash, sw.w.Flush()
+}
+
+func readSnapshotField(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxSnapshotFieldSize {
+		return nil, errors.WithDetailf(ErrSnapshotFormat, "record size %d exceeds the limit", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+	return data, nil
+}
+
+// walkSnapshot pass the records to the fn until the end record, and check the content
+// hash of the records with both the end record and the expected hash
+func walkSnapshot(r io.Reader, contentHash *bc.Hash, fn func(recordType byte, key, value []byte) error) error {
+	reader := bufio.NewReader(r)
+	hasher := sha3pool.Get256()
+	defer sha3pool.Put256(hasher)
+
+	for {
+		recordType, err := reader.ReadByte()
+		if err != nil {
+			return errors.WithDetail(ErrSnapshotFormat, "missing the end record")
+		}
+
+		key, err := readSnapshotField(reader)
+		if err != nil {
+			return err
+		}
+
+		value, err := readSnapshotField(reader)
+		if err != nil {
+			return err
+		}
+
+		if recordType == snapshotEndRecord {
+			var b32 [32]byte
+			hasher.Read(b32[:])
+			if sum := bc.NewHash(b32); !bytes.Equal(value, sum.Bytes()) {
+				return errors.WithDetail(ErrSnapshotFormat, "the content hash of the end record doesn't match the records")
+			} else if sum != *contentHash {
+				return errors.WithDetailf(ErrSnapshotHash, "got %x, want %x", sum.Bytes(), contentHash.Bytes())
+			}
+			return nil
+		}
+
+		hasher.Write(encodeSnapshotRecord(recordType, key, value))
+		if err := fn(recordType, key, value); err != nil {
+			return err
+		}
+	}
+}
+
+func hashFromBytes(b []byte) (bc.Hash, error) {
+	if len(b) != 32 {
+		return bc.Hash{}, errors.WithDetailf(ErrSnapshotFormat, "bad hash length %d", len(b))
+	}
+
+	var b32 [32]byte
+	copy(b32[:], b)
+	return bc.NewHash(b32), nil
+}
+
+// votePendingRange return the shortest and the longest lock time of the vote outputs
+func votePendingRange() (uint64, uint64) {
+	minPending, maxPending := consensus.VotePendingBlockNums(0), consensus.VotePendingBlockNums(0)
+	for _, pendingNum := range consensus.ActiveNetParams.VotePendingBlockNums {
+		if pendingNum.Num < minPending {
+			minPending = pendingNum.Num
+		}
+		if pendingNum.Num > maxPending {
+			maxPending = pendingNum.Num
+		}
+	}
+	return minPending, maxPending
+}
+
+// canonicalUtxoEntry clear the block heights which don't affect the validation of the blocks
+// after the snapshot, so the content of the snapshot doesn't depend on the reorganizations
+// seen by the exporting node
+func canonicalUtxoEntry(entry *storage.UtxoEntry, snapshotHeight uint64) *storage.UtxoEntry {
+	result := storage.NewUtxoEntry(entry.Type, entry.BlockHeight, false)
+	switch entry.Type {
+	case storage.NormalUTXOType:
+		result.BlockHeight = 0
+	case storage.VoteUTXOType:
+		if _, maxPending := votePendingRange(); entry.BlockHeight+maxPending <= snapshotHeight {
+			result.BlockHeight = 0
+		}
+	}
+	return result
+}
+
+func (s *Store) getMainChainBlock(height uint64) (*types.Block, error) {
+	hash, err := GetMainChainHash(s.db, height)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := GetBlockHeader(s.db, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := GetBlockTransactions(s.db, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Block{BlockHeader: *header, Transactions: txs}, nil
+}
+
+// detachToFinalized rollback the blocks after the finalized checkpoint the same way as the
+// chain reorganization, the returned views are the changes to the state in the store
+func (s *Store) detachToFinalized(storeStatus *state.BlockStoreState) (*state.UtxoViewpoint, *state.ContractViewpoint, error) {
+	utxoView := state.NewUtxoViewpoint()
+	contractView := state.NewContractViewpoint()
+	for height := storeStatus.Height; height > storeStatus.FinalizedHeight; height-- {
+		block, err := s.getMainChainBlock(height)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bcBlock := types.MapBlock(block)
+		if err := getTransactionsUtxo(s.db, utxoView, bcBlock.Transactions); err != nil {
+			return nil, nil, err
+		}
+
+		if err := utxoView.DetachBlock(bcBlock); err != nil {
+			return nil, nil, err
+		}
+
+		if err := contractView.DetachBlock(block); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return utxoView, contractView, s.restoreVoteHeights(utxoView, storeStatus)
+}
+
+// restoreVoteHeights find the block heights of the vote outputs restored by the rollback, the
+// rollback doesn't know the heights of the spent outputs. A vote output spent after the
+// finalized checkpoint must be unlocked by then, so only the blocks in the range of the lock
+// time are searched, the outputs not found are old enough to be unlocked after the snapshot.
+func (s *Store) restoreVoteHeights(view *state.UtxoViewpoint, storeStatus *state.BlockStoreState) error {
+	restored := map[bc.Hash]*storage.UtxoEntry{}
+	for hash, entry := range view.Entries {
+		if entry.Type == storage.VoteUTXOType && !entry.Spent {
+			restored[hash] = entry
+		}
+	}
+
+	minPending, maxPending := votePendingRange()
+	if len(restored) == 0 || storeStatus.Height < minPending {
+		return nil
+	}
+
+	lowest, highest := uint64(0), storeStatus.Height-minPending
+	if storeStatus.FinalizedHeight+1 > maxPending {
+		lowest = storeStatus.FinalizedHeight + 1 - maxPending
+	}
+
+	if highest > storeStatus.FinalizedHeight {
+		highest = storeStatus.FinalizedHeight
+	}
+
+	for height := lowest; height <= highest && len(restored) > 0; height++ {
+		block, err := s.getMainChainBlock(height)
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range block.Transactions {
+			for i := range tx.Outputs {
+				if entry, ok := restored[*tx.OutputID(i)]; ok {
+					entry.BlockHeight = height
+					delete(restored, *tx.OutputID(i))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ExportSnapshot write the chain state at the last finalized checkpoint, the blocks after
+// the checkpoint are rolled back from the utxo set and the contract view. The snapshot
+// contains the main chain headers, the finalized block, the main chain checkpoints up to the
+// finalized one, the utxo set and the registered contracts. The returned content hash must
+// be verified by the importer.
+func (s *Store) ExportSnapshot(w io.Writer) (*SnapshotInfo, *bc.Hash, error) {
+	storeStatus := s.GetStoreStatus()
+	if storeStatus == nil {
+		return nil, nil, errSnapshotEmptyStore
+	}
+
+	utxoView, contractView, err := s.detachToFinalized(storeStatus)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &SnapshotInfo{Version: snapshotVersion, Height: storeStatus.FinalizedHeight, Hash: *storeStatus.FinalizedHash}
+	rawInfo, err := json.Marshal(info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sw := newSnapshotWriter(w)
+	if err := sw.write(snapshotInfoRecord, nil, rawInfo); err != nil {
+		return nil, nil, err
+	}
+
+	for height := uint64(0); height <= info.Height; height++ {
+		hash, err := GetMainChainHash(s.db, height)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		header, err := GetBlockHeader(s.db, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the sup links are collected by each node from the verification messages, they
+		// are not covered by the block hash and would make the content nondeterministic
+		header.SupLinks = nil
+		rawHeader, err := header.MarshalText()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := sw.write(snapshotHeaderRecord, hash.Bytes(), rawHeader); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rawTxs := s.db.Get(CalcBlockTransactionsKey(&info.Hash))
+	if rawTxs == nil {
+		return nil, nil, errors.Wrap(errSnapshotEmptyStore, "missing the transactions of the finalized block")
+	}
+
+	if err := sw.write(snapshotBlockRecord, info.Hash.Bytes(), rawTxs); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.exportCheckpoints(sw, info); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.exportUtxos(sw, utxoView, info.Height); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.exportContracts(sw, contractView); err != nil {
+		return nil, nil, err
+	}
+
+	contentHash, err := sw.close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "height": info.Height, "hash": info.Hash.String()}).Info("snapshot exported")
+	return info, contentHash, nil
+}
+
+// exportCheckpoints write the main chain checkpoints up to the finalized one, the earlier
+// checkpoints are kept for the verifications which use them as the source
+func (s *Store) exportCheckpoints(sw *snapshotWriter, info *SnapshotInfo) error {
+	for height := uint64(0); height < info.Height; height += consensus.ActiveNetParams.BlocksOfEpoch {
+		hash, err := GetMainChainHash(s.db, height)
+		if err != nil {
+			return err
+		}
+
+		if rawCheckpoint := s.db.Get(calcCheckpointKey(height, hash)); rawCheckpoint != nil {
+			if err := sw.write(snapshotCheckpointRecord, hash.Bytes(), rawCheckpoint); err != nil {
+				return err
+			}
+		}
+	}
+
+	rawCheckpoint := s.db.Get(calcCheckpointKey(info.Height, &info.Hash))
+	if rawCheckpoint == nil {
+		return errors.Wrap(errSnapshotEmptyStore, "missing the finalized checkpoint")
+	}
+	return sw.write(snapshotCheckpointRecord, info.Hash.Bytes(), rawCheckpoint)
+}
+
+// exportUtxos merge the rolled back utxo view into the utxo set of the store by the key order
+func (s *Store) exportUtxos(sw *snapshotWriter, view *state.UtxoViewpoint, snapshotHeight uint64) error {
+	hashes := make([]bc.Hash, 0, len(view.Entries))
+	for hash := range view.Entries {
+		hashes = append(hashes, hash)
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i].Bytes(), hashes[j].Bytes()) < 0
+	})
+
+	iter := s.db.IteratorPrefix(UtxoKeyPrefix)
+	defer iter.Release()
+
+	for valid := iter.Next(); valid || len(hashes) > 0; {
+		var key []byte
+		if valid {
+			key = iter.Key()[len(UtxoKeyPrefix):]
+		}
+
+		var hash bc.Hash
+		entry := &storage.UtxoEntry{}
+		if len(hashes) > 0 && (!valid || bytes.Compare(hashes[0].Bytes(), key) <= 0) {
+			// the entry of the view overrides the one in the store
+			if valid && bytes.Equal(hashes[0].Bytes(), key) {
+				valid = iter.Next()
+			}
+
+			hash, entry, hashes = hashes[0], view.Entries[hashes[0]], hashes[1:]
+		} else {
+			var err error
+			if hash, err = hashFromBytes(key); err != nil {
+				return err
+			}
+
+			if err := proto.Unmarshal(iter.Value(), entry); err != nil {
+				return errors.Wrap(err, "unmarshaling utxo entry")
+			}
+			valid = iter.Next()
+		}
+
+		if entry.Spent {
+			continue
+		}
+
+		data, err := proto.Marshal(canonicalUtxoEntry(entry, snapshotHeight))
+		if err != nil {
+			return errors.Wrap(err, "marshaling utxo entry")
+		}
+
+		if err := sw.write(snapshotUtxoRecord, hash.Bytes(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportContracts write the registered contracts except the ones registered by the rolled back blocks
+func (s *Store) exportContracts(sw *snapshotWriter, view *state.ContractViewpoint) error {
+	iter := s.db.IteratorPrefix(ContractPrefix)
+	defer iter.Release()
+
+	for iter.Next() {
+		var hash [32]byte
+		copy(hash[:], iter.Key()[len(ContractPrefix):])
+		if value, ok := view.DetachEntries[hash]; ok && bytes.Equal(value, iter.Value()) {
+			continue
+		}
+
+		if err := sw.write(snapshotContractRecord, hash[:], iter.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotLoader check the records of the snapshot, and write them to the batch when the
+// batch is set
+type snapshotLoader struct {
+	db          dbm.DB
+	batch       dbm.Batch
+	batchSize   int
+	genesisHash bc.Hash
+
+	info       *SnapshotInfo
+	lastType   byte
+	headerNum  uint64
+	headerHash bc.Hash
+	header     *types.BlockHeader
+	block      bool
+	checkpoint bool
+
+	checkpointHeight uint64
+}
+
+// complete check whether the chain part of the snapshot is loaded
+func (l *snapshotLoader) complete() bool {
+	return l.block && l.checkpoint
+}
+
+func (l *snapshotLoader) set(key, value []byte) {
+	if l.batch == nil {
+		return
+	}
+
+	l.batch.Set(key, value)
+	if l.batchSize++; l.batchSize >= snapshotBatchSize {
+		l.batch.Write()
+		l.batch, l.batchSize = l.db.NewBatch(), 0
+	}
+}
+
+func (l *snapshotLoader) load(recordType byte, key, value []byte) error {
+	if recordType < l.lastType || recordType >= snapshotEndRecord {
+		return errors.WithDetailf(ErrSnapshotFormat, "unexpected record type %d after %d", recordType, l.lastType)
+	}
+
+	if recordType == l.lastType && (recordType == snapshotInfoRecord || recordType == snapshotBlockRecord) {
+		return errors.WithDetailf(ErrSnapshotFormat, "duplicated record type %d", recordType)
+	}
+
+	if l.info == nil && recordType != snapshotInfoRecord {
+		return errors.WithDetail(ErrSnapshotFormat, "missing the info record")
+	}
+
+	if l.lastType <= snapshotHeaderRecord && recordType > snapshotHeaderRecord && (l.header == nil || l.headerHash != l.info.Hash || l.header.Height != l.info.Height) {
+		return errors.WithDetail(ErrSnapshotChain, "the headers don't end with the snapshot block")
+	}
+
+	if recordType > snapshotCheckpointRecord && !l.complete() {
+		return errors.WithDetail(ErrSnapshotFormat, "missing the block or the checkpoint")
+	}
+
+	l.lastType = recordType
+	switch recordType {
+	case snapshotInfoRecord:
+		return l.loadInfo(value)
+	case snapshotHeaderRecord:
+		return l.loadHeader(key, value)
+	case snapshotBlockRecord:
+		return l.loadBlock(key, value)
+	case snapshotCheckpointRecord:
+		return l.loadCheckpoint(key, value)
+	case snapshotUtxoRecord:
+		hash, err := hashFromBytes(key)
+		if err != nil {
+			return err
+		}
+
+		if err := proto.Unmarshal(value, &storage.UtxoEntry{}); err != nil {
+			return errors.WithDetail(ErrSnapshotFormat, err.Error())
+		}
+
+		l.set(CalcUtxoKey(&hash), value)
+	case snapshotContractRecord:
+		// value:"txID+program.Code" len(txID) == 32
+		if len(key) != 32 || len(value) <= 32 {
+			return errors.WithDetail(ErrSnapshotFormat, "bad contract record")
+		}
+
+		var hash [32]byte
+		copy(hash[:], key)
+		l.set(CalcContractKey(hash), value)
+	}
+	return nil
+}
+
+func (l *snapshotLoader) loadInfo(value []byte) error {
+	info := &SnapshotInfo{}
+	if err := json.Unmarshal(value, info); err != nil {
+		return errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	if info.Version != snapshotVersion {
+		return errors.WithDetailf(ErrSnapshotFormat, "unsupported snapshot version %d", info.Version)
+	}
+
+	l.info = info
+	return nil
+}
+
+// loadHeader check the headers are linked from the genesis to the snapshot block, and save
+// them as the main chain
+func (l *snapshotLoader) loadHeader(key, value []byte) error {
+	header := &types.BlockHeader{}
+	if err := header.UnmarshalText(value); err != nil {
+		return errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	hash := header.Hash()
+	if !bytes.Equal(key, hash.Bytes()) || header.Height != l.headerNum || header.Height > l.info.Height {
+		return errors.WithDetailf(ErrSnapshotChain, "bad header at height %d", l.headerNum)
+	}
+
+	if header.Height == 0 && hash != l.genesisHash {
+		return errors.WithDetail(ErrSnapshotChain, "genesis block mismatch")
+	}
+
+	if header.Height > 0 && header.PreviousBlockHash != l.headerHash {
+		return errors.WithDetailf(ErrSnapshotChain, "header at height %d isn't linked to the previous one", header.Height)
+	}
+
+	rawHashes, err := json.Marshal([]*bc.Hash{&hash})
+	if err != nil {
+		return err
+	}
+
+	rawHash, err := hash.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	l.set(CalcBlockHeaderKey(&hash), value)
+	l.set(CalcBlockHashesKey(header.Height), rawHashes)
+	l.set(calcMainChainIndexPrefix(header.Height), rawHash)
+	l.headerNum, l.headerHash, l.header = l.headerNum+1, hash, header
+	return nil
+}
+
+func (l *snapshotLoader) loadBlock(key, value []byte) error {
+	if !bytes.Equal(key, l.info.Hash.Bytes()) {
+		return errors.WithDetail(ErrSnapshotChain, "the block isn't the snapshot block")
+	}
+
+	block := &types.Block{}
+	if err := block.UnmarshalText(value); err != nil {
+		return errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	txs := make([]*bc.Tx, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txs = append(txs, tx.Tx)
+	}
+
+	merkleRoot, err := types.TxMerkleRoot(txs)
+	if err != nil {
+		return errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	if merkleRoot != l.header.TransactionsMerkleRoot {
+		return errors.WithDetail(ErrSnapshotChain, "the transactions don't match the snapshot block")
+	}
+
+	l.set(CalcBlockTransactionsKey(&l.info.Hash), value)
+	l.block = true
+	return nil
+}
+
+// loadCheckpoint check the checkpoints are in the height order and end with the finalized
+// checkpoint of the snapshot block
+func (l *snapshotLoader) loadCheckpoint(key, value []byte) error {
+	checkpoint := &state.Checkpoint{}
+	if err := json.Unmarshal(value, checkpoint); err != nil {
+		return errors.WithDetail(ErrSnapshotFormat, err.Error())
+	}
+
+	if l.checkpoint || !bytes.Equal(key, checkpoint.Hash.Bytes()) || checkpoint.Height > l.info.Height || checkpoint.Height < l.checkpointHeight {
+		return errors.WithDetailf(ErrSnapshotFormat, "unexpected checkpoint at height %d", checkpoint.Height)
+	}
+
+	l.checkpointHeight = checkpoint.Height + 1
+	if checkpoint.Height < l.info.Height {
+		l.set(calcCheckpointKey(checkpoint.Height, &checkpoint.Hash), value)
+		return nil
+	}
+
+	if checkpoint.Hash != l.info.Hash {
+		return errors.WithDetail(ErrSnapshotChain, "the checkpoint isn't the snapshot block")
+	}
+
+	if checkpoint.Height != 0 && checkpoint.Status != state.Finalized {
+		return errors.WithDetail(ErrSnapshotChain, "the checkpoint isn't finalized")
+	}
+
+	l.set(calcCheckpointKey(checkpoint.Height, &checkpoint.Hash), value)
+	l.checkpoint = true
+	return nil
+}
+
+// ImportSnapshot load the snapshot into the empty store after the content hash of the whole
+// snapshot is verified, the chain continues from the snapshot block and the transactions of
+// the earlier blocks are not available.
+func (s *Store) ImportSnapshot(r io.ReadSeeker, genesisHash, contentHash *bc.Hash) (*SnapshotInfo, error) {
+	if s.GetStoreStatus() != nil {
+		return nil, errSnapshotStoreInit
+	}
+
+	// nothing is written to the store until the whole snapshot is verified
+	verifier := &snapshotLoader{genesisHash: *genesisHash}
+	if err := walkSnapshot(r, contentHash, verifier.load); err != nil {
+		return nil, err
+	}
+
+	if !verifier.complete() {
+		return nil, errors.WithDetail(ErrSnapshotFormat, "missing the block or the checkpoint")
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	loader := &snapshotLoader{db: s.db, batch: s.db.NewBatch(), genesisHash: *genesisHash}
+	if err := walkSnapshot(r, contentHash, loader.load); err != nil {
+		return nil, err
+	}
+
+	info := loader.info
+	rawStatus, err := json.Marshal(&state.BlockStoreState{
+		Height:          info.Height,
+		Hash:            &info.Hash,
+		FinalizedHeight: info.Height,
+		FinalizedHash:   &info.Hash,
+		BaseHeight:      info.Height,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loader.batch.Set(BlockStoreKey, rawStatus)
+	loader.batch.Write()
+	log.WithFields(log.Fields{"module": logModule, "height": info.Height, "hash": info.Hash.String()}).Info("snapshot imported")
+	return info, nil
+}
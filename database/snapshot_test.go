@@ -0,0 +1,148 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+var snapshotAsset = bc.AssetID{V0: 1}
+
+func newSnapshotBlock(t *testing.T, height uint64, prevHash bc.Hash, txs ...*types.Tx) *types.Block {
+	coinbase := types.NewTx(types.TxData{
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte{byte(height)})},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(snapshotAsset, height+1, []byte{0x51}, nil)},
+	})
+
+	block := &types.Block{
+		BlockHeader:  types.BlockHeader{Height: height, PreviousBlockHash: prevHash},
+		Transactions: append([]*types.Tx{coinbase}, txs...),
+	}
+
+	bcTxs := []*bc.Tx{}
+	for _, tx := range block.Transactions {
+		bcTxs = append(bcTxs, tx.Tx)
+	}
+
+	merkleRoot, err := types.TxMerkleRoot(bcTxs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block.TransactionsMerkleRoot = merkleRoot
+	return block
+}
+
+func newSnapshotSpend(tx *types.Tx, index int, programs ...[]byte) *types.Tx {
+	output := tx.Entries[*tx.OutputID(index)].(*bc.OriginalOutput)
+	data := types.TxData{
+		Inputs: []*types.TxInput{types.NewSpendInput(nil, *output.Source.Ref, snapshotAsset, output.Source.Value.Amount, output.Source.Position, tx.Outputs[index].ControlProgram, nil)},
+	}
+
+	for _, program := range programs {
+		data.Outputs = append(data.Outputs, types.NewOriginalTxOutput(snapshotAsset, output.Source.Value.Amount/uint64(len(programs)), program, nil))
+	}
+	return types.NewTx(data)
+}
+
+func TestExportImportSnapshot(t *testing.T) {
+	fundTx := types.NewTx(types.TxData{
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte{0xff})},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(snapshotAsset, 100, []byte{0x00, 0x14, 0xaa}, nil)},
+	})
+	genesis := newSnapshotBlock(t, 0, bc.Hash{}, fundTx)
+
+	splitTx := newSnapshotSpend(fundTx, 0, []byte{0x00, 0x14, 0xbb}, []byte{0x00, 0x14, 0xcc})
+	block1 := newSnapshotBlock(t, 1, genesis.Hash(), splitTx)
+
+	spendTx := newSnapshotSpend(splitTx, 0, []byte{0x00, 0x14, 0xdd})
+	block2 := newSnapshotBlock(t, 2, block1.Hash(), spendTx)
+
+	store := NewStore(dbm.NewMemDB())
+	view := state.NewUtxoViewpoint()
+	headers := []*types.BlockHeader{}
+	for _, block := range []*types.Block{genesis, block1, block2} {
+		if err := store.SaveBlock(block); err != nil {
+			t.Fatal(err)
+		}
+
+		bcBlock := types.MapBlock(block)
+		if err := store.GetTransactionsUtxo(view, bcBlock.Transactions); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := view.ApplyBlock(bcBlock); err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, &block.BlockHeader)
+	}
+
+	block1Hash := block1.Hash()
+	checkpoint := &state.Checkpoint{Height: 1, Hash: block1Hash, ParentHash: genesis.Hash(), Status: state.Finalized}
+	if err := store.SaveCheckpoints([]*state.Checkpoint{checkpoint}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SaveChainStatus(&block2.BlockHeader, headers, view, state.NewContractViewpoint(), 1, &block1Hash); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	info, contentHash, err := store.ExportSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Height != 1 || info.Hash != block1Hash {
+		t.Fatalf("got snapshot at %d %x, want the finalized block", info.Height, info.Hash.Bytes())
+	}
+
+	genesisHash := genesis.Hash()
+	badHash := bc.Hash{V0: 1}
+	if _, err := NewStore(dbm.NewMemDB()).ImportSnapshot(bytes.NewReader(buf.Bytes()), &genesisHash, &badHash); errors.Root(err) != ErrSnapshotHash {
+		t.Errorf("got err %v, want %v", err, ErrSnapshotHash)
+	}
+
+	if _, err := NewStore(dbm.NewMemDB()).ImportSnapshot(bytes.NewReader(buf.Bytes()), &badHash, contentHash); errors.Root(err) != ErrSnapshotChain {
+		t.Errorf("got err %v, want %v", err, ErrSnapshotChain)
+	}
+
+	importStore := NewStore(dbm.NewMemDB())
+	if _, err := importStore.ImportSnapshot(bytes.NewReader(buf.Bytes()), &genesisHash, contentHash); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := importStore.GetStoreStatus(); status.Height != 1 || status.BaseHeight != 1 || *status.FinalizedHash != block1Hash {
+		t.Errorf("got store status %v, want the snapshot block", status)
+	}
+
+	if _, err := importStore.GetBlock(&block1Hash); err != nil {
+		t.Errorf("get the snapshot block: %v", err)
+	}
+
+	if hash, err := importStore.GetMainChainHash(0); err != nil || *hash != genesisHash {
+		t.Errorf("got the main chain hash %v at height 0, err %v", hash, err)
+	}
+
+	// the output spent by the rolled back block is restored, the outputs of it are removed
+	if _, err := importStore.GetUtxo(splitTx.OutputID(0)); err != nil {
+		t.Errorf("get the restored utxo: %v", err)
+	}
+
+	if _, err := importStore.GetUtxo(spendTx.OutputID(0)); err == nil {
+		t.Error("got the utxo of the rolled back block")
+	}
+
+	if _, err := importStore.GetUtxo(fundTx.OutputID(0)); err == nil {
+		t.Error("got the spent utxo")
+	}
+
+	if _, err := importStore.ImportSnapshot(bytes.NewReader(buf.Bytes()), &genesisHash, contentHash); err != errSnapshotStoreInit {
+		t.Errorf("got err %v, want %v", err, errSnapshotStoreInit)
+	}
+}
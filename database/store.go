@@ -14,7 +14,13 @@ import (
 	"coingod/protocol/state"
 )
 
-const logModule = "leveldb"
+const (
+	logModule = "leveldb"
+
+	// maxPruneHeights limit the heights pruned by one chain status update, the node switched
+	// to the pruned mode catches up gradually instead of blocking the block processing
+	maxPruneHeights = 10000
+)
 
 var (
 	// BlockStoreKey block store key
@@ -39,6 +45,9 @@ func loadBlockStoreStateJSON(db dbm.DB) *state.BlockStoreState {
 type Store struct {
 	db    dbm.DB
 	cache cache
+
+	prune      bool
+	pruneDepth uint64
 }
 
 // NewStore creates and returns a new Store object.
@@ -70,6 +79,19 @@ func NewStore(db dbm.DB) *Store {
 	}
 }
 
+// EnablePrune make the store delete the transactions of the blocks deeper than the depth
+// below the last finalized checkpoint, the headers, the main chain index and the utxo set
+// are kept. The depth is at least the lock time of the vote outputs, the snapshot export
+// searches the vote outputs in the blocks of the lock time.
+func (s *Store) EnablePrune(depth uint64) {
+	if _, maxPending := votePendingRange(); depth < maxPending {
+		depth = maxPending
+	}
+
+	s.prune = true
+	s.pruneDepth = depth
+}
+
 // GetBlockHeader return the BlockHeader by given hash
 func (s *Store) GetBlockHeader(hash *bc.Hash) (*types.BlockHeader, error) {
 	return s.cache.lookupBlockHeader(hash)
@@ -204,6 +226,19 @@ func (s *Store) SaveChainStatus(blockHeader *types.BlockHeader, mainBlockHeaders
 		return err
 	}
 
+	var baseHeight uint64
+	if storeStatus := loadBlockStoreStateJSON(s.db); storeStatus != nil {
+		baseHeight = storeStatus.BaseHeight
+	}
+
+	var prunedHashes []*bc.Hash
+	if s.prune {
+		var err error
+		if baseHeight, prunedHashes, err = s.pruneBlockTxs(batch, baseHeight, finalizedHeight); err != nil {
+			return err
+		}
+	}
+
 	blockHeaderHash := blockHeader.Hash()
 	bytes, err := json.Marshal(
 		state.BlockStoreState{
@@ -211,6 +246,7 @@ func (s *Store) SaveChainStatus(blockHeader *types.BlockHeader, mainBlockHeaders
 			Hash:            &blockHeaderHash,
 			FinalizedHeight: finalizedHeight,
 			FinalizedHash:   finalizedHash,
+			BaseHeight:      baseHeight,
 		})
 	if err != nil {
 		return err
@@ -238,5 +274,38 @@ func (s *Store) SaveChainStatus(blockHeader *types.BlockHeader, mainBlockHeaders
 		clearCacheFunc()
 	}
 
+	for _, hash := range prunedHashes {
+		s.cache.removeBlockTxs(hash)
+	}
 	return nil
 }
+
+// pruneBlockTxs delete the transactions of the blocks from the base height to the prune
+// height in the batch, the blocks of the forks are pruned too. It returns the new base height
+// and the hashes of the pruned blocks.
+func (s *Store) pruneBlockTxs(batch dbm.Batch, baseHeight, finalizedHeight uint64) (uint64, []*bc.Hash, error) {
+	if finalizedHeight <= baseHeight+s.pruneDepth {
+		return baseHeight, nil, nil
+	}
+
+	pruneHeight := finalizedHeight - s.pruneDepth
+	if pruneHeight > baseHeight+maxPruneHeights {
+		pruneHeight = baseHeight + maxPruneHeights
+	}
+
+	prunedHashes := []*bc.Hash{}
+	for height := baseHeight; height < pruneHeight; height++ {
+		hashes, err := GetBlockHashesByHeight(s.db, height)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, hash := range hashes {
+			batch.Delete(CalcBlockTransactionsKey(hash))
+			prunedHashes = append(prunedHashes, hash)
+		}
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "from": baseHeight, "to": pruneHeight}).Debug("prune the block transactions")
+	return pruneHeight, prunedHashes, nil
+}
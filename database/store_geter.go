@@ -20,6 +20,7 @@ const (
 	checkpoint
 	utxo
 	contract
+	slashingEvidence
 )
 
 var (
@@ -31,6 +32,7 @@ var (
 	checkpointKeyPrefix     = []byte{checkpoint, colon}
 	UtxoKeyPrefix           = []byte{utxo, colon}
 	ContractPrefix          = []byte{contract, colon}
+	slashingEvidencePrefix  = []byte{slashingEvidence, colon}
 )
 
 func calcMainChainIndexPrefix(height uint64) []byte {
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/protocol/bc"
+	"coingod/protocol/state"
+)
+
+func calcSlashingEvidenceKey(id *bc.Hash) []byte {
+	return append(slashingEvidencePrefix, id.Bytes()...)
+}
+
+// SlashingEvidenceExist check if the slashing evidence is stored in disk
+func (s *Store) SlashingEvidenceExist(id *bc.Hash) bool {
+	return s.db.Get(calcSlashingEvidenceKey(id)) != nil
+}
+
+// ListSlashingEvidences return all the slashing evidences stored in disk
+func (s *Store) ListSlashingEvidences() ([]*state.SlashingEvidence, error) {
+	iter := s.db.IteratorPrefix(slashingEvidencePrefix)
+	defer iter.Release()
+
+	evidences := []*state.SlashingEvidence{}
+	for iter.Next() {
+		evidence := &state.SlashingEvidence{}
+		if err := json.Unmarshal(iter.Value(), evidence); err != nil {
+			return nil, err
+		}
+
+		evidences = append(evidences, evidence)
+	}
+	return evidences, nil
+}
+
+// SaveSlashingEvidence persists the slashing evidence, key by the id of evidence
+func (s *Store) SaveSlashingEvidence(evidence *state.SlashingEvidence) error {
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		return err
+	}
+
+	id := evidence.ID()
+	s.db.Set(calcSlashingEvidenceKey(&id), data)
+	log.WithFields(log.Fields{
+		"module":  logModule,
+		"pub_key": evidence.PubKey,
+		"type":    evidence.Type.String(),
+		"id":      id.String(),
+	}).Warn("slashing evidence saved on disk")
+	return nil
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+	"coingod/protocol/state"
+	"coingod/testutil"
+)
+
+func TestSlashingEvidenceStore(t *testing.T) {
+	store := NewStore(dbm.NewMemDB())
+	a := state.SignedVote{SourceHash: bc.Hash{V0: 1}, SourceHeight: 0, TargetHash: bc.Hash{V0: 2}, TargetHeight: 100, Signature: []byte{0x01}}
+	b := state.SignedVote{SourceHash: bc.Hash{V0: 1}, SourceHeight: 0, TargetHash: bc.Hash{V0: 3}, TargetHeight: 100, Signature: []byte{0x02}}
+	c := state.SignedVote{SourceHash: bc.Hash{V0: 3}, SourceHeight: 100, TargetHash: bc.Hash{V0: 4}, TargetHeight: 200, Signature: []byte{0x03}}
+	evidences := []*state.SlashingEvidence{
+		state.NewSlashingEvidence("pub_key_1", state.SameHeightSlashing, a, b),
+		state.NewSlashingEvidence("pub_key_2", state.SpanHeightSlashing, a, c),
+	}
+
+	for _, evidence := range evidences {
+		id := evidence.ID()
+		if store.SlashingEvidenceExist(&id) {
+			t.Fatalf("the evidence %s exists before saved", id.String())
+		}
+
+		if err := store.SaveSlashingEvidence(evidence); err != nil {
+			t.Fatal(err)
+		}
+
+		if !store.SlashingEvidenceExist(&id) {
+			t.Errorf("the evidence %s doesn't exist after saved", id.String())
+		}
+	}
+
+	// save the known evidence again doesn't duplicate it
+	if err := store.SaveSlashingEvidence(evidences[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	gotEvidences, err := store.ListSlashingEvidences()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gotEvidences) != len(evidences) {
+		t.Fatalf("got %d evidences, want %d", len(gotEvidences), len(evidences))
+	}
+
+	for _, want := range evidences {
+		found := false
+		for _, got := range gotEvidences {
+			if got.ID() == want.ID() {
+				found = testutil.DeepEqual(got, want)
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("the evidence %v is not listed", want)
+		}
+	}
+}
@@ -92,3 +92,47 @@ func TestSaveBlock(t *testing.T) {
 		t.Errorf("got block header:%v, expect block header:%v", gotBlockHeader, block.BlockHeader)
 	}
 }
+
+func TestPruneBlockTxs(t *testing.T) {
+	store := NewStore(dbm.NewMemDB())
+	store.prune, store.pruneDepth = true, 1
+
+	blocks := []*types.Block{newSnapshotBlock(t, 0, bc.Hash{})}
+	for height := uint64(1); height < 5; height++ {
+		blocks = append(blocks, newSnapshotBlock(t, height, blocks[height-1].Hash()))
+	}
+
+	forkBlock := newSnapshotBlock(t, 1, blocks[0].Hash())
+	forkBlock.Timestamp = 1
+	headers := []*types.BlockHeader{}
+	for _, block := range append(blocks, forkBlock) {
+		if err := store.SaveBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, &block.BlockHeader)
+	}
+
+	finalizedHash := blocks[3].Hash()
+	if err := store.SaveChainStatus(&blocks[4].BlockHeader, headers[:5], state.NewUtxoViewpoint(), state.NewContractViewpoint(), 3, &finalizedHash); err != nil {
+		t.Fatal(err)
+	}
+
+	if baseHeight := store.GetStoreStatus().BaseHeight; baseHeight != 2 {
+		t.Errorf("got base height %d, want 2", baseHeight)
+	}
+
+	for _, block := range append(blocks, forkBlock) {
+		blockHash := block.Hash()
+		if _, err := store.GetBlockHeader(&blockHash); err != nil {
+			t.Errorf("get the header at height %d: %v", block.Height, err)
+		}
+
+		if _, err := store.GetBlockTransactions(&blockHash); (err != nil) != (block.Height < 2) {
+			t.Errorf("got err %v of the transactions at height %d, want pruned below the base", err, block.Height)
+		}
+	}
+
+	if hash, err := store.GetMainChainHash(0); err != nil || *hash != blocks[0].Hash() {
+		t.Errorf("got the main chain hash %v at height 0, err %v", hash, err)
+	}
+}
@@ -0,0 +1,235 @@
+// Package explorer indexes the balances and the transactions of every control program and
+// the holders of every asset on the main chain, so the node could answer the queries of
+// arbitrary addresses and assets which are not owned by the wallet.
+package explorer
+
+import (
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"coingod/errors"
+	"coingod/follower"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+// ErrMissingHistory means the blocks needed by the indexes are not available in the chain
+var ErrMissingHistory = errors.New("the blocks before the base block of the chain are not available")
+
+// Chain is the chain service used by the indexer
+type Chain interface {
+	follower.Chain
+}
+
+// AssetChange is the amount of the asset received and sent by the address in the transaction
+type AssetChange struct {
+	AssetID  bc.AssetID `json:"asset_id"`
+	Received uint64     `json:"received"`
+	Sent     uint64     `json:"sent"`
+}
+
+// AddressTx is the transaction related to the address
+type AddressTx struct {
+	TxID           bc.Hash        `json:"tx_id"`
+	BlockHeight    uint64         `json:"block_height"`
+	BlockHash      bc.Hash        `json:"block_hash"`
+	BlockTimestamp uint64         `json:"block_timestamp"`
+	Position       uint32         `json:"position"`
+	Changes        []*AssetChange `json:"changes"`
+}
+
+type holdingKey struct {
+	program string
+	assetID bc.AssetID
+}
+
+// holdingChange is the outputs of the asset received and spent by the program in the block
+type holdingChange struct {
+	received      uint64
+	spent         uint64
+	receivedUTXOs uint64
+	spentUTXOs    uint64
+}
+
+// blockIndex is the holding changes and the related transactions of each program in the block
+type blockIndex struct {
+	holdings map[holdingKey]*holdingChange
+	txs      map[string][]*AddressTx
+}
+
+func newBlockIndex(block *types.Block) *blockIndex {
+	index := &blockIndex{holdings: make(map[holdingKey]*holdingChange), txs: make(map[string][]*AddressTx)}
+	blockHash := block.Hash()
+	for i, tx := range block.Transactions {
+		changes := make(map[string]map[bc.AssetID]*AssetChange)
+		change := func(program []byte, assetID bc.AssetID) (*holdingChange, *AssetChange) {
+			key := holdingKey{program: hex.EncodeToString(program), assetID: assetID}
+			if _, ok := index.holdings[key]; !ok {
+				index.holdings[key] = &holdingChange{}
+			}
+
+			if _, ok := changes[key.program]; !ok {
+				changes[key.program] = make(map[bc.AssetID]*AssetChange)
+			}
+
+			if _, ok := changes[key.program][assetID]; !ok {
+				changes[key.program][assetID] = &AssetChange{AssetID: assetID}
+			}
+			return index.holdings[key], changes[key.program][assetID]
+		}
+
+		for _, input := range tx.Inputs {
+			if inputType := input.InputType(); inputType != types.SpendInputType && inputType != types.VetoInputType {
+				continue
+			}
+
+			h, c := change(input.ControlProgram(), input.AssetID())
+			h.spent += input.Amount()
+			h.spentUTXOs++
+			c.Sent += input.Amount()
+		}
+
+		for _, output := range tx.Outputs {
+			if output.AssetId == nil {
+				continue
+			}
+
+			h, c := change(output.ControlProgram, *output.AssetId)
+			h.received += output.Amount
+			h.receivedUTXOs++
+			c.Received += output.Amount
+		}
+
+		for program, assetChanges := range changes {
+			addressTx := &AddressTx{
+				TxID:           tx.ID,
+				BlockHeight:    block.Height,
+				BlockHash:      blockHash,
+				BlockTimestamp: block.Timestamp,
+				Position:       uint32(i),
+			}
+			for _, c := range assetChanges {
+				addressTx.Changes = append(addressTx.Changes, c)
+			}
+
+			sort.Slice(addressTx.Changes, func(i, j int) bool {
+				return assetIDString(addressTx.Changes[i].AssetID) < assetIDString(addressTx.Changes[j].AssetID)
+			})
+			index.txs[program] = append(index.txs[program], addressTx)
+		}
+	}
+	return index
+}
+
+// Indexer follow the main chain and maintain the address and asset indexes
+type Indexer struct {
+	mu     sync.RWMutex
+	store  *Store
+	chain  Chain
+	status *status
+}
+
+// NewIndexer create the indexer which continues from the last applied block of the store,
+// the genesis block is applied when the store is empty
+func NewIndexer(store *Store, chain Chain) (*Indexer, error) {
+	bestStatus, err := store.getStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	// the indexes must be built from the genesis, the node bootstrapped from a snapshot
+	// doesn't have the earlier blocks
+	indexedHeight := uint64(0)
+	if bestStatus != nil {
+		indexedHeight = bestStatus.Height
+	}
+
+	if baseHeight := chain.BaseBlockHeight(); indexedHeight < baseHeight {
+		return nil, errors.WithDetailf(ErrMissingHistory, "indexer height %d, base height %d", indexedHeight, baseHeight)
+	}
+
+	indexer := &Indexer{store: store, chain: chain, status: bestStatus}
+	if bestStatus != nil {
+		return indexer, nil
+	}
+
+	genesis, err := chain.GetBlockByHeight(0)
+	if err != nil {
+		return nil, err
+	}
+
+	newStatus := &status{Height: 0, Hash: genesis.Hash()}
+	if err := store.saveBlock(newBlockIndex(genesis), false, newStatus); err != nil {
+		return nil, err
+	}
+
+	indexer.status = newStatus
+	return indexer, nil
+}
+
+// Sync apply the main chain blocks to the indexes, the blocks are detached when the
+// chain is reorganized. It returns error when the blocks to apply are not available in
+// the chain. It must be run as a goroutine.
+func (idx *Indexer) Sync() error {
+	return follower.NewFollower("explorer indexer", idx.chain, idx).Run()
+}
+
+// BestChain return the height and hash of the last applied block
+func (idx *Indexer) BestChain() (uint64, bc.Hash) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.status.Height, idx.status.Hash
+}
+
+// GetBalances return the balances of all the assets controlled by the program
+func (idx *Indexer) GetBalances(program []byte) ([]*Balance, error) {
+	return idx.store.GetBalances(program)
+}
+
+// ListTransactions return the transactions of the program from the latest to the oldest
+func (idx *Indexer) ListTransactions(program []byte, after string, limit int) ([]*AddressTx, string, error) {
+	return idx.store.ListTransactions(program, after, limit)
+}
+
+// ListHolders return the control programs holding the asset
+func (idx *Indexer) ListHolders(assetID bc.AssetID, after string, limit int) ([]*Balance, string, error) {
+	return idx.store.ListHolders(assetID, after, limit)
+}
+
+// ApplyBlock add the block to the indexes, the block must be the child of the last
+// applied block
+func (idx *Indexer) ApplyBlock(block *types.Block) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if block.PreviousBlockHash != idx.status.Hash {
+		return errors.New("the applied block is not the child of the indexer status")
+	}
+
+	newStatus := &status{Height: block.Height, Hash: block.Hash()}
+	if err := idx.store.saveBlock(newBlockIndex(block), false, newStatus); err != nil {
+		return err
+	}
+
+	idx.status = newStatus
+	return nil
+}
+
+// DetachBlock remove the last applied block from the indexes
+func (idx *Indexer) DetachBlock(block *types.Block) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if block.Hash() != idx.status.Hash {
+		return errors.New("the detached block is not the indexer status")
+	}
+
+	newStatus := &status{Height: block.Height - 1, Hash: block.PreviousBlockHash}
+	if err := idx.store.saveBlock(newBlockIndex(block), true, newStatus); err != nil {
+		return err
+	}
+
+	idx.status = newStatus
+	return nil
+}
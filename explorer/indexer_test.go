@@ -0,0 +1,121 @@
+package explorer
+
+import (
+	"encoding/hex"
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+type mockChain struct {
+	blocks []*types.Block
+}
+
+func (c *mockChain) BaseBlockHeight() uint64 {
+	return 0
+}
+
+func (c *mockChain) BestBlockHeight() uint64 {
+	return uint64(len(c.blocks) - 1)
+}
+
+func (c *mockChain) InMainChain(hash bc.Hash) bool {
+	return true
+}
+
+func (c *mockChain) BlockWaiter(height uint64) <-chan struct{} {
+	return make(chan struct{})
+}
+
+func (c *mockChain) GetBlockByHeight(height uint64) (*types.Block, error) {
+	if height >= uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return c.blocks[height], nil
+}
+
+func (c *mockChain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
+	for _, block := range c.blocks {
+		if block.Hash() == *hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestIndexerApplyDetach(t *testing.T) {
+	asset := bc.AssetID{V0: 1}
+	alice, bob := []byte{0x00, 0x14, 0xaa}, []byte{0x00, 0x14, 0xbb}
+	genesis := &types.Block{
+		BlockHeader: types.BlockHeader{Height: 0},
+		Transactions: []*types.Tx{types.NewTx(types.TxData{
+			Outputs: []*types.TxOutput{types.NewOriginalTxOutput(asset, 100, alice, nil)},
+		})},
+	}
+
+	genesisTx := genesis.Transactions[0]
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{Height: 1, PreviousBlockHash: genesis.Hash()},
+		Transactions: []*types.Tx{types.NewTx(types.TxData{
+			Inputs: []*types.TxInput{types.NewSpendInput(nil, *genesisTx.OutputID(0), asset, 100, 0, alice, nil)},
+			Outputs: []*types.TxOutput{
+				types.NewOriginalTxOutput(asset, 60, bob, nil),
+				types.NewOriginalTxOutput(asset, 40, alice, nil),
+			},
+		})},
+	}
+
+	indexer, err := NewIndexer(NewStore(dbm.NewMemDB()), &mockChain{blocks: []*types.Block{genesis, block}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := indexer.ApplyBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	balances, err := indexer.GetBalances(alice)
+	if err != nil || len(balances) != 1 || balances[0].Amount != 40 || balances[0].UTXONum != 1 {
+		t.Fatalf("got balances %v, err %v", balances, err)
+	}
+
+	holders, next, err := indexer.ListHolders(asset, "", 1)
+	if err != nil || len(holders) != 1 || holders[0].ControlProgram != hex.EncodeToString(alice) || next == "" {
+		t.Fatalf("got holders %v, next %s, err %v", holders, next, err)
+	}
+
+	if holders, next, err = indexer.ListHolders(asset, next, 1); err != nil || len(holders) != 1 || holders[0].Amount != 60 || next != "" {
+		t.Fatalf("got holders %v, next %s, err %v on the second page", holders, next, err)
+	}
+
+	txs, _, err := indexer.ListTransactions(alice, "", 0)
+	if err != nil || len(txs) != 2 || txs[0].BlockHeight != 1 || txs[1].TxID != genesisTx.ID {
+		t.Fatalf("got transactions %v, err %v", txs, err)
+	}
+
+	if changes := txs[0].Changes; len(changes) != 1 || changes[0].Received != 40 || changes[0].Sent != 100 {
+		t.Errorf("got changes %v, want received 40 and sent 100", changes)
+	}
+
+	if err := indexer.DetachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if balances, err := indexer.GetBalances(bob); err != nil || len(balances) != 0 {
+		t.Errorf("got balances %v, err %v after the rollback", balances, err)
+	}
+
+	if balances, err := indexer.GetBalances(alice); err != nil || len(balances) != 1 || balances[0].Amount != 100 {
+		t.Errorf("got balances %v, err %v after the rollback", balances, err)
+	}
+
+	if txs, _, err := indexer.ListTransactions(alice, "", 0); err != nil || len(txs) != 1 {
+		t.Errorf("got transactions %v, err %v after the rollback", txs, err)
+	}
+
+	if height, hash := indexer.BestChain(); height != 0 || hash != genesis.Hash() {
+		t.Errorf("got best chain %d %v, want the genesis", height, hash)
+	}
+}
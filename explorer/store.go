@@ -0,0 +1,305 @@
+package explorer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/protocol/bc"
+)
+
+const (
+	colon = byte(0x3a)
+
+	addressBalance byte = iota + 1
+	assetHolder
+	addressTx
+	indexerStatus
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var (
+	addressBalancePrefixKey = []byte{addressBalance, colon}
+	assetHolderPrefixKey    = []byte{assetHolder, colon}
+	addressTxPrefixKey      = []byte{addressTx, colon}
+	indexerStatusKey        = []byte{indexerStatus, colon}
+
+	// ErrBadCursor means the cursor of the list is in bad format
+	ErrBadCursor = errors.New("invalid explorer cursor")
+)
+
+func assetIDString(assetID bc.AssetID) string {
+	return hex.EncodeToString(assetID.Bytes())
+}
+
+func addressBalancePrefix(program string) []byte {
+	return append(append([]byte{}, addressBalancePrefixKey...), program+":"...)
+}
+
+func addressBalanceKey(program string, assetID bc.AssetID) []byte {
+	return append(addressBalancePrefix(program), assetIDString(assetID)...)
+}
+
+func assetHolderPrefix(assetID bc.AssetID) []byte {
+	return append(append([]byte{}, assetHolderPrefixKey...), assetIDString(assetID)+":"...)
+}
+
+func assetHolderKey(assetID bc.AssetID, program string) []byte {
+	return append(assetHolderPrefix(assetID), program...)
+}
+
+func addressTxPrefix(program string) []byte {
+	return append(append([]byte{}, addressTxPrefixKey...), program+":"...)
+}
+
+func addressTxKey(program, cursor string) []byte {
+	return append(addressTxPrefix(program), cursor...)
+}
+
+// formatTxCursor invert the height and the position, so the latest transaction
+// comes first in the index
+func formatTxCursor(blockHeight uint64, position uint32) string {
+	return fmt.Sprintf("%016x%08x", ^blockHeight, ^position)
+}
+
+func parseTxCursor(cursor string) (uint64, uint32, error) {
+	if len(cursor) != len(formatTxCursor(0, 0)) {
+		return 0, 0, ErrBadCursor
+	}
+
+	height, err := strconv.ParseUint(cursor[:16], 16, 64)
+	if err != nil {
+		return 0, 0, ErrBadCursor
+	}
+
+	position, err := strconv.ParseUint(cursor[16:], 16, 32)
+	if err != nil {
+		return 0, 0, ErrBadCursor
+	}
+	return ^height, ^uint32(position), nil
+}
+
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	} else if limit > maxListLimit {
+		return maxListLimit
+	}
+	return limit
+}
+
+// status is the last block applied by the indexer
+type status struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// holding is the unspent outputs of the asset controlled by the program
+type holding struct {
+	Amount  uint64 `json:"amount"`
+	UTXONum uint64 `json:"utxo_num"`
+}
+
+// Balance is the unspent amount of the asset controlled by the program
+type Balance struct {
+	ControlProgram string     `json:"control_program"`
+	AssetID        bc.AssetID `json:"asset_id"`
+	Amount         uint64     `json:"amount"`
+	UTXONum        uint64     `json:"utxo_num"`
+}
+
+// Store persist the address and asset indexes of the main chain
+type Store struct {
+	db dbm.DB
+}
+
+// NewStore create the store of the explorer indexes
+func NewStore(db dbm.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetBalances return the balances of all the assets controlled by the program
+func (s *Store) GetBalances(program []byte) ([]*Balance, error) {
+	programHex := hex.EncodeToString(program)
+	prefix := addressBalancePrefix(programHex)
+	iter := s.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	result := []*Balance{}
+	for iter.Next() {
+		assetID := bc.AssetID{}
+		if err := assetID.UnmarshalText(iter.Key()[len(prefix):]); err != nil {
+			return nil, err
+		}
+
+		h := &holding{}
+		if err := json.Unmarshal(iter.Value(), h); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &Balance{ControlProgram: programHex, AssetID: assetID, Amount: h.Amount, UTXONum: h.UTXONum})
+	}
+	return result, nil
+}
+
+// ListHolders return the control programs holding the asset by the program order. The
+// returned cursor should be passed as the after of the next call, the empty cursor means
+// the list is finished.
+func (s *Store) ListHolders(assetID bc.AssetID, after string, limit int) ([]*Balance, string, error) {
+	if _, err := hex.DecodeString(after); err != nil {
+		return nil, "", ErrBadCursor
+	}
+
+	prefix := assetHolderPrefix(assetID)
+	iter := s.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	valid := iter.Seek(assetHolderKey(assetID, after))
+	if valid && after != "" && string(iter.Key()) == string(assetHolderKey(assetID, after)) {
+		valid = iter.Next()
+	}
+
+	result := []*Balance{}
+	next := ""
+	for limit = listLimit(limit); valid && len(result) < limit; valid = iter.Next() {
+		h := &holding{}
+		if err := json.Unmarshal(iter.Value(), h); err != nil {
+			return nil, "", err
+		}
+
+		next = string(iter.Key()[len(prefix):])
+		result = append(result, &Balance{ControlProgram: next, AssetID: assetID, Amount: h.Amount, UTXONum: h.UTXONum})
+	}
+
+	if !valid {
+		next = ""
+	}
+	return result, next, nil
+}
+
+// ListTransactions return the transactions of the program from the latest to the oldest.
+// The returned cursor should be passed as the after of the next call, the empty cursor
+// means the list is finished.
+func (s *Store) ListTransactions(program []byte, after string, limit int) ([]*AddressTx, string, error) {
+	if after != "" {
+		if _, _, err := parseTxCursor(after); err != nil {
+			return nil, "", err
+		}
+	}
+
+	programHex := hex.EncodeToString(program)
+	prefix := addressTxPrefix(programHex)
+	iter := s.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	valid := iter.Seek(addressTxKey(programHex, after))
+	if valid && after != "" && string(iter.Key()) == string(addressTxKey(programHex, after)) {
+		valid = iter.Next()
+	}
+
+	result := []*AddressTx{}
+	next := ""
+	for limit = listLimit(limit); valid && len(result) < limit; valid = iter.Next() {
+		tx := &AddressTx{}
+		if err := json.Unmarshal(iter.Value(), tx); err != nil {
+			return nil, "", err
+		}
+
+		next = strings.TrimPrefix(string(iter.Key()), string(prefix))
+		result = append(result, tx)
+	}
+
+	if !valid {
+		next = ""
+	}
+	return result, next, nil
+}
+
+func (s *Store) getStatus() (*status, error) {
+	data := s.db.Get(indexerStatusKey)
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &status{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Store) getHolding(program string, assetID bc.AssetID) (*holding, error) {
+	data := s.db.Get(addressBalanceKey(program, assetID))
+	if data == nil {
+		return &holding{}, nil
+	}
+
+	result := &holding{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveBlock apply or rollback the index of the block and move the status in one batch
+func (s *Store) saveBlock(index *blockIndex, rollback bool, newStatus *status) error {
+	batch := s.db.NewBatch()
+	for key, change := range index.holdings {
+		h, err := s.getHolding(key.program, key.assetID)
+		if err != nil {
+			return err
+		}
+
+		if rollback {
+			h.Amount, h.UTXONum = h.Amount+change.spent-change.received, h.UTXONum+change.spentUTXOs-change.receivedUTXOs
+		} else {
+			h.Amount, h.UTXONum = h.Amount+change.received-change.spent, h.UTXONum+change.receivedUTXOs-change.spentUTXOs
+		}
+
+		if h.UTXONum == 0 {
+			batch.Delete(addressBalanceKey(key.program, key.assetID))
+			batch.Delete(assetHolderKey(key.assetID, key.program))
+			continue
+		}
+
+		data, err := json.Marshal(h)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(addressBalanceKey(key.program, key.assetID), data)
+		batch.Set(assetHolderKey(key.assetID, key.program), data)
+	}
+
+	for program, txs := range index.txs {
+		for _, tx := range txs {
+			key := addressTxKey(program, formatTxCursor(tx.BlockHeight, tx.Position))
+			if rollback {
+				batch.Delete(key)
+				continue
+			}
+
+			data, err := json.Marshal(tx)
+			if err != nil {
+				return err
+			}
+
+			batch.Set(key, data)
+		}
+	}
+
+	data, err := json.Marshal(newStatus)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(indexerStatusKey, data)
+	batch.Write()
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package follower feeds the main chain blocks to the services which index the chain out
+// of the core, the blocks are detached from the service when the chain is reorganized.
+package follower
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+const (
+	logModule = "follower"
+
+	minRetryInterval = time.Second
+	maxRetryInterval = time.Minute
+)
+
+// ErrPrunedHistory means the blocks to apply are pruned or before the snapshot of the chain,
+// the handler can never catch up with the chain
+var ErrPrunedHistory = errors.New("the blocks followed by the handler are not available in the chain")
+
+// Chain is the chain service followed by the handler
+type Chain interface {
+	BaseBlockHeight() uint64
+	BestBlockHeight() uint64
+	BlockWaiter(height uint64) <-chan struct{}
+	GetBlockByHeight(height uint64) (*types.Block, error)
+	GetBlockByHash(hash *bc.Hash) (*types.Block, error)
+	InMainChain(hash bc.Hash) bool
+}
+
+// Handler is the service follows the main chain
+type Handler interface {
+	// BestChain return the height and hash of the last applied block
+	BestChain() (uint64, bc.Hash)
+	// ApplyBlock apply the block which is the child of the last applied block
+	ApplyBlock(block *types.Block) error
+	// DetachBlock rollback the last applied block
+	DetachBlock(block *types.Block) error
+}
+
+// CaughtUpHandler is the handler which has the work to do when it has caught up with the chain
+type CaughtUpHandler interface {
+	Handler
+	CaughtUp()
+}
+
+// Follower apply the main chain blocks to the handler one by one
+type Follower struct {
+	name          string
+	chain         Chain
+	handler       Handler
+	retryInterval time.Duration
+}
+
+// NewFollower create the follower, the name is used in the logs
+func NewFollower(name string, chain Chain, handler Handler) *Follower {
+	return &Follower{name: name, chain: chain, handler: handler, retryInterval: minRetryInterval}
+}
+
+// Run follow the main chain forever, the failed step is retried with the growing interval
+// so the handler is never left behind silently. It only returns when the blocks to apply
+// are not available in the chain. It must be run as a goroutine.
+func (f *Follower) Run() error {
+	for {
+		err := f.step()
+		if errors.Root(err) == ErrPrunedHistory {
+			return err
+		}
+
+		if err != nil {
+			log.WithFields(log.Fields{"module": logModule, "follower": f.name, "err": err, "retry": f.retryInterval}).Error("fail on follow the chain")
+			time.Sleep(f.retryInterval)
+			if f.retryInterval *= 2; f.retryInterval > maxRetryInterval {
+				f.retryInterval = maxRetryInterval
+			}
+			continue
+		}
+
+		f.retryInterval = minRetryInterval
+	}
+}
+
+// step detach the handler block which is not in the main chain, or apply the next main
+// chain block, it waits for the new block when the handler has caught up with the chain
+func (f *Follower) step() error {
+	height, hash := f.handler.BestChain()
+	if !f.chain.InMainChain(hash) {
+		block, err := f.chain.GetBlockByHash(&hash)
+		if err != nil {
+			return err
+		}
+
+		return f.handler.DetachBlock(block)
+	}
+
+	if height >= f.chain.BestBlockHeight() {
+		if h, ok := f.handler.(CaughtUpHandler); ok {
+			h.CaughtUp()
+		}
+
+		<-f.chain.BlockWaiter(height + 1)
+		return nil
+	}
+
+	if baseHeight := f.chain.BaseBlockHeight(); height+1 < baseHeight {
+		return errors.WithDetailf(ErrPrunedHistory, "%s at height %d, the chain keeps the blocks from height %d", f.name, height, baseHeight)
+	}
+
+	block, err := f.chain.GetBlockByHeight(height + 1)
+	if err != nil {
+		return err
+	}
+
+	// the chain is reorganized after the check, the block will be detached in the next step
+	if block.PreviousBlockHash != hash {
+		return nil
+	}
+
+	return f.handler.ApplyBlock(block)
+}
@@ -0,0 +1,131 @@
+package follower
+
+import (
+	"testing"
+
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+type mockChain struct {
+	blocks     []*types.Block
+	baseHeight uint64
+}
+
+func (c *mockChain) BaseBlockHeight() uint64 {
+	return c.baseHeight
+}
+
+func (c *mockChain) BestBlockHeight() uint64 {
+	return uint64(len(c.blocks) - 1)
+}
+
+func (c *mockChain) BlockWaiter(height uint64) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	ch <- struct{}{}
+	return ch
+}
+
+func (c *mockChain) GetBlockByHeight(height uint64) (*types.Block, error) {
+	return c.blocks[height], nil
+}
+
+func (c *mockChain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
+	return &types.Block{BlockHeader: types.BlockHeader{Height: hash.V0, PreviousBlockHash: bc.Hash{V1: hash.V0 - 1}}}, nil
+}
+
+func (c *mockChain) InMainChain(hash bc.Hash) bool {
+	for _, block := range c.blocks {
+		if block.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
+type mockHandler struct {
+	height   uint64
+	hash     bc.Hash
+	caughtUp int
+}
+
+func (h *mockHandler) BestChain() (uint64, bc.Hash) {
+	return h.height, h.hash
+}
+
+func (h *mockHandler) ApplyBlock(block *types.Block) error {
+	h.height, h.hash = block.Height, block.Hash()
+	return nil
+}
+
+func (h *mockHandler) DetachBlock(block *types.Block) error {
+	h.height, h.hash = block.Height-1, block.PreviousBlockHash
+	return nil
+}
+
+func (h *mockHandler) CaughtUp() {
+	h.caughtUp++
+}
+
+func newTestChain(num int) *mockChain {
+	chain := &mockChain{}
+	var prevHash bc.Hash
+	for i := 0; i < num; i++ {
+		block := &types.Block{BlockHeader: types.BlockHeader{Height: uint64(i), PreviousBlockHash: prevHash}}
+		chain.blocks = append(chain.blocks, block)
+		prevHash = block.Hash()
+	}
+	return chain
+}
+
+func TestFollowerStep(t *testing.T) {
+	chain := newTestChain(3)
+	handler := &mockHandler{height: 0, hash: chain.blocks[0].Hash()}
+	f := NewFollower("test", chain, handler)
+	for i := 1; i < 3; i++ {
+		if err := f.step(); err != nil {
+			t.Fatal(err)
+		}
+
+		if handler.height != uint64(i) || handler.hash != chain.blocks[i].Hash() {
+			t.Fatalf("got handler at height %d, want %d", handler.height, i)
+		}
+	}
+
+	if err := f.step(); err != nil {
+		t.Fatal(err)
+	}
+
+	if handler.caughtUp != 1 || handler.height != 2 {
+		t.Fatalf("got handler caught up %d times at height %d", handler.caughtUp, handler.height)
+	}
+}
+
+func TestFollowerDetachOrphanBlock(t *testing.T) {
+	chain := newTestChain(3)
+	// the handler is at the orphan block 2, whose parent is the orphan block 1
+	handler := &mockHandler{height: 2, hash: bc.Hash{V0: 2}}
+	f := NewFollower("test", chain, handler)
+	if err := f.step(); err != nil {
+		t.Fatal(err)
+	}
+
+	if handler.height != 1 || handler.hash != (bc.Hash{V1: 1}) {
+		t.Fatalf("got handler at height %d hash %v after detach", handler.height, handler.hash)
+	}
+}
+
+func TestFollowerPrunedHistory(t *testing.T) {
+	chain := newTestChain(3)
+	chain.baseHeight = 2
+	handler := &mockHandler{height: 0, hash: chain.blocks[0].Hash()}
+	f := NewFollower("test", chain, handler)
+	if err := f.Run(); errors.Root(err) != ErrPrunedHistory {
+		t.Fatalf("got error %v, want %v", err, ErrPrunedHistory)
+	}
+
+	if handler.height != 0 {
+		t.Fatalf("got handler at height %d, want 0", handler.height)
+	}
+}
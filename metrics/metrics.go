@@ -0,0 +1,341 @@
+// Package metrics exposes the node status in the prometheus text format. The metrics
+// are registered to the DefaultRegistry by the packages which own the data, and the
+// registry is served by the /metrics endpoint of the api server.
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets is the default histogram buckets in seconds for the latency
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry is the registry served by the node
+var DefaultRegistry = NewRegistry()
+
+type metric interface {
+	name() string
+	help() string
+	kind() string
+	write(w *bufio.Writer)
+}
+
+// Registry is the set of the named metrics
+type Registry struct {
+	mtx     sync.RWMutex
+	metrics map[string]metric
+}
+
+// NewRegistry create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register add the metric to the registry, the metric registered later replaces the
+// one with the same name
+func (r *Registry) register(m metric) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.metrics[m.name()] = m
+}
+
+// ServeHTTP write all the metrics in the prometheus text format
+func (r *Registry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.mtx.RLock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mtx.RUnlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+	rw.Header().Set("Content-Type", contentType)
+	w := bufio.NewWriter(rw)
+	for _, m := range metrics {
+		w.WriteString("# HELP " + m.name() + " " + escapeHelp(m.help()) + "\n")
+		w.WriteString("# TYPE " + m.name() + " " + m.kind() + "\n")
+		m.write(w)
+	}
+	w.Flush()
+}
+
+// Handler return the http handler of the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+type desc struct {
+	metricName string
+	metricHelp string
+	labels     []string
+}
+
+func (d *desc) name() string { return d.metricName }
+func (d *desc) help() string { return d.metricHelp }
+
+// Counter is the metric which only goes up
+type Counter struct {
+	bits uint64
+}
+
+// Add increase the counter by the non-negative delta
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+
+	for {
+		old := atomic.LoadUint64(&c.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&c.bits, old, updated) {
+			return
+		}
+	}
+}
+
+// Inc increase the counter by 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value return the current value of the counter
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+// CounterVec is the counters partitioned by the label values
+type CounterVec struct {
+	desc
+	mtx      sync.RWMutex
+	counters map[string]*labeledCounter
+}
+
+type labeledCounter struct {
+	values []string
+	*Counter
+}
+
+// NewCounterVec create the counter vector and register it to the DefaultRegistry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec create the counter vector in the registry
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{desc: desc{metricName: name, metricHelp: help, labels: labels}, counters: make(map[string]*labeledCounter)}
+	r.register(v)
+	return v
+}
+
+// With return the counter of the label values, the num of the values must match the labels
+func (v *CounterVec) With(values ...string) *Counter {
+	key := strings.Join(values, "\xff")
+	v.mtx.RLock()
+	c, ok := v.counters[key]
+	v.mtx.RUnlock()
+	if ok {
+		return c.Counter
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	if c, ok := v.counters[key]; ok {
+		return c.Counter
+	}
+
+	c = &labeledCounter{values: append([]string{}, values...), Counter: &Counter{}}
+	v.counters[key] = c
+	return c.Counter
+}
+
+func (v *CounterVec) kind() string { return "counter" }
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.mtx.RLock()
+	counters := make([]*labeledCounter, 0, len(v.counters))
+	for _, c := range v.counters {
+		counters = append(counters, c)
+	}
+	v.mtx.RUnlock()
+
+	sort.Slice(counters, func(i, j int) bool {
+		return strings.Join(counters[i].values, "\xff") < strings.Join(counters[j].values, "\xff")
+	})
+	for _, c := range counters {
+		writeSample(w, v.metricName, v.labels, c.values, c.Value())
+	}
+}
+
+// GaugeValue is one sample of the gauge vector
+type GaugeValue struct {
+	LabelValues []string
+	Value       float64
+}
+
+// gaugeFunc is the gauge which is read from the callback when it's collected
+type gaugeFunc struct {
+	desc
+	collect func() []GaugeValue
+}
+
+// NewGaugeFunc register the gauge which value is returned by the callback
+func NewGaugeFunc(name, help string, fn func() float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, fn)
+}
+
+// NewGaugeFunc register the gauge which value is returned by the callback
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{desc: desc{metricName: name, metricHelp: help}, collect: func() []GaugeValue {
+		return []GaugeValue{{Value: fn()}}
+	}})
+}
+
+// NewGaugeVecFunc register the gauges partitioned by the labels, the values are returned
+// by the callback
+func NewGaugeVecFunc(name, help string, labels []string, fn func() []GaugeValue) {
+	DefaultRegistry.NewGaugeVecFunc(name, help, labels, fn)
+}
+
+// NewGaugeVecFunc register the gauges partitioned by the labels, the values are returned
+// by the callback
+func (r *Registry) NewGaugeVecFunc(name, help string, labels []string, fn func() []GaugeValue) {
+	r.register(&gaugeFunc{desc: desc{metricName: name, metricHelp: help, labels: labels}, collect: fn})
+}
+
+func (g *gaugeFunc) kind() string { return "gauge" }
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	for _, v := range g.collect() {
+		writeSample(w, g.metricName, g.labels, v.LabelValues, v.Value)
+	}
+}
+
+// HistogramVec is the histograms partitioned by the label values
+type HistogramVec struct {
+	desc
+	buckets    []float64
+	mtx        sync.Mutex
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec create the histogram vector and register it to the DefaultRegistry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec create the histogram vector in the registry, the buckets are the
+// sorted upper bounds
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	v := &HistogramVec{desc: desc{metricName: name, metricHelp: help, labels: labels}, buckets: buckets, histograms: make(map[string]*histogram)}
+	r.register(v)
+	return v
+}
+
+// Observe add the observation to the histogram of the label values
+func (v *HistogramVec) Observe(value float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	h, ok := v.histograms[key]
+	if !ok {
+		h = &histogram{values: append([]string{}, values...), counts: make([]uint64, len(v.buckets))}
+		v.histograms[key] = h
+	}
+
+	for i, bound := range v.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (v *HistogramVec) kind() string { return "histogram" }
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.mtx.Lock()
+	histograms := make([]histogram, 0, len(v.histograms))
+	for _, h := range v.histograms {
+		histograms = append(histograms, histogram{values: h.values, counts: append([]uint64{}, h.counts...), count: h.count, sum: h.sum})
+	}
+	v.mtx.Unlock()
+
+	sort.Slice(histograms, func(i, j int) bool {
+		return strings.Join(histograms[i].values, "\xff") < strings.Join(histograms[j].values, "\xff")
+	})
+	bucketLabels := append(append([]string{}, v.labels...), "le")
+	for _, h := range histograms {
+		for i, bound := range v.buckets {
+			writeSample(w, v.metricName+"_bucket", bucketLabels, append(append([]string{}, h.values...), formatFloat(bound)), float64(h.counts[i]))
+		}
+		writeSample(w, v.metricName+"_bucket", bucketLabels, append(append([]string{}, h.values...), "+Inf"), float64(h.count))
+		writeSample(w, v.metricName+"_sum", v.labels, h.values, h.sum)
+		writeSample(w, v.metricName+"_count", v.labels, h.values, float64(h.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			labelValue := ""
+			if i < len(values) {
+				labelValue = values[i]
+			}
+			w.WriteString(label + "=\"" + escapeLabelValue(labelValue) + "\"")
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer("\\", `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, "\"", `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistryServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	counters := registry.NewCounterVec("test_events_total", "Num of the events", "kind")
+	counters.With("ban").Inc()
+	counters.With("ban").Add(2)
+	counters.With("a\"b").Inc()
+
+	registry.NewGaugeFunc("test_height", "Height of the\nchain", func() float64 { return 42 })
+	registry.NewGaugeVecFunc("test_peers", "Num of the peers", []string{"direction"}, func() []GaugeValue {
+		return []GaugeValue{{LabelValues: []string{"inbound"}, Value: 3}}
+	})
+
+	latency := registry.NewHistogramVec("test_latency_seconds", "Latency", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(5, "/a")
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	want := `# HELP test_events_total Num of the events
+# TYPE test_events_total counter
+test_events_total{kind="a\"b"} 1
+test_events_total{kind="ban"} 3
+# HELP test_height Height of the\nchain
+# TYPE test_height gauge
+test_height 42
+# HELP test_latency_seconds Latency
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/a",le="0.1"} 1
+test_latency_seconds_bucket{route="/a",le="1"} 2
+test_latency_seconds_bucket{route="/a",le="+Inf"} 3
+test_latency_seconds_sum{route="/a"} 5.55
+test_latency_seconds_count{route="/a"} 3
+# HELP test_peers Num of the peers
+# TYPE test_peers gauge
+test_peers{direction="inbound"} 3
+`
+	if got := recorder.Body.String(); got != want {
+		t.Errorf("got metrics:\n%s\nwant:\n%s", got, want)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got content type %s", contentType)
+	}
+}
@@ -21,26 +21,39 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	//ErrNoToken is returned when authenticate is called with no token.
 	ErrNoToken = errors.New("no token")
+	//ErrTokenExpired is returned when authenticate is called with expired token.
+	ErrTokenExpired = errors.New("token expired")
+	//ErrIPNotAllowed is returned when the request ip isn't in the allow-list of the token.
+	ErrIPNotAllowed = errors.New("ip is not allowed by the token")
+	//ErrPermissionDenied is returned when the token doesn't have the scope of the request path.
+	ErrPermissionDenied = errors.New("permission denied")
 )
 
+//RouteScope return the scope required by the request path, the path with empty scope
+//can only be accessed by the token without scope restriction.
+type RouteScope func(path string) string
+
 //API describe the token authenticate.
 type API struct {
-	disable  bool
-	tokens   *accesstoken.CredentialStore
-	tokenMu  sync.Mutex // protects the following
-	tokenMap map[string]tokenResult
+	disable    bool
+	tokens     *accesstoken.CredentialStore
+	routeScope RouteScope
+	tokenMu    sync.Mutex // protects the following
+	tokenMap   map[string]tokenResult
 }
 
 type tokenResult struct {
 	lastLookup time.Time
+	token      *accesstoken.Token
 }
 
 //NewAPI create a token authenticate object.
-func NewAPI(tokens *accesstoken.CredentialStore, disable bool) *API {
+func NewAPI(tokens *accesstoken.CredentialStore, routeScope RouteScope, disable bool) *API {
 	return &API{
-		disable:  disable,
-		tokens:   tokens,
-		tokenMap: make(map[string]tokenResult),
+		disable:    disable,
+		tokens:     tokens,
+		routeScope: routeScope,
+		tokenMap:   make(map[string]tokenResult),
 	}
 }
 
@@ -49,10 +62,11 @@ func NewAPI(tokens *accesstoken.CredentialStore, disable bool) *API {
 func (a *API) Authenticate(req *http.Request) (*http.Request, error) {
 	ctx := req.Context()
 
-	token, err := a.tokenAuthn(req)
-	if err == nil && token != "" {
+	tokenID, token, err := a.tokenAuthn(req)
+	if err == nil && tokenID != "" {
 		// if this request was successfully authenticated with a token, pass the token along
-		ctx = newContextWithToken(ctx, token)
+		ctx = newContextWithToken(ctx, tokenID)
+		ctx = newContextWithPermission(ctx, &token.Permission)
 	}
 
 	local := a.localhostAuthn(req)
@@ -100,31 +114,62 @@ func (a *API) localhostAuthn(req *http.Request) bool {
 	return true
 }
 
-func (a *API) tokenAuthn(req *http.Request) (string, error) {
+func (a *API) tokenAuthn(req *http.Request) (string, *accesstoken.Token, error) {
 	if a.disable {
-		return "", nil
+		return "", nil, nil
 	}
 
 	user, pw, ok := req.BasicAuth()
 	if !ok {
-		return "", ErrNoToken
+		return "", nil, ErrNoToken
 	}
-	return user, a.cachedTokenAuthnCheck(req.Context(), user, pw)
+
+	token, err := a.cachedTokenAuthnCheck(req.Context(), user, pw)
+	if err != nil {
+		return user, nil, err
+	}
+	return user, token, a.permissionCheck(req, token)
 }
 
-func (a *API) cachedTokenAuthnCheck(ctx context.Context, user, pw string) error {
+func (a *API) cachedTokenAuthnCheck(ctx context.Context, user, pw string) (*accesstoken.Token, error) {
 	a.tokenMu.Lock()
 	res, ok := a.tokenMap[user+pw]
 	a.tokenMu.Unlock()
 	if !ok || time.Now().After(res.lastLookup.Add(tokenExpiry)) {
-		err := a.tokens.Check(user, pw)
+		token, err := a.tokens.Lookup(user, pw)
 		if err != nil {
-			return ErrInvalidToken
+			return nil, ErrInvalidToken
 		}
-		res = tokenResult{lastLookup: time.Now()}
+		res = tokenResult{lastLookup: time.Now(), token: token}
 		a.tokenMu.Lock()
 		a.tokenMap[user+pw] = res
 		a.tokenMu.Unlock()
 	}
+	return res.token, nil
+}
+
+// permissionCheck checks the expiry, the ip allow-list and the scopes of the token
+func (a *API) permissionCheck(req *http.Request, token *accesstoken.Token) error {
+	if token.Expired(time.Now()) {
+		return errors.WithDetailf(ErrTokenExpired, "token %q expired at %s", token.ID, token.ExpiresAt.Format(time.RFC3339))
+	}
+
+	h, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		h = req.RemoteAddr
+	}
+
+	if !token.AllowIP(net.ParseIP(h)) {
+		return errors.WithDetailf(ErrIPNotAllowed, "ip %s", h)
+	}
+
+	scope := ""
+	if a.routeScope != nil {
+		scope = a.routeScope(req.URL.Path)
+	}
+
+	if !token.HasScope(scope) {
+		return errors.WithDetailf(ErrPermissionDenied, "token %q can't access %s", token.ID, req.URL.Path)
+	}
 	return nil
 }
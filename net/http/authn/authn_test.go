@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"coingod/accesstoken"
 	dbm "coingod/database/leveldb"
@@ -28,7 +29,7 @@ func TestAuthenticate(t *testing.T) {
 		{"alice", "alice:abcsdsdfassdfsefsfsfesfesfefsefa", ErrInvalidToken},
 	}
 
-	api := NewAPI(tokenStore, false)
+	api := NewAPI(tokenStore, nil, false)
 
 	for _, c := range cases {
 		var username, password string
@@ -49,3 +50,95 @@ func TestAuthenticate(t *testing.T) {
 		}
 	}
 }
+
+func TestAuthenticatePermission(t *testing.T) {
+	tokenDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+	tokenStore := accesstoken.NewStore(tokenDB)
+
+	expiresAt := time.Now().Add(time.Hour)
+	permissions := map[string]*accesstoken.Permission{
+		"full":     {},
+		"explorer": {Scopes: []string{accesstoken.ScopeChainRead}},
+		"expired":  {ExpiresAt: &expiresAt},
+		"internal": {AllowedIPs: []string{"10.0.0.0/8", "192.168.1.1"}},
+	}
+
+	tokens := map[string]*accesstoken.Token{}
+	for id, permission := range permissions {
+		token, err := tokenStore.CreateWithPermission(id, "client", permission)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[id] = token
+	}
+
+	routeScope := func(path string) string {
+		if path == "/get-block" {
+			return accesstoken.ScopeChainRead
+		}
+		return ""
+	}
+	api := NewAPI(tokenStore, routeScope, false)
+
+	cases := []struct {
+		id, path, remoteAddr string
+		expired              bool
+		want                 error
+	}{
+		{id: "full", path: "/sign-transaction", remoteAddr: "8.8.8.8:1000"},
+		{id: "explorer", path: "/get-block", remoteAddr: "8.8.8.8:1000"},
+		{id: "explorer", path: "/sign-transaction", remoteAddr: "8.8.8.8:1000", want: ErrPermissionDenied},
+		{id: "expired", path: "/get-block", remoteAddr: "8.8.8.8:1000"},
+		{id: "expired", path: "/get-block", remoteAddr: "8.8.8.8:1000", expired: true, want: ErrTokenExpired},
+		{id: "internal", path: "/get-block", remoteAddr: "10.1.2.3:1000"},
+		{id: "internal", path: "/get-block", remoteAddr: "192.168.1.1:1000"},
+		{id: "internal", path: "/get-block", remoteAddr: "192.168.1.2:1000", want: ErrIPNotAllowed},
+	}
+
+	for i, c := range cases {
+		token := tokens[c.id]
+		if c.expired {
+			expiredAt := time.Now().Add(-time.Minute)
+			token.ExpiresAt = &expiredAt
+		}
+
+		req, _ := http.NewRequest("POST", c.path, nil)
+		req.RemoteAddr = c.remoteAddr
+		if err := api.permissionCheck(req, token); errors.Root(err) != c.want {
+			t.Errorf("case %d: permission check of %s on %s error = %v want %v", i, c.id, c.path, err, c.want)
+		}
+	}
+}
+
+func TestAuthenticateScopeContext(t *testing.T) {
+	tokenDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+	tokenStore := accesstoken.NewStore(tokenDB)
+	token, err := tokenStore.CreateWithPermission("explorer", "client", &accesstoken.Permission{Scopes: []string{accesstoken.ScopeChainRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routeScope := func(path string) string { return accesstoken.ScopeChainRead }
+	api := NewAPI(tokenStore, routeScope, false)
+
+	toks := strings.SplitN(token.Token, ":", 2)
+	req, _ := http.NewRequest("GET", "/websocket-subscribe", nil)
+	req.RemoteAddr = "8.8.8.8:1000"
+	req.SetBasicAuth(toks[0], toks[1])
+	req, err = api.Authenticate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !HasScope(req.Context(), accesstoken.ScopeChainRead) || HasScope(req.Context(), accesstoken.ScopeWalletRead) {
+		t.Error("the scopes in the context mismatch with the token")
+	}
+
+	// the request passed without the token is permitted to all scopes
+	req, _ = http.NewRequest("GET", "/websocket-subscribe", nil)
+	if !HasScope(req.Context(), accesstoken.ScopeWalletRead) {
+		t.Error("the request without the token is restricted")
+	}
+}
@@ -2,6 +2,8 @@ package authn
 
 import (
 	"context"
+
+	"coingod/accesstoken"
 )
 
 type key int
@@ -9,6 +11,7 @@ type key int
 const (
 	tokenKey key = iota
 	localhostKey
+	permissionKey
 )
 
 // newContextWithToken sets the token in a new context and returns the context.
@@ -39,3 +42,19 @@ func Localhost(ctx context.Context) bool {
 	}
 	return false
 }
+
+// newContextWithPermission sets the permission of the authenticated token in a new context
+// and returns that context.
+func newContextWithPermission(ctx context.Context, permission *accesstoken.Permission) context.Context {
+	return context.WithValue(ctx, permissionKey, permission)
+}
+
+// HasScope returns whether the request is permitted to the scope, the request passed without
+// the token (the authentication is disabled or from the loopback) is permitted to all scopes.
+func HasScope(ctx context.Context, scope string) bool {
+	permission, ok := ctx.Value(permissionKey).(*accesstoken.Permission)
+	if !ok {
+		return true
+	}
+	return permission.HasScope(scope)
+}
@@ -32,6 +32,10 @@ var (
 	ErrWSInternal = errors.New("Websocket Internal error")
 	// ErrWSClientQuit means the websocket client is disconnected
 	ErrWSClientQuit = errors.New("Websocket client quit")
+	// ErrWSMissingAlias means the alias of txfeed is not specified by the request
+	ErrWSMissingAlias = errors.New("Websocket request missing txfeed alias")
+	// ErrWSPermissionDenied means the access token of the client can't read the wallet
+	ErrWSPermissionDenied = errors.New("Websocket request permission denied")
 
 	// timeZeroVal is simply the zero value for a time.Time and is used to avoid creating multiple instances.
 	timeZeroVal time.Time
@@ -47,16 +51,26 @@ func (s semaphore) acquire() { s <- struct{}{} }
 func (s semaphore) release() { <-s }
 
 // wsTopicHandler describes a callback function used to handle a specific topic.
-type wsTopicHandler func(*WSClient)
+type wsTopicHandler func(*WSClient, *WSRequest) error
 
 // wsHandlers maps websocket topic strings to appropriate websocket handler
 // functions.  This is set by init because help references wsHandlers and thus
 // causes a dependency loop.
 var wsHandlers = map[string]wsTopicHandler{
-	"notify_raw_blocks":            handleNotifyBlocks,
-	"notify_new_transactions":      handleNotifyNewTransactions,
-	"stop_notify_raw_blocks":       handleStopNotifyBlocks,
-	"stop_notify_new_transactions": handleStopNotifyNewTransactions,
+	"notify_raw_blocks":                 handleNotifyBlocks,
+	"notify_new_transactions":           handleNotifyNewTransactions,
+	"stop_notify_raw_blocks":            handleStopNotifyBlocks,
+	"stop_notify_new_transactions":      handleStopNotifyNewTransactions,
+	"notify_transaction_feed":           handleNotifyTxFeed,
+	"stop_notify_transaction_feed":      handleStopNotifyTxFeed,
+	"notify_checkpoint_status":          handleNotifyCheckpoints,
+	"stop_notify_checkpoint_status":     handleStopNotifyCheckpoints,
+	"notify_finalized_height":           handleNotifyFinality,
+	"stop_notify_finalized_height":      handleStopNotifyFinality,
+	"notify_reorganization":             handleNotifyReorgs,
+	"stop_notify_reorganization":        handleStopNotifyReorgs,
+	"notify_filtered_transactions":      handleNotifyFilteredTxs,
+	"stop_notify_filtered_transactions": handleStopNotifyFilteredTxs,
 }
 
 // responseMessage houses a message to send to a connected websocket client as
@@ -94,10 +108,13 @@ type WSClient struct {
 	quit              chan struct{}
 	wg                sync.WaitGroup
 	notificationMgr   *WSNotificationManager
+	// walletRead indicate whether the client is permitted to the wallet topics
+	walletRead bool
 }
 
-// NewWebsocketClient means to create a new object to the connected websocket client
-func NewWebsocketClient(w http.ResponseWriter, r *http.Request, notificationMgr *WSNotificationManager) (*WSClient, error) {
+// NewWebsocketClient means to create a new object to the connected websocket client,
+// walletRead permits the client to subscribe the wallet topics
+func NewWebsocketClient(w http.ResponseWriter, r *http.Request, notificationMgr *WSNotificationManager, walletRead bool) (*WSClient, error) {
 	// Limit max number of websocket clients.
 	if notificationMgr.IsMaxConnect() {
 		return nil, fmt.Errorf("numOfMaxWS: %d, disconnecting: %s", notificationMgr.MaxNumWebsockets, r.RemoteAddr)
@@ -119,6 +136,7 @@ func NewWebsocketClient(w http.ResponseWriter, r *http.Request, notificationMgr
 		sendChan:          make(chan responseMessage, websocketSendBufferSize),
 		quit:              make(chan struct{}),
 		notificationMgr:   notificationMgr,
+		walletRead:        walletRead,
 	}
 	return client, nil
 }
@@ -159,7 +177,7 @@ out:
 
 		c.serviceRequestSem.acquire()
 		go func() {
-			c.serviceRequest(request.Topic)
+			c.serviceRequest(&request)
 			c.serviceRequestSem.release()
 		}()
 	}
@@ -170,15 +188,17 @@ out:
 	log.WithFields(log.Fields{"module": logModule, "remoteAddress": c.addr}).Debug("Websocket client input handler done")
 }
 
-func (c *WSClient) serviceRequest(topic string) {
+func (c *WSClient) serviceRequest(request *WSRequest) {
 	var respErr error
 
-	if wsHandler, ok := wsHandlers[topic]; ok {
-		wsHandler(c)
+	if wsHandler, ok := wsHandlers[request.Topic]; ok {
+		if err := wsHandler(c, request); err != nil {
+			respErr = errors.Wrap(err, ErrWSInternal)
+		}
 	} else {
-		err := fmt.Errorf("There is not this topic: %s", topic)
+		err := fmt.Errorf("There is not this topic: %s", request.Topic)
 		respErr = errors.Wrap(err, ErrWSInternal)
-		log.WithFields(log.Fields{"module": logModule, "topic": topic}).Debug("There is not this topic")
+		log.WithFields(log.Fields{"module": logModule, "topic": request.Topic}).Debug("There is not this topic")
 	}
 
 	resp := NewWSResponse(NTRequestStatus.String(), nil, respErr)
@@ -352,21 +372,102 @@ func (c *WSClient) WaitForShutdown() {
 }
 
 // handleNotifyBlocks implements the notifyblocks topic extension for websocket connections.
-func handleNotifyBlocks(wsc *WSClient) {
+func handleNotifyBlocks(wsc *WSClient, _ *WSRequest) error {
 	wsc.notificationMgr.RegisterBlockUpdates(wsc)
+	return nil
 }
 
 // handleStopNotifyBlocks implements the stopnotifyblocks topic extension for websocket connections.
-func handleStopNotifyBlocks(wsc *WSClient) {
+func handleStopNotifyBlocks(wsc *WSClient, _ *WSRequest) error {
 	wsc.notificationMgr.UnregisterBlockUpdates(wsc)
+	return nil
 }
 
 // handleNotifyNewTransations implements the notifynewtransactions topic extension for websocket connections.
-func handleNotifyNewTransactions(wsc *WSClient) {
+func handleNotifyNewTransactions(wsc *WSClient, _ *WSRequest) error {
 	wsc.notificationMgr.RegisterNewMempoolTxsUpdates(wsc)
+	return nil
 }
 
 // handleStopNotifyNewTransations implements the stopnotifynewtransactions topic extension for websocket connections.
-func handleStopNotifyNewTransactions(wsc *WSClient) {
+func handleStopNotifyNewTransactions(wsc *WSClient, _ *WSRequest) error {
 	wsc.notificationMgr.UnregisterNewMempoolTxsUpdates(wsc)
+	return nil
+}
+
+// handleNotifyTxFeed implements the notify_transaction_feed topic extension for websocket connections.
+func handleNotifyTxFeed(wsc *WSClient, request *WSRequest) error {
+	if !wsc.walletRead {
+		return ErrWSPermissionDenied
+	}
+
+	if request.Alias == "" {
+		return ErrWSMissingAlias
+	}
+
+	wsc.notificationMgr.RegisterTxFeedUpdates(wsc, request.Alias)
+	return nil
+}
+
+// handleStopNotifyTxFeed implements the stop_notify_transaction_feed topic extension for websocket connections.
+func handleStopNotifyTxFeed(wsc *WSClient, request *WSRequest) error {
+	if request.Alias == "" {
+		return ErrWSMissingAlias
+	}
+
+	wsc.notificationMgr.UnregisterTxFeedUpdates(wsc, request.Alias)
+	return nil
+}
+
+// handleNotifyCheckpoints implements the notify_checkpoint_status topic extension for websocket connections.
+func handleNotifyCheckpoints(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.RegisterCheckpointUpdates(wsc)
+	return nil
+}
+
+// handleStopNotifyCheckpoints implements the stop_notify_checkpoint_status topic extension for websocket connections.
+func handleStopNotifyCheckpoints(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.UnregisterCheckpointUpdates(wsc)
+	return nil
+}
+
+// handleNotifyFinality implements the notify_finalized_height topic extension for websocket connections.
+func handleNotifyFinality(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.RegisterFinalityUpdates(wsc)
+	return nil
+}
+
+// handleStopNotifyFinality implements the stop_notify_finalized_height topic extension for websocket connections.
+func handleStopNotifyFinality(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.UnregisterFinalityUpdates(wsc)
+	return nil
+}
+
+// handleNotifyReorgs implements the notify_reorganization topic extension for websocket connections.
+func handleNotifyReorgs(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.RegisterReorgUpdates(wsc)
+	return nil
+}
+
+// handleStopNotifyReorgs implements the stop_notify_reorganization topic extension for websocket connections.
+func handleStopNotifyReorgs(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.UnregisterReorgUpdates(wsc)
+	return nil
+}
+
+// handleNotifyFilteredTxs implements the notify_filtered_transactions topic extension for websocket connections.
+func handleNotifyFilteredTxs(wsc *WSClient, request *WSRequest) error {
+	filter, err := newTxFilter(request.Filter)
+	if err != nil {
+		return err
+	}
+
+	wsc.notificationMgr.RegisterFilteredTxUpdates(wsc, filter)
+	return nil
+}
+
+// handleStopNotifyFilteredTxs implements the stop_notify_filtered_transactions topic extension for websocket connections.
+func handleStopNotifyFilteredTxs(wsc *WSClient, _ *WSRequest) error {
+	wsc.notificationMgr.UnregisterFilteredTxUpdates(wsc)
+	return nil
 }
@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"encoding/hex"
+
+	"coingod/common"
+	"coingod/consensus"
+	"coingod/consensus/bcrp"
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm/vmutil"
+)
+
+// maxFilterSize is the max num of the conditions in one filter
+const maxFilterSize = 10000
+
+var (
+	// ErrWSMissingFilter means the filter is not specified by the request
+	ErrWSMissingFilter = errors.New("Websocket request missing filter")
+	// ErrWSBadFilter means the filter of the request is invalid
+	ErrWSBadFilter = errors.New("Websocket request invalid filter")
+)
+
+// WSFilter selects the transactions for the filtered subscription, a transaction is
+// matched when any of its inputs or outputs meets any condition of the filter
+type WSFilter struct {
+	ControlPrograms []chainjson.HexBytes `json:"control_programs,omitempty"`
+	Addresses       []string             `json:"addresses,omitempty"`
+	AssetIDs        []bc.AssetID         `json:"asset_ids,omitempty"`
+	ContractHashes  []chainjson.HexBytes `json:"contract_hashes,omitempty"`
+}
+
+// txFilter is the parsed WSFilter which is evaluated by the notification manager
+type txFilter struct {
+	programs  map[string]bool
+	assets    map[bc.AssetID]bool
+	contracts map[string]bool
+}
+
+func newTxFilter(f *WSFilter) (*txFilter, error) {
+	if f == nil {
+		return nil, ErrWSMissingFilter
+	}
+
+	size := len(f.ControlPrograms) + len(f.Addresses) + len(f.AssetIDs) + len(f.ContractHashes)
+	if size == 0 {
+		return nil, ErrWSMissingFilter
+	}
+
+	if size > maxFilterSize {
+		return nil, errors.WithDetailf(ErrWSBadFilter, "the filter has %d conditions, exceeds the limit %d", size, maxFilterSize)
+	}
+
+	filter := &txFilter{programs: make(map[string]bool), assets: make(map[bc.AssetID]bool), contracts: make(map[string]bool)}
+	for _, program := range f.ControlPrograms {
+		filter.programs[hex.EncodeToString(program)] = true
+	}
+
+	for _, address := range f.Addresses {
+		program, err := programByAddress(address)
+		if err != nil {
+			return nil, errors.WithDetailf(ErrWSBadFilter, "invalid address %s", address)
+		}
+
+		filter.programs[hex.EncodeToString(program)] = true
+	}
+
+	for _, assetID := range f.AssetIDs {
+		filter.assets[assetID] = true
+	}
+
+	for _, hash := range f.ContractHashes {
+		if len(hash) != consensus.BCRPContractHashDataSize {
+			return nil, errors.WithDetailf(ErrWSBadFilter, "invalid contract hash %s", hex.EncodeToString(hash))
+		}
+
+		filter.contracts[hex.EncodeToString(hash)] = true
+	}
+	return filter, nil
+}
+
+func programByAddress(address string) ([]byte, error) {
+	addr, err := common.DecodeAddress(address, &consensus.ActiveNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	switch addr.(type) {
+	case *common.AddressWitnessPubKeyHash:
+		return vmutil.P2WPKHProgram(addr.ScriptAddress())
+	case *common.AddressWitnessScriptHash:
+		return vmutil.P2WSHProgram(addr.ScriptAddress())
+	}
+	return nil, ErrWSBadFilter
+}
+
+// match return whether any input or output of the transaction meets the filter
+func (f *txFilter) match(tx *types.Tx) bool {
+	for _, input := range tx.Inputs {
+		if f.assets[input.AssetID()] || f.matchProgram(input.ControlProgram()) {
+			return true
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if (output.AssetId != nil && f.assets[*output.AssetId]) || f.matchProgram(output.ControlProgram) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *txFilter) matchProgram(program []byte) bool {
+	if len(program) == 0 {
+		return false
+	}
+
+	if f.programs[hex.EncodeToString(program)] {
+		return true
+	}
+
+	if len(f.contracts) == 0 || !bcrp.IsCallContractScript(program) {
+		return false
+	}
+
+	hash, err := bcrp.ParseContractHash(program)
+	return err == nil && f.contracts[hex.EncodeToString(hash[:])]
+}
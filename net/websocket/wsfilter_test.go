@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"testing"
+
+	"coingod/common"
+	"coingod/consensus"
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm/vmutil"
+)
+
+func TestTxFilterMatch(t *testing.T) {
+	pubKeyHash := make([]byte, 20)
+	pubKeyHash[0] = 1
+	address, err := common.NewAddressWitnessPubKeyHash(pubKeyHash, &consensus.ActiveNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addressProgram, err := vmutil.P2WPKHProgram(pubKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contractHash := make([]byte, consensus.BCRPContractHashDataSize)
+	contractHash[0] = 2
+	contractProgram, err := vmutil.CallContractProgram(contractHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherProgram := []byte{0x00, 0x14, 0xaa}
+	asset := bc.AssetID{V0: 1}
+	otherAsset := bc.AssetID{V0: 2}
+	newTx := func(inputProgram, outputProgram []byte, assetID bc.AssetID) *types.Tx {
+		return types.NewTx(types.TxData{
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, assetID, 100, 0, inputProgram, nil)},
+			Outputs: []*types.TxOutput{types.NewOriginalTxOutput(assetID, 100, outputProgram, nil)},
+		})
+	}
+
+	cases := []struct {
+		filter *WSFilter
+		tx     *types.Tx
+		want   bool
+	}{
+		{
+			filter: &WSFilter{Addresses: []string{address.EncodeAddress()}},
+			tx:     newTx(otherProgram, addressProgram, otherAsset),
+			want:   true,
+		},
+		{
+			filter: &WSFilter{ControlPrograms: []chainjson.HexBytes{addressProgram}},
+			tx:     newTx(addressProgram, otherProgram, otherAsset),
+			want:   true,
+		},
+		{
+			filter: &WSFilter{AssetIDs: []bc.AssetID{asset}},
+			tx:     newTx(otherProgram, otherProgram, asset),
+			want:   true,
+		},
+		{
+			filter: &WSFilter{ContractHashes: []chainjson.HexBytes{contractHash}},
+			tx:     newTx(otherProgram, contractProgram, otherAsset),
+			want:   true,
+		},
+		{
+			filter: &WSFilter{Addresses: []string{address.EncodeAddress()}, AssetIDs: []bc.AssetID{asset}, ContractHashes: []chainjson.HexBytes{contractHash}},
+			tx:     newTx(otherProgram, otherProgram, otherAsset),
+			want:   false,
+		},
+	}
+
+	for i, c := range cases {
+		filter, err := newTxFilter(c.filter)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+
+		if got := filter.match(c.tx); got != c.want {
+			t.Errorf("case %d: got match %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestNewTxFilterErr(t *testing.T) {
+	cases := []struct {
+		filter *WSFilter
+		want   error
+	}{
+		{filter: nil, want: ErrWSMissingFilter},
+		{filter: &WSFilter{}, want: ErrWSMissingFilter},
+		{filter: &WSFilter{Addresses: []string{"invalid"}}, want: ErrWSBadFilter},
+		{filter: &WSFilter{ContractHashes: []chainjson.HexBytes{{0x01}}}, want: ErrWSBadFilter},
+		{filter: &WSFilter{AssetIDs: make([]bc.AssetID, maxFilterSize+1)}, want: ErrWSBadFilter},
+	}
+
+	for i, c := range cases {
+		if _, err := newTxFilter(c.filter); errors.Root(err) != c.want {
+			t.Errorf("case %d: got err %v, want %v", i, err, c.want)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package websocket
 
 // WSRequest means the data structure of the request
 type WSRequest struct {
-	Topic string `json:"topic"`
+	Topic  string    `json:"topic"`
+	Alias  string    `json:"alias,omitempty"`
+	Filter *WSFilter `json:"filter,omitempty"`
 }
 
 // NewWSRequest creates a request data object
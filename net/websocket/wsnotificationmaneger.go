@@ -7,16 +7,22 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"coingod/blockchain/txfeed"
 	"coingod/event"
 	"coingod/protocol"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
+	"coingod/protocol/casper"
+	"coingod/protocol/state"
 )
 
 // Notification types
 type notificationBlockConnected types.Block
 type notificationBlockDisconnected types.Block
 type notificationTxDescAcceptedByMempool protocol.TxDesc
+type notificationTxFeed txfeed.TxFeedEvent
+type notificationCheckpoint casper.CheckpointEvent
+type notificationReorg protocol.ReorgEvent
 
 // Notification control requests
 type notificationRegisterClient WSClient
@@ -25,6 +31,25 @@ type notificationRegisterBlocks WSClient
 type notificationUnregisterBlocks WSClient
 type notificationRegisterNewMempoolTxs WSClient
 type notificationUnregisterNewMempoolTxs WSClient
+type notificationRegisterCheckpoints WSClient
+type notificationUnregisterCheckpoints WSClient
+type notificationRegisterFinality WSClient
+type notificationUnregisterFinality WSClient
+type notificationRegisterReorgs WSClient
+type notificationUnregisterReorgs WSClient
+type notificationRegisterTxFeed struct {
+	wsc   *WSClient
+	alias string
+}
+type notificationUnregisterTxFeed struct {
+	wsc   *WSClient
+	alias string
+}
+type notificationRegisterFilteredTxs struct {
+	wsc    *WSClient
+	filter *txFilter
+}
+type notificationUnregisterFilteredTxs WSClient
 
 // NotificationType represents the type of a notification message.
 type NotificationType int
@@ -37,6 +62,17 @@ const (
 	NTRawBlockDisconnected
 	NTNewTransaction
 	NTRequestStatus
+	// NTTransactionFeed indicates a transaction matched by the txfeed is attached or detached.
+	NTTransactionFeed
+	// NTCheckpointStatus indicates a checkpoint is justified or finalized by casper.
+	NTCheckpointStatus
+	// NTFinalizedHeight indicates the finalized height of the chain is advanced.
+	NTFinalizedHeight
+	// NTReorganization indicates blocks are detached from the main chain by a reorganization or rollback.
+	NTReorganization
+	// NTFilteredTransaction indicates a transaction matched by the filter of the client is
+	// accepted by the mempool, attached or detached.
+	NTFilteredTransaction
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -46,6 +82,45 @@ var notificationTypeStrings = map[NotificationType]string{
 	NTRawBlockDisconnected: "raw_blocks_disconnected",
 	NTNewTransaction:       "new_transaction",
 	NTRequestStatus:        "request_status",
+	NTTransactionFeed:      "transaction_feed",
+	NTCheckpointStatus:     "checkpoint_status",
+	NTFinalizedHeight:      "finalized_height",
+	NTReorganization:       "reorganization",
+	NTFilteredTransaction:  "filtered_transaction",
+}
+
+var checkpointStatusStrings = map[state.CheckpointStatus]string{
+	state.Growing:     "growing",
+	state.Unjustified: "unjustified",
+	state.Justified:   "justified",
+	state.Finalized:   "finalized",
+}
+
+// blockID is the height and hash of a block in the notifications
+type blockID struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// the status of the transaction in the filtered transaction notification
+const (
+	filteredTxUnconfirmed = "unconfirmed"
+	filteredTxConfirmed   = "confirmed"
+	filteredTxDetached    = "detached"
+)
+
+// filteredClient is the websocket client with the filter of the transactions
+type filteredClient struct {
+	wsc    *WSClient
+	filter *txFilter
+}
+
+func blockIDs(headers []*types.BlockHeader) []*blockID {
+	result := []*blockID{}
+	for _, header := range headers {
+		result = append(result, &blockID{Height: header.Height, Hash: header.Hash()})
+	}
+	return result
 }
 
 // String returns the NotificationType in human-readable form.
@@ -90,6 +165,8 @@ type WSNotificationManager struct {
 	chain                *protocol.Chain
 	eventDispatcher      *event.Dispatcher
 	txMsgSub             *event.Subscription
+	txFeedSub            *event.Subscription
+	consensusSub         *event.Subscription
 }
 
 // NewWsNotificationManager returns a new notification manager ready for use. See WSNotificationManager for more details.
@@ -250,12 +327,84 @@ out:
 	m.wg.Done()
 }
 
+// txFeedQueryLoop constantly pass the transaction matched by txfeed to the
+// notification manager for txfeed notification processing.
+func (m *WSNotificationManager) txFeedQueryLoop() {
+out:
+	for {
+		select {
+		case obj, ok := <-m.txFeedSub.Chan():
+			if !ok {
+				log.WithFields(log.Fields{"module": logModule}).Warning("txfeed subscription channel closed")
+				break out
+			}
+
+			ev, ok := obj.Data.(txfeed.TxFeedEvent)
+			if !ok {
+				log.WithFields(log.Fields{"module": logModule}).Error("event type error")
+				continue
+			}
+
+			select {
+			case m.queueNotification <- (*notificationTxFeed)(&ev):
+			case <-m.quit:
+				break out
+			}
+		case <-m.quit:
+			break out
+		}
+	}
+
+	m.wg.Done()
+}
+
+// consensusQueryLoop constantly pass the casper checkpoint events and the chain
+// reorganization events to the notification manager for processing.
+func (m *WSNotificationManager) consensusQueryLoop() {
+out:
+	for {
+		select {
+		case obj, ok := <-m.consensusSub.Chan():
+			if !ok {
+				log.WithFields(log.Fields{"module": logModule}).Warning("consensus event subscription channel closed")
+				break out
+			}
+
+			var n interface{}
+			switch ev := obj.Data.(type) {
+			case casper.CheckpointEvent:
+				n = (*notificationCheckpoint)(&ev)
+			case protocol.ReorgEvent:
+				n = (*notificationReorg)(&ev)
+			default:
+				log.WithFields(log.Fields{"module": logModule}).Error("event type error")
+				continue
+			}
+
+			select {
+			case m.queueNotification <- n:
+			case <-m.quit:
+				break out
+			}
+		case <-m.quit:
+			break out
+		}
+	}
+
+	m.wg.Done()
+}
+
 // notificationHandler reads notifications and control messages from the queue handler and processes one at a time.
 func (m *WSNotificationManager) notificationHandler() {
 	// clients is a map of all currently connected websocket clients.
 	clients := make(map[chan struct{}]*WSClient)
 	blockNotifications := make(map[chan struct{}]*WSClient)
 	txNotifications := make(map[chan struct{}]*WSClient)
+	txFeedNotifications := make(map[string]map[chan struct{}]*WSClient)
+	checkpointNotifications := make(map[chan struct{}]*WSClient)
+	finalityNotifications := make(map[chan struct{}]*WSClient)
+	reorgNotifications := make(map[chan struct{}]*WSClient)
+	filteredTxNotifications := make(map[chan struct{}]*filteredClient)
 
 out:
 	for {
@@ -270,18 +419,48 @@ out:
 				if len(blockNotifications) != 0 {
 					m.notifyBlockConnected(blockNotifications, block)
 				}
+				if len(filteredTxNotifications) != 0 {
+					m.notifyFilteredBlockTxs(filteredTxNotifications, block, filteredTxConfirmed)
+				}
 
 			case *notificationBlockDisconnected:
 				block := (*types.Block)(n)
 				if len(blockNotifications) != 0 {
 					m.notifyBlockDisconnected(blockNotifications, block)
 				}
+				if len(filteredTxNotifications) != 0 {
+					m.notifyFilteredBlockTxs(filteredTxNotifications, block, filteredTxDetached)
+				}
 
 			case *notificationTxDescAcceptedByMempool:
 				txDesc := (*protocol.TxDesc)(n)
 				if len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, txDesc)
 				}
+				if len(filteredTxNotifications) != 0 {
+					m.notifyFilteredTx(filteredTxNotifications, txDesc.Tx, filteredTxUnconfirmed, nil)
+				}
+
+			case *notificationTxFeed:
+				ev := (*txfeed.TxFeedEvent)(n)
+				if clients, ok := txFeedNotifications[ev.Alias]; ok {
+					m.notifyForTxFeed(clients, ev)
+				}
+
+			case *notificationCheckpoint:
+				ev := (*casper.CheckpointEvent)(n)
+				if len(checkpointNotifications) != 0 {
+					m.notifyCheckpoint(checkpointNotifications, ev)
+				}
+				if ev.Status == state.Finalized && len(finalityNotifications) != 0 {
+					m.notifyFinalizedHeight(finalityNotifications, ev)
+				}
+
+			case *notificationReorg:
+				ev := (*protocol.ReorgEvent)(n)
+				if len(reorgNotifications) != 0 {
+					m.notifyReorg(reorgNotifications, ev)
+				}
 
 			case *notificationRegisterBlocks:
 				wsc := (*WSClient)(n)
@@ -299,6 +478,46 @@ out:
 				wsc := (*WSClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterCheckpoints:
+				wsc := (*WSClient)(n)
+				checkpointNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterCheckpoints:
+				wsc := (*WSClient)(n)
+				delete(checkpointNotifications, wsc.quit)
+
+			case *notificationRegisterFinality:
+				wsc := (*WSClient)(n)
+				finalityNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterFinality:
+				wsc := (*WSClient)(n)
+				delete(finalityNotifications, wsc.quit)
+
+			case *notificationRegisterReorgs:
+				wsc := (*WSClient)(n)
+				reorgNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterReorgs:
+				wsc := (*WSClient)(n)
+				delete(reorgNotifications, wsc.quit)
+
+			case *notificationRegisterTxFeed:
+				if _, ok := txFeedNotifications[n.alias]; !ok {
+					txFeedNotifications[n.alias] = make(map[chan struct{}]*WSClient)
+				}
+				txFeedNotifications[n.alias][n.wsc.quit] = n.wsc
+
+			case *notificationUnregisterTxFeed:
+				removeTxFeedClient(txFeedNotifications, n.alias, n.wsc)
+
+			case *notificationRegisterFilteredTxs:
+				filteredTxNotifications[n.wsc.quit] = &filteredClient{wsc: n.wsc, filter: n.filter}
+
+			case *notificationUnregisterFilteredTxs:
+				wsc := (*WSClient)(n)
+				delete(filteredTxNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*WSClient)(n)
 				clients[wsc.quit] = wsc
@@ -307,6 +526,13 @@ out:
 				wsc := (*WSClient)(n)
 				delete(blockNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(checkpointNotifications, wsc.quit)
+				delete(finalityNotifications, wsc.quit)
+				delete(reorgNotifications, wsc.quit)
+				delete(filteredTxNotifications, wsc.quit)
+				for alias := range txFeedNotifications {
+					removeTxFeedClient(txFeedNotifications, alias, wsc)
+				}
 				delete(clients, wsc.quit)
 
 			default:
@@ -406,6 +632,182 @@ func (m *WSNotificationManager) notifyForNewTx(clients map[chan struct{}]*WSClie
 	}
 }
 
+// RegisterTxFeedUpdates requests notifications to the passed websocket client
+// when a transaction matched by the txfeed is attached or detached.
+func (m *WSNotificationManager) RegisterTxFeedUpdates(wsc *WSClient, alias string) {
+	m.queueNotification <- &notificationRegisterTxFeed{wsc: wsc, alias: alias}
+}
+
+// UnregisterTxFeedUpdates removes the txfeed notifications to the passed websocket client.
+func (m *WSNotificationManager) UnregisterTxFeedUpdates(wsc *WSClient, alias string) {
+	m.queueNotification <- &notificationUnregisterTxFeed{wsc: wsc, alias: alias}
+}
+
+func removeTxFeedClient(txFeedNotifications map[string]map[chan struct{}]*WSClient, alias string, wsc *WSClient) {
+	clients, ok := txFeedNotifications[alias]
+	if !ok {
+		return
+	}
+
+	delete(clients, wsc.quit)
+	if len(clients) == 0 {
+		delete(txFeedNotifications, alias)
+	}
+}
+
+// notifyForTxFeed notifies websocket clients that have registered for the txfeed
+// when a matched transaction is attached or detached.
+func (m *WSNotificationManager) notifyForTxFeed(clients map[chan struct{}]*WSClient, ev *txfeed.TxFeedEvent) {
+	resp := NewWSResponse(NTTransactionFeed.String(), struct {
+		Alias    string      `json:"alias"`
+		Cursor   string      `json:"cursor"`
+		Detached bool        `json:"detached"`
+		Tx       interface{} `json:"transaction"`
+	}{
+		Alias:    ev.Alias,
+		Cursor:   ev.Cursor,
+		Detached: ev.Detached,
+		Tx:       ev.Tx,
+	}, nil)
+	marshalledJSON, err := json.Marshal(resp)
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "error": err}).Error("Failed to marshal txfeed notification")
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterCheckpointUpdates requests notifications to the passed websocket client
+// when a checkpoint is justified or finalized.
+func (m *WSNotificationManager) RegisterCheckpointUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationRegisterCheckpoints)(wsc)
+}
+
+// UnregisterCheckpointUpdates removes the checkpoint notifications to the passed websocket client.
+func (m *WSNotificationManager) UnregisterCheckpointUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationUnregisterCheckpoints)(wsc)
+}
+
+// RegisterFinalityUpdates requests notifications to the passed websocket client
+// when the finalized height is advanced.
+func (m *WSNotificationManager) RegisterFinalityUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationRegisterFinality)(wsc)
+}
+
+// UnregisterFinalityUpdates removes the finality notifications to the passed websocket client.
+func (m *WSNotificationManager) UnregisterFinalityUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationUnregisterFinality)(wsc)
+}
+
+// RegisterReorgUpdates requests notifications to the passed websocket client
+// when blocks are detached from the main chain.
+func (m *WSNotificationManager) RegisterReorgUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationRegisterReorgs)(wsc)
+}
+
+// UnregisterReorgUpdates removes the reorganization notifications to the passed websocket client.
+func (m *WSNotificationManager) UnregisterReorgUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationUnregisterReorgs)(wsc)
+}
+
+// notifyCheckpoint notifies websocket clients that have registered for the checkpoint
+// updates when a checkpoint is justified or finalized.
+func (m *WSNotificationManager) notifyCheckpoint(clients map[chan struct{}]*WSClient, ev *casper.CheckpointEvent) {
+	resp := NewWSResponse(NTCheckpointStatus.String(), struct {
+		Height uint64  `json:"height"`
+		Hash   bc.Hash `json:"hash"`
+		Status string  `json:"status"`
+	}{
+		Height: ev.Height,
+		Hash:   ev.Hash,
+		Status: checkpointStatusStrings[ev.Status],
+	}, nil)
+	m.queueToClients(clients, resp)
+}
+
+// notifyFinalizedHeight notifies websocket clients that have registered for the finality
+// updates when the finalized height is advanced, all the blocks up to the height are
+// irreversible.
+func (m *WSNotificationManager) notifyFinalizedHeight(clients map[chan struct{}]*WSClient, ev *casper.CheckpointEvent) {
+	m.queueToClients(clients, NewWSResponse(NTFinalizedHeight.String(), &blockID{Height: ev.Height, Hash: ev.Hash}, nil))
+}
+
+// notifyReorg notifies websocket clients that have registered for the reorganization
+// updates when blocks are detached from the main chain.
+func (m *WSNotificationManager) notifyReorg(clients map[chan struct{}]*WSClient, ev *protocol.ReorgEvent) {
+	resp := NewWSResponse(NTReorganization.String(), struct {
+		ForkHeight     uint64     `json:"fork_height"`
+		DetachedBlocks []*blockID `json:"detached_blocks"`
+		AttachedBlocks []*blockID `json:"attached_blocks"`
+	}{
+		ForkHeight:     ev.ForkHeight,
+		DetachedBlocks: blockIDs(ev.Detached),
+		AttachedBlocks: blockIDs(ev.Attached),
+	}, nil)
+	m.queueToClients(clients, resp)
+}
+
+// RegisterFilteredTxUpdates requests notifications to the passed websocket client when a
+// transaction matched by the filter is accepted by the mempool, attached or detached, the
+// previous filter of the client is replaced.
+func (m *WSNotificationManager) RegisterFilteredTxUpdates(wsc *WSClient, filter *txFilter) {
+	m.queueNotification <- &notificationRegisterFilteredTxs{wsc: wsc, filter: filter}
+}
+
+// UnregisterFilteredTxUpdates removes the filtered transaction notifications to the passed websocket client.
+func (m *WSNotificationManager) UnregisterFilteredTxUpdates(wsc *WSClient) {
+	m.queueNotification <- (*notificationUnregisterFilteredTxs)(wsc)
+}
+
+// notifyFilteredBlockTxs notifies the filtered transaction clients of the matched
+// transactions in the block which is attached or detached.
+func (m *WSNotificationManager) notifyFilteredBlockTxs(clients map[chan struct{}]*filteredClient, block *types.Block, status string) {
+	id := &blockID{Height: block.Height, Hash: block.Hash()}
+	for _, tx := range block.Transactions {
+		m.notifyFilteredTx(clients, tx, status, id)
+	}
+}
+
+// notifyFilteredTx notifies websocket clients whose filter matches the transaction.
+func (m *WSNotificationManager) notifyFilteredTx(clients map[chan struct{}]*filteredClient, tx *types.Tx, status string, block *blockID) {
+	matched := make(map[chan struct{}]*WSClient)
+	for quit, c := range clients {
+		if c.filter.match(tx) {
+			matched[quit] = c.wsc
+		}
+	}
+
+	if len(matched) == 0 {
+		return
+	}
+
+	resp := NewWSResponse(NTFilteredTransaction.String(), struct {
+		Status string    `json:"status"`
+		Block  *blockID  `json:"block,omitempty"`
+		Tx     *types.Tx `json:"transaction"`
+	}{
+		Status: status,
+		Block:  block,
+		Tx:     tx,
+	}, nil)
+	m.queueToClients(matched, resp)
+}
+
+func (m *WSNotificationManager) queueToClients(clients map[chan struct{}]*WSClient, resp *WSResponse) {
+	marshalledJSON, err := json.Marshal(resp)
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "error": err}).Errorf("Failed to marshal %s notification", resp.NotificationType)
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // AddClient adds the passed websocket client to the notification manager.
 func (m *WSNotificationManager) AddClient(wsc *WSClient) {
 	m.queueNotification <- (*notificationRegisterClient)(wsc)
@@ -474,11 +876,23 @@ func (m *WSNotificationManager) Start() error {
 		return err
 	}
 
-	m.wg.Add(4)
+	m.txFeedSub, err = m.eventDispatcher.Subscribe(txfeed.TxFeedEvent{})
+	if err != nil {
+		return err
+	}
+
+	m.consensusSub, err = m.eventDispatcher.Subscribe(casper.CheckpointEvent{}, protocol.ReorgEvent{})
+	if err != nil {
+		return err
+	}
+
+	m.wg.Add(6)
 	go m.blockNotify()
 	go m.queueHandler()
 	go m.notificationHandler()
 	go m.memPoolTxQueryLoop()
+	go m.txFeedQueryLoop()
+	go m.consensusQueryLoop()
 	return nil
 }
 
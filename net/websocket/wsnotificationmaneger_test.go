@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"coingod/event"
+	"coingod/protocol"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/casper"
+	"coingod/protocol/state"
+)
+
+// newTestNotificationManager start the goroutines which pass the consensus events to
+// the clients, the chain is not needed by them
+func newTestNotificationManager(t *testing.T, dispatcher *event.Dispatcher) *WSNotificationManager {
+	m := &WSNotificationManager{
+		queueNotification: make(chan interface{}),
+		notificationMsgs:  make(chan interface{}),
+		numClients:        make(chan int),
+		quit:              make(chan struct{}),
+		eventDispatcher:   dispatcher,
+	}
+
+	var err error
+	if m.consensusSub, err = dispatcher.Subscribe(casper.CheckpointEvent{}, protocol.ReorgEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.wg.Add(3)
+	go m.queueHandler()
+	go m.notificationHandler()
+	go m.consensusQueryLoop()
+	return m
+}
+
+func newTestClient() *WSClient {
+	return &WSClient{ntfnChan: make(chan []byte, 10), quit: make(chan struct{})}
+}
+
+func receiveNotification(t *testing.T, wsc *WSClient) map[string]interface{} {
+	select {
+	case msg := <-wsc.ntfnChan:
+		var resp map[string]interface{}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout on receive the notification")
+	}
+	return nil
+}
+
+func TestConsensusNotifications(t *testing.T) {
+	dispatcher := event.NewDispatcher()
+	defer dispatcher.Stop()
+
+	m := newTestNotificationManager(t, dispatcher)
+	defer m.Shutdown()
+
+	checkpointClient, finalityClient, reorgClient := newTestClient(), newTestClient(), newTestClient()
+	m.RegisterCheckpointUpdates(checkpointClient)
+	m.RegisterFinalityUpdates(finalityClient)
+	m.RegisterReorgUpdates(reorgClient)
+
+	detached := &types.BlockHeader{Height: 11, PreviousBlockHash: bc.Hash{V0: 1}}
+	attached := &types.BlockHeader{Height: 11, PreviousBlockHash: bc.Hash{V0: 2}}
+	events := []interface{}{
+		casper.CheckpointEvent{Height: 100, Hash: bc.Hash{V0: 100}, Status: state.Justified},
+		casper.CheckpointEvent{Height: 100, Hash: bc.Hash{V0: 100}, Status: state.Finalized},
+		protocol.ReorgEvent{ForkHeight: 10, Detached: []*types.BlockHeader{detached}, Attached: []*types.BlockHeader{attached}},
+	}
+	for _, ev := range events {
+		if err := dispatcher.Post(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, status := range []string{"justified", "finalized"} {
+		resp := receiveNotification(t, checkpointClient)
+		data := resp["data"].(map[string]interface{})
+		if resp["notification_type"] != "checkpoint_status" || data["status"] != status || data["height"] != float64(100) {
+			t.Fatalf("got checkpoint notification %v, want %s checkpoint at height 100", resp, status)
+		}
+	}
+
+	resp := receiveNotification(t, finalityClient)
+	if data := resp["data"].(map[string]interface{}); resp["notification_type"] != "finalized_height" || data["height"] != float64(100) {
+		t.Fatalf("got finality notification %v, want finalized height 100", resp)
+	}
+
+	resp = receiveNotification(t, reorgClient)
+	data := resp["data"].(map[string]interface{})
+	if resp["notification_type"] != "reorganization" || data["fork_height"] != float64(10) {
+		t.Fatalf("got reorganization notification %v, want fork height 10", resp)
+	}
+
+	for key, header := range map[string]*types.BlockHeader{"detached_blocks": detached, "attached_blocks": attached} {
+		blocks := data[key].([]interface{})
+		hash, _ := header.Hash().MarshalText()
+		if len(blocks) != 1 || blocks[0].(map[string]interface{})["hash"] != string(hash) {
+			t.Fatalf("got %s %v, want the block %s", key, blocks, hash)
+		}
+	}
+
+	// the events are handled in order, the clients got nothing more than the subscribed topics
+	for _, wsc := range []*WSClient{checkpointClient, finalityClient, reorgClient} {
+		select {
+		case msg := <-wsc.ntfnChan:
+			t.Fatalf("got unexpected notification %s", msg)
+		default:
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package chainmgr
 
 import (
+	"errors"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"coingod/consensus"
 	dbm "coingod/database/leveldb"
+	msgs "coingod/netsync/messages"
 	"coingod/netsync/peers"
 	"coingod/p2p/security"
 	"coingod/protocol/bc"
@@ -25,6 +27,8 @@ var (
 	maxNumOfBlocksPerMsg      = uint64(64)
 	maxNumOfHeadersPerMsg     = uint64(1000)
 	maxNumOfBlocksRegularSync = uint64(128)
+
+	errPrunedBlocks = errors.New("the transactions of the requested blocks are pruned")
 )
 
 // Fetcher is the interface for fetch struct
@@ -32,12 +36,15 @@ type Fetcher interface {
 	processBlock(peerID string, block *types.Block)
 	processBlocks(peerID string, blocks []*types.Block)
 	processHeaders(peerID string, headers []*types.BlockHeader)
+	processBlockNotFound(peerID string, msg *msgs.BlockNotFoundMessage)
 	requireBlock(peerID string, height uint64) (*types.Block, error)
 }
 
+// blockMsg is the block replied by the peer, or the reply that the requested block is pruned
 type blockMsg struct {
-	block  *types.Block
-	peerID string
+	block    *types.Block
+	notFound *msgs.BlockNotFoundMessage
+	peerID   string
 }
 
 type blocksMsg struct {
@@ -78,6 +85,10 @@ func (bk *blockKeeper) locateBlocks(locator []*bc.Hash, stopHash *bc.Hash, isTim
 		return nil, err
 	}
 
+	if len(headers) > 0 && headers[0].Height < bk.chain.BaseBlockHeight() {
+		return nil, errPrunedBlocks
+	}
+
 	blocks := []*types.Block{}
 	for _, header := range headers {
 		headerHash := header.Hash()
@@ -153,6 +164,10 @@ func (bk *blockKeeper) processHeaders(peerID string, headers []*types.BlockHeade
 	bk.msgFetcher.processHeaders(peerID, headers)
 }
 
+func (bk *blockKeeper) processBlockNotFound(peerID string, msg *msgs.BlockNotFoundMessage) {
+	bk.msgFetcher.processBlockNotFound(peerID, msg)
+}
+
 func (bk *blockKeeper) regularBlockSync() error {
 	peerHeight := bk.syncPeer.Height()
 	bestHeight := bk.chain.BestBlockHeight()
@@ -163,7 +178,9 @@ func (bk *blockKeeper) regularBlockSync() error {
 
 	for i := bestHeight + 1; i <= targetHeight; {
 		block, err := bk.msgFetcher.requireBlock(bk.syncPeer.ID(), i)
-		if err != nil {
+		if err == errPrunedBlocks {
+			return err
+		} else if err != nil {
 			bk.peers.ProcessIllegal(bk.syncPeer.ID(), security.LevelConnException, err.Error())
 			return err
 		}
@@ -205,7 +222,8 @@ func (bk *blockKeeper) checkSyncType() int {
 		}
 	}
 
-	peer = bk.peers.BestPeer(consensus.SFFullNode)
+	// the pruned peers can't serve the blocks of the history
+	peer = bk.peers.BestPeerFrom(consensus.SFFullNode, bestHeight+1)
 	if peer == nil {
 		log.WithFields(log.Fields{"module": logModule}).Debug("can't find sync peer")
 		return noNeedSync
@@ -285,6 +285,52 @@ func TestRequireBlock(t *testing.T) {
 	}
 }
 
+func TestRequirePrunedBlocks(t *testing.T) {
+	tmp, err := ioutil.TempDir(".", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data folder: %v", err)
+	}
+	testDBA := dbm.NewDB("testdba", "leveldb", tmp)
+	testDBB := dbm.NewDB("testdbb", "leveldb", tmp)
+	defer func() {
+		testDBB.Close()
+		testDBA.Close()
+		os.RemoveAll(tmp)
+	}()
+
+	blocks := mockBlocks(nil, 5)
+	a := mockSync(blocks[:1], nil, testDBA)
+	b := mockSync(blocks[:5], nil, testDBB)
+	b.chain.(*mock.Chain).SetBaseBlockHeight(3)
+	// the messages are delivered synchronously without the status exchange
+	B2A := NewP2PPeer("192.168.0.1", "test node A", consensus.SFFullNode)
+	A2B := NewP2PPeer("192.168.0.2", "test node B", consensus.PrunedServices)
+	A2B.SetConnection(B2A, b)
+	B2A.SetConnection(A2B, a)
+	a.AddPeer(A2B)
+	b.AddPeer(B2A)
+
+	// the pruned peer replies at once instead of leaving the request to the timeout
+	startTime := time.Now()
+	fetcher := a.blockKeeper.msgFetcher.(*msgFetcher)
+	if _, err := fetcher.requireBlock("test node B", 1); errors.Root(err) != errPrunedBlocks {
+		t.Errorf("got error %v on require the pruned block, want %v", err, errPrunedBlocks)
+	}
+
+	blockHash, stopHash := blocks[1].Hash(), blocks[4].Hash()
+	if _, err := fetcher.requireBlocks("test node B", []*bc.Hash{&blockHash}, &stopHash); err != errPrunedBlocks {
+		t.Errorf("got error %v on require the pruned blocks, want %v", err, errPrunedBlocks)
+	}
+
+	if time.Since(startTime) >= requireBlockTimeout {
+		t.Errorf("the requests of the pruned blocks wait for the timeout")
+	}
+
+	if got, err := fetcher.requireBlock("test node B", 4); err != nil || got.Hash() != blocks[4].Hash() {
+		t.Errorf("got block %v, error %v on require the kept block", got, err)
+	}
+}
+
 func TestSendMerkleBlock(t *testing.T) {
 	if testcontrol.IgnoreTestTemporary {
 		return
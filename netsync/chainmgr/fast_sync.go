@@ -2,6 +2,7 @@ package chainmgr
 
 import (
 	"sync"
+	"sync/atomic"
 
 	log "github.com/sirupsen/logrus"
 
@@ -32,6 +33,17 @@ type fastSync struct {
 	blockProcessor *blockProcessor
 	peers          *peers.PeerSet
 	mainSyncPeer   *peers.Peer
+
+	// the range of the running sync, the target height is zero when it's not running
+	startHeight  uint64
+	targetHeight uint64
+}
+
+// FastSyncStatus is the progress of the fast sync
+type FastSyncStatus struct {
+	Syncing      bool
+	StartHeight  uint64
+	TargetHeight uint64
 }
 
 func newFastSync(chain Chain, msgFetcher MsgFetcher, storage *storage, peers *peers.PeerSet) *fastSync {
@@ -75,8 +87,14 @@ func (fs *fastSync) blockLocator() []*bc.Hash {
 
 // createFetchBlocksTasks get the skeleton and assign tasks according to the skeleton.
 func (fs *fastSync) createFetchBlocksTasks(stopBlock *types.Block) ([]*fetchBlocksWork, error) {
-	// Find peers that meet the height requirements.
-	peers := fs.peers.GetPeersByHeight(stopBlock.Height + fastSyncPivotGap)
+	// Find peers that meet the height requirements, the pruned peers refuse the old blocks.
+	peers := []*peers.Peer{}
+	for _, peer := range fs.peers.GetPeersByHeight(stopBlock.Height + fastSyncPivotGap) {
+		if !peer.IsPruned() {
+			peers = append(peers, peer)
+		}
+	}
+
 	if len(peers) == 0 {
 		return nil, errNoSyncPeer
 	}
@@ -136,6 +154,10 @@ func (fs *fastSync) process() error {
 		return err
 	}
 
+	atomic.StoreUint64(&fs.startHeight, tasks[0].startHeader.Height)
+	atomic.StoreUint64(&fs.targetHeight, tasks[len(tasks)-1].stopHeader.Height)
+	defer atomic.StoreUint64(&fs.targetHeight, 0)
+
 	downloadNotifyCh := make(chan struct{}, 1)
 	processStopCh := make(chan struct{})
 	var wg sync.WaitGroup
@@ -160,6 +182,15 @@ func (fs *fastSync) findSyncRange() (*types.Block, error) {
 	return fs.msgFetcher.requireBlock(fs.mainSyncPeer.ID(), bestHeight+length)
 }
 
+func (fs *fastSync) status() *FastSyncStatus {
+	targetHeight := atomic.LoadUint64(&fs.targetHeight)
+	return &FastSyncStatus{
+		Syncing:      targetHeight != 0,
+		StartHeight:  atomic.LoadUint64(&fs.startHeight),
+		TargetHeight: targetHeight,
+	}
+}
+
 func (fs *fastSync) setSyncPeer(peer *peers.Peer) {
 	fs.mainSyncPeer = peer
 }
@@ -29,6 +29,7 @@ type Chain interface {
 	BestBlockHeader() *types.BlockHeader
 	LastJustifiedHeader() (*types.BlockHeader, error)
 	BestBlockHeight() uint64
+	BaseBlockHeight() uint64
 	GetBlockByHash(*bc.Hash) (*types.Block, error)
 	GetBlockByHeight(uint64) (*types.Block, error)
 	GetHeaderByHash(*bc.Hash) (*types.BlockHeader, error)
@@ -60,6 +61,7 @@ type Manager struct {
 	chain       Chain
 	mempool     Mempool
 	blockKeeper *blockKeeper
+	lightKeeper *lightKeeper
 	peers       *peers.PeerSet
 
 	txSyncCh chan *txSyncMsg
@@ -91,6 +93,12 @@ func NewManager(config *cfg.Config, sw Switch, chain Chain, mempool Mempool, dis
 	return manager, nil
 }
 
+// EnableLightMode make the manager only sync the block headers and the wallet related
+// transactions into the light chain, it must be called before Start
+func (m *Manager) EnableLightMode(chain *LightChain, wallet LightWallet) {
+	m.lightKeeper = newLightKeeper(chain, wallet, m.peers)
+}
+
 // AddPeer add the network layer peer to logic layer
 func (m *Manager) AddPeer(peer peers.BasePeer) {
 	m.peers.AddPeer(peer)
@@ -99,15 +107,28 @@ func (m *Manager) AddPeer(peer peers.BasePeer) {
 //IsCaughtUp check wheather the peer finish the sync
 func (m *Manager) IsCaughtUp() bool {
 	peer := m.peers.BestPeer(consensus.SFFullNode)
+	if m.lightKeeper != nil {
+		return peer == nil || peer.Height() <= m.lightKeeper.chain.BestBlockHeight()
+	}
 	return peer == nil || peer.Height() <= m.chain.BestBlockHeight()
 }
 
+// FastSyncStatus return the progress of the fast sync
+func (m *Manager) FastSyncStatus() *FastSyncStatus {
+	return m.blockKeeper.fastSync.status()
+}
+
 func (m *Manager) handleBlockMsg(peer *peers.Peer, msg *msgs.BlockMessage) {
 	block, err := msg.GetBlock()
 	if err != nil {
 		return
 	}
 
+	if m.lightKeeper != nil {
+		m.lightKeeper.processBlock(peer.ID(), block)
+		return
+	}
+
 	m.blockKeeper.processBlock(peer.ID(), block)
 }
 
@@ -142,6 +163,12 @@ func (m *Manager) handleGetBlockMsg(peer *peers.Peer, msg *msgs.GetBlockMessage)
 		block, err = m.chain.GetBlockByHash(msg.GetHash())
 	}
 	if err != nil {
+		if m.isPrunedBlock(msg.Height, msg.GetHash()) {
+			log.WithFields(log.Fields{"module": logModule, "peer": peer.Addr()}).Debug("refuse the request of the pruned block")
+			m.sendBlockNotFound(peer, msg.Height, msg.GetHash())
+			return
+		}
+
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on handleGetBlockMsg get block from chain")
 		return
 	}
@@ -155,6 +182,35 @@ func (m *Manager) handleGetBlockMsg(peer *peers.Peer, msg *msgs.GetBlockMessage)
 	}
 }
 
+// isPrunedBlock check whether the transactions of the requested block are pruned, the block
+// is requested by the hash when the height is zero
+func (m *Manager) isPrunedBlock(height uint64, hash *bc.Hash) bool {
+	if height == 0 {
+		header, err := m.chain.GetHeaderByHash(hash)
+		if err != nil {
+			return false
+		}
+		height = header.Height
+	}
+	return height < m.chain.BaseBlockHeight()
+}
+
+func (m *Manager) sendBlockNotFound(peer *peers.Peer, height uint64, hash *bc.Hash) {
+	if ok := peer.SendBlockNotFound(height, hash); !ok {
+		m.peers.RemovePeer(peer.ID())
+	}
+}
+
+// handleBlockNotFoundMsg stop waiting for the blocks which are pruned by the peer
+func (m *Manager) handleBlockNotFoundMsg(peer *peers.Peer, msg *msgs.BlockNotFoundMessage) {
+	if m.lightKeeper != nil {
+		m.lightKeeper.processBlockNotFound(peer.ID(), msg)
+		return
+	}
+
+	m.blockKeeper.processBlockNotFound(peer.ID(), msg)
+}
+
 func (m *Manager) handleGetBlocksMsg(peer *peers.Peer, msg *msgs.GetBlocksMessage) {
 	endTime := time.Now().Add(requireBlocksTimeout / 10)
 	isTimeout := func() bool {
@@ -162,6 +218,12 @@ func (m *Manager) handleGetBlocksMsg(peer *peers.Peer, msg *msgs.GetBlocksMessag
 	}
 
 	blocks, err := m.blockKeeper.locateBlocks(msg.GetBlockLocator(), msg.GetStopHash(), isTimeout)
+	if err == errPrunedBlocks {
+		log.WithFields(log.Fields{"module": logModule, "peer": peer.Addr()}).Debug("refuse the request of the pruned blocks")
+		m.sendBlockNotFound(peer, 0, msg.GetStopHash())
+		return
+	}
+
 	if err != nil || len(blocks) == 0 {
 		log.WithFields(log.Fields{
 			"module": logModule,
@@ -221,6 +283,12 @@ func (m *Manager) handleGetMerkleBlockMsg(peer *peers.Peer, msg *msgs.GetMerkleB
 		block, err = m.chain.GetBlockByHash(msg.GetHash())
 	}
 	if err != nil {
+		if m.isPrunedBlock(msg.Height, msg.GetHash()) {
+			log.WithFields(log.Fields{"module": logModule, "peer": peer.Addr()}).Debug("refuse the request of the pruned merkle block")
+			m.sendBlockNotFound(peer, msg.Height, msg.GetHash())
+			return
+		}
+
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on handleGetMerkleBlockMsg get block from chain")
 		return
 	}
@@ -243,6 +311,11 @@ func (m *Manager) handleHeadersMsg(peer *peers.Peer, msg *msgs.HeadersMessage) {
 		return
 	}
 
+	if m.lightKeeper != nil {
+		m.lightKeeper.processHeaders(peer.ID(), headers)
+		return
+	}
+
 	m.blockKeeper.processHeaders(peer.ID(), headers)
 }
 
@@ -254,6 +327,11 @@ func (m *Manager) handleStatusMsg(basePeer peers.BasePeer, msg *msgs.StatusMessa
 }
 
 func (m *Manager) handleTransactionMsg(peer *peers.Peer, msg *msgs.TransactionMessage) {
+	// the light node can't validate the transactions without the utxo set
+	if m.lightKeeper != nil {
+		return
+	}
+
 	tx, err := msg.GetTransaction()
 	if err != nil {
 		m.peers.ProcessIllegal(peer.ID(), security.LevelConnException, "fail on get tx from message")
@@ -272,6 +350,11 @@ func (m *Manager) handleTransactionMsg(peer *peers.Peer, msg *msgs.TransactionMe
 }
 
 func (m *Manager) handleTransactionsMsg(peer *peers.Peer, msg *msgs.TransactionsMessage) {
+	// the light node can't validate the transactions without the utxo set
+	if m.lightKeeper != nil {
+		return
+	}
+
 	txs, err := msg.GetTransactions()
 	if err != nil {
 		m.peers.ProcessIllegal(peer.ID(), security.LevelConnException, "fail on get txs from message")
@@ -338,6 +421,9 @@ func (m *Manager) processMsg(basePeer peers.BasePeer, msgType byte, msg msgs.Blo
 	case *msgs.BlocksMessage:
 		m.handleBlocksMsg(peer, msg)
 
+	case *msgs.BlockNotFoundMessage:
+		m.handleBlockNotFoundMsg(peer, msg)
+
 	case *msgs.FilterLoadMessage:
 		m.handleFilterLoadMsg(peer, msg)
 
@@ -364,6 +450,17 @@ func (m *Manager) RemovePeer(peerID string) {
 	m.peers.RemovePeer(peerID)
 }
 
+// chainStatus return the best header and the last justified header of the synced chain
+func (m *Manager) chainStatus() (*types.BlockHeader, *types.BlockHeader, error) {
+	if m.lightKeeper != nil {
+		lastJustifiedHeader, err := m.lightKeeper.chain.LastJustifiedHeader()
+		return m.lightKeeper.chain.BestBlockHeader(), lastJustifiedHeader, err
+	}
+
+	lastJustifiedHeader, err := m.chain.LastJustifiedHeader()
+	return m.chain.BestBlockHeader(), lastJustifiedHeader, err
+}
+
 // SendStatus sent the current self status to remote peer
 func (m *Manager) SendStatus(peer peers.BasePeer) error {
 	p := m.peers.GetPeer(peer.ID())
@@ -371,12 +468,12 @@ func (m *Manager) SendStatus(peer peers.BasePeer) error {
 		return errors.New("invalid peer")
 	}
 
-	lastJustifiedHeader, err := m.chain.LastJustifiedHeader()
+	bestHeader, lastJustifiedHeader, err := m.chainStatus()
 	if err != nil {
 		return err
 	}
 
-	if err := p.SendStatus(m.chain.BestBlockHeader(), lastJustifiedHeader); err != nil {
+	if err := p.SendStatus(bestHeader, lastJustifiedHeader); err != nil {
 		m.peers.RemovePeer(p.ID())
 		return err
 	}
@@ -390,7 +487,11 @@ func (m *Manager) Start() error {
 	if err != nil {
 		return err
 	}
-	m.blockKeeper.start()
+	if m.lightKeeper != nil {
+		m.lightKeeper.start()
+	} else {
+		m.blockKeeper.start()
+	}
 	go m.broadcastTxsLoop()
 	go m.syncMempoolLoop()
 
@@ -399,6 +500,10 @@ func (m *Manager) Start() error {
 
 //Stop stop sync manager
 func (m *Manager) Stop() {
-	m.blockKeeper.stop()
+	if m.lightKeeper != nil {
+		m.lightKeeper.stop()
+	} else {
+		m.blockKeeper.stop()
+	}
 	close(m.quit)
 }
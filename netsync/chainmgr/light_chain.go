@@ -0,0 +1,561 @@
+package chainmgr
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/config"
+	"coingod/consensus"
+	"coingod/crypto/ed25519/chainkd"
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+	"coingod/protocol/validation"
+	"coingod/signer"
+)
+
+var (
+	lightBlockPrefix      = []byte("LB:")
+	lightMainChainPrefix  = []byte("LM:")
+	lightCheckpointPrefix = []byte("LC:")
+	lightChainStatusKey   = []byte("lightChainStatus")
+
+	errLightBlockNotFound = errors.New("light block not found")
+	errLightOrphanBlock   = errors.New("parent of the light block not found")
+	errLightBadHeight     = errors.New("light block height is not continuous")
+	errLightBadHeader     = errors.New("invalid light block header")
+	errLightBadSupLink    = errors.New("invalid supLink signature")
+	errLightUnjustified   = errors.New("light block isn't backed by the justified checkpoint")
+	errRollbackJustified  = errors.New("can't rollback the justified checkpoint")
+)
+
+// lightChainStatus is the persisted status of the light chain
+type lightChainStatus struct {
+	BestHash      bc.Hash `json:"best_hash"`
+	JustifiedHash bc.Hash `json:"justified_hash"`
+}
+
+func calcLightBlockKey(hash *bc.Hash) []byte {
+	return append(append([]byte{}, lightBlockPrefix...), hash.Bytes()...)
+}
+
+func calcLightCheckpointKey(hash *bc.Hash) []byte {
+	return append(append([]byte{}, lightCheckpointPrefix...), hash.Bytes()...)
+}
+
+func calcLightMainChainKey(height uint64) []byte {
+	key := make([]byte, len(lightMainChainPrefix)+8)
+	copy(key, lightMainChainPrefix)
+	binary.BigEndian.PutUint64(key[len(lightMainChainPrefix):], height)
+	return key
+}
+
+// LightChain keeps the block headers synced by the light node. Each block only contains the
+// transactions of the wallet and the vote transactions, so it can be used as the block source
+// of the wallet just like the full chain. The vote transactions are picked from the full block
+// verified by the merkle root of the header, and applied to the checkpoint of each epoch, so the
+// light chain knows the validators to verify the signatures of the block headers and the supLinks
+// of the checkpoints.
+type LightChain struct {
+	db              dbm.DB
+	cond            sync.Cond
+	bestHeader      *types.BlockHeader
+	justifiedHeader *types.BlockHeader
+}
+
+// NewLightChain load the light chain from the database, the genesis block is saved
+// as the justified checkpoint which is signed by the federation validators when the
+// database is empty
+func NewLightChain(db dbm.DB) (*LightChain, error) {
+	c := &LightChain{db: db}
+	c.cond.L = new(sync.Mutex)
+
+	status := &lightChainStatus{}
+	if rawStatus := db.Get(lightChainStatusKey); rawStatus != nil {
+		if err := json.Unmarshal(rawStatus, status); err != nil {
+			return nil, err
+		}
+	} else {
+		genesis := config.GenesisBlock()
+		rawBlock, err := genesis.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		genesisHash := genesis.Hash()
+		checkpoint := &state.Checkpoint{
+			Hash:      genesisHash,
+			Timestamp: genesis.Timestamp,
+			Status:    state.Justified,
+			Rewards:   make(map[string]uint64),
+			Votes:     make(map[string]uint64),
+		}
+
+		status = &lightChainStatus{BestHash: genesisHash, JustifiedHash: genesisHash}
+		batch := db.NewBatch()
+		batch.Set(calcLightBlockKey(&genesisHash), rawBlock)
+		batch.Set(calcLightMainChainKey(0), genesisHash.Bytes())
+		if err := saveLightCheckpoints(batch, checkpoint); err != nil {
+			return nil, err
+		}
+
+		if err := saveLightChainStatus(batch, status); err != nil {
+			return nil, err
+		}
+		batch.Write()
+	}
+
+	var err error
+	if c.bestHeader, err = c.GetHeaderByHash(&status.BestHash); err != nil {
+		return nil, err
+	}
+
+	if c.justifiedHeader, err = c.GetHeaderByHash(&status.JustifiedHash); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func saveLightChainStatus(batch dbm.Batch, status *lightChainStatus) error {
+	rawStatus, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(lightChainStatusKey, rawStatus)
+	return nil
+}
+
+func saveLightCheckpoints(batch dbm.Batch, checkpoints ...*state.Checkpoint) error {
+	for _, checkpoint := range checkpoints {
+		rawCheckpoint, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(calcLightCheckpointKey(&checkpoint.Hash), rawCheckpoint)
+	}
+	return nil
+}
+
+func (c *LightChain) getCheckpoint(hash *bc.Hash) (*state.Checkpoint, error) {
+	rawCheckpoint := c.db.Get(calcLightCheckpointKey(hash))
+	if rawCheckpoint == nil {
+		return nil, errors.WithDetailf(errLightBlockNotFound, "checkpoint %s", hash.String())
+	}
+
+	checkpoint := &state.Checkpoint{}
+	if err := json.Unmarshal(rawCheckpoint, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// parentCheckpoint return the checkpoint of the last epoch before the block, the block must be
+// signed by the validator of the checkpoint
+func (c *LightChain) parentCheckpoint(prevHash *bc.Hash) (*state.Checkpoint, error) {
+	header, err := c.GetHeaderByHash(prevHash)
+	if err != nil {
+		return nil, err
+	}
+
+	height := header.Height / consensus.ActiveNetParams.BlocksOfEpoch * consensus.ActiveNetParams.BlocksOfEpoch
+	hash := *prevHash
+	for header.Height > height {
+		if c.InMainChain(hash) {
+			mainHash, err := c.getMainChainHash(height)
+			if err != nil {
+				return nil, err
+			}
+
+			hash = *mainHash
+			break
+		}
+
+		hash = header.PreviousBlockHash
+		if header, err = c.GetHeaderByHash(&hash); err != nil {
+			return nil, err
+		}
+	}
+	return c.getCheckpoint(&hash)
+}
+
+// newCheckpoint apply the votes of the blocks in the epoch which ends with the block to the parent checkpoint
+func (c *LightChain) newCheckpoint(parent *state.Checkpoint, block *types.Block) (*state.Checkpoint, error) {
+	blocks := []*types.Block{block}
+	for hash := block.PreviousBlockHash; hash != parent.Hash; {
+		prev, err := c.GetBlockByHash(&hash)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks = append([]*types.Block{prev}, blocks...)
+		hash = prev.PreviousBlockHash
+	}
+
+	checkpoint := state.NewCheckpoint(parent)
+	for _, b := range blocks {
+		if err := checkpoint.IncreaseVotes(b); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// applySupLinks verify the signatures of the supLinks to the checkpoint by the validators of its parent
+// checkpoint, the checkpoint is justified by the supLink signed by the majority of the validators from
+// a justified ancestor checkpoint, it returns the checkpoints whose status are changed
+func (c *LightChain) applySupLinks(target *state.Checkpoint, supLinks types.SupLinks) ([]*state.Checkpoint, error) {
+	parent, err := c.getCheckpoint(&target.ParentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := parent.EffectiveValidators()
+	affectedCheckpoints := []*state.Checkpoint{}
+	for _, supLink := range supLinks {
+		verifiedLink := &types.SupLink{SourceHeight: supLink.SourceHeight, SourceHash: supLink.SourceHash}
+		vote := &signer.Vote{SourceHeight: supLink.SourceHeight, SourceHash: supLink.SourceHash, TargetHeight: target.Height, TargetHash: target.Hash}
+		for _, validator := range validators {
+			signature := supLink.Signatures[validator.Order]
+			if len(signature) == 0 {
+				continue
+			}
+
+			if err := verifyVoteSignature(validator.PubKey, vote, signature); err != nil {
+				return nil, err
+			}
+
+			verifiedLink.Signatures[validator.Order] = signature
+		}
+
+		if target.Status != state.Unjustified || !verifiedLink.IsMajority(len(validators)) {
+			continue
+		}
+
+		source, err := c.ancestorCheckpoint(parent, &supLink.SourceHash)
+		if err != nil || source.Status < state.Justified {
+			continue
+		}
+
+		target.Status = state.Justified
+		affectedCheckpoints = append(affectedCheckpoints, target)
+		if source.Hash == parent.Hash {
+			source.Status = state.Finalized
+			affectedCheckpoints = append(affectedCheckpoints, source)
+		}
+	}
+	return affectedCheckpoints, nil
+}
+
+// ancestorCheckpoint find the checkpoint by the hash from the checkpoint and its ancestors
+func (c *LightChain) ancestorCheckpoint(checkpoint *state.Checkpoint, hash *bc.Hash) (*state.Checkpoint, error) {
+	for checkpoint.Hash != *hash {
+		if checkpoint.Height == 0 {
+			return nil, errors.WithDetailf(errLightBlockNotFound, "checkpoint %s isn't the ancestor", hash.String())
+		}
+
+		var err error
+		if checkpoint, err = c.getCheckpoint(&checkpoint.ParentHash); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoint, nil
+}
+
+func verifyVoteSignature(pubKey string, vote *signer.Vote, signature []byte) error {
+	message, err := vote.Message()
+	if err != nil {
+		return err
+	}
+
+	rawPubKey, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return err
+	}
+
+	var xPub chainkd.XPub
+	copy(xPub[:], rawPubKey)
+	if !xPub.Verify(message, signature) {
+		return errors.WithDetailf(errLightBadSupLink, "validator %s", pubKey)
+	}
+	return nil
+}
+
+// BestBlockHeader return the header of the light chain tail block
+func (c *LightChain) BestBlockHeader() *types.BlockHeader {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return c.bestHeader
+}
+
+// BestBlockHeight return the height of the light chain tail block
+func (c *LightChain) BestBlockHeight() uint64 {
+	return c.BestBlockHeader().Height
+}
+
+// LastJustifiedHeader return the header of the last justified checkpoint
+func (c *LightChain) LastJustifiedHeader() (*types.BlockHeader, error) {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return c.justifiedHeader, nil
+}
+
+// BaseBlockHeight return the lowest height of the light chain, it always starts from the genesis
+func (c *LightChain) BaseBlockHeight() uint64 {
+	return 0
+}
+
+// BlockExist check whether the block is saved in the light chain
+func (c *LightChain) BlockExist(hash *bc.Hash) bool {
+	return c.db.Get(calcLightBlockKey(hash)) != nil
+}
+
+// GetBlockByHash return the block with the related transactions by the given hash
+func (c *LightChain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
+	rawBlock := c.db.Get(calcLightBlockKey(hash))
+	if rawBlock == nil {
+		return nil, errors.WithDetailf(errLightBlockNotFound, "hash %s", hash.String())
+	}
+
+	block := &types.Block{}
+	if err := block.UnmarshalText(rawBlock); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockByHeight return the main chain block with the related transactions by the given height
+func (c *LightChain) GetBlockByHeight(height uint64) (*types.Block, error) {
+	hash, err := c.getMainChainHash(height)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetBlockByHash(hash)
+}
+
+// GetHeaderByHash return the block header by the given hash
+func (c *LightChain) GetHeaderByHash(hash *bc.Hash) (*types.BlockHeader, error) {
+	block, err := c.GetBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &block.BlockHeader, nil
+}
+
+// GetHeaderByHeight return the main chain block header by the given height
+func (c *LightChain) GetHeaderByHeight(height uint64) (*types.BlockHeader, error) {
+	block, err := c.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &block.BlockHeader, nil
+}
+
+// InMainChain checks whether the block is in the main chain
+func (c *LightChain) InMainChain(hash bc.Hash) bool {
+	header, err := c.GetHeaderByHash(&hash)
+	if err != nil {
+		return false
+	}
+
+	mainHash, err := c.getMainChainHash(header.Height)
+	return err == nil && *mainHash == hash
+}
+
+// BlockWaiter returns a channel that waits for the block at the given height.
+func (c *LightChain) BlockWaiter(height uint64) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	go func() {
+		c.cond.L.Lock()
+		defer c.cond.L.Unlock()
+		for c.bestHeader.Height < height {
+			c.cond.Wait()
+		}
+		ch <- struct{}{}
+	}()
+
+	return ch
+}
+
+// ConnectBlock verify the block header by the validators of its parent checkpoint, then save the
+// block. The main chain is switched to the branch of the block when the branch has the higher
+// justified checkpoint, or the same justified checkpoint and the higher tail, otherwise the block
+// is only kept for the branch. The block forked before the last justified checkpoint is rejected,
+// so is the block more than 2 epochs higher than the justified checkpoint, since it isn't backed
+// by the supLinks signed by the validators.
+func (c *LightChain) ConnectBlock(block *types.Block) error {
+	parent, err := c.GetHeaderByHash(&block.PreviousBlockHash)
+	if err != nil {
+		return errLightOrphanBlock
+	}
+
+	if block.Height != parent.Height+1 {
+		return errLightBadHeight
+	}
+
+	justifiedHeader, err := c.LastJustifiedHeader()
+	if err != nil {
+		return err
+	}
+
+	if block.Height > justifiedHeader.Height+2*consensus.ActiveNetParams.BlocksOfEpoch {
+		return errors.WithDetailf(errLightUnjustified, "block height %d, justified height %d", block.Height, justifiedHeader.Height)
+	}
+
+	parentCheckpoint, err := c.parentCheckpoint(&block.PreviousBlockHash)
+	if err != nil {
+		return err
+	}
+
+	if err := validation.ValidateBlockHeader(&block.BlockHeader, parent, parentCheckpoint); err != nil {
+		return errors.Sub(errLightBadHeader, err)
+	}
+
+	var checkpoints []*state.Checkpoint
+	if block.Height%consensus.ActiveNetParams.BlocksOfEpoch == 0 {
+		checkpoint, err := c.newCheckpoint(parentCheckpoint, block)
+		if err != nil {
+			return err
+		}
+
+		if checkpoints, err = c.applySupLinks(checkpoint, block.SupLinks); err != nil {
+			return err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	branchJustified, err := c.branchJustifiedHeader(&block.BlockHeader, parentCheckpoint, checkpoints)
+	if err != nil {
+		return err
+	}
+
+	rawBlock, err := block.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	blockHash := block.Hash()
+	batch := c.db.NewBatch()
+	batch.Set(calcLightBlockKey(&blockHash), rawBlock)
+	if err := saveLightCheckpoints(batch, checkpoints...); err != nil {
+		return err
+	}
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	forkHeader := parent
+	branchHashes := []bc.Hash{blockHash}
+	for !c.InMainChain(forkHeader.Hash()) {
+		branchHashes = append(branchHashes, forkHeader.Hash())
+		if forkHeader, err = c.GetHeaderByHash(&forkHeader.PreviousBlockHash); err != nil {
+			return err
+		}
+	}
+
+	if forkHeader.Height < c.justifiedHeader.Height {
+		return errRollbackJustified
+	}
+
+	if branchJustified.Height < c.justifiedHeader.Height || branchJustified.Height == c.justifiedHeader.Height && block.Height <= c.bestHeader.Height {
+		batch.Write()
+		log.WithFields(log.Fields{"module": logModule, "height": block.Height, "hash": blockHash.String()}).Debug("light block is saved to the side branch")
+		return nil
+	}
+
+	for i, hash := range branchHashes {
+		batch.Set(calcLightMainChainKey(block.Height-uint64(i)), hash.Bytes())
+	}
+
+	for height := block.Height + 1; height <= c.bestHeader.Height; height++ {
+		batch.Delete(calcLightMainChainKey(height))
+	}
+
+	if err := saveLightChainStatus(batch, &lightChainStatus{BestHash: blockHash, JustifiedHash: branchJustified.Hash()}); err != nil {
+		return err
+	}
+
+	batch.Write()
+	c.bestHeader = &block.BlockHeader
+	c.justifiedHeader = branchJustified
+	log.WithFields(log.Fields{"module": logModule, "height": block.Height, "hash": blockHash.String(), "txs": len(block.Transactions)}).Debug("light chain best status has been update")
+	c.cond.Broadcast()
+	return nil
+}
+
+// branchJustifiedHeader return the header of the last justified checkpoint in the branch of the block,
+// the checkpoints are the ones connected with the block
+func (c *LightChain) branchJustifiedHeader(header *types.BlockHeader, parent *state.Checkpoint, checkpoints []*state.Checkpoint) (*types.BlockHeader, error) {
+	if len(checkpoints) > 0 && checkpoints[len(checkpoints)-1].Status >= state.Justified {
+		return header, nil
+	}
+
+	checkpoint := parent
+	for checkpoint.Status < state.Justified {
+		var err error
+		if checkpoint, err = c.getCheckpoint(&checkpoint.ParentHash); err != nil {
+			return nil, err
+		}
+	}
+	return c.GetHeaderByHash(&checkpoint.Hash)
+}
+
+// ApplySupLinks verify the supLinks of the checkpoint header in the main chain, the last justified
+// checkpoint only moves forward when the checkpoint is justified by the verified supLinks
+func (c *LightChain) ApplySupLinks(header *types.BlockHeader) error {
+	hash := header.Hash()
+	if header.Height%consensus.ActiveNetParams.BlocksOfEpoch != 0 || !c.InMainChain(hash) {
+		return errors.WithDetailf(errLightBlockNotFound, "checkpoint %s isn't in the main chain", hash.String())
+	}
+
+	checkpoint, err := c.getCheckpoint(&hash)
+	if err != nil {
+		return err
+	}
+
+	checkpoints, err := c.applySupLinks(checkpoint, header.SupLinks)
+	if err != nil || len(checkpoints) == 0 {
+		return err
+	}
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	batch := c.db.NewBatch()
+	if err := saveLightCheckpoints(batch, checkpoints...); err != nil {
+		return err
+	}
+
+	justifiedHeader := c.justifiedHeader
+	if header.Height > justifiedHeader.Height {
+		justifiedHeader = header
+	}
+
+	if err := saveLightChainStatus(batch, &lightChainStatus{BestHash: c.bestHeader.Hash(), JustifiedHash: justifiedHeader.Hash()}); err != nil {
+		return err
+	}
+
+	batch.Write()
+	c.justifiedHeader = justifiedHeader
+	return nil
+}
+
+func (c *LightChain) getMainChainHash(height uint64) (*bc.Hash, error) {
+	rawHash := c.db.Get(calcLightMainChainKey(height))
+	if rawHash == nil {
+		return nil, errors.WithDetailf(errLightBlockNotFound, "height %d", height)
+	}
+
+	var b32 [32]byte
+	copy(b32[:], rawHash)
+	hash := bc.NewHash(b32)
+	return &hash, nil
+}
@@ -0,0 +1,405 @@
+package chainmgr
+
+import (
+	"encoding/hex"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/consensus"
+	"coingod/errors"
+	msgs "coingod/netsync/messages"
+	"coingod/netsync/peers"
+	"coingod/p2p/security"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+var (
+	errBadTxMerkleRoot      = errors.New("transactions mismatch with the merkle root of the header")
+	errMismatchedBlock      = errors.New("block mismatch with the header")
+	errBadLightHeaders      = errors.New("invalid headers for light sync")
+	errMismatchedCheckpoint = errors.New("checkpoint header mismatch with the light chain")
+)
+
+// LightWallet is the interface for the wallet of the light node
+type LightWallet interface {
+	// ControlPrograms return the programs whose transactions are kept by the light chain
+	ControlPrograms() ([][]byte, error)
+}
+
+// lightKeeper syncs the block headers from the best peer, and fetches the full block of each
+// header. All the transactions are verified by the merkle root of the header, so the votes
+// applied to the checkpoints are complete, while only the transactions of the wallet and the
+// vote transactions are kept in the light chain.
+type lightKeeper struct {
+	chain  *LightChain
+	wallet LightWallet
+	peers  *peers.PeerSet
+
+	headersProcessCh chan *headersMsg
+	blockProcessCh   chan *blockMsg
+	quit             chan struct{}
+}
+
+func newLightKeeper(chain *LightChain, wallet LightWallet, peers *peers.PeerSet) *lightKeeper {
+	return &lightKeeper{
+		chain:            chain,
+		wallet:           wallet,
+		peers:            peers,
+		headersProcessCh: make(chan *headersMsg, headersProcessChSize),
+		blockProcessCh:   make(chan *blockMsg, blockProcessChSize),
+		quit:             make(chan struct{}),
+	}
+}
+
+// verifyBlockTransactions check the transactions of the block are exactly the ones committed by the header
+func verifyBlockTransactions(block *types.Block) error {
+	bcTxs := make([]*bc.Tx, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		bcTxs = append(bcTxs, tx.Tx)
+	}
+
+	merkleRoot, err := types.TxMerkleRoot(bcTxs)
+	if err != nil {
+		return err
+	}
+
+	if merkleRoot != block.TransactionsMerkleRoot {
+		return errBadTxMerkleRoot
+	}
+	return nil
+}
+
+// lightBlock return the block which only contains the transactions of the programs and the vote transactions
+func lightBlock(block *types.Block, programs map[string]bool) *types.Block {
+	txs := []*types.Tx{}
+	for _, tx := range block.Transactions {
+		if isVoteTx(tx) || isRelatedTx(tx, programs) {
+			txs = append(txs, tx)
+		}
+	}
+	return &types.Block{BlockHeader: block.BlockHeader, Transactions: txs}
+}
+
+func isRelatedTx(tx *types.Tx, programs map[string]bool) bool {
+	for _, input := range tx.Inputs {
+		if inp, ok := input.TypedInput.(*types.SpendInput); ok && programs[hex.EncodeToString(inp.ControlProgram)] {
+			return true
+		}
+	}
+	for _, output := range tx.Outputs {
+		if programs[hex.EncodeToString(output.ControlProgram)] {
+			return true
+		}
+	}
+	return false
+}
+
+func isVoteTx(tx *types.Tx) bool {
+	for _, input := range tx.Inputs {
+		if _, ok := input.TypedInput.(*types.VetoInput); ok {
+			return true
+		}
+	}
+	for _, output := range tx.Outputs {
+		if _, ok := output.TypedOutput.(*types.VoteOutput); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLightHeaders check the headers are continuous, the first header is the fork point
+// which must exist in the light chain and not lower than the justified checkpoint unless
+// the following headers are already in the main chain. The signatures of each header are
+// verified by the light chain when the block is connected, since the validators of the
+// checkpoint depend on the votes of the previous blocks.
+func checkLightHeaders(chain *LightChain, headers []*types.BlockHeader) error {
+	if len(headers) == 0 {
+		return errors.WithDetail(errBadLightHeaders, "empty headers")
+	}
+
+	startHash := headers[0].Hash()
+	if !chain.InMainChain(startHash) {
+		return errors.WithDetail(errBadLightHeaders, "fork point is not in the main chain")
+	}
+
+	justifiedHeader, err := chain.LastJustifiedHeader()
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(headers); i++ {
+		prev, header := headers[i-1], headers[i]
+		if header.PreviousBlockHash != prev.Hash() || header.Height != prev.Height+1 {
+			return errors.WithDetailf(errBadLightHeaders, "header %d isn't continuous", header.Height)
+		}
+
+		if header.Timestamp < prev.Timestamp+consensus.ActiveNetParams.BlockTimeInterval {
+			return errors.WithDetailf(errBadLightHeaders, "header %d has bad timestamp", header.Height)
+		}
+
+		if prev.Height < justifiedHeader.Height && !chain.InMainChain(header.Hash()) {
+			return errors.Wrap(errRollbackJustified, "headers fork before the justified checkpoint")
+		}
+	}
+	return nil
+}
+
+// blockLocator return the hashes of the main chain from the tail, the step grows exponentially
+func (lk *lightKeeper) blockLocator() []*bc.Hash {
+	header := lk.chain.BestBlockHeader()
+	locator := []*bc.Hash{}
+	step := uint64(1)
+
+	for header != nil {
+		headerHash := header.Hash()
+		locator = append(locator, &headerHash)
+		if header.Height == 0 {
+			break
+		}
+
+		var err error
+		if header.Height < step {
+			header, err = lk.chain.GetHeaderByHeight(0)
+		} else {
+			header, err = lk.chain.GetHeaderByHeight(header.Height - step)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Error("lightKeeper fail on get blockLocator")
+			break
+		}
+
+		if len(locator) >= 9 {
+			step *= 2
+		}
+	}
+	return locator
+}
+
+func (lk *lightKeeper) processHeaders(peerID string, headers []*types.BlockHeader) {
+	lk.headersProcessCh <- &headersMsg{headers: headers, peerID: peerID}
+}
+
+func (lk *lightKeeper) processBlock(peerID string, block *types.Block) {
+	lk.blockProcessCh <- &blockMsg{block: block, peerID: peerID}
+}
+
+func (lk *lightKeeper) processBlockNotFound(peerID string, msg *msgs.BlockNotFoundMessage) {
+	lk.blockProcessCh <- &blockMsg{notFound: msg, peerID: peerID}
+}
+
+func (lk *lightKeeper) requireHeaders(peer *peers.Peer, locator []*bc.Hash, stopHash *bc.Hash) ([]*types.BlockHeader, error) {
+	if ok := peer.GetHeaders(locator, stopHash, 0); !ok {
+		return nil, errSendMsg
+	}
+
+	timeout := time.NewTimer(requireHeadersTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case msg := <-lk.headersProcessCh:
+			if msg.peerID != peer.ID() {
+				continue
+			}
+			return msg.headers, nil
+		case <-timeout.C:
+			return nil, errors.Wrap(errRequestTimeout, "requireHeaders")
+		case <-lk.quit:
+			return nil, errPeerDropped
+		}
+	}
+}
+
+func (lk *lightKeeper) requireBlock(peer *peers.Peer, header *types.BlockHeader) (*types.Block, error) {
+	hash := header.Hash()
+	if ok := peer.GetBlockByHash(&hash); !ok {
+		return nil, errSendMsg
+	}
+
+	timeout := time.NewTimer(requireBlockTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case msg := <-lk.blockProcessCh:
+			if msg.peerID != peer.ID() {
+				continue
+			}
+
+			if msg.notFound != nil {
+				if *msg.notFound.GetHash() == hash {
+					return nil, errPrunedBlocks
+				}
+				continue
+			}
+
+			if msg.block.Hash() != hash {
+				return nil, errMismatchedBlock
+			}
+
+			if err := verifyBlockTransactions(msg.block); err != nil {
+				return nil, err
+			}
+			return msg.block, nil
+		case <-timeout.C:
+			return nil, errors.Wrap(errRequestTimeout, "requireBlock")
+		case <-lk.quit:
+			return nil, errPeerDropped
+		}
+	}
+}
+
+// updateJustified fetch the headers of the checkpoints above the justified checkpoint from the peer
+// again, since the supLinks are added to the header after the block is synced. The justified checkpoint
+// only moves forward by the verified supLinks of the headers.
+func (lk *lightKeeper) updateJustified(peer *peers.Peer) error {
+	justifiedHeader, err := lk.chain.LastJustifiedHeader()
+	if err != nil {
+		return err
+	}
+
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	for height := justifiedHeader.Height + blocksOfEpoch; height <= lk.chain.BestBlockHeight(); height += blocksOfEpoch {
+		header, err := lk.chain.GetHeaderByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		hash := header.Hash()
+		headers, err := lk.requireHeaders(peer, []*bc.Hash{&header.PreviousBlockHash}, &hash)
+		if err != nil {
+			lk.peers.ProcessIllegal(peer.ID(), security.LevelConnException, err.Error())
+			return err
+		}
+
+		// the peer may be on the other branch, it isn't punished for the mismatched checkpoint
+		if len(headers) == 0 || headers[len(headers)-1].Hash() != hash {
+			return errMismatchedCheckpoint
+		}
+
+		if err := lk.chain.ApplySupLinks(headers[len(headers)-1]); err != nil {
+			if errors.Root(err) == errLightBadSupLink {
+				lk.peers.ProcessIllegal(peer.ID(), security.LevelMsgIllegal, err.Error())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (lk *lightKeeper) startSync() bool {
+	// the pruned peers can't serve the blocks of the history
+	peer := lk.peers.BestPeerFrom(consensus.SFFullNode, lk.chain.BestBlockHeight()+1)
+	if peer == nil {
+		log.WithFields(log.Fields{"module": logModule}).Debug("can't find light sync peer")
+		return false
+	}
+
+	if err := lk.updateJustified(peer); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on update light chain justified checkpoint")
+		return false
+	}
+
+	bestHash := peer.BestHash()
+	if bestHash == nil || peer.Height() <= lk.chain.BestBlockHeight() {
+		return false
+	}
+
+	if err := lk.lightBlockSync(peer, bestHash); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on light block sync")
+		return false
+	}
+
+	if err := lk.updateJustified(peer); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on update light chain justified checkpoint")
+	}
+	return true
+}
+
+func (lk *lightKeeper) lightBlockSync(peer *peers.Peer, stopHash *bc.Hash) error {
+	headers, err := lk.requireHeaders(peer, lk.blockLocator(), stopHash)
+	if err != nil {
+		lk.peers.ProcessIllegal(peer.ID(), security.LevelConnException, err.Error())
+		return err
+	}
+
+	if err := checkLightHeaders(lk.chain, headers); err != nil {
+		lk.peers.ProcessIllegal(peer.ID(), security.LevelMsgIllegal, err.Error())
+		return err
+	}
+
+	rawPrograms, err := lk.wallet.ControlPrograms()
+	if err != nil {
+		return err
+	}
+
+	programs := make(map[string]bool, len(rawPrograms))
+	for _, program := range rawPrograms {
+		programs[hex.EncodeToString(program)] = true
+	}
+
+	for _, header := range headers[1:] {
+		// the block of the other branch is kept by the light chain as well
+		if hash := header.Hash(); lk.chain.BlockExist(&hash) {
+			continue
+		}
+
+		block, err := lk.requireBlock(peer, header)
+		if errors.Root(err) == errBadTxMerkleRoot || err == errMismatchedBlock {
+			lk.peers.ProcessIllegal(peer.ID(), security.LevelMsgIllegal, err.Error())
+			return err
+		} else if err == errPrunedBlocks {
+			return err
+		} else if err != nil {
+			lk.peers.ProcessIllegal(peer.ID(), security.LevelConnException, err.Error())
+			return err
+		}
+
+		if err := lk.chain.ConnectBlock(lightBlock(block, programs)); errors.Root(err) == errLightUnjustified {
+			// the following headers can be synced after the checkpoint is justified
+			break
+		} else if err != nil {
+			lk.peers.ProcessIllegal(peer.ID(), security.LevelMsgIllegal, err.Error())
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "height": lk.chain.BestBlockHeight()}).Info("light sync success")
+	return nil
+}
+
+func (lk *lightKeeper) start() {
+	go lk.syncWorker()
+}
+
+func (lk *lightKeeper) stop() {
+	close(lk.quit)
+}
+
+func (lk *lightKeeper) syncWorker() {
+	syncTicker := time.NewTicker(syncCycle)
+	defer syncTicker.Stop()
+
+	for {
+		select {
+		case <-syncTicker.C:
+			if update := lk.startSync(); !update {
+				continue
+			}
+
+			lastJustifiedHeader, err := lk.chain.LastJustifiedHeader()
+			if err != nil {
+				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail get last just justified header")
+			}
+
+			if err := lk.peers.BroadcastNewStatus(lk.chain.BestBlockHeader(), lastJustifiedHeader); err != nil {
+				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail on light syncWorker broadcast new status")
+			}
+		case <-lk.quit:
+			return
+		}
+	}
+}
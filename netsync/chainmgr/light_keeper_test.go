@@ -0,0 +1,331 @@
+package chainmgr
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"coingod/config"
+	"coingod/consensus"
+	"coingod/crypto/ed25519/chainkd"
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+	"coingod/signer"
+)
+
+func TestVerifyBlockTransactions(t *testing.T) {
+	txs, bcTxs := mockTxs(7)
+	otherTxs, _ := mockTxs(1)
+	merkleRoot, err := types.TxMerkleRoot(bcTxs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc    string
+		txs     []*types.Tx
+		wantErr error
+	}{
+		{desc: "all the txs", txs: txs},
+		{desc: "tx is missing", txs: append([]*types.Tx{txs[0]}, txs[2:]...), wantErr: errBadTxMerkleRoot},
+		{desc: "tx is replaced", txs: append(append([]*types.Tx{}, txs[:6]...), otherTxs...), wantErr: errBadTxMerkleRoot},
+		{desc: "txs are reordered", txs: append(append([]*types.Tx{}, txs[1:]...), txs[0]), wantErr: errBadTxMerkleRoot},
+	}
+
+	for i, c := range cases {
+		block := &types.Block{BlockHeader: types.BlockHeader{BlockCommitment: types.BlockCommitment{TransactionsMerkleRoot: merkleRoot}}, Transactions: c.txs}
+		if err := verifyBlockTransactions(block); err != c.wantErr {
+			t.Errorf("case %d(%s): got err %v, want %v", i, c.desc, err, c.wantErr)
+		}
+	}
+}
+
+func TestLightBlock(t *testing.T) {
+	assetID := bc.NewAssetID([32]byte{1})
+	txs := []*types.Tx{
+		types.NewTx(types.TxData{
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, assetID, 5, 0, []byte("walletProgram"), nil)},
+			Outputs: []*types.TxOutput{types.NewOriginalTxOutput(assetID, 5, []byte("otherProgram"), nil)},
+		}),
+		types.NewTx(types.TxData{
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 2}, assetID, 5, 0, []byte("otherProgram"), nil)},
+			Outputs: []*types.TxOutput{types.NewOriginalTxOutput(assetID, 5, []byte("otherProgram"), nil)},
+		}),
+		types.NewTx(types.TxData{
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 3}, assetID, 5, 0, []byte("otherProgram"), nil)},
+			Outputs: []*types.TxOutput{types.NewOriginalTxOutput(assetID, 5, []byte("walletProgram"), nil)},
+		}),
+		types.NewTx(types.TxData{
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 4}, assetID, 5, 0, []byte("otherProgram"), nil)},
+			Outputs: []*types.TxOutput{types.NewVoteOutput(assetID, 5, []byte("otherProgram"), []byte("vote"), nil)},
+		}),
+	}
+
+	block := lightBlock(&types.Block{BlockHeader: types.BlockHeader{Height: 1}, Transactions: txs}, map[string]bool{hex.EncodeToString([]byte("walletProgram")): true})
+	wantTxs := []*types.Tx{txs[0], txs[2], txs[3]}
+	if block.Height != 1 || len(block.Transactions) != len(wantTxs) {
+		t.Fatalf("got light block %d with %d txs, want %d txs", block.Height, len(block.Transactions), len(wantTxs))
+	}
+
+	for i, tx := range wantTxs {
+		if block.Transactions[i].ID != tx.ID {
+			t.Errorf("tx %d: got %v, want %v", i, block.Transactions[i].ID, tx.ID)
+		}
+	}
+}
+
+type lightValidators struct {
+	xprvs []chainkd.XPrv
+}
+
+// newLightValidators replace the federation validators by the generated keys, the returned
+// function restores the federation validators
+func newLightValidators(t *testing.T, num int) (*lightValidators, func()) {
+	federationXpubs := consensus.ActiveNetParams.FederationXpubs
+	validators := &lightValidators{}
+	xpubs := []chainkd.XPub{}
+	for i := 0; i < num; i++ {
+		xprv, xpub, err := chainkd.NewXKeys(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		validators.xprvs = append(validators.xprvs, xprv)
+		xpubs = append(xpubs, xpub)
+	}
+
+	consensus.ActiveNetParams.FederationXpubs = xpubs
+	return validators, func() { consensus.ActiveNetParams.FederationXpubs = federationXpubs }
+}
+
+func (v *lightValidators) xprv(pubKey string) chainkd.XPrv {
+	for _, xprv := range v.xprvs {
+		if xprv.XPub().String() == pubKey {
+			return xprv
+		}
+	}
+	return chainkd.XPrv{}
+}
+
+// newLightBlock create the block signed by the validator of its parent checkpoint in the light chain
+func (v *lightValidators) newLightBlock(t *testing.T, chain *LightChain, parent *types.BlockHeader, timeSkip uint64) *types.Block {
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Version:           1,
+			Height:            parent.Height + 1,
+			PreviousBlockHash: parent.Hash(),
+			Timestamp:         parent.Timestamp + consensus.ActiveNetParams.BlockTimeInterval*timeSkip,
+		},
+	}
+
+	checkpoint, err := chain.parentCheckpoint(&block.PreviousBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockHash := block.Hash()
+	block.BlockWitness = v.xprv(checkpoint.GetValidator(block.Timestamp).PubKey).Sign(blockHash.Bytes())
+	return block
+}
+
+// connectLightBlocks connect the blocks following the parent to the light chain
+func (v *lightValidators) connectLightBlocks(t *testing.T, chain *LightChain, parent *types.BlockHeader, num int, timeSkip uint64) []*types.Block {
+	blocks := []*types.Block{}
+	for i := 0; i < num; i++ {
+		block := v.newLightBlock(t, chain, parent, timeSkip)
+		if err := chain.ConnectBlock(block); err != nil {
+			t.Fatal(err)
+		}
+
+		blocks = append(blocks, block)
+		parent = &block.BlockHeader
+	}
+	return blocks
+}
+
+// supLink create the supLink from the source to the target signed by the federation validators with the given orders
+func (v *lightValidators) supLink(source, target *types.BlockHeader, orders ...int) *types.SupLink {
+	supLink := &types.SupLink{SourceHeight: source.Height, SourceHash: source.Hash()}
+	vote := &signer.Vote{SourceHeight: source.Height, SourceHash: source.Hash(), TargetHeight: target.Height, TargetHash: target.Hash()}
+	message, _ := vote.Message()
+	for _, order := range orders {
+		supLink.Signatures[order] = v.xprvs[order].Sign(message)
+	}
+	return supLink
+}
+
+func TestLightChainConnectBlock(t *testing.T) {
+	validators, restore := newLightValidators(t, 1)
+	defer restore()
+
+	chain, err := NewLightChain(dbm.NewMemDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis := config.GenesisBlock()
+	if chain.BestBlockHeight() != 0 || !chain.InMainChain(genesis.Hash()) {
+		t.Fatal("light chain isn't initialized with the genesis block")
+	}
+
+	mainBlocks := validators.connectLightBlocks(t, chain, &genesis.BlockHeader, 4, 1)
+	if chain.BestBlockHeight() != 4 {
+		t.Fatalf("got best height %d, want 4", chain.BestBlockHeight())
+	}
+
+	orphan := &types.Block{BlockHeader: types.BlockHeader{Version: 1, Height: 6, PreviousBlockHash: bc.Hash{V0: 1}}}
+	if err := chain.ConnectBlock(orphan); err != errLightOrphanBlock {
+		t.Fatalf("got err %v, want %v", err, errLightOrphanBlock)
+	}
+
+	unsigned := validators.newLightBlock(t, chain, &mainBlocks[3].BlockHeader, 1)
+	unsigned.BlockWitness = nil
+	if err := chain.ConnectBlock(unsigned); errors.Root(err) != errLightBadHeader {
+		t.Fatalf("got err %v, want %v", err, errLightBadHeader)
+	}
+
+	// switch to the fork branch from height 2
+	forkBlocks := validators.connectLightBlocks(t, chain, &mainBlocks[1].BlockHeader, 3, 2)
+	for _, block := range mainBlocks[2:] {
+		if chain.InMainChain(block.Hash()) {
+			t.Errorf("block %d should be rollback", block.Height)
+		}
+	}
+
+	wantBlocks := append([]*types.Block{mainBlocks[0], mainBlocks[1]}, forkBlocks...)
+	for _, block := range wantBlocks {
+		got, err := chain.GetBlockByHeight(block.Height)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Hash() != block.Hash() {
+			t.Errorf("height %d: got block %v, want %v", block.Height, got.Hash(), block.Hash())
+		}
+	}
+
+	if chain.BestBlockHeight() != 5 {
+		t.Errorf("got best height %d, want 5", chain.BestBlockHeight())
+	}
+}
+
+func TestLightChainJustifiedBySupLinks(t *testing.T) {
+	validators, restore := newLightValidators(t, 3)
+	defer restore()
+
+	chain, err := NewLightChain(dbm.NewMemDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis := config.GenesisBlock()
+	firstBlocks := validators.connectLightBlocks(t, chain, &genesis.BlockHeader, 9, 1)
+
+	// the checkpoint with the supLink signed by the minority isn't justified
+	checkpoint := validators.newLightBlock(t, chain, &firstBlocks[8].BlockHeader, 1)
+	checkpoint.SupLinks = types.SupLinks{validators.supLink(&genesis.BlockHeader, &checkpoint.BlockHeader, 0)}
+	if err := chain.ConnectBlock(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if justified, _ := chain.LastJustifiedHeader(); justified.Height != 0 {
+		t.Fatalf("got justified height %d, want 0", justified.Height)
+	}
+
+	blocks := validators.connectLightBlocks(t, chain, &checkpoint.BlockHeader, 10, 1)
+	unbacked := validators.newLightBlock(t, chain, &blocks[9].BlockHeader, 1)
+	if err := chain.ConnectBlock(unbacked); errors.Root(err) != errLightUnjustified {
+		t.Fatalf("got err %v, want %v", err, errLightUnjustified)
+	}
+
+	forged := checkpoint.BlockHeader
+	forged.SupLinks = types.SupLinks{validators.supLink(&genesis.BlockHeader, &checkpoint.BlockHeader, 0, 1, 2)}
+	forged.SupLinks[0].Signatures[2] = forged.SupLinks[0].Signatures[1]
+	if err := chain.ApplySupLinks(&forged); errors.Root(err) != errLightBadSupLink {
+		t.Fatalf("got err %v, want %v", err, errLightBadSupLink)
+	}
+
+	if justified, _ := chain.LastJustifiedHeader(); justified.Height != 0 {
+		t.Fatalf("got justified height %d after the forged supLink, want 0", justified.Height)
+	}
+
+	header := checkpoint.BlockHeader
+	header.SupLinks = types.SupLinks{validators.supLink(&genesis.BlockHeader, &checkpoint.BlockHeader, 0, 1, 2)}
+	if err := chain.ApplySupLinks(&header); err != nil {
+		t.Fatal(err)
+	}
+
+	if justified, _ := chain.LastJustifiedHeader(); justified.Hash() != checkpoint.Hash() {
+		t.Fatalf("got justified height %d, want the checkpoint %d", justified.Height, checkpoint.Height)
+	}
+
+	genesisHash := genesis.Hash()
+	if source, err := chain.getCheckpoint(&genesisHash); err != nil || source.Status != state.Finalized {
+		t.Errorf("the source checkpoint of the direct child should be finalized, got %v err %v", source, err)
+	}
+
+	if err := chain.ConnectBlock(unbacked); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fork before the justified checkpoint is rejected
+	forkBlock := validators.newLightBlock(t, chain, &firstBlocks[7].BlockHeader, 2)
+	if err := chain.ConnectBlock(forkBlock); err != errRollbackJustified {
+		t.Fatalf("got err %v, want %v", err, errRollbackJustified)
+	}
+}
+
+func TestLightChainForkChoice(t *testing.T) {
+	validators, restore := newLightValidators(t, 1)
+	defer restore()
+
+	chain, err := NewLightChain(dbm.NewMemDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis := config.GenesisBlock()
+	mainBlocks := validators.connectLightBlocks(t, chain, &genesis.BlockHeader, 12, 1)
+
+	// the shorter branch is kept but the main chain isn't switched
+	sideBlocks := validators.connectLightBlocks(t, chain, &mainBlocks[7].BlockHeader, 1, 2)
+	for _, block := range sideBlocks {
+		hash := block.Hash()
+		if !chain.BlockExist(&hash) || chain.InMainChain(hash) {
+			t.Fatalf("side block %d should be saved out of the main chain", block.Height)
+		}
+	}
+
+	if best := chain.BestBlockHeader(); best.Hash() != mainBlocks[11].Hash() {
+		t.Fatalf("got best height %d, want the main chain tail %d", best.Height, mainBlocks[11].Height)
+	}
+
+	// the branch with the justified checkpoint wins even it's lower
+	checkpoint := validators.newLightBlock(t, chain, &sideBlocks[0].BlockHeader, 1)
+	checkpoint.SupLinks = types.SupLinks{validators.supLink(&genesis.BlockHeader, &checkpoint.BlockHeader, 0)}
+	if err := chain.ConnectBlock(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if best := chain.BestBlockHeader(); best.Hash() != checkpoint.Hash() {
+		t.Fatalf("got best height %d, want the justified checkpoint %d", best.Height, checkpoint.Height)
+	}
+
+	if justified, _ := chain.LastJustifiedHeader(); justified.Hash() != checkpoint.Hash() {
+		t.Fatalf("got justified height %d, want the checkpoint %d", justified.Height, checkpoint.Height)
+	}
+
+	for _, block := range mainBlocks[8:] {
+		if chain.InMainChain(block.Hash()) {
+			t.Errorf("block %d should be rollback", block.Height)
+		}
+	}
+
+	for _, block := range append(append([]*types.Block{}, mainBlocks[:8]...), sideBlocks...) {
+		if got, err := chain.GetHeaderByHeight(block.Height); err != nil || got.Hash() != block.Hash() {
+			t.Errorf("height %d: got header %v err %v, want %v", block.Height, got, err, block.Hash())
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"coingod/errors"
+	msgs "coingod/netsync/messages"
 	"coingod/netsync/peers"
 	"coingod/p2p/security"
 	"coingod/protocol/bc"
@@ -58,6 +59,7 @@ type msgFetcher struct {
 	blockProcessCh   chan *blockMsg
 	blocksProcessCh  chan *blocksMsg
 	headersProcessCh chan *headersMsg
+	// the blocks are nil when they are pruned by the peer
 	blocksMsgChanMap map[string]chan []*types.Block
 	mux              sync.RWMutex
 }
@@ -117,7 +119,10 @@ func (mf *msgFetcher) fetchBlocks(work *fetchBlocksWork, peerID string) ([]*type
 	startHash := work.startHeader.Hash()
 	stopHash := work.stopHeader.Hash()
 	blocks, err := mf.requireBlocks(peerID, []*bc.Hash{&startHash}, &stopHash)
-	if err != nil {
+	if err == errPrunedBlocks {
+		mf.syncPeers.delete(peerID)
+		return nil, err
+	} else if err != nil {
 		mf.syncPeers.delete(peerID)
 		mf.peers.ProcessIllegal(peerID, security.LevelConnException, err.Error())
 		return nil, err
@@ -258,6 +263,25 @@ func (mf *msgFetcher) processBlocks(peerID string, blocks []*types.Block) {
 	blocksMsgChan <- blocks
 }
 
+// processBlockNotFound stop waiting for the blocks which are pruned by the peer, the block is
+// required by the height and the blocks are required by the locator
+func (mf *msgFetcher) processBlockNotFound(peerID string, msg *msgs.BlockNotFoundMessage) {
+	if msg.Height != 0 {
+		mf.blockProcessCh <- &blockMsg{notFound: msg, peerID: peerID}
+		return
+	}
+
+	mf.mux.RLock()
+	blocksMsgChan, ok := mf.blocksMsgChanMap[peerID]
+	mf.mux.RUnlock()
+	if ok {
+		select {
+		case blocksMsgChan <- nil:
+		default:
+		}
+	}
+}
+
 func (mf *msgFetcher) processHeaders(peerID string, headers []*types.BlockHeader) {
 	mf.headersProcessCh <- &headersMsg{headers: headers, peerID: peerID}
 }
@@ -281,6 +305,12 @@ func (mf *msgFetcher) requireBlock(peerID string, height uint64) (*types.Block,
 			if msg.peerID != peerID {
 				continue
 			}
+			if msg.notFound != nil {
+				if msg.notFound.Height == height {
+					return nil, errPrunedBlocks
+				}
+				continue
+			}
 			if msg.block.Height != height {
 				continue
 			}
@@ -311,6 +341,9 @@ func (mf *msgFetcher) requireBlocks(peerID string, locator []*bc.Hash, stopHash
 	defer timeout.Stop()
 	select {
 	case blocks := <-receiveCh:
+		if blocks == nil {
+			return nil, errPrunedBlocks
+		}
 		return blocks, nil
 	case <-timeout.C:
 		return nil, errRequestBlocksTimeout
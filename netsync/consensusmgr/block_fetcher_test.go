@@ -8,6 +8,7 @@ import (
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"coingod/protocol/casper"
+	"coingod/protocol/state"
 )
 
 type peerMgr struct {
@@ -50,6 +51,10 @@ func (c *chain) ProcessBlockVerification(*casper.ValidCasperSignMsg) error {
 	return nil
 }
 
+func (c *chain) ProcessSlashingEvidence(*state.SlashingEvidence) error {
+	return nil
+}
+
 func TestBlockFetcher(t *testing.T) {
 	peers := peers.NewPeerSet(&peerMgr{})
 	testCase := []struct {
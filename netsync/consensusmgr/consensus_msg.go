@@ -3,6 +3,7 @@ package consensusmgr
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -11,11 +12,13 @@ import (
 	"coingod/netsync/peers"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
 )
 
 const (
-	blockSignatureByte = byte(0x10)
-	blockProposeByte   = byte(0x11)
+	blockSignatureByte   = byte(0x10)
+	blockProposeByte     = byte(0x11)
+	slashingEvidenceByte = byte(0x12)
 )
 
 // ConsensusMessage is a generic message for consensus reactor.
@@ -29,6 +32,7 @@ var _ = wire.RegisterInterface(
 	struct{ ConsensusMessage }{},
 	wire.ConcreteType{O: &BlockVerificationMsg{}, Byte: blockSignatureByte},
 	wire.ConcreteType{O: &BlockProposeMsg{}, Byte: blockProposeByte},
+	wire.ConcreteType{O: &SlashingEvidenceMsg{}, Byte: slashingEvidenceByte},
 )
 
 // decodeMessage decode msg
@@ -134,3 +138,59 @@ func (bp *BlockProposeMsg) BroadcastFilterTargetPeers(ps *peers.PeerSet) []strin
 
 	return ps.PeersWithoutBlock(block.Hash())
 }
+
+// SlashingEvidenceMsg slashing evidence message transferred between nodes.
+type SlashingEvidenceMsg struct {
+	RawEvidence []byte
+}
+
+// NewSlashingEvidenceMsg create new slashing evidence msg.
+func NewSlashingEvidenceMsg(evidence *state.SlashingEvidence) (ConsensusMessage, error) {
+	rawEvidence, err := json.Marshal(evidence)
+	if err != nil {
+		return nil, err
+	}
+	return &SlashingEvidenceMsg{RawEvidence: rawEvidence}, nil
+}
+
+// GetSlashingEvidence get slashing evidence from msg.
+func (s *SlashingEvidenceMsg) GetSlashingEvidence() (*state.SlashingEvidence, error) {
+	evidence := &state.SlashingEvidence{}
+	if err := json.Unmarshal(s.RawEvidence, evidence); err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
+
+func (s *SlashingEvidenceMsg) String() string {
+	evidence, err := s.GetSlashingEvidence()
+	if err != nil {
+		return "{err: wrong message}"
+	}
+	id := evidence.ID()
+	return fmt.Sprintf("{id: %s, pub_key: %s, type: %s}", id.String(), evidence.PubKey, evidence.Type.String())
+}
+
+// BroadcastMarkSendRecord mark send message record to prevent messages from being sent repeatedly.
+func (s *SlashingEvidenceMsg) BroadcastMarkSendRecord(ps *peers.PeerSet, peers []string) {
+	evidence, err := s.GetSlashingEvidence()
+	if err != nil {
+		return
+	}
+
+	id := evidence.ID()
+	for _, peer := range peers {
+		ps.MarkSlashingEvidence(peer, &id)
+	}
+}
+
+// BroadcastFilterTargetPeers filter target peers to filter the nodes that need to send messages.
+func (s *SlashingEvidenceMsg) BroadcastFilterTargetPeers(ps *peers.PeerSet) []string {
+	evidence, err := s.GetSlashingEvidence()
+	if err != nil {
+		return nil
+	}
+
+	id := evidence.ID()
+	return ps.PeersWithoutSlashingEvidence(&id)
+}
@@ -15,6 +15,7 @@ var _ = wire.RegisterInterface(
 	struct{ ConsensusMessage }{},
 	wire.ConcreteType{O: &BlockVerificationMsg{}, Byte: blockSignatureByte},
 	wire.ConcreteType{O: &BlockProposeMsg{}, Byte: blockProposeByte},
+	wire.ConcreteType{O: &SlashingEvidenceMsg{}, Byte: slashingEvidenceByte},
 )
 
 func TestDecodeMessage(t *testing.T) {
@@ -37,6 +38,12 @@ func TestDecodeMessage(t *testing.T) {
 			},
 			msgType: blockProposeByte,
 		},
+		{
+			msg: &SlashingEvidenceMsg{
+				RawEvidence: []byte{0x01, 0x02},
+			},
+			msgType: slashingEvidenceByte,
+		},
 	}
 	for i, c := range testCases {
 		binMsg := wire.BinaryBytes(struct{ ConsensusMessage }{c.msg})
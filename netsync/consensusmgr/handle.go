@@ -6,6 +6,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"coingod/errors"
 	"coingod/event"
 	"coingod/netsync/peers"
 	"coingod/p2p"
@@ -13,6 +14,7 @@ import (
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"coingod/protocol/casper"
+	"coingod/protocol/state"
 )
 
 // Switch is the interface for p2p switch.
@@ -26,6 +28,7 @@ type Chain interface {
 	GetHeaderByHash(*bc.Hash) (*types.BlockHeader, error)
 	ProcessBlock(*types.Block) (bool, error)
 	ProcessBlockVerification(*casper.ValidCasperSignMsg) error
+	ProcessSlashingEvidence(*state.SlashingEvidence) error
 }
 
 type Peers interface {
@@ -34,6 +37,7 @@ type Peers interface {
 	GetPeer(id string) *peers.Peer
 	MarkBlock(peerID string, hash *bc.Hash)
 	MarkBlockVerification(peerID string, signature []byte)
+	MarkSlashingEvidence(peerID string, id *bc.Hash)
 	ProcessIllegal(peerID string, level byte, reason string)
 	RemovePeer(peerID string)
 	SetStatus(peerID string, height uint64, hash *bc.Hash)
@@ -89,6 +93,9 @@ func (m *Manager) processMsg(peerID string, msgType byte, msg ConsensusMessage)
 	case *BlockVerificationMsg:
 		m.handleBlockVerificationMsg(peerID, msg)
 
+	case *SlashingEvidenceMsg:
+		m.handleSlashingEvidenceMsg(peerID, msg)
+
 	default:
 		logrus.WithFields(logrus.Fields{"module": logModule, "peer": peerID, "message_type": reflect.TypeOf(msg)}).Error("unhandled message type")
 	}
@@ -119,6 +126,24 @@ func (m *Manager) handleBlockVerificationMsg(peerID string, msg *BlockVerificati
 	}
 }
 
+func (m *Manager) handleSlashingEvidenceMsg(peerID string, msg *SlashingEvidenceMsg) {
+	evidence, err := msg.GetSlashingEvidence()
+	if err != nil {
+		m.peers.ProcessIllegal(peerID, security.LevelMsgIllegal, err.Error())
+		return
+	}
+
+	id := evidence.ID()
+	m.peers.MarkSlashingEvidence(peerID, &id)
+	// only the provably invalid evidence is punished, the validators and the blocks may be
+	// unknown to the node on another fork
+	if err := m.chain.ProcessSlashingEvidence(evidence); errors.Root(err) == casper.ErrInvalidSlashingEvidence {
+		m.peers.ProcessIllegal(peerID, security.LevelMsgIllegal, err.Error())
+	} else if err != nil {
+		logrus.WithFields(logrus.Fields{"module": logModule, "peer": peerID, "err": err}).Warning("fail on process slashing evidence")
+	}
+}
+
 func (m *Manager) blockProposeMsgBroadcastLoop() {
 	m.msgBroadcastLoop(event.NewProposedBlockEvent{}, func(data interface{}) (ConsensusMessage, error) {
 		ev := data.(event.NewProposedBlockEvent)
@@ -138,6 +163,13 @@ func (m *Manager) blockVerificationMsgBroadcastLoop() {
 	})
 }
 
+func (m *Manager) slashingEvidenceMsgBroadcastLoop() {
+	m.msgBroadcastLoop(state.SlashingEvidence{}, func(data interface{}) (ConsensusMessage, error) {
+		evidence := data.(state.SlashingEvidence)
+		return NewSlashingEvidenceMsg(&evidence)
+	})
+}
+
 func (m *Manager) msgBroadcastLoop(msgType interface{}, newMsg func(event interface{}) (ConsensusMessage, error)) {
 	subscribeType := reflect.TypeOf(msgType)
 	msgSub, err := m.eventDispatcher.Subscribe(msgType)
@@ -186,6 +218,7 @@ func (m *Manager) Start() error {
 	go m.blockFetcher.blockProcessorLoop()
 	go m.blockProposeMsgBroadcastLoop()
 	go m.blockVerificationMsgBroadcastLoop()
+	go m.slashingEvidenceMsgBroadcastLoop()
 	return nil
 }
 
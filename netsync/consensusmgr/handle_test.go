@@ -16,6 +16,7 @@ import (
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"coingod/protocol/casper"
+	"coingod/protocol/state"
 )
 
 type p2peer struct {
@@ -99,6 +100,10 @@ func (c *mockChain) ProcessBlockVerification(*casper.ValidCasperSignMsg) error {
 	return nil
 }
 
+func (c *mockChain) ProcessSlashingEvidence(*state.SlashingEvidence) error {
+	return nil
+}
+
 type mockPeers struct {
 	msgCount       *int
 	knownBlock     *bc.Hash
@@ -134,6 +139,9 @@ func (ps *mockPeers) MarkBlockVerification(peerID string, signature []byte) {
 	*ps.knownSignature = append(*ps.knownSignature, signature...)
 }
 
+func (ps *mockPeers) MarkSlashingEvidence(peerID string, id *bc.Hash) {
+}
+
 func (ps *mockPeers) ProcessIllegal(peerID string, level byte, reason string) {
 
 }
@@ -21,6 +21,7 @@ const (
 	HeadersResponseByte = byte(0x13)
 	BlocksRequestByte   = byte(0x14)
 	BlocksResponseByte  = byte(0x15)
+	BlockNotFoundByte   = byte(0x16)
 	StatusByte          = byte(0x21)
 	NewTransactionByte  = byte(0x30)
 	NewTransactionsByte = byte(0x31)
@@ -48,6 +49,7 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{&HeadersMessage{}, HeadersResponseByte},
 	wire.ConcreteType{&GetBlocksMessage{}, BlocksRequestByte},
 	wire.ConcreteType{&BlocksMessage{}, BlocksResponseByte},
+	wire.ConcreteType{&BlockNotFoundMessage{}, BlockNotFoundByte},
 	wire.ConcreteType{&StatusMessage{}, StatusByte},
 	wire.ConcreteType{&TransactionMessage{}, NewTransactionByte},
 	wire.ConcreteType{&TransactionsMessage{}, NewTransactionsByte},
@@ -268,6 +270,26 @@ func (m *BlocksMessage) String() string {
 	return fmt.Sprintf("{blocks_length: %d}", len(m.RawBlocks))
 }
 
+//BlockNotFoundMessage reply the request of the blocks whose transactions are pruned, the
+//height and the hash are taken from the request
+type BlockNotFoundMessage struct {
+	Height  uint64
+	RawHash [32]byte
+}
+
+//GetHash reutrn the hash of the request
+func (m *BlockNotFoundMessage) GetHash() *bc.Hash {
+	hash := bc.NewHash(m.RawHash)
+	return &hash
+}
+
+func (m *BlockNotFoundMessage) String() string {
+	if m.Height > 0 {
+		return fmt.Sprintf("{height: %d}", m.Height)
+	}
+	return fmt.Sprintf("{hash: %s}", hex.EncodeToString(m.RawHash[:]))
+}
+
 //StatusResponseMessage get status response msg
 type StatusMessage struct {
 	BestHeight      uint64
@@ -21,6 +21,7 @@ const (
 	maxKnownTxs           = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownSignatures    = 1024  // Maximum block signatures to keep in the known list (prevent DOS)
 	maxKnownBlocks        = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
+	maxKnownEvidences     = 1024  // Maximum slashing evidence ids to keep in the known list (prevent DOS)
 	maxFilterAddressSize  = 50
 	maxFilterAddressCount = 1000
 
@@ -86,6 +87,7 @@ type Peer struct {
 	knownTxs        *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks     *set.Set // Set of block hashes known to be known by this peer
 	knownSignatures *set.Set // Set of block signatures known to be known by this peer
+	knownEvidences  *set.Set // Set of slashing evidence ids known to be known by this peer
 	knownStatus     uint64   // Set of chain status known to be known by this peer
 	filterAdds      *set.Set // Set of addresses that the spv node cares about.
 }
@@ -97,6 +99,7 @@ func newPeer(basePeer BasePeer) *Peer {
 		knownTxs:        set.New(),
 		knownBlocks:     set.New(),
 		knownSignatures: set.New(),
+		knownEvidences:  set.New(),
 		filterAdds:      set.New(),
 	}
 }
@@ -115,6 +118,13 @@ func (p *Peer) JustifiedHeight() uint64 {
 	return p.justifiedHeight
 }
 
+func (p *Peer) BestHash() *bc.Hash {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	return p.bestHash
+}
+
 func (p *Peer) AddFilterAddress(address []byte) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -159,6 +169,12 @@ func (p *Peer) GetHeaders(locator []*bc.Hash, stopHash *bc.Hash, skip uint64) bo
 	return p.TrySend(msgs.BlockchainChannel, msg)
 }
 
+// GetBlockByHash request the block by the hash, the light node fetches the block of the header
+func (p *Peer) GetBlockByHash(hash *bc.Hash) bool {
+	msg := struct{ msgs.BlockchainMessage }{&msgs.GetBlockMessage{RawHash: hash.Byte32()}}
+	return p.TrySend(msgs.BlockchainChannel, msg)
+}
+
 func (p *Peer) GetPeerInfo() *PeerInfo {
 	p.mtx.RLock()
 	defer p.mtx.RUnlock()
@@ -216,6 +232,17 @@ func (p *Peer) isSPVNode() bool {
 	return !p.services.IsEnable(consensus.SFFullNode)
 }
 
+// IsPruned check whether the peer only keeps the transactions of the recent blocks
+func (p *Peer) IsPruned() bool {
+	return p.services.IsEnable(consensus.SFPruned)
+}
+
+// HasBlock check whether the peer can serve the transactions of the block at the height, the
+// pruned peer keeps the blocks above its justified checkpoint for sure
+func (p *Peer) HasBlock(height uint64) bool {
+	return !p.IsPruned() || height > p.JustifiedHeight()
+}
+
 func (p *Peer) MarkBlock(hash *bc.Hash) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -243,6 +270,16 @@ func (p *Peer) markSign(signature []byte) {
 	p.knownSignatures.Add(hex.EncodeToString(signature))
 }
 
+func (p *Peer) markSlashingEvidence(id *bc.Hash) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for p.knownEvidences.Size() >= maxKnownEvidences {
+		p.knownEvidences.Pop()
+	}
+	p.knownEvidences.Add(id.String())
+}
+
 func (p *Peer) markTransaction(hash *bc.Hash) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -284,6 +321,13 @@ func (p *Peer) SendBlocks(blocks []*types.Block) (bool, error) {
 	return true, nil
 }
 
+// SendBlockNotFound reply the request of the block whose transactions are pruned, so the
+// requester turns to the other peers instead of waiting for the timeout
+func (p *Peer) SendBlockNotFound(height uint64, hash *bc.Hash) bool {
+	msg := struct{ msgs.BlockchainMessage }{&msgs.BlockNotFoundMessage{Height: height, RawHash: hash.Byte32()}}
+	return p.TrySend(msgs.BlockchainChannel, msg)
+}
+
 func (p *Peer) SendHeaders(headers []*types.BlockHeader) (bool, error) {
 	msg, err := msgs.NewHeadersMessage(headers)
 	if err != nil {
@@ -408,12 +452,22 @@ func (ps *PeerSet) AddPeer(peer BasePeer) {
 }
 
 func (ps *PeerSet) BestPeer(flag consensus.ServiceFlag) *Peer {
+	return ps.bestPeer(flag, func(*Peer) bool { return true })
+}
+
+// BestPeerFrom find the best peer which can serve the blocks from the height, the pruned
+// peers are skipped for the blocks of the history
+func (ps *PeerSet) BestPeerFrom(flag consensus.ServiceFlag, height uint64) *Peer {
+	return ps.bestPeer(flag, func(p *Peer) bool { return p.HasBlock(height) })
+}
+
+func (ps *PeerSet) bestPeer(flag consensus.ServiceFlag, filter func(*Peer) bool) *Peer {
 	ps.mtx.RLock()
 	defer ps.mtx.RUnlock()
 
 	var bestPeer *Peer
 	for _, p := range ps.peers {
-		if !p.services.IsEnable(flag) {
+		if !p.services.IsEnable(flag) || !filter(p) {
 			continue
 		}
 		if bestPeer == nil || p.JustifiedHeight() > bestPeer.JustifiedHeight() ||
@@ -547,6 +601,14 @@ func (ps *PeerSet) MarkBlockVerification(peerID string, signature []byte) {
 	peer.markSign(signature)
 }
 
+func (ps *PeerSet) MarkSlashingEvidence(peerID string, id *bc.Hash) {
+	peer := ps.GetPeer(peerID)
+	if peer == nil {
+		return
+	}
+	peer.markSlashingEvidence(id)
+}
+
 func (ps *PeerSet) MarkStatus(peerID string, height uint64) {
 	peer := ps.GetPeer(peerID)
 	if peer == nil {
@@ -592,6 +654,19 @@ func (ps *PeerSet) PeersWithoutSignature(signature []byte) []string {
 	return peers
 }
 
+func (ps *PeerSet) PeersWithoutSlashingEvidence(id *bc.Hash) []string {
+	ps.mtx.RLock()
+	defer ps.mtx.RUnlock()
+
+	var peers []string
+	for _, peer := range ps.peers {
+		if !peer.knownEvidences.Has(id.String()) {
+			peers = append(peers, peer.ID())
+		}
+	}
+	return peers
+}
+
 func (ps *PeerSet) peersWithoutNewStatus(height uint64) []*Peer {
 	ps.mtx.RLock()
 	defer ps.mtx.RUnlock()
@@ -353,6 +353,29 @@ func TestIrreversibleStatus(t *testing.T) {
 	}
 }
 
+func TestBestPeerFrom(t *testing.T) {
+	ps := NewPeerSet(&basePeerSet{})
+	ps.AddPeer(&basePeer{id: peer1ID, serviceFlag: consensus.DefaultServices})
+	ps.AddPeer(&basePeer{id: peer2ID, serviceFlag: consensus.PrunedServices})
+	ps.SetJustifiedStatus(peer1ID, 1000, &block1000Hash)
+	ps.SetJustifiedStatus(peer2ID, 3000, &block3000Hash)
+
+	cases := []struct {
+		height uint64
+		want   string
+	}{
+		{height: 2000, want: peer1ID},
+		{height: 3000, want: peer1ID},
+		{height: 3001, want: peer2ID},
+	}
+
+	for i, c := range cases {
+		if peer := ps.BestPeerFrom(consensus.SFFullNode, c.height); peer.ID() != c.want {
+			t.Errorf("case %d: got best peer %s from height %d, want %s", i, peer.ID(), c.height, c.want)
+		}
+	}
+}
+
 func TestGetPeersByHeight(t *testing.T) {
 	ps := NewPeerSet(&basePeerSet{})
 	ps.AddPeer(&basePeer{id: peer1ID, serviceFlag: consensus.SFFullNode})
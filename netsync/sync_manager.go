@@ -2,6 +2,7 @@ package netsync
 
 import (
 	"errors"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -13,7 +14,9 @@ import (
 	"coingod/netsync/consensusmgr"
 	"coingod/netsync/peers"
 	"coingod/p2p"
+	"coingod/p2p/security"
 	"coingod/protocol"
+	"coingod/protocol/bc/types"
 )
 
 const (
@@ -28,6 +31,8 @@ var (
 type ChainMgr interface {
 	Start() error
 	IsCaughtUp() bool
+	FastSyncStatus() *chainmgr.FastSyncStatus
+	EnableLightMode(chain *chainmgr.LightChain, wallet chainmgr.LightWallet)
 	Stop()
 }
 
@@ -44,6 +49,13 @@ type Switch interface {
 	IsListening() bool
 	DialPeerWithAddress(addr *p2p.NetAddress) error
 	Peers() *p2p.PeerSet
+	NumPeers() (lan, outbound, inbound, dialing int)
+	BanPeer(ip string, duration time.Duration, reason string) error
+	UnbanPeer(ip string) error
+	ListBannedPeers() []*security.BannedPeer
+	ListPeerScores() []*security.PeerScore
+	ReloadAllowedPeers() error
+	AllowedPeers() (bool, []string)
 }
 
 //SyncManager Sync Manager is responsible for the business layer information synchronization
@@ -61,6 +73,11 @@ func NewSyncManager(config *config.Config, chain *protocol.Chain, txPool *protoc
 	if err != nil {
 		return nil, err
 	}
+
+	// the transactions below the base block are pruned or not restored from the snapshot
+	if chain.BaseBlockHeight() > 0 {
+		sw.EnablePruned()
+	}
 	peers := peers.NewPeerSet(sw)
 
 	chainManger, err := chainmgr.NewManager(config, sw, chain, txPool, dispatcher, peers, fastSyncDB)
@@ -77,6 +94,11 @@ func NewSyncManager(config *config.Config, chain *protocol.Chain, txPool *protoc
 	}, nil
 }
 
+// EnableLightMode make the node sync the block headers and the wallet related transactions only
+func (sm *SyncManager) EnableLightMode(chain *chainmgr.LightChain, wallet chainmgr.LightWallet) {
+	sm.chainMgr.EnableLightMode(chain, wallet)
+}
+
 // Start message sync manager service.
 func (sm *SyncManager) Start() error {
 	if err := sm.sw.Start(); err != nil {
@@ -115,6 +137,34 @@ func (sm *SyncManager) IsCaughtUp() bool {
 	return sm.chainMgr.IsCaughtUp()
 }
 
+// FastSyncStatus return the progress of the fast sync
+func (sm *SyncManager) FastSyncStatus() *chainmgr.FastSyncStatus {
+	return sm.chainMgr.FastSyncStatus()
+}
+
+// NumPeers return the num of the lan, outbound, inbound and dialing peers
+func (sm *SyncManager) NumPeers() (lan, outbound, inbound, dialing int) {
+	if sm.config.VaultMode {
+		return 0, 0, 0, 0
+	}
+	return sm.sw.NumPeers()
+}
+
+// TrafficStatus return the sum of the current send and receive rate of the connected
+// peers in bytes per second
+func (sm *SyncManager) TrafficStatus() (sendRate, recvRate int64) {
+	if sm.config.VaultMode {
+		return 0, 0
+	}
+
+	for _, peer := range sm.sw.Peers().List() {
+		sentStatus, receivedStatus := peer.TrafficStatus()
+		sendRate += sentStatus.CurRate
+		recvRate += receivedStatus.CurRate
+	}
+	return sendRate, recvRate
+}
+
 // PeerCount count the number of connected peers.
 func (sm *SyncManager) PeerCount() int {
 	if sm.config.VaultMode {
@@ -159,3 +209,40 @@ func (sm *SyncManager) StopPeer(peerID string) error {
 	sm.peers.RemovePeer(peerID)
 	return nil
 }
+
+// BanPeer ban the ip for the duration and disconnect the peers of the ip
+func (sm *SyncManager) BanPeer(ip string, duration time.Duration, reason string) error {
+	return sm.sw.BanPeer(ip, duration, reason)
+}
+
+// UnbanPeer remove the ip from the blacklist
+func (sm *SyncManager) UnbanPeer(ip string) error {
+	return sm.sw.UnbanPeer(ip)
+}
+
+// ListBannedPeers return the peers in the blacklist
+func (sm *SyncManager) ListBannedPeers() []*security.BannedPeer {
+	return sm.sw.ListBannedPeers()
+}
+
+// ListPeerScores return the ban scores of the misbehaving peers
+func (sm *SyncManager) ListPeerScores() []*security.PeerScore {
+	return sm.sw.ListPeerScores()
+}
+
+// ReloadAllowedPeers reload the allow list from the config and disconnect the peers
+// which are no longer allowed
+func (sm *SyncManager) ReloadAllowedPeers() error {
+	return sm.sw.ReloadAllowedPeers()
+}
+
+// AllowedPeers return whether the allow list is enabled and the allowed node public keys
+func (sm *SyncManager) AllowedPeers() (bool, []string) {
+	return sm.sw.AllowedPeers()
+}
+
+// BroadcastTx send the transaction to the peers directly, the light node relays the submitted
+// transactions without the transaction pool
+func (sm *SyncManager) BroadcastTx(tx *types.Tx) error {
+	return sm.peers.BroadcastTx(tx)
+}
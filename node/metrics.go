@@ -0,0 +1,68 @@
+package node
+
+import (
+	"coingod/metrics"
+	"coingod/netsync"
+	"coingod/protocol"
+)
+
+// registerMetrics expose the status of the chain, the mempool and the sync manager by
+// the /metrics endpoint, the values are read when the endpoint is scraped
+func registerMetrics(chain *protocol.Chain, txPool *protocol.TxPool, syncManager *netsync.SyncManager) {
+	metrics.NewGaugeFunc("coingod_chain_best_height", "Height of the best block", func() float64 {
+		return float64(chain.BestBlockHeight())
+	})
+	metrics.NewGaugeFunc("coingod_chain_justified_height", "Height of the last justified checkpoint", func() float64 {
+		return float64(chain.JustifiedHeight())
+	})
+	metrics.NewGaugeFunc("coingod_chain_finalized_height", "Height of the last finalized checkpoint", func() float64 {
+		return float64(chain.FinalizedHeight())
+	})
+	metrics.NewGaugeFunc("coingod_chain_orphan_blocks", "Num of the orphan blocks waiting for the parent", func() float64 {
+		return float64(chain.NumOrphanBlocks())
+	})
+
+	metrics.NewGaugeFunc("coingod_mempool_transactions", "Num of the transactions in the mempool", func() float64 {
+		poolSize, _ := txPool.Size()
+		return float64(poolSize)
+	})
+	metrics.NewGaugeFunc("coingod_mempool_orphan_transactions", "Num of the orphan transactions in the mempool", func() float64 {
+		_, orphanSize := txPool.Size()
+		return float64(orphanSize)
+	})
+
+	metrics.NewGaugeFunc("coingod_sync_caught_up", "Whether the node has caught up with the best peer", func() float64 {
+		if syncManager.IsCaughtUp() {
+			return 1
+		}
+		return 0
+	})
+	metrics.NewGaugeVecFunc("coingod_sync_fast_sync_height", "Start and target height of the running fast sync, zero when it's not running", []string{"bound"}, func() []metrics.GaugeValue {
+		status := syncManager.FastSyncStatus()
+		if !status.Syncing {
+			return []metrics.GaugeValue{{LabelValues: []string{"start"}}, {LabelValues: []string{"target"}}}
+		}
+
+		return []metrics.GaugeValue{
+			{LabelValues: []string{"start"}, Value: float64(status.StartHeight)},
+			{LabelValues: []string{"target"}, Value: float64(status.TargetHeight)},
+		}
+	})
+
+	metrics.NewGaugeVecFunc("coingod_p2p_peers", "Num of the peers by the connection direction", []string{"direction"}, func() []metrics.GaugeValue {
+		lan, outbound, inbound, dialing := syncManager.NumPeers()
+		return []metrics.GaugeValue{
+			{LabelValues: []string{"lan"}, Value: float64(lan)},
+			{LabelValues: []string{"outbound"}, Value: float64(outbound)},
+			{LabelValues: []string{"inbound"}, Value: float64(inbound)},
+			{LabelValues: []string{"dialing"}, Value: float64(dialing)},
+		}
+	})
+	metrics.NewGaugeVecFunc("coingod_p2p_traffic_rate_bytes", "Current send and receive rate of all the peers in bytes per second", []string{"direction"}, func() []metrics.GaugeValue {
+		sendRate, recvRate := syncManager.TrafficStatus()
+		return []metrics.GaugeValue{
+			{LabelValues: []string{"send"}, Value: float64(sendRate)},
+			{LabelValues: []string{"receive"}, Value: float64(recvRate)},
+		}
+	})
+}
@@ -26,10 +26,14 @@ import (
 	dbm "coingod/database/leveldb"
 	"coingod/env"
 	"coingod/event"
+	"coingod/explorer"
 	coingodLog "coingod/log"
 	"coingod/net/websocket"
 	"coingod/netsync"
+	"coingod/netsync/chainmgr"
 	"coingod/protocol"
+	"coingod/validator"
+	"coingod/validator/reward"
 	w "coingod/wallet"
 )
 
@@ -51,7 +55,11 @@ type Node struct {
 	notificationMgr *websocket.WSNotificationManager
 	api             *api.API
 	chain           *protocol.Chain
+	lightChain      *chainmgr.LightChain
 	traceService    *contract.TraceService
+	validatorStats  *validator.Tracker
+	voteReward      *reward.Distributor
+	explorer        *explorer.Indexer
 	blockProposer   *blockproposer.BlockProposer
 	miningEnable    bool
 }
@@ -68,19 +76,26 @@ func NewNode(config *cfg.Config) *Node {
 	}
 	coreDB := dbm.NewDB("core", config.DBBackend, config.DBDir())
 	store := database.NewStore(coreDB)
+	if config.Prune.Enable {
+		store.EnablePrune(config.Prune.Depth)
+	}
 
 	tokenDB := dbm.NewDB("accesstoken", config.DBBackend, config.DBDir())
 	accessTokens := accesstoken.NewStore(tokenDB)
 
 	dispatcher := event.NewDispatcher()
 	txPool := protocol.NewTxPool(store, dispatcher)
+	if config.Mempool.ReplaceByFee {
+		txPool.EnableReplaceByFee(config.Mempool.MinFeeBump)
+	}
 
 	chain, err := protocol.NewChain(store, txPool, dispatcher)
 	if err != nil {
 		cmn.Exit(cmn.Fmt("Failed to create chain structure: %v", err))
 	}
 
-	traceService := startTraceUpdater(chain, config)
+	traceService := startTraceUpdater(chain, config, dispatcher)
+	validatorStats := startValidatorTracker(chain, config)
 
 	var accounts *account.Manager
 	var assets *asset.Registry
@@ -91,13 +106,32 @@ func NewNode(config *cfg.Config) *Node {
 		cmn.Exit(cmn.Fmt("initialize HSM failed: %v", err))
 	}
 
+	var walletChain w.Chain = chain
+	var accountChain account.Chain = chain
+	var lightChain *chainmgr.LightChain
+	if config.LightMode {
+		if config.Wallet.Disable {
+			cmn.Exit("Light mode requires the wallet")
+		}
+
+		lightDB := dbm.NewDB("lightchain", config.DBBackend, config.DBDir())
+		if lightChain, err = chainmgr.NewLightChain(lightDB); err != nil {
+			cmn.Exit(cmn.Fmt("Failed to create light chain: %v", err))
+		}
+		walletChain = lightChain
+		accountChain = lightChain
+	}
+
 	if !config.Wallet.Disable {
 		walletDB := dbm.NewDB("wallet", config.DBBackend, config.DBDir())
-		accounts = account.NewManager(walletDB, chain)
-		assets = asset.NewRegistry(walletDB, chain)
+		accounts = account.NewManager(walletDB, accountChain)
+		assets = asset.NewRegistry(walletDB, accountChain)
 		contracts := contract.NewRegistry(walletDB)
-		wallet, err = w.NewWallet(walletDB, accounts, assets, contracts, hsm, chain, dispatcher, config.Wallet.TxIndex)
+		wallet, err = w.NewWallet(walletDB, accounts, assets, contracts, hsm, walletChain, dispatcher, config.Wallet.TxIndex)
 		if err != nil {
+			if config.LightMode {
+				cmn.Exit(cmn.Fmt("Light mode failed to init the wallet: %v", err))
+			}
 			log.WithFields(log.Fields{"module": logModule, "error": err}).Error("init NewWallet")
 		}
 
@@ -107,12 +141,39 @@ func NewNode(config *cfg.Config) *Node {
 		}
 	}
 
+	var voteReward *reward.Distributor
+	if config.VoteReward.Enable {
+		if wallet == nil {
+			cmn.Exit("Vote reward distribution requires the wallet")
+		}
+		if config.Prune.Enable {
+			cmn.Exit("Vote reward distribution requires the full blocks, it's not supported in the pruned mode")
+		}
+		voteReward = startVoteRewardDistributor(config, chain, accounts, hsm)
+	}
+
+	var explorerIndexer *explorer.Indexer
+	if config.Explorer.Enable {
+		if config.LightMode {
+			cmn.Exit("Explorer requires the full blocks, it's not supported in the light mode")
+		}
+		if config.Prune.Enable {
+			cmn.Exit("Explorer requires the full blocks, it's not supported in the pruned mode")
+		}
+		explorerIndexer = startExplorerIndexer(chain, config)
+	}
+
 	fastSyncDB := dbm.NewDB("fastsync", config.DBBackend, config.DBDir())
 	syncManager, err := netsync.NewSyncManager(config, chain, txPool, dispatcher, fastSyncDB)
 	if err != nil {
 		cmn.Exit(cmn.Fmt("Failed to create sync manager: %v", err))
 	}
 
+	if lightChain != nil {
+		syncManager.EnableLightMode(lightChain, wallet)
+	}
+
+	registerMetrics(chain, txPool, syncManager)
 	notificationMgr := websocket.NewWsNotificationManager(config.Websocket.MaxNumWebsockets, config.Websocket.MaxNumConcurrentReqs, chain, dispatcher)
 
 	// run the profile server
@@ -134,7 +195,11 @@ func NewNode(config *cfg.Config) *Node {
 		accessTokens:    accessTokens,
 		wallet:          wallet,
 		chain:           chain,
+		lightChain:      lightChain,
 		traceService:    traceService,
+		validatorStats:  validatorStats,
+		voteReward:      voteReward,
+		explorer:        explorerIndexer,
 		miningEnable:    config.Mining,
 		notificationMgr: notificationMgr,
 	}
@@ -144,15 +209,68 @@ func NewNode(config *cfg.Config) *Node {
 	return node
 }
 
-func startTraceUpdater(chain *protocol.Chain, cfg *cfg.Config) *contract.TraceService {
+func startTraceUpdater(chain *protocol.Chain, cfg *cfg.Config, dispatcher *event.Dispatcher) *contract.TraceService {
 	db := dbm.NewDB("trace", cfg.DBBackend, cfg.DBDir())
 	store := contract.NewTraceStore(db)
-	tracerService := contract.NewTraceService(contract.NewInfrastructure(chain, store))
+	tracerService, err := contract.NewTraceService(contract.NewInfrastructure(chain, store, dispatcher))
+	if err != nil {
+		cmn.Exit(cmn.Fmt("Failed to create trace service: %v", err))
+	}
+
 	traceUpdater := contract.NewTraceUpdater(tracerService, chain)
-	go traceUpdater.Sync()
+	go func() {
+		if err := traceUpdater.Sync(); err != nil {
+			cmn.Exit(cmn.Fmt("Contract trace updater stopped: %v", err))
+		}
+	}()
 	return tracerService
 }
 
+func startValidatorTracker(chain *protocol.Chain, cfg *cfg.Config) *validator.Tracker {
+	db := dbm.NewDB("validator", cfg.DBBackend, cfg.DBDir())
+	tracker, err := validator.NewTracker(validator.NewStore(db), chain)
+	if err != nil {
+		cmn.Exit(cmn.Fmt("Failed to create validator tracker: %v", err))
+	}
+
+	go func() {
+		if err := tracker.Sync(); err != nil {
+			cmn.Exit(cmn.Fmt("Validator tracker stopped: %v", err))
+		}
+	}()
+	return tracker
+}
+
+func startVoteRewardDistributor(config *cfg.Config, chain *protocol.Chain, accounts *account.Manager, hsm *pseudohsm.HSM) *reward.Distributor {
+	db := dbm.NewDB("votereward", config.DBBackend, config.DBDir())
+	distributor, err := reward.NewDistributor(config, reward.NewStore(db), chain, accounts, hsm)
+	if err != nil {
+		cmn.Exit(cmn.Fmt("Failed to create vote reward distributor: %v", err))
+	}
+
+	go func() {
+		if err := distributor.Sync(); err != nil {
+			cmn.Exit(cmn.Fmt("Vote reward distributor stopped: %v", err))
+		}
+	}()
+	return distributor
+}
+
+func startExplorerIndexer(chain *protocol.Chain, cfg *cfg.Config) *explorer.Indexer {
+	db := dbm.NewDB("explorer", cfg.DBBackend, cfg.DBDir())
+	indexer, err := explorer.NewIndexer(explorer.NewStore(db), chain)
+	if err != nil {
+		cmn.Exit(cmn.Fmt("Failed to create explorer indexer: %v", err))
+	}
+
+	go func() {
+		if err := indexer.Sync(); err != nil {
+			cmn.Exit(cmn.Fmt("Explorer indexer stopped: %v", err))
+		}
+	}()
+	return indexer
+}
+
 func initNodeConfig(config *cfg.Config) error {
 	if err := lockDataDirectory(config); err != nil {
 		cmn.Exit("Error: " + err.Error())
@@ -177,10 +295,8 @@ func lockDataDirectory(config *cfg.Config) error {
 }
 
 func initActiveNetParams(config *cfg.Config) {
-	var exist bool
-	consensus.ActiveNetParams, exist = consensus.NetParams[config.ChainID]
-	if !exist {
-		cmn.Exit(cmn.Fmt("chain_id[%v] don't exist", config.ChainID))
+	if err := consensus.InitActiveNetParams(config.ChainID, config.GenesisPath()); err != nil {
+		cmn.Exit(err.Error())
 	}
 }
 
@@ -199,7 +315,10 @@ func launchWebBrowser(port string) {
 }
 
 func (n *Node) initAndstartAPIServer() {
-	n.api = api.NewAPI(n.syncManager, n.wallet, n.blockProposer, n.chain, n.traceService, n.config, n.accessTokens, n.eventDispatcher, n.notificationMgr)
+	n.api = api.NewAPI(n.syncManager, n.wallet, n.blockProposer, n.chain, n.traceService, n.validatorStats, n.voteReward, n.explorer, n.config, n.accessTokens, n.eventDispatcher, n.notificationMgr)
+	if n.lightChain != nil {
+		n.api.EnableLightMode(n.lightChain)
+	}
 
 	listenAddr := env.String("LISTEN", n.config.ApiAddress)
 	env.Parse()
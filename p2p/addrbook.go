@@ -0,0 +1,306 @@
+package p2p
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dbm "coingod/database/leveldb"
+)
+
+const (
+	maxAddrBookSize = 2000
+
+	// the address is removed after continuous failures when it has not been connected recently
+	maxAddrFailures    = 10
+	addrStaleDuration  = 7 * 24 * time.Hour
+	addrRetryInterval  = time.Minute
+	maxAddrRetryFactor = 60
+	addrBanDuration    = time.Hour
+)
+
+var knownAddrPrefixKey = []byte("KA:")
+
+func knownAddrKey(addr string) []byte {
+	return append(append([]byte{}, knownAddrPrefixKey...), addr...)
+}
+
+// knownAddress is the address in the book with the connection history
+type knownAddress struct {
+	Addr        string    `json:"addr"`
+	LastSeen    time.Time `json:"last_seen"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success"`
+	LastBanned  time.Time `json:"last_banned"`
+	Attempts    uint32    `json:"attempts"` // continuous failures since the last success
+	Successes   uint32    `json:"successes"`
+	BanScore    uint32    `json:"ban_score"`
+
+	netAddr *NetAddress
+}
+
+// chance return the relative priority of the address, the address which has been connected
+// is preferred and the failures and the misbehavior reduce the priority
+func (ka *knownAddress) chance(now time.Time) float64 {
+	c := 1 + math.Min(float64(ka.Successes), 10)
+	if !ka.LastSuccess.IsZero() && now.Sub(ka.LastSuccess) < addrStaleDuration {
+		c *= 2
+	}
+
+	c /= math.Pow(1.5, float64(ka.Attempts))
+	return c / (1 + float64(ka.BanScore)/10)
+}
+
+// isBad return whether the address should be removed from the book
+func (ka *knownAddress) isBad(now time.Time) bool {
+	return ka.Attempts >= maxAddrFailures && now.Sub(ka.LastSuccess) > addrStaleDuration
+}
+
+// dialable return whether the address is neither banned recently nor in the retry backoff
+func (ka *knownAddress) dialable(now time.Time) bool {
+	if now.Sub(ka.LastBanned) < addrBanDuration {
+		return false
+	}
+
+	factor := ka.Attempts * ka.Attempts
+	if factor > maxAddrRetryFactor {
+		factor = maxAddrRetryFactor
+	}
+	return now.Sub(ka.LastAttempt) >= time.Duration(factor)*addrRetryInterval
+}
+
+// addrGroup return the /16 subnet of the ipv4 address and the /32 subnet of the ipv6 address,
+// the outbound peers are picked from different groups to avoid being surrounded
+func addrGroup(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(32, 128)).String()
+}
+
+// AddrBook persist the known peer addresses with the connection outcomes, it's used to
+// pick the outbound peers and keeps the node connectable after restarts without seeds
+type AddrBook struct {
+	mtx   sync.Mutex
+	db    dbm.DB
+	addrs map[string]*knownAddress
+}
+
+// NewAddrBook create the address book and load the saved addresses
+func NewAddrBook(db dbm.DB) *AddrBook {
+	book := &AddrBook{db: db, addrs: make(map[string]*knownAddress)}
+	iter := db.IteratorPrefix(knownAddrPrefixKey)
+	defer iter.Release()
+
+	for iter.Next() {
+		ka := &knownAddress{}
+		if err := json.Unmarshal(iter.Value(), ka); err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Warn("fail on load known address")
+			continue
+		}
+
+		netAddr, err := NewNetAddressString(ka.Addr)
+		if err != nil {
+			log.WithFields(log.Fields{"module": logModule, "address": ka.Addr, "err": err}).Warn("fail on parse known address")
+			continue
+		}
+
+		ka.netAddr = netAddr
+		book.addrs[ka.Addr] = ka
+	}
+	return book
+}
+
+// Size return the num of the known addresses
+func (a *AddrBook) Size() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return len(a.addrs)
+}
+
+// AddAddress add the address found from the discovery or refresh its last seen time
+func (a *AddrBook) AddAddress(addr *NetAddress) {
+	if addr.isLAN || !addr.Valid() {
+		return
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.getOrAdd(addr)
+	ka.LastSeen = time.Now()
+	a.save(ka)
+}
+
+// MarkGood record the successful connection to the address
+func (a *AddrBook) MarkGood(addr *NetAddress) {
+	if addr.isLAN || !addr.Valid() {
+		return
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := time.Now()
+	ka := a.getOrAdd(addr)
+	ka.LastSeen, ka.LastAttempt, ka.LastSuccess = now, now, now
+	ka.Attempts = 0
+	ka.Successes++
+	a.save(ka)
+}
+
+// MarkFailed record the failed connection to the address, the address is removed when
+// it keeps failing
+func (a *AddrBook) MarkFailed(addr *NetAddress) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka, ok := a.addrs[addr.String()]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	ka.LastAttempt = now
+	ka.Attempts++
+	if ka.isBad(now) {
+		a.remove(ka)
+		return
+	}
+	a.save(ka)
+}
+
+// MarkBanScore record the ban score of all the addresses of the ip
+func (a *AddrBook) MarkBanScore(ip string, score uint32, banned bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, ka := range a.addrs {
+		if ka.netAddr.IP.String() != ip {
+			continue
+		}
+
+		// the ban score is cleared after the peer is banned, so the last score is kept
+		if banned {
+			ka.LastBanned = time.Now()
+		} else {
+			ka.BanScore = score
+		}
+		a.save(ka)
+	}
+}
+
+// ResetBan clear the ban records of all the addresses of the ip
+func (a *AddrBook) ResetBan(ip string) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, ka := range a.addrs {
+		if ka.netAddr.IP.String() == ip {
+			ka.BanScore, ka.LastBanned = 0, time.Time{}
+			a.save(ka)
+		}
+	}
+}
+
+// PickAddresses return at most num addresses to dial by the priority order, the addresses
+// from the groups not in the used groups are picked first
+func (a *AddrBook) PickAddresses(num int, usedGroups map[string]bool, exclude func(*NetAddress) bool) []*NetAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := time.Now()
+	candidates := []*knownAddress{}
+	for _, ka := range a.addrs {
+		if ka.dialable(now) && !exclude(ka.netAddr) {
+			candidates = append(candidates, ka)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if ci, cj := candidates[i].chance(now), candidates[j].chance(now); ci != cj {
+			return ci > cj
+		}
+		return candidates[i].Addr < candidates[j].Addr
+	})
+
+	groups := make(map[string]bool)
+	for group := range usedGroups {
+		groups[group] = true
+	}
+
+	result := []*NetAddress{}
+	picked := make(map[string]bool)
+	for _, ka := range candidates {
+		if len(result) >= num {
+			return result
+		}
+
+		if group := addrGroup(ka.netAddr.IP); !groups[group] {
+			groups[group] = true
+			picked[ka.Addr] = true
+			result = append(result, ka.netAddr)
+		}
+	}
+
+	for _, ka := range candidates {
+		if len(result) >= num {
+			break
+		}
+
+		if !picked[ka.Addr] {
+			result = append(result, ka.netAddr)
+		}
+	}
+	return result
+}
+
+func (a *AddrBook) getOrAdd(addr *NetAddress) *knownAddress {
+	if ka, ok := a.addrs[addr.String()]; ok {
+		return ka
+	}
+
+	if len(a.addrs) >= maxAddrBookSize {
+		a.evict()
+	}
+
+	ka := &knownAddress{Addr: addr.String(), netAddr: addr}
+	a.addrs[ka.Addr] = ka
+	return ka
+}
+
+// evict remove the address with the lowest priority to make room for the new address
+func (a *AddrBook) evict() {
+	now := time.Now()
+	var worst *knownAddress
+	for _, ka := range a.addrs {
+		if worst == nil || ka.chance(now) < worst.chance(now) {
+			worst = ka
+		}
+	}
+
+	if worst != nil {
+		a.remove(worst)
+	}
+}
+
+func (a *AddrBook) remove(ka *knownAddress) {
+	delete(a.addrs, ka.Addr)
+	a.db.Delete(knownAddrKey(ka.Addr))
+}
+
+func (a *AddrBook) save(ka *knownAddress) {
+	data, err := json.Marshal(ka)
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "address": ka.Addr, "err": err}).Error("fail on save known address")
+		return
+	}
+
+	a.db.Set(knownAddrKey(ka.Addr), data)
+}
@@ -0,0 +1,104 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+
+	dbm "coingod/database/leveldb"
+)
+
+func TestAddrBookPersist(t *testing.T) {
+	db := dbm.NewMemDB()
+	book := NewAddrBook(db)
+	good := NewNetAddressIPPort(net.ParseIP("8.8.1.1"), 46656)
+	bad := NewNetAddressIPPort(net.ParseIP("9.9.1.1"), 46656)
+	book.AddAddress(good)
+	book.AddAddress(bad)
+	book.AddAddress(NewLANNetAddressIPPort(net.ParseIP("192.168.1.1"), 46656))
+	book.MarkGood(good)
+	book.MarkFailed(bad)
+
+	reloaded := NewAddrBook(db)
+	if reloaded.Size() != 2 {
+		t.Fatalf("got %d addresses after reload, want 2", reloaded.Size())
+	}
+
+	if ka := reloaded.addrs[good.String()]; ka.Successes != 1 || ka.LastSuccess.IsZero() {
+		t.Errorf("got known address %v, want the success recorded", ka)
+	}
+
+	if ka := reloaded.addrs[bad.String()]; ka.Attempts != 1 {
+		t.Errorf("got attempts %d, want 1", ka.Attempts)
+	}
+
+	for i := 1; i < maxAddrFailures; i++ {
+		reloaded.MarkFailed(bad)
+	}
+
+	if _, ok := reloaded.addrs[bad.String()]; ok || NewAddrBook(db).Size() != 1 {
+		t.Error("the address keeps failing is not removed")
+	}
+}
+
+func TestAddrBookPickAddresses(t *testing.T) {
+	book := NewAddrBook(dbm.NewMemDB())
+	addrs := []*NetAddress{
+		NewNetAddressIPPort(net.ParseIP("8.8.1.1"), 46656),
+		NewNetAddressIPPort(net.ParseIP("8.8.2.2"), 46656),
+		NewNetAddressIPPort(net.ParseIP("9.9.1.1"), 46656),
+		NewNetAddressIPPort(net.ParseIP("10.10.1.1"), 46656),
+		NewNetAddressIPPort(net.ParseIP("11.11.1.1"), 46656),
+	}
+	for _, addr := range addrs {
+		book.AddAddress(addr)
+	}
+
+	book.MarkGood(addrs[0])
+	book.MarkGood(addrs[1])
+	book.MarkBanScore(addrs[3].IP.String(), 60, false)
+	book.MarkBanScore(addrs[4].IP.String(), 0, true)
+
+	noExclude := func(*NetAddress) bool { return false }
+	cases := []struct {
+		num        int
+		usedGroups map[string]bool
+		exclude    func(*NetAddress) bool
+		want       []*NetAddress
+	}{
+		{
+			num:     2,
+			exclude: noExclude,
+			want:    []*NetAddress{addrs[0], addrs[2]},
+		},
+		{
+			num:     4,
+			exclude: noExclude,
+			want:    []*NetAddress{addrs[0], addrs[2], addrs[3], addrs[1]},
+		},
+		{
+			num:        1,
+			usedGroups: map[string]bool{"8.8.0.0": true},
+			exclude:    noExclude,
+			want:       []*NetAddress{addrs[2]},
+		},
+		{
+			num:     2,
+			exclude: func(addr *NetAddress) bool { return addr.Equals(addrs[0]) },
+			want:    []*NetAddress{addrs[1], addrs[2]},
+		},
+	}
+
+	for i, c := range cases {
+		got := book.PickAddresses(c.num, c.usedGroups, c.exclude)
+		if len(got) != len(c.want) {
+			t.Fatalf("case %d: got %v, want %v", i, got, c.want)
+		}
+
+		for j := range got {
+			if !got[j].Equals(c.want[j]) {
+				t.Errorf("case %d: got %v, want %v", i, got, c.want)
+				break
+			}
+		}
+	}
+}
@@ -1,12 +1,20 @@
 package connection
 
 import (
+	"fmt"
 	"io"
 	"sync/atomic"
 	"time"
 
 	wire "github.com/tendermint/go-wire"
 	cmn "github.com/tendermint/tmlibs/common"
+
+	"coingod/metrics"
+)
+
+var (
+	channelSentBytes     = metrics.NewCounterVec("coingod_p2p_channel_sent_bytes_total", "Bytes of the msg packets sent by the p2p channel", "channel")
+	channelReceivedBytes = metrics.NewCounterVec("coingod_p2p_channel_received_bytes_total", "Bytes of the msg packets received by the p2p channel", "channel")
 )
 
 // ChannelDescriptor is the setting of channel
@@ -41,6 +49,8 @@ type channel struct {
 	sending       []byte
 	priority      int
 	recentlySent  int64 // exponential moving average
+	sentBytes     *metrics.Counter
+	receivedBytes *metrics.Counter
 }
 
 func newChannel(conn *MConnection, desc *ChannelDescriptor) *channel {
@@ -48,13 +58,16 @@ func newChannel(conn *MConnection, desc *ChannelDescriptor) *channel {
 	if desc.Priority <= 0 {
 		cmn.PanicSanity("Channel default priority must be a postive integer")
 	}
+	label := fmt.Sprintf("0x%02x", desc.ID)
 	return &channel{
-		conn:      conn,
-		desc:      desc,
-		id:        desc.ID,
-		sendQueue: make(chan []byte, desc.SendQueueCapacity),
-		recving:   make([]byte, 0, desc.RecvBufferCapacity),
-		priority:  desc.Priority,
+		conn:          conn,
+		desc:          desc,
+		id:            desc.ID,
+		sendQueue:     make(chan []byte, desc.SendQueueCapacity),
+		recving:       make([]byte, 0, desc.RecvBufferCapacity),
+		priority:      desc.Priority,
+		sentBytes:     channelSentBytes.With(label),
+		receivedBytes: channelReceivedBytes.With(label),
 	}
 }
 
@@ -107,6 +120,7 @@ func (ch *channel) recvMsgPacket(packet msgPacket) ([]byte, error) {
 		return nil, wire.ErrBinaryReadOverflow
 	}
 
+	ch.receivedBytes.Add(float64(len(packet.Bytes)))
 	ch.recving = append(ch.recving, packet.Bytes...)
 	if packet.EOF == byte(0x01) {
 		msgBytes := ch.recving
@@ -150,6 +164,7 @@ func (ch *channel) writeMsgPacketTo(w io.Writer) (n int, err error) {
 	wire.WriteBinary(packet, w, &n, &err)
 	if err == nil {
 		ch.recentlySent += int64(n)
+		ch.sentBytes.Add(float64(n))
 	}
 	return
 }
@@ -28,7 +28,14 @@ type NodeInfo struct {
 }
 
 func NewNodeInfo(config *cfg.Config, pubkey ed25519.PublicKey, listenAddr string) *NodeInfo {
-	other := []string{strconv.FormatUint(uint64(consensus.DefaultServices), 10)}
+	services := consensus.DefaultServices
+	if config.LightMode {
+		services = consensus.LightServices
+	} else if config.Prune.Enable {
+		services = consensus.PrunedServices
+	}
+
+	other := []string{strconv.FormatUint(uint64(services), 10)}
 	if config.NodeAlias != "" {
 		other = append(other, config.NodeAlias)
 	}
@@ -42,6 +49,14 @@ func NewNodeInfo(config *cfg.Config, pubkey ed25519.PublicKey, listenAddr string
 	}
 }
 
+// enablePruned advertise the pruned services instead of the default ones, the light node
+// serves nothing in any case
+func (info *NodeInfo) enablePruned() {
+	if info.Other[0] == strconv.FormatUint(uint64(consensus.DefaultServices), 10) {
+		info.Other[0] = strconv.FormatUint(uint64(consensus.PrunedServices), 10)
+	}
+}
+
 // CompatibleWith checks if two NodeInfo are compatible with eachother.
 // CONTRACT: two nodes are compatible if the major version matches and network match
 func (info *NodeInfo) CompatibleWith(other *NodeInfo) error {
@@ -0,0 +1,29 @@
+package p2p
+
+import (
+	"strconv"
+	"testing"
+
+	cfg "coingod/config"
+	"coingod/consensus"
+)
+
+func TestNodeInfoEnablePruned(t *testing.T) {
+	cases := []struct {
+		lightMode bool
+		want      consensus.ServiceFlag
+	}{
+		{lightMode: false, want: consensus.PrunedServices},
+		{lightMode: true, want: consensus.LightServices},
+	}
+
+	for i, c := range cases {
+		config := cfg.DefaultConfig()
+		config.LightMode = c.lightMode
+		info := NewNodeInfo(config, nil, "")
+		info.enablePruned()
+		if info.Other[0] != strconv.FormatUint(uint64(c.want), 10) {
+			t.Errorf("case %d: got services %s, want %d", i, info.Other[0], c.want)
+		}
+	}
+}
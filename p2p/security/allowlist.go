@@ -0,0 +1,89 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/metrics"
+)
+
+var (
+	ErrPeerNotAllowed    = errors.New("peer is not in the allow list")
+	ErrBadAllowedPeer    = errors.New("invalid node public key in the allow list")
+	ErrAllowListDisabled = errors.New("allow list is not enabled in the config")
+)
+
+var rejectedPeers = metrics.NewCounterVec("coingod_p2p_rejected_peers_total", "Num of the peer handshakes rejected by the allow list")
+
+// AllowList only let the peers with the allowed node public keys connect once it's enabled
+type AllowList struct {
+	enabled bool
+	peers   map[string]bool
+
+	mtx sync.RWMutex
+}
+
+func NewAllowList() *AllowList {
+	return &AllowList{peers: make(map[string]bool)}
+}
+
+// Set enable the allow list and replace the allowed node public keys
+func (al *AllowList) Set(pubKeys []string) error {
+	peers := make(map[string]bool)
+	for _, pubKey := range pubKeys {
+		pubKey = strings.ToLower(pubKey)
+		if key, err := hex.DecodeString(pubKey); err != nil || len(key) != ed25519.PublicKeySize {
+			log.WithFields(log.Fields{"module": logModule, "pub_key": pubKey}).Error("invalid allowed peer")
+			return ErrBadAllowedPeer
+		}
+
+		peers[pubKey] = true
+	}
+
+	al.mtx.Lock()
+	defer al.mtx.Unlock()
+
+	al.enabled = true
+	al.peers = peers
+	return nil
+}
+
+// List return whether the allow list is enabled and the sorted allowed node public keys
+func (al *AllowList) List() (bool, []string) {
+	al.mtx.RLock()
+	defer al.mtx.RUnlock()
+
+	pubKeys := []string{}
+	for pubKey := range al.peers {
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	sort.Strings(pubKeys)
+	return al.enabled, pubKeys
+}
+
+// IsAllowed return whether the node public key may connect
+func (al *AllowList) IsAllowed(pubKey string) bool {
+	al.mtx.RLock()
+	defer al.mtx.RUnlock()
+
+	return !al.enabled || al.peers[strings.ToLower(pubKey)]
+}
+
+// DoFilter reject the peer not in the allow list, the peer is passed when its public key is
+// still unknown before the handshake
+func (al *AllowList) DoFilter(ip string, pubKey string) error {
+	if pubKey == "" || al.IsAllowed(pubKey) {
+		return nil
+	}
+
+	rejectedPeers.With().Inc()
+	log.WithFields(log.Fields{"module": logModule, "address": ip, "pub_key": pubKey}).Warn("reject the peer not in the allow list")
+	return ErrPeerNotAllowed
+}
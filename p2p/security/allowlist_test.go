@@ -0,0 +1,41 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllowList(t *testing.T) {
+	allowed := strings.Repeat("ab", 32)
+	other := strings.Repeat("cd", 32)
+	al := NewAllowList()
+	if err := al.DoFilter("1.1.1.1", other); err != nil {
+		t.Errorf("got err %v before the allow list is enabled", err)
+	}
+
+	if err := al.Set([]string{"abcd"}); err != ErrBadAllowedPeer {
+		t.Errorf("got err %v, want %v", err, ErrBadAllowedPeer)
+	}
+
+	if err := al.Set([]string{strings.ToUpper(allowed)}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		pubKey string
+		want   error
+	}{
+		{pubKey: allowed, want: nil},
+		{pubKey: other, want: ErrPeerNotAllowed},
+		{pubKey: "", want: nil},
+	}
+	for i, c := range cases {
+		if err := al.DoFilter("1.1.1.1", c.pubKey); err != c.want {
+			t.Errorf("case %d: got err %v, want %v", i, err, c.want)
+		}
+	}
+
+	if enabled, pubKeys := al.List(); !enabled || len(pubKeys) != 1 || pubKeys[0] != allowed {
+		t.Errorf("got enabled %v, pub keys %v", enabled, pubKeys)
+	}
+}
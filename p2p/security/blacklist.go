@@ -3,6 +3,7 @@ package security
 import (
 	"encoding/json"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,56 +14,104 @@ import (
 const (
 	defaultBanDuration = time.Hour * 1
 	blacklistKey       = "BlacklistPeers"
+	banReasonsKey      = "BlacklistReasons"
 )
 
 var (
 	ErrConnectBannedPeer = errors.New("connect banned peer")
+	ErrPeerNotBanned     = errors.New("peer is not banned")
 )
 
+// BannedPeer is the peer in the blacklist with the ban end time
+type BannedPeer struct {
+	IP     string    `json:"ip"`
+	BanEnd time.Time `json:"ban_end"`
+	Reason string    `json:"reason"`
+}
+
 type Blacklist struct {
-	peers map[string]time.Time
-	db    dbm.DB
+	peers   map[string]time.Time
+	reasons map[string]string
+	db      dbm.DB
 
 	mtx sync.Mutex
 }
 
 func NewBlacklist(config *cfg.Config) *Blacklist {
 	return &Blacklist{
-		peers: make(map[string]time.Time),
-		db:    dbm.NewDB("blacklist", config.DBBackend, config.DBDir()),
+		peers:   make(map[string]time.Time),
+		reasons: make(map[string]string),
+		db:      dbm.NewDB("blacklist", config.DBBackend, config.DBDir()),
 	}
 }
 
-//AddPeer add peer to blacklist
-func (bl *Blacklist) AddPeer(ip string) error {
+//AddPeer add peer to blacklist for the duration
+func (bl *Blacklist) AddPeer(ip string, duration time.Duration, reason string) error {
 	bl.mtx.Lock()
 	defer bl.mtx.Unlock()
 
 	// delete expired banned peers
 	for peer, banEnd := range bl.peers {
-		if time.Now().Before(banEnd) {
+		if !time.Now().Before(banEnd) {
 			delete(bl.peers, peer)
+			delete(bl.reasons, peer)
 		}
 	}
 	// add banned peer
-	bl.peers[ip] = time.Now().Add(defaultBanDuration)
-	dataJSON, err := json.Marshal(bl.peers)
-	if err != nil {
-		return err
+	bl.peers[ip] = time.Now().Add(duration)
+	bl.reasons[ip] = reason
+	return bl.save()
+}
+
+// DelPeer remove the peer from the blacklist
+func (bl *Blacklist) DelPeer(ip string) error {
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+
+	if banEnd, ok := bl.peers[ip]; !ok || !time.Now().Before(banEnd) {
+		return ErrPeerNotBanned
 	}
 
-	bl.db.Set([]byte(blacklistKey), dataJSON)
-	return nil
+	return bl.delPeer(ip)
+}
+
+// ListPeers return the banned peers which are not expired by the ip order
+func (bl *Blacklist) ListPeers() []*BannedPeer {
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+
+	result := []*BannedPeer{}
+	for ip, banEnd := range bl.peers {
+		if time.Now().Before(banEnd) {
+			result = append(result, &BannedPeer{IP: ip, BanEnd: banEnd, Reason: bl.reasons[ip]})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].IP < result[j].IP })
+	return result
 }
 
 func (bl *Blacklist) delPeer(ip string) error {
 	delete(bl.peers, ip)
-	dataJson, err := json.Marshal(bl.peers)
+	delete(bl.reasons, ip)
+	return bl.save()
+}
+
+func (bl *Blacklist) save() error {
+	dataJSON, err := json.Marshal(bl.peers)
 	if err != nil {
 		return err
 	}
 
-	bl.db.Set([]byte(blacklistKey), dataJson)
+	reasonsJSON, err := json.Marshal(bl.reasons)
+	if err != nil {
+		return err
+	}
+
+	batch := bl.db.NewBatch()
+	batch.Set([]byte(blacklistKey), dataJSON)
+	batch.Set([]byte(banReasonsKey), reasonsJSON)
+	batch.Write()
 	return nil
 }
 
@@ -94,5 +143,11 @@ func (bl *Blacklist) LoadPeers() error {
 		}
 	}
 
+	if reasonsJSON := bl.db.Get([]byte(banReasonsKey)); reasonsJSON != nil {
+		if err := json.Unmarshal(reasonsJSON, &bl.reasons); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
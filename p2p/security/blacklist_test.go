@@ -0,0 +1,50 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	dbm "coingod/database/leveldb"
+)
+
+func TestBlacklist(t *testing.T) {
+	db := dbm.NewMemDB()
+	bl := &Blacklist{peers: make(map[string]time.Time), reasons: make(map[string]string), db: db}
+	if err := bl.AddPeer("1.1.1.1", time.Hour, "spam"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bl.AddPeer("2.2.2.2", time.Hour, "invalid block"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bl.DoFilter("1.1.1.1", ""); err != ErrConnectBannedPeer {
+		t.Errorf("got err %v, want %v", err, ErrConnectBannedPeer)
+	}
+
+	if err := bl.DelPeer("2.2.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bl.DelPeer("2.2.2.2"); err != ErrPeerNotBanned {
+		t.Errorf("got err %v, want %v", err, ErrPeerNotBanned)
+	}
+
+	reloaded := &Blacklist{peers: make(map[string]time.Time), reasons: make(map[string]string), db: db}
+	if err := reloaded.LoadPeers(); err != nil {
+		t.Fatal(err)
+	}
+
+	peers := reloaded.ListPeers()
+	if len(peers) != 1 || peers[0].IP != "1.1.1.1" || peers[0].Reason != "spam" {
+		t.Errorf("got banned peers %v after reload", peers)
+	}
+
+	if err := reloaded.AddPeer("3.3.3.3", -time.Second, "expired"); err != nil {
+		t.Fatal(err)
+	}
+
+	if peers := reloaded.ListPeers(); len(peers) != 1 {
+		t.Errorf("got banned peers %v, want the expired ban skipped", peers)
+	}
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"sort"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -18,6 +19,12 @@ const (
 	levelConnExceptionTransient  = uint32(20)
 )
 
+// PeerScore is the current ban score of the peer
+type PeerScore struct {
+	IP    string `json:"ip"`
+	Score uint32 `json:"score"`
+}
+
 type PeersBanScore struct {
 	peers map[string]*DynamicBanScore
 	mtx   sync.Mutex
@@ -36,6 +43,39 @@ func (ps *PeersBanScore) DelPeer(ip string) {
 	delete(ps.peers, ip)
 }
 
+// Score return the current ban score of the peer, zero is returned for the unknown peer
+func (ps *PeersBanScore) Score(ip string) uint32 {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+
+	banScore, ok := ps.peers[ip]
+	if !ok {
+		return 0
+	}
+	return banScore.Int()
+}
+
+// List return the peers with non zero ban score by the score descending order
+func (ps *PeersBanScore) List() []*PeerScore {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+
+	result := []*PeerScore{}
+	for ip, banScore := range ps.peers {
+		if score := banScore.Int(); score > 0 {
+			result = append(result, &PeerScore{IP: ip, Score: score})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].IP < result[j].IP
+	})
+	return result
+}
+
 func (ps *PeersBanScore) Increase(ip string, level byte, reason string) bool {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
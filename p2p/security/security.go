@@ -1,25 +1,84 @@
 package security
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
 
 	cfg "coingod/config"
+	"coingod/metrics"
 )
 
 const logModule = "p2pSecurity"
 
+var banEvents = metrics.NewCounterVec("coingod_p2p_ban_events_total", "Num of the peers banned by the misbehavior level", "level")
+
+func levelName(level byte) string {
+	switch level {
+	case LevelMsgIllegal:
+		return "msg_illegal"
+	case LevelConnException:
+		return "conn_exception"
+	default:
+		return "unknown"
+	}
+}
+
 type Security struct {
+	config        *cfg.Config
 	filter        *PeerFilter
 	blacklist     *Blacklist
 	peersBanScore *PeersBanScore
+	allowList     *AllowList
 }
 
 func NewSecurity(config *cfg.Config) *Security {
 	return &Security{
+		config:        config,
 		filter:        NewPeerFilter(),
 		blacklist:     NewBlacklist(config),
 		peersBanScore: NewPeersScore(),
+		allowList:     NewAllowList(),
+	}
+}
+
+// BanScore return the ban score the peer has accumulated by the misbehavior
+func (s *Security) BanScore(ip string) uint32 {
+	return s.peersBanScore.Score(ip)
+}
+
+// BanPeer add the peer to the blacklist by the operator
+func (s *Security) BanPeer(ip string, duration time.Duration, reason string) error {
+	if duration <= 0 {
+		duration = defaultBanDuration
 	}
+
+	if err := s.blacklist.AddPeer(ip, duration, reason); err != nil {
+		return err
+	}
+
+	s.peersBanScore.DelPeer(ip)
+	return nil
+}
+
+// UnbanPeer remove the peer from the blacklist and clear its ban score
+func (s *Security) UnbanPeer(ip string) error {
+	if err := s.blacklist.DelPeer(ip); err != nil {
+		return err
+	}
+
+	s.peersBanScore.DelPeer(ip)
+	return nil
+}
+
+// ListBannedPeers return the peers in the blacklist
+func (s *Security) ListBannedPeers() []*BannedPeer {
+	return s.blacklist.ListPeers()
+}
+
+// ListPeerScores return the ban scores of the peers which have misbehaved
+func (s *Security) ListPeerScores() []*PeerScore {
+	return s.peersBanScore.List()
 }
 
 func (s *Security) DoFilter(ip string, pubKey string) error {
@@ -31,7 +90,8 @@ func (s *Security) IsBanned(ip string, level byte, reason string) bool {
 		return false
 	}
 
-	if err := s.blacklist.AddPeer(ip); err != nil {
+	banEvents.With(levelName(level)).Inc()
+	if err := s.blacklist.AddPeer(ip, defaultBanDuration, reason); err != nil {
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail on add ban peer")
 	}
 	//clear peer score
@@ -39,6 +99,35 @@ func (s *Security) IsBanned(ip string, level byte, reason string) bool {
 	return true
 }
 
+// ReloadAllowedPeers read the allowed node public keys from the config again
+func (s *Security) ReloadAllowedPeers() error {
+	if !s.config.P2P.AllowListEnabled() {
+		return ErrAllowListDisabled
+	}
+
+	pubKeys, err := s.config.AllowedPeers()
+	if err != nil {
+		return err
+	}
+
+	if err := s.allowList.Set(pubKeys); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "num": len(pubKeys)}).Info("load the allowed peers")
+	return nil
+}
+
+// AllowedPeers return whether the allow list is enabled and the allowed node public keys
+func (s *Security) AllowedPeers() (bool, []string) {
+	return s.allowList.List()
+}
+
+// IsAllowed return whether the node public key may connect
+func (s *Security) IsAllowed(pubKey string) bool {
+	return s.allowList.IsAllowed(pubKey)
+}
+
 func (s *Security) RegisterFilter(filter Filter) {
 	s.filter.register(filter)
 }
@@ -49,5 +138,10 @@ func (s *Security) Start() error {
 	}
 
 	s.filter.register(s.blacklist)
+	if err := s.ReloadAllowedPeers(); err != nil && err != ErrAllowListDisabled {
+		return err
+	}
+
+	s.filter.register(s.allowList)
 	return nil
 }
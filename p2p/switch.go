@@ -13,6 +13,7 @@ import (
 	cfg "coingod/config"
 	"coingod/consensus"
 	"coingod/crypto/ed25519/chainkd"
+	dbm "coingod/database/leveldb"
 	"coingod/errors"
 	"coingod/event"
 	"coingod/p2p/connection"
@@ -35,6 +36,8 @@ var (
 	ErrDuplicatePeer  = errors.New("Duplicate peer")
 	ErrConnectSelf    = errors.New("Connect self")
 	ErrConnectSpvPeer = errors.New("Outbound connect spv peer")
+
+	errPrivateWithoutAllowList = errors.New("the private validator requires the allowed peers")
 )
 
 type discv interface {
@@ -47,10 +50,18 @@ type lanDiscv interface {
 }
 
 type Security interface {
+	AllowedPeers() (bool, []string)
+	BanPeer(ip string, duration time.Duration, reason string) error
+	BanScore(ip string) uint32
 	DoFilter(ip string, pubKey string) error
+	IsAllowed(pubKey string) bool
 	IsBanned(ip string, level byte, reason string) bool
+	ListBannedPeers() []*security.BannedPeer
+	ListPeerScores() []*security.PeerScore
 	RegisterFilter(filter security.Filter)
+	ReloadAllowedPeers() error
 	Start() error
+	UnbanPeer(ip string) error
 }
 
 // Switch handles peer connections and exposes an API to receive incoming messages
@@ -73,6 +84,7 @@ type Switch struct {
 	discv        discv
 	lanDiscv     lanDiscv
 	security     Security
+	addrBook     *AddrBook
 }
 
 // NewSwitch create a new Switch and set discover.
@@ -83,13 +95,24 @@ func NewSwitch(config *cfg.Config) (*Switch, error) {
 	var discv *dht.Network
 	var lanDiscv *mdns.LANDiscover
 
-	xPrv := config.PrivateKey()
+	if config.P2P.PrivateValidator {
+		if !config.P2P.AllowListEnabled() {
+			return nil, errPrivateWithoutAllowList
+		}
+
+		// the private validator is hidden behind the sentries, it only connects the
+		// keep dial sentries and is never discovered
+		config.P2P.LANDiscover = false
+	}
+
+	xPrv := config.NodeKey()
 	if !config.VaultMode {
 		// Create listener
 		l, listenAddr = GetListener(config.P2P)
-		discv, err = dht.NewDiscover(config, *xPrv, l.ExternalAddress().Port)
-		if err != nil {
-			return nil, err
+		if !config.P2P.PrivateValidator {
+			if discv, err = dht.NewDiscover(config, *xPrv, l.ExternalAddress().Port); err != nil {
+				return nil, err
+			}
 		}
 		if config.P2P.LANDiscover {
 			lanDiscv = mdns.NewLANDiscover(mdns.NewProtocol(config.ChainID), int(l.ExternalAddress().Port))
@@ -114,6 +137,7 @@ func newSwitch(config *cfg.Config, discv discv, lanDiscv lanDiscv, l Listener, p
 		lanDiscv:     lanDiscv,
 		nodeInfo:     NewNodeInfo(config, priv.XPub().PublicKey(), listenAddr),
 		security:     security.NewSecurity(config),
+		addrBook:     NewAddrBook(dbm.NewDB("addrbook", config.DBBackend, config.DBDir())),
 	}
 
 	sw.AddListener(l)
@@ -238,20 +262,83 @@ func (sw *Switch) DialPeerWithAddress(addr *NetAddress) error {
 	pc, err := newOutboundPeerConn(addr, sw.nodePrivKey, sw.peerConfig)
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "address": addr, " err": err}).Warn("DialPeer fail on newOutboundPeerConn")
+		sw.addrBook.MarkFailed(addr)
 		return err
 	}
 
 	if err = sw.AddPeer(pc, addr.isLAN); err != nil {
 		log.WithFields(log.Fields{"module": logModule, "address": addr, " err": err}).Warn("DialPeer fail on switch AddPeer")
 		pc.CloseConn()
+		if errors.Root(err) != ErrDuplicatePeer {
+			sw.addrBook.MarkFailed(addr)
+		}
 		return err
 	}
+
+	sw.addrBook.MarkGood(addr)
 	log.WithFields(log.Fields{"module": logModule, "address": addr, "peer num": sw.peers.Size()}).Debug("DialPeer added peer")
 	return nil
 }
 
 func (sw *Switch) IsBanned(ip string, level byte, reason string) bool {
-	return sw.security.IsBanned(ip, level, reason)
+	banned := sw.security.IsBanned(ip, level, reason)
+	sw.addrBook.MarkBanScore(ip, sw.security.BanScore(ip), banned)
+	return banned
+}
+
+// BanPeer ban the ip for the duration and disconnect the connected peers of the ip
+func (sw *Switch) BanPeer(ip string, duration time.Duration, reason string) error {
+	if err := sw.security.BanPeer(ip, duration, reason); err != nil {
+		return err
+	}
+
+	sw.addrBook.MarkBanScore(ip, 0, true)
+	for _, peer := range sw.peers.List() {
+		if peer.RemoteAddrHost() == ip {
+			sw.stopAndRemovePeer(peer, reason)
+		}
+	}
+	return nil
+}
+
+// UnbanPeer remove the ip from the blacklist
+func (sw *Switch) UnbanPeer(ip string) error {
+	if err := sw.security.UnbanPeer(ip); err != nil {
+		return err
+	}
+
+	sw.addrBook.ResetBan(ip)
+	return nil
+}
+
+// ReloadAllowedPeers reload the allow list from the config and disconnect the connected
+// peers which are no longer allowed
+func (sw *Switch) ReloadAllowedPeers() error {
+	if err := sw.security.ReloadAllowedPeers(); err != nil {
+		return err
+	}
+
+	for _, peer := range sw.peers.List() {
+		if !sw.security.IsAllowed(hex.EncodeToString(peer.PubKey())) {
+			sw.stopAndRemovePeer(peer, security.ErrPeerNotAllowed)
+		}
+	}
+	return nil
+}
+
+// AllowedPeers return whether the allow list is enabled and the allowed node public keys
+func (sw *Switch) AllowedPeers() (bool, []string) {
+	return sw.security.AllowedPeers()
+}
+
+// ListBannedPeers return the banned peers
+func (sw *Switch) ListBannedPeers() []*security.BannedPeer {
+	return sw.security.ListBannedPeers()
+}
+
+// ListPeerScores return the ban scores of the misbehaving peers
+func (sw *Switch) ListPeerScores() []*security.PeerScore {
+	return sw.security.ListPeerScores()
 }
 
 //IsDialing prevent duplicate dialing
@@ -294,6 +381,12 @@ func (sw *Switch) NodeInfo() *NodeInfo {
 	return sw.nodeInfo
 }
 
+// EnablePruned advertise the pruned services when the chain doesn't keep the transactions
+// of the early blocks, e.g. the chain restored from the snapshot. It's called before the start.
+func (sw *Switch) EnablePruned() {
+	sw.nodeInfo.enablePruned()
+}
+
 //Peers return switch peerset
 func (sw *Switch) Peers() *PeerSet {
 	return sw.peers
@@ -450,17 +543,29 @@ func (sw *Switch) ensureOutboundPeers() {
 	lanPeers, numOutPeers, _, numDialing := sw.NumPeers()
 	numToDial := minNumOutboundPeers - (numOutPeers + numDialing)
 	log.WithFields(log.Fields{"module": logModule, "numOutPeers": numOutPeers, "LANPeers": lanPeers, "numDialing": numDialing, "numToDial": numToDial}).Debug("ensure peers")
-	if numToDial <= 0 {
+	if numToDial <= 0 || sw.Config.P2P.PrivateValidator {
 		return
 	}
 
+	// the discovered nodes join the address book and compete with the known addresses
 	nodes := make([]*dht.Node, numToDial)
 	n := sw.discv.ReadRandomNodes(nodes)
-	addresses := make([]*NetAddress, 0)
 	for i := 0; i < n; i++ {
-		address := NewNetAddressIPPort(nodes[i].IP, nodes[i].TCP)
-		addresses = append(addresses, address)
+		sw.addrBook.AddAddress(NewNetAddressIPPort(nodes[i].IP, nodes[i].TCP))
+	}
+
+	connectedPeers := make(map[string]bool)
+	usedGroups := make(map[string]bool)
+	for _, peer := range sw.peers.List() {
+		connectedPeers[peer.RemoteAddrHost()] = true
+		if ip := net.ParseIP(peer.RemoteAddrHost()); ip != nil && peer.outbound && !peer.isLAN {
+			usedGroups[addrGroup(ip)] = true
+		}
 	}
+
+	addresses := sw.addrBook.PickAddresses(numToDial, usedGroups, func(addr *NetAddress) bool {
+		return connectedPeers[addr.IP.String()] || sw.IsDialing(addr) || sw.NodeInfo().ListenAddr == addr.String()
+	})
 	sw.dialPeers(addresses)
 }
 
@@ -41,7 +41,7 @@ type BlockProposer struct {
 //
 // It must be run as a goroutine.
 func (b *BlockProposer) generateBlocks() {
-	xpub := config.CommonConfig.PrivateKey().XPub()
+	xpub := config.CommonConfig.ValidatorSigner().XPub()
 	xpubStr := hex.EncodeToString(xpub[:])
 	ticker := time.NewTicker(time.Duration(consensus.ActiveNetParams.BlockTimeInterval) * time.Millisecond / 4)
 	defer ticker.Stop()
@@ -83,7 +83,7 @@ func (b *BlockProposer) generateBlocks() {
 
 		warnDuration := time.Duration(consensus.ActiveNetParams.BlockTimeInterval*warnTimeNum/warnTimeDenom) * time.Millisecond
 		criticalDuration := time.Duration(consensus.ActiveNetParams.BlockTimeInterval*criticalTimeNum/criticalTimeDenom) * time.Millisecond
-		block, err := proposal.NewBlockTemplate(b.chain, validator, b.accountManager, nextBlockTime, warnDuration, criticalDuration)
+		block, err := proposal.NewBlockTemplate(b.chain, validator, b.accountManager, nextBlockTime, warnDuration, criticalDuration, config.CommonConfig.TxSelection)
 		if err != nil {
 			log.WithFields(log.Fields{"module": logModule, "error": err}).Error("failed on create NewBlockTemplate")
 			continue
@@ -2,7 +2,6 @@ package proposal
 
 import (
 	"encoding/hex"
-	"sort"
 	"strconv"
 	"time"
 
@@ -30,9 +29,11 @@ const (
 	timeoutCritical
 )
 
-// NewBlockTemplate returns a new block template that is ready to be solved
-func NewBlockTemplate(chain *protocol.Chain, validator *state.Validator, accountManager *account.Manager, timestamp uint64, warnDuration, criticalDuration time.Duration) (*types.Block, error) {
+// NewBlockTemplate returns a new block template that is ready to be solved,
+// the txSelection decides the order of the transactions packed from the pool
+func NewBlockTemplate(chain *protocol.Chain, validator *state.Validator, accountManager *account.Manager, timestamp uint64, warnDuration, criticalDuration time.Duration, txSelection string) (*types.Block, error) {
 	builder := newBlockBuilder(chain, validator, accountManager, timestamp, warnDuration, criticalDuration)
+	builder.txSelection = txSelection
 	return builder.build()
 }
 
@@ -48,6 +49,7 @@ type blockBuilder struct {
 	criticalTimeoutCh <-chan time.Time
 	timeoutStatus     uint8
 	gasLeft           int64
+	txSelection       string
 }
 
 func newBlockBuilder(chain *protocol.Chain, validator *state.Validator, accountManager *account.Manager, timestamp uint64, warnDuration, criticalDuration time.Duration) *blockBuilder {
@@ -70,7 +72,7 @@ func newBlockBuilder(chain *protocol.Chain, validator *state.Validator, accountM
 		utxoView:          state.NewUtxoViewpoint(),
 		warnTimeoutCh:     time.After(warnDuration),
 		criticalTimeoutCh: time.After(criticalDuration),
-		gasLeft:           int64(consensus.MaxBlockGas),
+		gasLeft:           int64(consensus.Rules(block.Height).MaxBlockGas),
 		timeoutStatus:     timeoutOk,
 	}
 	return builder
@@ -91,7 +93,9 @@ func (b *blockBuilder) build() (*types.Block, error) {
 	}
 
 	blockHeader := &b.block.BlockHeader
-	b.chain.SignBlockHeader(blockHeader)
+	if err := b.chain.SignBlockHeader(blockHeader); err != nil {
+		return nil, errors.Wrap(err, "fail on sign block header")
+	}
 	return b.block, nil
 }
 
@@ -112,8 +116,7 @@ func (b *blockBuilder) applyCoinbaseTransaction() error {
 }
 
 func (b *blockBuilder) applyTransactionFromPool() error {
-	txDescList := b.chain.GetTxPool().GetTransactions()
-	sort.Sort(byTime(txDescList))
+	txDescList := sortTxDescs(b.chain.GetTxPool().GetTransactions(), b.txSelection)
 	return b.applyTransactions(txDescList, timeoutWarn)
 }
 
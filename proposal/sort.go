@@ -1,9 +1,189 @@
 package proposal
 
-import "coingod/protocol"
+import (
+	"container/heap"
+	"sort"
+
+	"coingod/config"
+	"coingod/protocol"
+	"coingod/protocol/bc"
+)
+
+const (
+	// TxSelectionFeeRate packs the transactions with the highest fee per weight first
+	TxSelectionFeeRate = config.TxSelectionFeeRate
+	// TxSelectionTime packs the transactions in the order they are added to the pool
+	TxSelectionTime = config.TxSelectionTime
+)
 
 type byTime []*protocol.TxDesc
 
 func (a byTime) Len() int           { return len(a) }
 func (a byTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byTime) Less(i, j int) bool { return a[i].Added.Before(a[j].Added) }
+
+// sortTxDescs return the transactions in the order they should be applied to the block
+func sortTxDescs(txDescs []*protocol.TxDesc, txSelection string) []*protocol.TxDesc {
+	if txSelection == TxSelectionFeeRate {
+		return sortByFeeRate(txDescs)
+	}
+
+	sort.Sort(byTime(txDescs))
+	return txDescs
+}
+
+// txPackage is the node of the in-pool dependency graph, a transaction can only
+// be packed after all its in-pool parents
+type txPackage struct {
+	desc     *protocol.TxDesc
+	parents  []*txPackage
+	children []*txPackage
+	selected bool
+	version  int
+}
+
+// ancestors return the unselected ancestors of the package in topological order, the package itself is the last one
+func (p *txPackage) ancestors() []*txPackage {
+	var result []*txPackage
+	visited := make(map[*txPackage]bool)
+	var visit func(*txPackage)
+	visit = func(n *txPackage) {
+		if n.selected || visited[n] {
+			return
+		}
+
+		visited[n] = true
+		for _, parent := range n.parents {
+			visit(parent)
+		}
+		result = append(result, n)
+	}
+
+	visit(p)
+	return result
+}
+
+// descendants return the unselected descendants of the package
+func (p *txPackage) descendants(visited map[*txPackage]bool) []*txPackage {
+	var result []*txPackage
+	for _, child := range p.children {
+		if child.selected || visited[child] {
+			continue
+		}
+
+		visited[child] = true
+		result = append(append(result, child), child.descendants(visited)...)
+	}
+	return result
+}
+
+type packageItem struct {
+	pkg     *txPackage
+	version int
+	fee     uint64
+	weight  uint64
+}
+
+func newPackageItem(pkg *txPackage) *packageItem {
+	item := &packageItem{pkg: pkg, version: pkg.version}
+	for _, ancestor := range pkg.ancestors() {
+		item.fee += ancestor.desc.Fee
+		item.weight += ancestor.desc.Weight
+	}
+
+	if item.weight == 0 {
+		item.weight = 1
+	}
+	return item
+}
+
+func (i *packageItem) feeRate() float64 {
+	return float64(i.fee) / float64(i.weight)
+}
+
+type packageHeap []*packageItem
+
+func (h packageHeap) Len() int      { return len(h) }
+func (h packageHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h packageHeap) Less(i, j int) bool {
+	if rateI, rateJ := h[i].feeRate(), h[j].feeRate(); rateI != rateJ {
+		return rateI > rateJ
+	}
+	return h[i].pkg.desc.Added.Before(h[j].pkg.desc.Added)
+}
+
+func (h *packageHeap) Push(x interface{}) { *h = append(*h, x.(*packageItem)) }
+func (h *packageHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+func buildTxPackages(txDescs []*protocol.TxDesc) []*txPackage {
+	pkgs := make([]*txPackage, len(txDescs))
+	outputs := make(map[bc.Hash]*txPackage)
+	for i, txDesc := range txDescs {
+		pkgs[i] = &txPackage{desc: txDesc}
+		for _, id := range txDesc.Tx.ResultIds {
+			outputs[*id] = pkgs[i]
+		}
+	}
+
+	for _, pkg := range pkgs {
+		linked := make(map[*txPackage]bool)
+		for _, input := range pkg.desc.Tx.Inputs {
+			spentOutputID, err := input.SpentOutputID()
+			if err != nil {
+				continue
+			}
+
+			parent, ok := outputs[spentOutputID]
+			if !ok || parent == pkg || linked[parent] {
+				continue
+			}
+
+			linked[parent] = true
+			pkg.parents = append(pkg.parents, parent)
+			parent.children = append(parent.children, pkg)
+		}
+	}
+	return pkgs
+}
+
+// sortByFeeRate pick the package with the highest fee rate each round, the fee rate of package
+// is calculated by the transaction with all its unselected in-pool ancestors (child pays for parent)
+func sortByFeeRate(txDescs []*protocol.TxDesc) []*protocol.TxDesc {
+	pkgs := buildTxPackages(txDescs)
+	h := make(packageHeap, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		h = append(h, newPackageItem(pkg))
+	}
+	heap.Init(&h)
+
+	result := make([]*protocol.TxDesc, 0, len(txDescs))
+	for h.Len() > 0 {
+		item := heap.Pop(&h).(*packageItem)
+		if item.pkg.selected || item.version != item.pkg.version {
+			continue
+		}
+
+		ancestors := item.pkg.ancestors()
+		for _, ancestor := range ancestors {
+			ancestor.selected = true
+			result = append(result, ancestor.desc)
+		}
+
+		// the fee rate of the descendants is changed since some ancestors have been packed
+		visited := make(map[*txPackage]bool)
+		for _, ancestor := range ancestors {
+			for _, descendant := range ancestor.descendants(visited) {
+				descendant.version++
+				heap.Push(&h, newPackageItem(descendant))
+			}
+		}
+	}
+	return result
+}
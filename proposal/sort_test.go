@@ -0,0 +1,186 @@
+package proposal
+
+import (
+	"testing"
+	"time"
+
+	"coingod/consensus"
+	"coingod/protocol"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+var (
+	testProgram = []byte{0x51}
+	baseTime    = time.Unix(1600000000, 0)
+)
+
+// newTestTxDesc create a transaction spend the CG from the source and pay the fee,
+// the added time is used for the time ordering
+func newTestTxDesc(sourceID bc.Hash, sourcePos, amount, fee, weight uint64, added int) *protocol.TxDesc {
+	tx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewSpendInput(nil, sourceID, *consensus.CGAssetID, amount, sourcePos, testProgram, nil)},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, amount-fee, testProgram, nil)},
+	})
+	return &protocol.TxDesc{Tx: tx, Fee: fee, Weight: weight, Added: baseTime.Add(time.Duration(added) * time.Second)}
+}
+
+// newTestChildTxDesc create a transaction spend the first output of the parent
+func newTestChildTxDesc(parent *protocol.TxDesc, fee, weight uint64, added int) *protocol.TxDesc {
+	output := parent.Tx.Entries[*parent.Tx.ResultIds[0]].(*bc.OriginalOutput)
+	return newTestTxDesc(*output.Source.Ref, output.Source.Position, output.Source.Value.Amount, fee, weight, added)
+}
+
+func txDescIndexes(all, sorted []*protocol.TxDesc) []int {
+	indexes := []int{}
+	for _, s := range sorted {
+		for i, a := range all {
+			if a == s {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	return indexes
+}
+
+func TestSortByFeeRate(t *testing.T) {
+	cases := []struct {
+		desc  string
+		build func() []*protocol.TxDesc
+		want  []int
+	}{
+		{
+			desc: "independent transactions",
+			build: func() []*protocol.TxDesc {
+				return []*protocol.TxDesc{
+					newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 10, 100, 0),
+					newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 50, 100, 1),
+					newTestTxDesc(bc.Hash{V0: 3}, 0, 1000, 30, 100, 2),
+				}
+			},
+			want: []int{1, 2, 0},
+		},
+		{
+			desc: "same fee rate keep the time order",
+			build: func() []*protocol.TxDesc {
+				return []*protocol.TxDesc{
+					newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 20, 200, 2),
+					newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 10, 100, 1),
+					newTestTxDesc(bc.Hash{V0: 3}, 0, 1000, 30, 300, 0),
+				}
+			},
+			want: []int{2, 1, 0},
+		},
+		{
+			desc: "child pays for parent",
+			build: func() []*protocol.TxDesc {
+				parent := newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 1, 100, 0)
+				return []*protocol.TxDesc{
+					parent,
+					newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 40, 100, 1),
+					newTestChildTxDesc(parent, 99, 100, 2),
+				}
+			},
+			want: []int{0, 2, 1},
+		},
+		{
+			desc: "child is packed after the parent even if it has higher fee rate",
+			build: func() []*protocol.TxDesc {
+				parent := newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 50, 100, 0)
+				child := newTestChildTxDesc(parent, 90, 100, 1)
+				return []*protocol.TxDesc{
+					child,
+					newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 60, 100, 2),
+					parent,
+				}
+			},
+			want: []int{2, 0, 1},
+		},
+		{
+			desc: "child with low fee is re-evaluated after parent is packed",
+			build: func() []*protocol.TxDesc {
+				parent := newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 100, 100, 0)
+				return []*protocol.TxDesc{
+					parent,
+					newTestChildTxDesc(parent, 1, 100, 1),
+					newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 40, 100, 2),
+				}
+			},
+			want: []int{0, 2, 1},
+		},
+	}
+
+	for i, c := range cases {
+		txDescs := c.build()
+		all := append([]*protocol.TxDesc{}, txDescs...)
+		got := txDescIndexes(all, sortTxDescs(txDescs, TxSelectionFeeRate))
+		if len(got) != len(c.want) {
+			t.Fatalf("case %d(%s): got %v, want %v", i, c.desc, got, c.want)
+		}
+
+		for j := range got {
+			if got[j] != c.want[j] {
+				t.Errorf("case %d(%s): got %v, want %v", i, c.desc, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+// packedReward simulate the block builder which stop packing when the weight limit is reached,
+// and return the validator reward calculated by the checkpoint
+func packedReward(t *testing.T, txDescs []*protocol.TxDesc, txSelection string, weightLimit uint64) uint64 {
+	coinbase := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte{0x01})},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 0, testProgram, nil)},
+	})
+
+	block := &types.Block{BlockHeader: types.BlockHeader{Height: 1}, Transactions: []*types.Tx{coinbase}}
+	for _, txDesc := range sortTxDescs(txDescs, txSelection) {
+		if txDesc.Weight > weightLimit {
+			break
+		}
+
+		weightLimit -= txDesc.Weight
+		block.Transactions = append(block.Transactions, txDesc.Tx)
+	}
+
+	checkpoint := &state.Checkpoint{Rewards: make(map[string]uint64), Votes: make(map[string]uint64)}
+	if err := checkpoint.Increase(block); err != nil {
+		t.Fatal(err)
+	}
+
+	var reward uint64
+	for _, amount := range checkpoint.Rewards {
+		reward += amount
+	}
+	return reward
+}
+
+func TestTxSelectionMaximizeReward(t *testing.T) {
+	build := func() []*protocol.TxDesc {
+		parent := newTestTxDesc(bc.Hash{V0: 1}, 0, 1000, 2, 100, 0)
+		return []*protocol.TxDesc{
+			parent,
+			newTestTxDesc(bc.Hash{V0: 2}, 0, 1000, 5, 100, 1),
+			newTestTxDesc(bc.Hash{V0: 3}, 0, 1000, 80, 100, 2),
+			newTestChildTxDesc(parent, 200, 100, 3),
+			newTestTxDesc(bc.Hash{V0: 4}, 0, 1000, 60, 100, 4),
+		}
+	}
+
+	baseReward := packedReward(t, nil, TxSelectionFeeRate, 0)
+	timeReward := packedReward(t, build(), TxSelectionTime, 300)
+	feeRateReward := packedReward(t, build(), TxSelectionFeeRate, 300)
+	if feeRateReward <= timeReward {
+		t.Errorf("fee rate selection reward %d should be greater than time selection reward %d", feeRateReward, timeReward)
+	}
+
+	// the best choice under the weight limit is parent + child + the tx with fee 80
+	if want := baseReward + 2 + 200 + 80; feeRateReward != want {
+		t.Errorf("got reward %d, want %d", feeRateReward, want)
+	}
+}
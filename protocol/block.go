@@ -89,6 +89,15 @@ func (c *Chain) calcReorganizeChain(beginAttach *types.BlockHeader, beginDetach
 	return attachBlockHeaders, detachBlockHeaders, nil
 }
 
+// ReorgEvent is posted after blocks are detached from the main chain, the detached blocks
+// are ordered from the old best block down to the fork point and the attached blocks are
+// ordered up from the fork point
+type ReorgEvent struct {
+	ForkHeight uint64
+	Detached   []*types.BlockHeader
+	Attached   []*types.BlockHeader
+}
+
 func (c *Chain) reorganizeChain(blockHeader *types.BlockHeader) error {
 	attachNodes, detachNodes, err := c.calcReorganizeChain(blockHeader, c.bestBlockHeader)
 	if err != nil {
@@ -160,6 +169,13 @@ func (c *Chain) reorganizeChain(blockHeader *types.BlockHeader) error {
 		return err
 	}
 
+	if len(detachNodes) > 0 && c.eventDispatcher != nil {
+		event := ReorgEvent{ForkHeight: detachNodes[len(detachNodes)-1].Height - 1, Detached: detachNodes, Attached: attachNodes}
+		if err := c.eventDispatcher.Post(event); err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Error("post reorg event")
+		}
+	}
+
 	for txHash := range txsToRemove {
 		c.txPool.RemoveTransaction(&txHash)
 	}
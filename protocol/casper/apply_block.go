@@ -131,8 +131,8 @@ func (c *Casper) myVerification(target *state.Checkpoint) *verification {
 		return nil
 	}
 
-	prvKey := config.CommonConfig.PrivateKey()
-	v, err := convertVerification(source, target, &ValidCasperSignMsg{PubKey: prvKey.XPub().String()})
+	validatorSigner := config.CommonConfig.ValidatorSigner()
+	v, err := convertVerification(source, target, &ValidCasperSignMsg{PubKey: validatorSigner.XPub().String()})
 	if err != nil {
 		return nil
 	}
@@ -142,12 +142,12 @@ func (c *Casper) myVerification(target *state.Checkpoint) *verification {
 		return nil
 	}
 
-	if err := v.Sign(*prvKey); err != nil {
-		log.WithField("module", logModule).Error("myVerification fail on sign msg")
+	if err := v.Sign(validatorSigner); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Error("myVerification fail on sign msg")
 		return nil
 	}
 
-	if err := c.verifyVerification(v); err != nil {
+	if _, err := c.verifyVerification(v); err != nil {
 		log.WithField("module", logModule).Warn("myVerification fail on find nest sign")
 		return nil
 	}
@@ -190,9 +190,13 @@ func (c *Casper) validVerificationsFromSupLink(target *state.Checkpoint, supLink
 
 	var result []*verification
 	for _, v := range supLinkToVerifications(source, target, supLink) {
-		if c.verifyVerification(v) == nil {
+		conflict, err := c.verifyVerification(v)
+		if err == nil {
 			result = append(result, v)
+			continue
 		}
+
+		c.tryRecordSlashingEvidence(v, conflict, err)
 	}
 	return result, nil
 }
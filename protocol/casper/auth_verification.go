@@ -46,7 +46,8 @@ func (c *Casper) AuthVerification(msg *ValidCasperSignMsg) error {
 }
 
 func (c *Casper) authVerification(v *verification, target *state.Checkpoint) error {
-	if err := c.verifyVerification(v); err != nil {
+	if conflict, err := c.verifyVerification(v); err != nil {
+		c.tryRecordSlashingEvidence(v, conflict, err)
 		return err
 	}
 
@@ -99,6 +100,7 @@ func (c *Casper) saveVerificationToHeader(v *verification) error {
 // source status is justified, and exist a super majority link from source to target
 func (c *Casper) setJustified(source, target *state.Checkpoint) {
 	target.Status = state.Justified
+	c.postCheckpointEvent(target)
 	// must direct child
 	if target.ParentHash == source.Hash {
 		c.setFinalized(source)
@@ -117,6 +119,14 @@ func (c *Casper) setFinalized(checkpoint *state.Checkpoint) {
 	newRoot.Status = state.Finalized
 	newRoot.Parent = nil
 	c.tree = newRoot
+	c.postCheckpointEvent(checkpoint)
+}
+
+func (c *Casper) postCheckpointEvent(checkpoint *state.Checkpoint) {
+	event := CheckpointEvent{Height: checkpoint.Height, Hash: checkpoint.Hash, Status: checkpoint.Status}
+	if err := c.msgQueue.Post(event); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err, "height": checkpoint.Height}).Error("post checkpoint event")
+	}
 }
 
 func (c *Casper) tryRollback(oldBestHash bc.Hash) error {
@@ -174,37 +184,39 @@ func (c *Casper) authCachedMsg(msg *ValidCasperSignMsg, msgKey string) error {
 	return c.authVerification(v, target)
 }
 
-func (c *Casper) verifyVerification(v *verification) error {
+// verifyVerification return the conflicting verification published by the same validator if the verification break the casper rules
+func (c *Casper) verifyVerification(v *verification) (*verification, error) {
 	if err := v.valid(); err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := c.verifySameHeight(v); err != nil {
-		return err
+	if conflict, err := c.verifySameHeight(v); err != nil {
+		return conflict, err
 	}
 
 	return c.verifySpanHeight(v)
 }
 
 // a validator must not publish two distinct votes for the same target height
-func (c *Casper) verifySameHeight(v *verification) error {
+func (c *Casper) verifySameHeight(v *verification) (*verification, error) {
 	checkpoints, err := c.store.GetCheckpointsByHeight(v.TargetHeight)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, checkpoint := range checkpoints {
 		for _, supLink := range checkpoint.SupLinks {
 			if len(supLink.Signatures[v.order]) != 0 && checkpoint.Hash != v.TargetHash {
-				return errSameHeightInVerification
+				return supLinkToConflict(v, checkpoint, supLink), errSameHeightInVerification
 			}
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 // a validator must not vote within the span of its other votes.
-func (c *Casper) verifySpanHeight(v *verification) error {
+func (c *Casper) verifySpanHeight(v *verification) (*verification, error) {
+	var conflict *verification
 	if c.tree.findOnlyOne(func(checkpoint *state.Checkpoint) bool {
 		if checkpoint.Height == v.TargetHeight {
 			return false
@@ -214,15 +226,16 @@ func (c *Casper) verifySpanHeight(v *verification) error {
 			if len(supLink.Signatures[v.order]) != 0 {
 				if (checkpoint.Height < v.TargetHeight && supLink.SourceHeight > v.SourceHeight) ||
 					(checkpoint.Height > v.TargetHeight && supLink.SourceHeight < v.SourceHeight) {
+					conflict = supLinkToConflict(v, checkpoint, supLink)
 					return true
 				}
 			}
 		}
 		return false
 	}) != nil {
-		return errSpanHeightInVerification
+		return conflict, errSpanHeightInVerification
 	}
-	return nil
+	return nil, nil
 }
 
 func verificationCacheKey(blockHash bc.Hash, pubKey string) string {
@@ -2,6 +2,7 @@ package casper
 
 import (
 	"testing"
+	"time"
 
 	"coingod/consensus"
 	"coingod/crypto/ed25519/chainkd"
@@ -11,6 +12,7 @@ import (
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"coingod/protocol/state"
+	"coingod/signer"
 	"coingod/testutil"
 )
 
@@ -80,7 +82,12 @@ func TestRollback(t *testing.T) {
 		TargetHeight: checkpoints[1].Height,
 		PubKey:       pubKey,
 	}
-	if err := v.Sign(xPrv); err != nil {
+	guard, err := signer.NewGuard("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Sign(signer.NewLocalSigner(xPrv, guard)); err != nil {
 		t.Fatal(err)
 	}
 
@@ -98,10 +105,70 @@ func TestRollback(t *testing.T) {
 	}
 }
 
+func TestCheckpointEvent(t *testing.T) {
+	root := &state.Checkpoint{Height: 0, Hash: bc.Hash{V0: 1}, Status: state.Justified}
+	child := &state.Checkpoint{Height: 100, Hash: bc.Hash{V0: 2}, ParentHash: root.Hash, Status: state.Unjustified}
+	grandchild := &state.Checkpoint{Height: 200, Hash: bc.Hash{V0: 3}, ParentHash: child.Hash, Status: state.Unjustified}
+
+	cases := []struct {
+		desc       string
+		target     *state.Checkpoint
+		wantEvents []CheckpointEvent
+	}{
+		{
+			desc:   "the direct child justified by the root finalizes the root",
+			target: child,
+			wantEvents: []CheckpointEvent{
+				{Height: child.Height, Hash: child.Hash, Status: state.Justified},
+				{Height: root.Height, Hash: root.Hash, Status: state.Finalized},
+			},
+		},
+		{
+			desc:   "the grandchild justified by the root doesn't finalize the root",
+			target: grandchild,
+			wantEvents: []CheckpointEvent{
+				{Height: grandchild.Height, Hash: grandchild.Hash, Status: state.Justified},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		source, target := *root, *c.target
+		dispatcher := event.NewDispatcher()
+		checkpointSub, err := dispatcher.Subscribe(CheckpointEvent{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		casper := NewCasper(&mockStore2{}, dispatcher, []*state.Checkpoint{&source, &target})
+		casper.setJustified(&source, &target)
+		for j, want := range c.wantEvents {
+			select {
+			case obj := <-checkpointSub.Chan():
+				if got := obj.Data.(CheckpointEvent); got != want {
+					t.Errorf("case %d(%s) event #%d: want %v, got %v", i, c.desc, j, want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("case %d(%s): event #%d is not posted", i, c.desc, j)
+			}
+		}
+
+		select {
+		case obj := <-checkpointSub.Chan():
+			t.Errorf("case %d(%s): got the unexpected event %v", i, c.desc, obj.Data)
+		case <-time.After(50 * time.Millisecond):
+		}
+		dispatcher.Stop()
+	}
+}
+
 type mockStore2 struct{}
 
 func (s *mockStore2) GetCheckpointsByHeight(u uint64) ([]*state.Checkpoint, error) { return nil, nil }
 func (s *mockStore2) SaveCheckpoints([]*state.Checkpoint) error                    { return nil }
+func (s *mockStore2) SlashingEvidenceExist(*bc.Hash) bool                          { return false }
+func (s *mockStore2) ListSlashingEvidences() ([]*state.SlashingEvidence, error)    { return nil, nil }
+func (s *mockStore2) SaveSlashingEvidence(*state.SlashingEvidence) error           { return nil }
 func (s *mockStore2) CheckpointsFromNode(height uint64, hash *bc.Hash) ([]*state.Checkpoint, error) {
 	return nil, nil
 }
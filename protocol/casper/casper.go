@@ -28,6 +28,13 @@ type RollbackMsg struct {
 	Reply    chan error
 }
 
+// CheckpointEvent is posted when the checkpoint is justified or finalized
+type CheckpointEvent struct {
+	Height uint64
+	Hash   bc.Hash
+	Status state.CheckpointStatus
+}
+
 type msgQueue interface {
 	Post(interface{}) error
 }
@@ -0,0 +1,159 @@
+package casper
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"coingod/errors"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+var (
+	// ErrInvalidSlashingEvidence means the evidence is provably invalid, the sender should be punished
+	ErrInvalidSlashingEvidence = errors.New("invalid slashing evidence")
+
+	errUnknownSlashingBlock = errors.New("the block which slashing evidence vote to is not found")
+)
+
+// ProcessSlashingEvidence verify the slashing evidence received from other node,
+// it will be saved and broadcast to the other nodes if it is not known before
+func (c *Casper) ProcessSlashingEvidence(evidence *state.SlashingEvidence) error {
+	evidence = state.NewSlashingEvidence(evidence.PubKey, evidence.Type, evidence.Votes[0], evidence.Votes[1])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.verifySlashingEvidence(evidence); err == errUnknownSlashingBlock {
+		log.WithFields(log.Fields{"module": logModule, "pub_key": evidence.PubKey}).Debug("ignore slashing evidence of unknown block")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.saveSlashingEvidence(evidence)
+}
+
+// SlashingEvidences return all the slashing evidences known by current node
+func (c *Casper) SlashingEvidences() ([]*state.SlashingEvidence, error) {
+	return c.store.ListSlashingEvidences()
+}
+
+// tryRecordSlashingEvidence record the slashing evidence when the verification is rejected by the casper rules,
+// the error of the other rules will be ignored
+func (c *Casper) tryRecordSlashingEvidence(v, conflict *verification, err error) {
+	var slashingType state.SlashingType
+	switch err {
+	case errSameHeightInVerification:
+		slashingType = state.SameHeightSlashing
+	case errSpanHeightInVerification:
+		slashingType = state.SpanHeightSlashing
+	default:
+		return
+	}
+
+	// the signature of conflict is found by the validator order, make sure that it is signed by the same validator
+	if conflict == nil || conflict.verifySignature() != nil {
+		log.WithFields(log.Fields{"module": logModule, "pub_key": v.PubKey}).Warn("conflicting verification is not signed by the validator")
+		return
+	}
+
+	evidence := state.NewSlashingEvidence(v.PubKey, slashingType, v.toSignedVote(), conflict.toSignedVote())
+	if err := c.saveSlashingEvidence(evidence); err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err, "pub_key": v.PubKey}).Error("fail on save slashing evidence")
+	}
+}
+
+func (c *Casper) saveSlashingEvidence(evidence *state.SlashingEvidence) error {
+	id := evidence.ID()
+	if c.store.SlashingEvidenceExist(&id) {
+		return nil
+	}
+
+	if err := c.store.SaveSlashingEvidence(evidence); err != nil {
+		return err
+	}
+
+	return c.msgQueue.Post(*evidence)
+}
+
+func (c *Casper) verifySlashingEvidence(evidence *state.SlashingEvidence) error {
+	a, b := evidence.Votes[0], evidence.Votes[1]
+	switch evidence.Type {
+	case state.SameHeightSlashing:
+		if a.TargetHeight != b.TargetHeight || a.TargetHash == b.TargetHash {
+			return errors.Wrap(ErrInvalidSlashingEvidence, "the votes are not conflicting")
+		}
+	case state.SpanHeightSlashing:
+		if !(a.SourceHeight < b.SourceHeight && b.TargetHeight < a.TargetHeight) &&
+			!(b.SourceHeight < a.SourceHeight && a.TargetHeight < b.TargetHeight) {
+			return errors.Wrap(ErrInvalidSlashingEvidence, "the votes are not surrounded")
+		}
+	default:
+		return errors.Wrap(ErrInvalidSlashingEvidence, "unknown slashing type")
+	}
+
+	for i := range evidence.Votes {
+		if err := c.verifySignedVote(evidence.PubKey, &evidence.Votes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySignedVote verify the vote is signed by the validator, and the height of vote is consistent with the block,
+// because the height is not the part of the signed message
+func (c *Casper) verifySignedVote(pubKey string, vote *state.SignedVote) error {
+	if !c.store.BlockExist(&vote.SourceHash) || !c.store.BlockExist(&vote.TargetHash) {
+		return errUnknownSlashingBlock
+	}
+
+	source, err := c.store.GetBlockHeader(&vote.SourceHash)
+	if err != nil {
+		return err
+	}
+
+	target, err := c.store.GetBlockHeader(&vote.TargetHash)
+	if err != nil {
+		return err
+	}
+
+	if source.Height != vote.SourceHeight || target.Height != vote.TargetHeight {
+		return errors.Wrap(ErrInvalidSlashingEvidence, "height of vote is mismatch with block")
+	}
+
+	validators, err := c.validators(&vote.TargetHash)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := validators[pubKey]; !ok {
+		return errPubKeyIsNotValidator
+	}
+
+	v := &verification{
+		SourceHash:   vote.SourceHash,
+		TargetHash:   vote.TargetHash,
+		SourceHeight: vote.SourceHeight,
+		TargetHeight: vote.TargetHeight,
+		Signature:    vote.Signature,
+		PubKey:       pubKey,
+	}
+
+	if err := v.valid(); err != nil {
+		return errors.Sub(ErrInvalidSlashingEvidence, err)
+	}
+	return nil
+}
+
+// supLinkToConflict build the verification which the validator has been published in the supLink of checkpoint
+func supLinkToConflict(v *verification, checkpoint *state.Checkpoint, supLink *types.SupLink) *verification {
+	return &verification{
+		SourceHash:   supLink.SourceHash,
+		TargetHash:   checkpoint.Hash,
+		SourceHeight: supLink.SourceHeight,
+		TargetHeight: checkpoint.Height,
+		Signature:    supLink.Signatures[v.order],
+		PubKey:       v.PubKey,
+		order:        v.order,
+	}
+}
@@ -0,0 +1,95 @@
+package casper
+
+import (
+	"testing"
+
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/state"
+)
+
+func TestSlashingEvidenceID(t *testing.T) {
+	a := state.SignedVote{SourceHash: bc.Hash{V0: 1}, SourceHeight: 0, TargetHash: bc.Hash{V0: 2}, TargetHeight: 100, Signature: []byte{0x01}}
+	b := state.SignedVote{SourceHash: bc.Hash{V0: 1}, SourceHeight: 0, TargetHash: bc.Hash{V0: 3}, TargetHeight: 100, Signature: []byte{0x02}}
+
+	evidence1 := state.NewSlashingEvidence(pubKey, state.SameHeightSlashing, a, b)
+	evidence2 := state.NewSlashingEvidence(pubKey, state.SameHeightSlashing, b, a)
+	if evidence1.ID() != evidence2.ID() {
+		t.Errorf("the id of evidence should not depend on the order of votes")
+	}
+}
+
+func TestVerifySlashingEvidence(t *testing.T) {
+	cases := []struct {
+		desc     string
+		evidence *state.SlashingEvidence
+		wantErr  error
+	}{
+		{
+			desc: "votes to the same target",
+			evidence: &state.SlashingEvidence{
+				PubKey: pubKey,
+				Type:   state.SameHeightSlashing,
+				Votes: [2]state.SignedVote{
+					{SourceHeight: 0, TargetHeight: 100, TargetHash: bc.Hash{V0: 1}},
+					{SourceHeight: 0, TargetHeight: 100, TargetHash: bc.Hash{V0: 1}},
+				},
+			},
+			wantErr: ErrInvalidSlashingEvidence,
+		},
+		{
+			desc: "votes to the different target height",
+			evidence: &state.SlashingEvidence{
+				PubKey: pubKey,
+				Type:   state.SameHeightSlashing,
+				Votes: [2]state.SignedVote{
+					{SourceHeight: 0, TargetHeight: 100, TargetHash: bc.Hash{V0: 1}},
+					{SourceHeight: 0, TargetHeight: 200, TargetHash: bc.Hash{V0: 2}},
+				},
+			},
+			wantErr: ErrInvalidSlashingEvidence,
+		},
+		{
+			desc: "votes are not surrounded",
+			evidence: &state.SlashingEvidence{
+				PubKey: pubKey,
+				Type:   state.SpanHeightSlashing,
+				Votes: [2]state.SignedVote{
+					{SourceHeight: 0, TargetHeight: 100, TargetHash: bc.Hash{V0: 1}},
+					{SourceHeight: 100, TargetHeight: 200, TargetHash: bc.Hash{V0: 2}},
+				},
+			},
+			wantErr: ErrInvalidSlashingEvidence,
+		},
+		{
+			desc: "unknown slashing type",
+			evidence: &state.SlashingEvidence{
+				PubKey: pubKey,
+				Votes: [2]state.SignedVote{
+					{SourceHeight: 0, TargetHeight: 300, TargetHash: bc.Hash{V0: 1}},
+					{SourceHeight: 100, TargetHeight: 200, TargetHash: bc.Hash{V0: 2}},
+				},
+			},
+			wantErr: ErrInvalidSlashingEvidence,
+		},
+		{
+			desc: "surrounded votes of unknown block",
+			evidence: &state.SlashingEvidence{
+				PubKey: pubKey,
+				Type:   state.SpanHeightSlashing,
+				Votes: [2]state.SignedVote{
+					{SourceHeight: 0, TargetHeight: 300, TargetHash: bc.Hash{V0: 1}},
+					{SourceHeight: 100, TargetHeight: 200, TargetHash: bc.Hash{V0: 2}},
+				},
+			},
+			wantErr: errUnknownSlashingBlock,
+		},
+	}
+
+	c := &Casper{store: &mockStore2{}}
+	for i, testCase := range cases {
+		if err := c.verifySlashingEvidence(testCase.evidence); errors.Root(err) != testCase.wantErr {
+			t.Errorf("case #%d(%s) want err:%v, got err:%v", i, testCase.desc, testCase.wantErr, err)
+		}
+	}
+}
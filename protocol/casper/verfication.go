@@ -1,17 +1,15 @@
 package casper
 
 import (
-	"bytes"
 	"encoding/hex"
 	"errors"
 
-	"golang.org/x/crypto/sha3"
-
 	"coingod/consensus"
 	"coingod/crypto/ed25519/chainkd"
 	"coingod/protocol/bc"
 	"coingod/protocol/bc/types"
 	"coingod/protocol/state"
+	"coingod/signer"
 )
 
 var errVerifySignature = errors.New("signature of verification message is invalid")
@@ -71,17 +69,26 @@ func supLinkToVerifications(source, target *state.Checkpoint, supLink *types.Sup
 	return result
 }
 
-// Sign used to sign the verification by specified xPrv
-func (v *verification) Sign(xPrv chainkd.XPrv) error {
-	message, err := v.encodeMessage()
+// Sign used to sign the verification by the validator signer
+func (v *verification) Sign(s signer.Signer) error {
+	signature, err := s.SignVote(v.toVote())
 	if err != nil {
 		return err
 	}
 
-	v.Signature = xPrv.Sign(message)
+	v.Signature = signature
 	return nil
 }
 
+func (v *verification) toVote() *signer.Vote {
+	return &signer.Vote{
+		SourceHeight: v.SourceHeight,
+		SourceHash:   v.SourceHash,
+		TargetHeight: v.TargetHeight,
+		TargetHash:   v.TargetHash,
+	}
+}
+
 func (v *verification) toValidCasperSignMsg() ValidCasperSignMsg {
 	return ValidCasperSignMsg{
 		SourceHash: v.SourceHash,
@@ -91,6 +98,16 @@ func (v *verification) toValidCasperSignMsg() ValidCasperSignMsg {
 	}
 }
 
+func (v *verification) toSignedVote() state.SignedVote {
+	return state.SignedVote{
+		SourceHash:   v.SourceHash,
+		SourceHeight: v.SourceHeight,
+		TargetHash:   v.TargetHash,
+		TargetHeight: v.TargetHeight,
+		Signature:    v.Signature,
+	}
+}
+
 func (v *verification) valid() error {
 	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
 	if v.SourceHeight%blocksOfEpoch != 0 || v.TargetHeight%blocksOfEpoch != 0 {
@@ -127,15 +144,5 @@ func (v *verification) verifySignature() error {
 
 // encodeMessage encode the verification for the validators to sign or verify
 func (v *verification) encodeMessage() ([]byte, error) {
-	buff := new(bytes.Buffer)
-	if _, err := v.SourceHash.WriteTo(buff); err != nil {
-		return nil, err
-	}
-
-	if _, err := v.TargetHash.WriteTo(buff); err != nil {
-		return nil, err
-	}
-
-	msg := sha3.Sum256(buff.Bytes())
-	return msg[:], nil
+	return v.toVote().Message()
 }
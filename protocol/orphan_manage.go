@@ -107,6 +107,13 @@ func (o *OrphanManage) Equals(o1 *OrphanManage) bool {
 	return testutil.DeepEqual(o.prevOrphans, o1.prevOrphans)
 }
 
+// Size return the num of the orphan blocks
+func (o *OrphanManage) Size() int {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return len(o.orphan)
+}
+
 // Get return the orphan block by hash
 func (o *OrphanManage) Get(hash *bc.Hash) (*types.Block, bool) {
 	o.mtx.RLock()
@@ -123,6 +123,16 @@ func (c *Chain) ProcessBlockVerification(v *casper.ValidCasperSignMsg) error {
 	return c.casper.AuthVerification(v)
 }
 
+// ProcessSlashingEvidence process the slashing evidence received from other node
+func (c *Chain) ProcessSlashingEvidence(evidence *state.SlashingEvidence) error {
+	return c.casper.ProcessSlashingEvidence(evidence)
+}
+
+// SlashingEvidences return all the slashing evidences known by the node
+func (c *Chain) SlashingEvidences() ([]*state.SlashingEvidence, error) {
+	return c.casper.SlashingEvidences()
+}
+
 // BestBlockHeight returns the current height of the blockchain.
 func (c *Chain) BestBlockHeight() uint64 {
 	c.cond.L.Lock()
@@ -145,11 +155,28 @@ func (c *Chain) BestChain() (uint64, bc.Hash) {
 	return c.bestBlockHeader.Height, c.bestBlockHeader.Hash()
 }
 
+// BaseBlockHeight return the lowest height of the blocks with the transactions, it's above
+// zero when the node is bootstrapped from a snapshot
+func (c *Chain) BaseBlockHeight() uint64 {
+	return c.store.GetStoreStatus().BaseHeight
+}
+
 func (c *Chain) FinalizedHeight() uint64 {
 	finalizedHeight, _ := c.casper.LastFinalized()
 	return finalizedHeight
 }
 
+// JustifiedHeight return the height of the last justified checkpoint
+func (c *Chain) JustifiedHeight() uint64 {
+	justifiedHeight, _ := c.casper.LastJustified()
+	return justifiedHeight
+}
+
+// NumOrphanBlocks return the num of the orphan blocks waiting for the parent
+func (c *Chain) NumOrphanBlocks() int {
+	return c.orphanManage.Size()
+}
+
 // AllValidators return all validators has vote num
 func (c *Chain) AllValidators(blockHash *bc.Hash) ([]*state.Validator, error) {
 	parentCheckpoint, err := c.casper.ParentCheckpoint(blockHash)
@@ -192,10 +219,16 @@ func (c *Chain) InMainChain(hash bc.Hash) bool {
 	return *blockHash == hash
 }
 
-func (c *Chain) SignBlockHeader(blockHeader *types.BlockHeader) {
-	xprv := config.CommonConfig.PrivateKey()
-	signature := xprv.Sign(blockHeader.Hash().Bytes())
+// SignBlockHeader sign the block header by the validator signer, the signer refuses to
+// sign the block conflicting with the signed one
+func (c *Chain) SignBlockHeader(blockHeader *types.BlockHeader) error {
+	signature, err := config.CommonConfig.ValidatorSigner().SignBlock(blockHeader)
+	if err != nil {
+		return err
+	}
+
 	blockHeader.Set(signature)
+	return nil
 }
 
 // This function must be called with mu lock in above level
@@ -0,0 +1,133 @@
+package protocol_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"coingod/consensus"
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/database"
+	dbm "coingod/database/leveldb"
+	"coingod/event"
+	"coingod/protocol"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm"
+	"coingod/test"
+)
+
+func newReorgTestChain(t *testing.T, dir string) (*protocol.Chain, *event.Dispatcher) {
+	dispatcher := event.NewDispatcher()
+	store := database.NewStore(dbm.NewDB("chain", "leveldb", dir))
+	chain, err := protocol.NewChain(store, protocol.NewTxPool(store, dispatcher), dispatcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain, dispatcher
+}
+
+// appendSignedBlocks append the blocks signed by the only validator, the coinbase program
+// tells the blocks of the forks apart
+func appendSignedBlocks(t *testing.T, chain *protocol.Chain, xprv chainkd.XPrv, num int, coinbaseProgram []byte) []*types.Block {
+	blocks := []*types.Block{}
+	for i := 0; i < num; i++ {
+		block, err := test.NewBlock(chain, nil, coinbaseProgram)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block.Set(xprv.Sign(block.Hash().Bytes()))
+		if _, err := chain.ProcessBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// main:            |------(height=7)
+// --------(height=5)
+// fork:            |------------(height=9)
+func TestReorgEvent(t *testing.T) {
+	xprv, err := chainkd.NewXPrv(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(xpubs []chainkd.XPub) { consensus.ActiveNetParams.FederationXpubs = xpubs }(consensus.ActiveNetParams.FederationXpubs)
+	consensus.ActiveNetParams.FederationXpubs = []chainkd.XPub{xprv.XPub()}
+
+	mainDir, err := ioutil.TempDir("", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(mainDir)
+
+	forkDir, err := ioutil.TempDir("", "fork")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(forkDir)
+
+	mainChain, dispatcher := newReorgTestChain(t, mainDir)
+	mainBlocks := appendSignedBlocks(t, mainChain, xprv, 7, []byte{byte(vm.OP_TRUE)})
+
+	forkChain, _ := newReorgTestChain(t, forkDir)
+	for _, block := range mainBlocks[:5] {
+		if _, err := forkChain.ProcessBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forkBlocks := appendSignedBlocks(t, forkChain, xprv, 4, []byte{byte(vm.OP_TRUE), byte(vm.OP_TRUE)})
+
+	reorgSub, err := dispatcher.Subscribe(protocol.ReorgEvent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reorgSub.Unsubscribe()
+
+	for _, block := range forkBlocks {
+		if _, err := mainChain.ProcessBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if bestHeight, bestHash := mainChain.BestChain(); bestHash != forkBlocks[3].Hash() {
+		t.Fatalf("got best block at height %d, want the fork block at height %d", bestHeight, forkBlocks[3].Height)
+	}
+
+	var reorg protocol.ReorgEvent
+	select {
+	case obj := <-reorgSub.Chan():
+		reorg = obj.Data.(protocol.ReorgEvent)
+	case <-time.After(time.Second):
+		t.Fatal("no reorg event is posted")
+	}
+
+	if reorg.ForkHeight != 5 {
+		t.Errorf("got fork height %d, want 5", reorg.ForkHeight)
+	}
+
+	// the detached blocks are ordered down to the fork point
+	if len(reorg.Detached) != 2 || reorg.Detached[0].Hash() != mainBlocks[6].Hash() || reorg.Detached[1].Hash() != mainBlocks[5].Hash() {
+		t.Errorf("got detached blocks %v, want the main blocks 7 and 6", reorg.Detached)
+	}
+
+	// the attached blocks are ordered up from the fork point
+	if len(reorg.Attached) == 0 {
+		t.Fatal("got no attached blocks")
+	}
+
+	for i, header := range reorg.Attached {
+		if header.Hash() != forkBlocks[i].Hash() {
+			t.Errorf("got attached block #%d at height %d, want the fork block at height %d", i, header.Height, forkBlocks[i].Height)
+		}
+	}
+
+	select {
+	case obj := <-reorgSub.Chan():
+		t.Errorf("got the unexpected reorg event %v", obj.Data)
+	default:
+	}
+}
@@ -116,6 +116,17 @@ func (c *Checkpoint) GetValidator(timeStamp uint64) *Validator {
 
 // Increase will increase the height of checkpoint
 func (c *Checkpoint) Increase(block *types.Block) error {
+	if err := c.IncreaseVotes(block); err != nil {
+		return err
+	}
+
+	c.applyValidatorReward(block)
+	return nil
+}
+
+// IncreaseVotes will increase the height of checkpoint by the block which may only contain part of
+// the transactions, the rewards are not applied since they require all the transactions of the block
+func (c *Checkpoint) IncreaseVotes(block *types.Block) error {
 	if block.PreviousBlockHash != c.Hash {
 		return errIncreaseCheckpoint
 	}
@@ -128,7 +139,6 @@ func (c *Checkpoint) Increase(block *types.Block) error {
 	c.Height = block.Height
 	c.Timestamp = block.Timestamp
 	c.applyVotes(block)
-	c.applyValidatorReward(block)
 	return nil
 }
 
@@ -147,8 +157,9 @@ func (c *Checkpoint) EffectiveValidators() map[string]*Validator {
 		return federationValidators()
 	}
 
+	maxNumOfValidators := consensus.Rules(c.Height).MaxNumOfValidators
 	result := make(map[string]*Validator)
-	for i := 0; i < len(validators) && i < consensus.MaxNumOfValidators; i++ {
+	for i := 0; i < len(validators) && i < maxNumOfValidators; i++ {
 		validator := validators[i]
 		validator.Order = i
 		result[validator.PubKey] = validator
@@ -215,7 +226,7 @@ func getValidatorOrder(startTimestamp, blockTimestamp, numOfValidators uint64) u
 func federationValidators() map[string]*Validator {
 	validators := map[string]*Validator{}
 	if consensus.ActiveNetParams.Name == consensus.SoloNetParams.Name {
-		consensus.ActiveNetParams.FederationXpubs = []chainkd.XPub{config.CommonConfig.PrivateKey().XPub()}
+		consensus.ActiveNetParams.FederationXpubs = []chainkd.XPub{config.CommonConfig.ValidatorSigner().XPub()}
 	}
 	for i, xPub := range consensus.ActiveNetParams.FederationXpubs {
 		validators[xPub.String()] = &Validator{PubKey: xPub.String(), Order: i}
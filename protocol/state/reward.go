@@ -8,11 +8,12 @@ import (
 )
 
 func (c *Checkpoint) validatorReward() uint64 {
-	if pledgeRate := c.pledgeRate(); pledgeRate <= consensus.RewardThreshold {
-		return uint64((pledgeRate + consensus.RewardThreshold) * float64(consensus.BlockReward))
+	rules := consensus.Rules(c.Height)
+	if pledgeRate := c.pledgeRate(); pledgeRate <= rules.RewardThreshold {
+		return uint64((pledgeRate + rules.RewardThreshold) * float64(rules.BlockReward))
 	}
 
-	return consensus.BlockReward
+	return rules.BlockReward
 }
 
 // pledgeRate validator's pledge rate
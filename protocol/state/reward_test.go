@@ -0,0 +1,103 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"coingod/consensus"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+func mockCoinbaseBlock(parent *Checkpoint, program []byte) *types.Block {
+	coinbaseTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte{byte(parent.Height)})},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 0, program, nil)},
+	})
+
+	return &types.Block{
+		BlockHeader: types.BlockHeader{
+			Height:            parent.Height + 1,
+			PreviousBlockHash: parent.Hash,
+			Timestamp:         parent.Timestamp + consensus.ActiveNetParams.BlockTimeInterval,
+		},
+		Transactions: []*types.Tx{coinbaseTx},
+	}
+}
+
+func TestRewardAcrossFork(t *testing.T) {
+	defer func(params consensus.Params) { consensus.ActiveNetParams = params }(consensus.ActiveNetParams)
+
+	consensus.ActiveNetParams = consensus.SoloNetParams
+	consensus.ActiveNetParams.BlocksOfEpoch = 5
+	consensus.ActiveNetParams.Forks = []consensus.Fork{
+		{Name: "reduce-reward", Height: 3, Rules: consensus.ChainRules{BlockReward: 100}},
+		{Name: "raise-threshold", Epoch: 1, Rules: consensus.ChainRules{RewardThreshold: 0.8}},
+	}
+
+	program := []byte{0x00, 0x14, 0x01}
+	checkpoint := &Checkpoint{Hash: bc.Hash{V0: 1}, Rewards: map[string]uint64{}, Votes: map[string]uint64{}}
+	wantRewards := []uint64{
+		1: consensus.BlockReward / 2,
+		2: consensus.BlockReward / 2,
+		3: 50,
+		4: 50,
+		5: 80,
+		6: 80,
+	}
+
+	var total uint64
+	for height := uint64(1); height < uint64(len(wantRewards)); height++ {
+		if err := checkpoint.Increase(mockCoinbaseBlock(checkpoint, program)); err != nil {
+			t.Fatal(err)
+		}
+
+		total += wantRewards[height]
+		if got := checkpoint.Rewards[fmt.Sprintf("%x", program)]; got != total {
+			t.Errorf("height %d: got total reward %d, want %d", height, got, total)
+		}
+	}
+
+	if consensus.IsForkActive("reduce-reward", 2) || !consensus.IsForkActive("reduce-reward", 3) || !consensus.IsForkActive("raise-threshold", 5) {
+		t.Error("fork activation mismatch the schedule")
+	}
+}
+
+func TestEffectiveValidatorsAcrossFork(t *testing.T) {
+	defer func(params consensus.Params) { consensus.ActiveNetParams = params }(consensus.ActiveNetParams)
+
+	consensus.ActiveNetParams = consensus.SoloNetParams
+	consensus.ActiveNetParams.Forks = []consensus.Fork{{Name: "fewer-validators", Epoch: 2, Rules: consensus.ChainRules{MaxNumOfValidators: 3}}}
+
+	votes := map[string]uint64{}
+	for i := 0; i < consensus.MaxNumOfValidators+2; i++ {
+		votes[fmt.Sprintf("%02x", i)] = consensus.ActiveNetParams.MinValidatorVoteNum + uint64(i)
+	}
+
+	cases := []struct {
+		height uint64
+		want   int
+	}{
+		{height: 100, want: consensus.MaxNumOfValidators},
+		{height: 199, want: consensus.MaxNumOfValidators},
+		{height: 200, want: 3},
+		{height: 300, want: 3},
+	}
+
+	for _, c := range cases {
+		checkpoint := &Checkpoint{Height: c.height, Status: Justified, Votes: votes}
+		validators := checkpoint.EffectiveValidators()
+		if len(validators) != c.want {
+			t.Errorf("height %d: got %d validators, want %d", c.height, len(validators), c.want)
+		}
+
+		// the validators with the most votes are kept
+		for order := 0; order < c.want; order++ {
+			pubKey := fmt.Sprintf("%02x", consensus.MaxNumOfValidators+1-order)
+			if v, ok := validators[pubKey]; !ok || v.Order != order {
+				t.Errorf("height %d: validator %s should be in order %d", c.height, pubKey, order)
+			}
+		}
+	}
+}
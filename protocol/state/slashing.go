@@ -0,0 +1,77 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"coingod/crypto/sha3pool"
+	chainjson "coingod/encoding/json"
+	"coingod/protocol/bc"
+)
+
+// SlashingType represent which casper rule has been broken by the validator
+type SlashingType uint8
+
+const (
+	// SameHeightSlashing means the validator publish two distinct votes for the same target height
+	SameHeightSlashing SlashingType = iota + 1
+
+	// SpanHeightSlashing means the validator publish a vote within the span of its other votes
+	SpanHeightSlashing
+)
+
+// String return the readable name of the slashing type
+func (s SlashingType) String() string {
+	switch s {
+	case SameHeightSlashing:
+		return "same_height"
+	case SpanHeightSlashing:
+		return "span_height"
+	default:
+		return "unknown"
+	}
+}
+
+// SignedVote is a verification signed by the validator, the signature is over the source hash and target hash
+type SignedVote struct {
+	SourceHash   bc.Hash            `json:"source_hash"`
+	SourceHeight uint64             `json:"source_height"`
+	TargetHash   bc.Hash            `json:"target_hash"`
+	TargetHeight uint64             `json:"target_height"`
+	Signature    chainjson.HexBytes `json:"signature"`
+}
+
+// SlashingEvidence is the proof that a validator has published two conflicting votes,
+// both of the votes are signed by the validator so that anyone can verify it independently
+type SlashingEvidence struct {
+	PubKey string        `json:"pub_key"`
+	Type   SlashingType  `json:"type"`
+	Votes  [2]SignedVote `json:"votes"`
+}
+
+// NewSlashingEvidence create a slashing evidence, the votes are sorted by target height and signature,
+// so the same pair of votes always produce the same evidence
+func NewSlashingEvidence(pubKey string, slashingType SlashingType, a, b SignedVote) *SlashingEvidence {
+	if a.TargetHeight > b.TargetHeight || (a.TargetHeight == b.TargetHeight && bytes.Compare(a.Signature, b.Signature) > 0) {
+		a, b = b, a
+	}
+
+	return &SlashingEvidence{PubKey: pubKey, Type: slashingType, Votes: [2]SignedVote{a, b}}
+}
+
+// ID return the unique identity of the evidence
+func (s *SlashingEvidence) ID() bc.Hash {
+	buff := new(bytes.Buffer)
+	buff.WriteString(s.PubKey)
+	for _, vote := range s.Votes {
+		vote.SourceHash.WriteTo(buff)
+		vote.TargetHash.WriteTo(buff)
+		binary.Write(buff, binary.BigEndian, vote.SourceHeight)
+		binary.Write(buff, binary.BigEndian, vote.TargetHeight)
+		buff.Write(vote.Signature)
+	}
+
+	var hash [32]byte
+	sha3pool.Sum256(hash[:], buff.Bytes())
+	return bc.NewHash(hash)
+}
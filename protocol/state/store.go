@@ -23,6 +23,10 @@ type Store interface {
 	GetCheckpointsByHeight(uint64) ([]*Checkpoint, error)
 	SaveCheckpoints([]*Checkpoint) error
 
+	SlashingEvidenceExist(*bc.Hash) bool
+	ListSlashingEvidences() ([]*SlashingEvidence, error)
+	SaveSlashingEvidence(*SlashingEvidence) error
+
 	SaveBlock(*types.Block) error
 	SaveBlockHeader(*types.BlockHeader) error
 	SaveChainStatus(*types.BlockHeader, []*types.BlockHeader, *UtxoViewpoint, *ContractViewpoint, uint64, *bc.Hash) error
@@ -34,4 +38,7 @@ type BlockStoreState struct {
 	Hash            *bc.Hash
 	FinalizedHeight uint64
 	FinalizedHash   *bc.Hash
+	// BaseHeight is the lowest height whose block transactions are kept in the store, the
+	// blocks below it are loaded from a snapshot and only their headers are available
+	BaseHeight uint64
 }
@@ -33,7 +33,10 @@ func (c *Chain) ValidateTx(tx *types.Tx) (bool, error) {
 	}
 
 	bh := c.BestBlockHeader()
-	gasStatus, err := validation.ValidateTx(tx.Tx, types.MapBlock(&types.Block{BlockHeader: *bh}), c.ProgramConverter)
+	// the transaction is packed into the next block, so it's validated by the rules of the next height
+	nextHeader := *bh
+	nextHeader.Height++
+	gasStatus, err := validation.ValidateTx(tx.Tx, types.MapBlock(&types.Block{BlockHeader: nextHeader}), c.ProgramConverter)
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "tx_id": tx.Tx.ID.String(), "error": err}).Info("transaction status fail")
 		c.txPool.AddErrCache(&tx.ID, err)
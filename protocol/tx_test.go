@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"sync"
+	"testing"
+
+	"coingod/consensus"
+	"coingod/errors"
+	"coingod/event"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/vm"
+)
+
+func TestValidateTxByNextHeightRules(t *testing.T) {
+	defer func(forks []consensus.Fork) { consensus.ActiveNetParams.Forks = forks }(consensus.ActiveNetParams.Forks)
+	consensus.ActiveNetParams.Forks = []consensus.Fork{{Name: "low_gas", Height: 100, Rules: consensus.ChainRules{MaxGasAmount: 1}}}
+
+	cases := []struct {
+		desc       string
+		bestHeight uint64
+		wantErr    error
+	}{
+		{desc: "the next block is before the fork", bestHeight: 98},
+		{desc: "the next block activates the fork", bestHeight: 99, wantErr: vm.ErrRunLimitExceeded},
+		{desc: "the best block activates the fork", bestHeight: 100, wantErr: vm.ErrRunLimitExceeded},
+	}
+
+	for i, c := range cases {
+		chain := &Chain{
+			txPool:          NewTxPool(&mockSpendableStore{}, event.NewDispatcher()),
+			bestBlockHeader: &types.BlockHeader{Height: c.bestHeight},
+		}
+		chain.cond.L = new(sync.Mutex)
+
+		tx := newSpendTx(bc.NewHash([32]byte{byte(i + 1)}), 0, 100000000, 10000000)
+		if _, err := chain.ValidateTx(tx); errors.Root(err) != c.wantErr {
+			t.Errorf("case %d(%s): got err %v, want %v", i, c.desc, err, c.wantErr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"container/heap"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -29,6 +30,8 @@ var (
 	maxMsgChSize    = 1000
 	maxNewTxNum     = 10000
 	maxOrphanNum    = 2000
+	maxPoolWeight   = uint64(64 * 1024 * 1024)
+	maxReplacedNum  = 100
 
 	orphanTTL                = 10 * time.Minute
 	orphanExpireScanInterval = 3 * time.Minute
@@ -39,6 +42,12 @@ var (
 	ErrPoolIsFull = errors.New("transaction pool reach the max number")
 	// ErrDustTx indicates transaction is dust tx
 	ErrDustTx = errors.New("transaction is dust tx")
+	// ErrDoubleSpend indicates transaction spends the utxo which has been spent by the transaction in the pool
+	ErrDoubleSpend = errors.New("transaction conflicts with the transaction in the pool")
+	// ErrInsufficientFeeBump indicates the replacement transaction doesn't pay enough fee
+	ErrInsufficientFeeBump = errors.New("replacement transaction fee is insufficient")
+	// ErrReplaceTooMany indicates the replacement transaction will evict too many transactions
+	ErrReplaceTooMany = errors.New("replacement transaction evicts too many transactions")
 )
 
 type TxMsgEvent struct{ TxMsg *TxPoolMsg }
@@ -63,6 +72,47 @@ type orphanTx struct {
 	expiration time.Time
 }
 
+// txPackage is the transaction with all its in-pool descendants, it's the unit of the eviction
+type txPackage struct {
+	txD    *TxDesc
+	fee    uint64
+	weight uint64
+	index  int
+}
+
+func (p *txPackage) feeRate() float64 {
+	if p.weight == 0 {
+		return float64(p.fee)
+	}
+	return float64(p.fee) / float64(p.weight)
+}
+
+// packageQueue is the min heap of the packages order by the package fee rate
+type packageQueue []*txPackage
+
+func (q packageQueue) Len() int           { return len(q) }
+func (q packageQueue) Less(i, j int) bool { return q[i].feeRate() < q[j].feeRate() }
+
+func (q packageQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *packageQueue) Push(x interface{}) {
+	p := x.(*txPackage)
+	p.index = len(*q)
+	*q = append(*q, p)
+}
+
+func (q *packageQueue) Pop() interface{} {
+	old := *q
+	p := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return p
+}
+
 // TxPool is use for store the unconfirmed transaction
 type TxPool struct {
 	lastUpdated     int64
@@ -72,8 +122,15 @@ type TxPool struct {
 	utxo            map[bc.Hash]*types.Tx
 	orphans         map[bc.Hash]*orphanTx
 	orphansByPrev   map[bc.Hash]map[bc.Hash]*orphanTx
+	spent           map[bc.Hash]*TxDesc
+	packages        map[bc.Hash]*txPackage
+	evictQueue      packageQueue
+	weight          uint64
 	errCache        *lru.Cache
 	eventDispatcher *event.Dispatcher
+
+	replaceByFee bool
+	minFeeBump   uint64
 }
 
 // NewTxPool init a new TxPool
@@ -85,6 +142,8 @@ func NewTxPool(store state.Store, dispatcher *event.Dispatcher) *TxPool {
 		utxo:            make(map[bc.Hash]*types.Tx),
 		orphans:         make(map[bc.Hash]*orphanTx),
 		orphansByPrev:   make(map[bc.Hash]map[bc.Hash]*orphanTx),
+		spent:           make(map[bc.Hash]*TxDesc),
+		packages:        make(map[bc.Hash]*txPackage),
 		errCache:        lru.New(maxCachedErrTxs),
 		eventDispatcher: dispatcher,
 	}
@@ -92,6 +151,16 @@ func NewTxPool(store state.Store, dispatcher *event.Dispatcher) *TxPool {
 	return tp
 }
 
+// EnableReplaceByFee allow the transaction to replace the conflicting transactions in the pool,
+// the replacement must pay minFeeBump percent more fee than all the replaced transactions
+func (tp *TxPool) EnableReplaceByFee(minFeeBump uint64) {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+
+	tp.replaceByFee = true
+	tp.minFeeBump = minFeeBump
+}
+
 // AddErrCache add a failed transaction record to lru cache
 func (tp *TxPool) AddErrCache(txHash *bc.Hash, err error) {
 	tp.mtx.Lock()
@@ -134,14 +203,124 @@ func (tp *TxPool) RemoveTransaction(txHash *bc.Hash) {
 		return
 	}
 
+	tp.removeTransaction(txD)
+	log.WithFields(log.Fields{"module": logModule, "tx_id": txHash}).Debug("remove tx from mempool")
+}
+
+func (tp *TxPool) removeTransaction(txD *TxDesc) {
+	tp.updateAncestorPackages(txD, false)
+	if pkg, ok := tp.packages[txD.Tx.ID]; ok {
+		heap.Remove(&tp.evictQueue, pkg.index)
+		delete(tp.packages, txD.Tx.ID)
+	}
+
 	for _, output := range txD.Tx.ResultIds {
 		delete(tp.utxo, *output)
 	}
-	delete(tp.pool, *txHash)
+
+	for _, spend := range txD.Tx.SpentOutputIDs {
+		if tp.spent[spend] == txD {
+			delete(tp.spent, spend)
+		}
+	}
+
+	delete(tp.pool, txD.Tx.ID)
+	tp.weight -= txD.Weight
 
 	atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
 	tp.eventDispatcher.Post(TxMsgEvent{TxMsg: &TxPoolMsg{TxDesc: txD, MsgType: MsgRemoveTx}})
-	log.WithFields(log.Fields{"module": logModule, "tx_id": txHash}).Debug("remove tx from mempool")
+}
+
+// evictTransaction remove the transaction with all the in-pool transactions and orphans depend on it
+func (tp *TxPool) evictTransaction(txD *TxDesc) {
+	for _, descendant := range tp.descendants(txD) {
+		tp.removeTransaction(descendant)
+		tp.removeOrphansByPrev(descendant.Tx)
+		log.WithFields(log.Fields{"module": logModule, "tx_id": descendant.Tx.ID.String()}).Debug("evict tx from mempool")
+	}
+}
+
+// descendants return the transaction and all the in-pool transactions spend its outputs directly or indirectly,
+// each transaction is placed after all its descendants so that the ancestors are still in the pool when it's removed
+func (tp *TxPool) descendants(txD *TxDesc) []*TxDesc {
+	result := []*TxDesc{}
+	visited := make(map[bc.Hash]bool)
+	var visit func(*TxDesc)
+	visit = func(txD *TxDesc) {
+		visited[txD.Tx.ID] = true
+		for _, output := range txD.Tx.ResultIds {
+			if child, ok := tp.spent[*output]; ok && !visited[child.Tx.ID] {
+				visit(child)
+			}
+		}
+		result = append(result, txD)
+	}
+
+	visit(txD)
+	return result
+}
+
+// ancestorPackages return the packages of all the in-pool transactions the transaction spends directly or indirectly
+func (tp *TxPool) ancestorPackages(tx *types.Tx) []*txPackage {
+	result := []*txPackage{}
+	visited := make(map[bc.Hash]bool)
+	for queue := []*types.Tx{tx}; len(queue) > 0; queue = queue[1:] {
+		for _, spend := range queue[0].SpentOutputIDs {
+			parent, ok := tp.utxo[spend]
+			if !ok || visited[parent.ID] {
+				continue
+			}
+
+			visited[parent.ID] = true
+			if pkg, ok := tp.packages[parent.ID]; ok {
+				result = append(result, pkg)
+			}
+			queue = append(queue, parent)
+		}
+	}
+	return result
+}
+
+// updateAncestorPackages add or subtract the fee and weight of the transaction to the packages of its ancestors
+func (tp *TxPool) updateAncestorPackages(txD *TxDesc, add bool) {
+	for _, pkg := range tp.ancestorPackages(txD.Tx) {
+		if add {
+			pkg.fee += txD.Fee
+			pkg.weight += txD.Weight
+		} else {
+			pkg.fee -= txD.Fee
+			pkg.weight -= txD.Weight
+		}
+		heap.Fix(&tp.evictQueue, pkg.index)
+	}
+}
+
+// sumPackage set the fee and weight of the package to the sum of the transaction and all its
+// in-pool descendants, it returns the number of the transactions in the package
+func (tp *TxPool) sumPackage(pkg *txPackage) int {
+	descendants := tp.descendants(pkg.txD)
+	pkg.fee, pkg.weight = 0, 0
+	for _, txD := range descendants {
+		pkg.fee += txD.Fee
+		pkg.weight += txD.Weight
+	}
+	heap.Fix(&tp.evictQueue, pkg.index)
+	return len(descendants)
+}
+
+// removeOrphansByPrev remove the orphans which wait for the outputs of the transaction
+func (tp *TxPool) removeOrphansByPrev(tx *types.Tx) {
+	for _, output := range tx.ResultIds {
+		orphans, ok := tp.orphansByPrev[*output]
+		if !ok {
+			continue
+		}
+
+		for hash, orphan := range orphans {
+			tp.removeOrphan(&hash)
+			tp.removeOrphansByPrev(orphan.Tx)
+		}
+	}
 }
 
 // GetTransaction return the TxDesc by hash
@@ -169,6 +348,13 @@ func (tp *TxPool) GetTransactions() []*TxDesc {
 	return txDs
 }
 
+// Size return the num of the transactions and the orphan transactions in the pool
+func (tp *TxPool) Size() (int, int) {
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+	return len(tp.pool), len(tp.orphans)
+}
+
 // IsTransactionInPool check wheather a transaction in pool or not
 func (tp *TxPool) IsTransactionInPool(txHash *bc.Hash) bool {
 	tp.mtx.RLock()
@@ -238,10 +424,24 @@ func (tp *TxPool) processTransaction(tx *types.Tx, height, fee uint64) (bool, er
 		return false, err
 	}
 
+	replaced, err := tp.checkReplacement(txD)
+	if err != nil {
+		return false, err
+	}
+
 	if len(requireParents) > 0 {
+		if len(replaced) > 0 {
+			return false, ErrDoubleSpend
+		}
 		return true, tp.addOrphan(txD, requireParents)
 	}
 
+	for _, replacedTx := range replaced {
+		if _, ok := tp.pool[replacedTx.Tx.ID]; ok {
+			tp.evictTransaction(replacedTx)
+		}
+	}
+
 	if err := tp.addTransaction(txD); err != nil {
 		return false, err
 	}
@@ -276,13 +476,31 @@ func (tp *TxPool) addOrphan(txD *TxDesc, requireParents []*bc.Hash) error {
 }
 
 func (tp *TxPool) addTransaction(txD *TxDesc) error {
-	if len(tp.pool) >= maxNewTxNum {
-		return ErrPoolIsFull
+	if err := tp.makeRoom(txD); err != nil {
+		return err
 	}
 
 	tx := txD.Tx
 	txD.Added = time.Now()
 	tp.pool[tx.ID] = txD
+	tp.weight += txD.Weight
+	for _, spend := range tx.SpentOutputIDs {
+		tp.spent[spend] = txD
+	}
+
+	// the transaction restored by the reorganization may be spent by the in-pool transactions,
+	// its package and the packages of its ancestors are summed from the descendants again
+	pkg := &txPackage{txD: txD}
+	tp.packages[tx.ID] = pkg
+	heap.Push(&tp.evictQueue, pkg)
+	if tp.sumPackage(pkg) > 1 {
+		for _, ancestor := range tp.ancestorPackages(tx) {
+			tp.sumPackage(ancestor)
+		}
+	} else {
+		tp.updateAncestorPackages(txD, true)
+	}
+
 	for _, id := range tx.ResultIds {
 		_, err := tx.OriginalOutput(*id)
 		if err != nil {
@@ -299,6 +517,107 @@ func (tp *TxPool) addTransaction(txD *TxDesc) error {
 	return nil
 }
 
+// conflicts return the transactions in the pool which spend the same utxo with the transaction
+func (tp *TxPool) conflicts(tx *types.Tx) []*TxDesc {
+	result := []*TxDesc{}
+	visited := make(map[bc.Hash]bool)
+	for _, spend := range tx.SpentOutputIDs {
+		if txD, ok := tp.spent[spend]; ok && !visited[txD.Tx.ID] {
+			visited[txD.Tx.ID] = true
+			result = append(result, txD)
+		}
+	}
+	return result
+}
+
+// checkReplacement return the transactions should be evicted when the transaction is added to the pool,
+// the replacement must have higher fee rate than each conflicting transaction and pay minFeeBump percent
+// more fee than all the conflicting transactions with their descendants
+func (tp *TxPool) checkReplacement(txD *TxDesc) ([]*TxDesc, error) {
+	conflicts := tp.conflicts(txD.Tx)
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+
+	if !tp.replaceByFee {
+		return nil, ErrDoubleSpend
+	}
+
+	replaced := []*TxDesc{}
+	visited := make(map[bc.Hash]bool)
+	for _, conflict := range conflicts {
+		if feeRate(txD) <= feeRate(conflict) {
+			return nil, ErrInsufficientFeeBump
+		}
+
+		for _, descendant := range tp.descendants(conflict) {
+			if !visited[descendant.Tx.ID] {
+				visited[descendant.Tx.ID] = true
+				replaced = append(replaced, descendant)
+			}
+		}
+	}
+
+	if len(replaced) > maxReplacedNum {
+		return nil, ErrReplaceTooMany
+	}
+
+	replacedFee := uint64(0)
+	for _, replacedTx := range replaced {
+		replacedFee += replacedTx.Fee
+		for _, output := range replacedTx.Tx.ResultIds {
+			if tp.spentBy(txD.Tx, output) {
+				return nil, ErrDoubleSpend
+			}
+		}
+	}
+
+	if float64(txD.Fee) < float64(replacedFee)*float64(100+tp.minFeeBump)/100 {
+		return nil, ErrInsufficientFeeBump
+	}
+	return replaced, nil
+}
+
+func (tp *TxPool) spentBy(tx *types.Tx, output *bc.Hash) bool {
+	for _, spend := range tx.SpentOutputIDs {
+		if spend == *output {
+			return true
+		}
+	}
+	return false
+}
+
+// makeRoom evict the package with the lowest package fee rate when the pool is full, the package
+// fee rate counts the in-pool descendants so a parent paid by its children is kept, the transaction
+// can't be added if its fee rate is not higher than the evicted package
+func (tp *TxPool) makeRoom(txD *TxDesc) error {
+	for len(tp.pool) >= maxNewTxNum || tp.weight+txD.Weight > maxPoolWeight {
+		if len(tp.evictQueue) == 0 || tp.evictQueue[0].feeRate() >= feeRate(txD) {
+			return ErrPoolIsFull
+		}
+
+		lowest := tp.evictQueue[0].txD
+		evicted := tp.descendants(lowest)
+		for _, evictedTx := range evicted {
+			for _, output := range evictedTx.Tx.ResultIds {
+				if tp.spentBy(txD.Tx, output) {
+					return ErrPoolIsFull
+				}
+			}
+		}
+
+		tp.evictTransaction(lowest)
+	}
+	return nil
+}
+
+func feeRate(txD *TxDesc) float64 {
+	if txD.Weight == 0 {
+		return float64(txD.Fee)
+	}
+	return float64(txD.Fee) / float64(txD.Weight)
+}
+
 func (tp *TxPool) checkOrphanUtxos(tx *types.Tx) ([]*bc.Hash, error) {
 	view := state.NewUtxoViewpoint()
 	if err := tp.store.GetTransactionsUtxo(view, []*bc.Tx{tx.Tx}); err != nil {
@@ -349,8 +668,14 @@ func (tp *TxPool) processOrphans(txD *TxDesc) {
 		}
 
 		if len(requireParents) == 0 {
-			addRely(processOrphan.Tx)
 			tp.removeOrphan(&processOrphan.Tx.ID)
+			if len(tp.conflicts(processOrphan.Tx)) > 0 {
+				log.WithFields(log.Fields{"module": logModule, "tx_id": processOrphan.Tx.ID.String()}).Debug("drop orphan conflicts with mempool")
+				tp.removeOrphansByPrev(processOrphan.Tx)
+				continue
+			}
+
+			addRely(processOrphan.Tx)
 			tp.addTransaction(processOrphan.TxDesc)
 		}
 	}
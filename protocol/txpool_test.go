@@ -101,6 +101,9 @@ func (s *mockStore) GetBlockHeader(hash *bc.Hash) (*types.BlockHeader, error)
 func (s *mockStore) GetCheckpoint(hash *bc.Hash) (*state.Checkpoint, error)       { return nil, nil }
 func (s *mockStore) GetCheckpointsByHeight(u uint64) ([]*state.Checkpoint, error) { return nil, nil }
 func (s *mockStore) SaveCheckpoints([]*state.Checkpoint) error                    { return nil }
+func (s *mockStore) SlashingEvidenceExist(*bc.Hash) bool                          { return false }
+func (s *mockStore) ListSlashingEvidences() ([]*state.SlashingEvidence, error)    { return nil, nil }
+func (s *mockStore) SaveSlashingEvidence(*state.SlashingEvidence) error           { return nil }
 func (s *mockStore) CheckpointsFromNode(height uint64, hash *bc.Hash) ([]*state.Checkpoint, error) {
 	return nil, nil
 }
@@ -255,6 +258,8 @@ func TestAddTransaction(t *testing.T) {
 			before: &TxPool{
 				pool:            map[bc.Hash]*TxDesc{},
 				utxo:            map[bc.Hash]*types.Tx{},
+				spent:           map[bc.Hash]*TxDesc{},
+				packages:        map[bc.Hash]*txPackage{},
 				eventDispatcher: dispatcher,
 			},
 			after: &TxPool{
@@ -276,6 +281,8 @@ func TestAddTransaction(t *testing.T) {
 			before: &TxPool{
 				pool:            map[bc.Hash]*TxDesc{},
 				utxo:            map[bc.Hash]*types.Tx{},
+				spent:           map[bc.Hash]*TxDesc{},
+				packages:        map[bc.Hash]*txPackage{},
 				eventDispatcher: dispatcher,
 			},
 			after: &TxPool{
@@ -382,6 +389,8 @@ func TestProcessOrphans(t *testing.T) {
 			before: &TxPool{
 				pool:            map[bc.Hash]*TxDesc{},
 				utxo:            map[bc.Hash]*types.Tx{},
+				spent:           map[bc.Hash]*TxDesc{},
+				packages:        map[bc.Hash]*txPackage{},
 				eventDispatcher: dispatcher,
 				orphans: map[bc.Hash]*orphanTx{
 					testTxs[3].ID: {
@@ -410,6 +419,11 @@ func TestProcessOrphans(t *testing.T) {
 					*testTxs[3].ResultIds[0]: testTxs[3],
 					*testTxs[3].ResultIds[1]: testTxs[3],
 				},
+				spent: map[bc.Hash]*TxDesc{
+					testTxs[3].SpentOutputIDs[0]: {
+						Tx: testTxs[3],
+					},
+				},
 				eventDispatcher: dispatcher,
 				orphans:         map[bc.Hash]*orphanTx{},
 				orphansByPrev:   map[bc.Hash]map[bc.Hash]*orphanTx{},
@@ -420,6 +434,8 @@ func TestProcessOrphans(t *testing.T) {
 			before: &TxPool{
 				pool:            map[bc.Hash]*TxDesc{},
 				utxo:            map[bc.Hash]*types.Tx{},
+				spent:           map[bc.Hash]*TxDesc{},
+				packages:        map[bc.Hash]*txPackage{},
 				eventDispatcher: dispatcher,
 				orphans: map[bc.Hash]*orphanTx{
 					testTxs[3].ID: {
@@ -465,6 +481,14 @@ func TestProcessOrphans(t *testing.T) {
 					*testTxs[4].ResultIds[0]: testTxs[4],
 					*testTxs[4].ResultIds[1]: testTxs[4],
 				},
+				spent: map[bc.Hash]*TxDesc{
+					testTxs[3].SpentOutputIDs[0]: {
+						Tx: testTxs[3],
+					},
+					testTxs[4].SpentOutputIDs[0]: {
+						Tx: testTxs[4],
+					},
+				},
 				eventDispatcher: dispatcher,
 				orphans:         map[bc.Hash]*orphanTx{},
 				orphansByPrev:   map[bc.Hash]map[bc.Hash]*orphanTx{},
@@ -600,6 +624,9 @@ func (s *mockStore1) GetBlockHeader(hash *bc.Hash) (*types.BlockHeader, error)
 func (s *mockStore1) GetCheckpoint(hash *bc.Hash) (*state.Checkpoint, error)       { return nil, nil }
 func (s *mockStore1) GetCheckpointsByHeight(u uint64) ([]*state.Checkpoint, error) { return nil, nil }
 func (s *mockStore1) SaveCheckpoints([]*state.Checkpoint) error                    { return nil }
+func (s *mockStore1) SlashingEvidenceExist(*bc.Hash) bool                          { return false }
+func (s *mockStore1) ListSlashingEvidences() ([]*state.SlashingEvidence, error)    { return nil, nil }
+func (s *mockStore1) SaveSlashingEvidence(*state.SlashingEvidence) error           { return nil }
 func (s *mockStore1) CheckpointsFromNode(height uint64, hash *bc.Hash) ([]*state.Checkpoint, error) {
 	return nil, nil
 }
@@ -627,6 +654,8 @@ func TestProcessTransaction(t *testing.T) {
 		utxo:            make(map[bc.Hash]*types.Tx),
 		orphans:         make(map[bc.Hash]*orphanTx),
 		orphansByPrev:   make(map[bc.Hash]map[bc.Hash]*orphanTx),
+		spent:           make(map[bc.Hash]*TxDesc),
+		packages:        make(map[bc.Hash]*txPackage),
 		store:           &mockStore1{},
 		eventDispatcher: event.NewDispatcher(),
 	}
@@ -706,3 +735,241 @@ func TestProcessTransaction(t *testing.T) {
 		}
 	}
 }
+
+type mockSpendableStore struct {
+	mockStore
+}
+
+func (s *mockSpendableStore) GetTransactionsUtxo(utxoView *state.UtxoViewpoint, txs []*bc.Tx) error {
+	for _, tx := range txs {
+		for _, hash := range tx.SpentOutputIDs {
+			utxoView.Entries[hash] = &storage.UtxoEntry{Type: storage.NormalUTXOType, Spent: false}
+		}
+	}
+	return nil
+}
+
+func newTestTxPool(store state.Store) *TxPool {
+	return &TxPool{
+		pool:            make(map[bc.Hash]*TxDesc),
+		utxo:            make(map[bc.Hash]*types.Tx),
+		orphans:         make(map[bc.Hash]*orphanTx),
+		orphansByPrev:   make(map[bc.Hash]map[bc.Hash]*orphanTx),
+		spent:           make(map[bc.Hash]*TxDesc),
+		packages:        make(map[bc.Hash]*txPackage),
+		store:           store,
+		eventDispatcher: event.NewDispatcher(),
+	}
+}
+
+func newSpendTx(sourceID bc.Hash, sourcePos, amount, fee uint64) *types.Tx {
+	return types.NewTx(types.TxData{
+		SerializedSize: 100,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, sourceID, *consensus.CGAssetID, amount, sourcePos, []byte{0x51}, nil),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewOriginalTxOutput(*consensus.CGAssetID, amount-fee, []byte{0x51}, nil),
+		},
+	})
+}
+
+func newChildTx(parent *types.Tx, fee uint64) *types.Tx {
+	output := parent.Entries[*parent.ResultIds[0]].(*bc.OriginalOutput)
+	return newSpendTx(*output.Source.Ref, output.Source.Position, output.Source.Value.Amount, fee)
+}
+
+func TestReplaceByFee(t *testing.T) {
+	txPool := newTestTxPool(&mockStore1{})
+	if _, err := txPool.processTransaction(testTxs[0], 0, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := txPool.processTransaction(testTxs[1], 0, 200); err != ErrDoubleSpend {
+		t.Fatalf("got err %v, want %v when replace by fee is disabled", err, ErrDoubleSpend)
+	}
+
+	txPool.EnableReplaceByFee(10)
+	if _, err := txPool.processTransaction(testTxs[1], 0, 105); err != ErrInsufficientFeeBump {
+		t.Fatalf("got err %v, want %v when fee bump is too small", err, ErrInsufficientFeeBump)
+	}
+
+	if _, err := txPool.processTransaction(testTxs[1], 0, 110); err != nil {
+		t.Fatal(err)
+	}
+
+	if txPool.IsTransactionInPool(&testTxs[0].ID) || !txPool.IsTransactionInPool(&testTxs[1].ID) {
+		t.Fatal("the conflicting transaction should be replaced")
+	}
+
+	for _, spend := range testTxs[1].SpentOutputIDs {
+		if txPool.spent[spend].Tx != testTxs[1] {
+			t.Errorf("spent output %v should be indexed to the replacement", spend)
+		}
+	}
+}
+
+func TestReplaceByFeeWithDescendants(t *testing.T) {
+	txPool := newTestTxPool(&mockSpendableStore{})
+	txPool.EnableReplaceByFee(10)
+
+	parent := newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 10)
+	child := newChildTx(parent, 50)
+	for _, c := range []struct {
+		tx  *types.Tx
+		fee uint64
+	}{{parent, 10}, {child, 50}} {
+		if _, err := txPool.processTransaction(c.tx, 0, c.fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replacement := newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 60)
+	if _, err := txPool.processTransaction(replacement, 0, 60); err != ErrInsufficientFeeBump {
+		t.Fatalf("got err %v, want %v when the fee of descendants is not covered", err, ErrInsufficientFeeBump)
+	}
+
+	replacement = newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 66)
+	if _, err := txPool.processTransaction(replacement, 0, 66); err != nil {
+		t.Fatal(err)
+	}
+
+	if txPool.IsTransactionInPool(&parent.ID) || txPool.IsTransactionInPool(&child.ID) {
+		t.Error("the conflicting transaction should be evicted with its descendants")
+	}
+
+	if len(txPool.pool) != 1 || len(txPool.utxo) != 1 || len(txPool.spent) != 1 {
+		t.Errorf("got pool %d utxo %d spent %d, want 1 for each", len(txPool.pool), len(txPool.utxo), len(txPool.spent))
+	}
+}
+
+func TestEvictLowestFeeRate(t *testing.T) {
+	defer func(num int) { maxNewTxNum = num }(maxNewTxNum)
+	maxNewTxNum = 2
+
+	txPool := newTestTxPool(&mockSpendableStore{})
+	lowParent := newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 1)
+	child := newChildTx(lowParent, 20)
+	orphan := newChildTx(child, 20)
+	high := newSpendTx(bc.NewHash([32]byte{0x12}), 0, 1000, 50)
+	middle := newSpendTx(bc.NewHash([32]byte{0x13}), 0, 1000, 30)
+	low := newSpendTx(bc.NewHash([32]byte{0x14}), 0, 1000, 5)
+
+	for _, c := range []struct {
+		tx  *types.Tx
+		fee uint64
+	}{{lowParent, 1}, {child, 20}} {
+		if _, err := txPool.processTransaction(c.tx, 0, c.fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requireParent := orphan.SpentOutputIDs[0]
+	orphanDesc := &TxDesc{Tx: orphan, Weight: orphan.SerializedSize, Fee: 20}
+	if err := txPool.addOrphan(orphanDesc, []*bc.Hash{&requireParent}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := txPool.processTransaction(high, 0, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	if txPool.IsTransactionInPool(&lowParent.ID) || txPool.IsTransactionInPool(&child.ID) {
+		t.Error("the lowest fee rate transaction should be evicted with its descendants")
+	}
+
+	if len(txPool.orphans) != 0 || len(txPool.orphansByPrev) != 0 {
+		t.Error("the orphan depends on the evicted transaction should be removed")
+	}
+
+	if _, err := txPool.processTransaction(middle, 0, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := txPool.processTransaction(low, 0, 5); err != ErrPoolIsFull {
+		t.Fatalf("got err %v, want %v when the fee rate is lower than all the pool transactions", err, ErrPoolIsFull)
+	}
+
+	if txPool.weight != high.SerializedSize+middle.SerializedSize {
+		t.Errorf("got pool weight %d, want %d", txPool.weight, high.SerializedSize+middle.SerializedSize)
+	}
+}
+
+func TestEvictByPackageFeeRate(t *testing.T) {
+	defer func(num int) { maxNewTxNum = num }(maxNewTxNum)
+	maxNewTxNum = 3
+
+	txPool := newTestTxPool(&mockSpendableStore{})
+	parent := newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 1)
+	child := newChildTx(parent, 60)
+	middle := newSpendTx(bc.NewHash([32]byte{0x12}), 0, 1000, 20)
+	high := newSpendTx(bc.NewHash([32]byte{0x13}), 0, 1000, 40)
+
+	for _, c := range []struct {
+		tx  *types.Tx
+		fee uint64
+	}{{parent, 1}, {child, 60}, {middle, 20}} {
+		if _, err := txPool.processTransaction(c.tx, 0, c.fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if pkg := txPool.packages[parent.ID]; pkg.fee != 61 || pkg.weight != parent.SerializedSize+child.SerializedSize {
+		t.Fatalf("got parent package fee %d weight %d, want the fee and weight with the child", pkg.fee, pkg.weight)
+	}
+
+	if _, err := txPool.processTransaction(high, 0, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	if !txPool.IsTransactionInPool(&parent.ID) || !txPool.IsTransactionInPool(&child.ID) || txPool.IsTransactionInPool(&middle.ID) {
+		t.Fatal("the package with the lowest package fee rate should be evicted instead of the parent paid by its child")
+	}
+
+	txPool.RemoveTransaction(&child.ID)
+	if pkg := txPool.packages[parent.ID]; pkg.fee != 1 || pkg.weight != parent.SerializedSize {
+		t.Errorf("got parent package fee %d weight %d after the child removed, want its own fee and weight", pkg.fee, pkg.weight)
+	}
+
+	if len(txPool.packages) != len(txPool.pool) || len(txPool.evictQueue) != len(txPool.pool) {
+		t.Errorf("got %d packages and %d queued, want %d", len(txPool.packages), len(txPool.evictQueue), len(txPool.pool))
+	}
+}
+
+func TestPackageOfRestoredTransaction(t *testing.T) {
+	txPool := newTestTxPool(&mockSpendableStore{})
+	grandparent := newSpendTx(bc.NewHash([32]byte{0x11}), 0, 1000, 1)
+	parent := newChildTx(grandparent, 2)
+	child := newChildTx(parent, 60)
+	for _, c := range []struct {
+		tx  *types.Tx
+		fee uint64
+	}{{grandparent, 1}, {parent, 2}, {child, 60}} {
+		if _, err := txPool.processTransaction(c.tx, 0, c.fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the parent is confirmed by a block, then restored to the pool by the reorganization
+	txPool.RemoveTransaction(&parent.ID)
+	if _, err := txPool.processTransaction(parent, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if pkg := txPool.packages[parent.ID]; pkg.fee != 62 || pkg.weight != parent.SerializedSize+child.SerializedSize {
+		t.Fatalf("got restored parent package fee %d weight %d, want the fee and weight with the child", pkg.fee, pkg.weight)
+	}
+
+	if pkg := txPool.packages[grandparent.ID]; pkg.fee != 63 {
+		t.Fatalf("got grandparent package fee %d, want 63", pkg.fee)
+	}
+
+	txPool.RemoveTransaction(&child.ID)
+	if pkg := txPool.packages[parent.ID]; pkg.fee != 2 || pkg.weight != parent.SerializedSize {
+		t.Errorf("got parent package fee %d weight %d after the child removed, want its own fee and weight", pkg.fee, pkg.weight)
+	}
+
+	if pkg := txPool.packages[grandparent.ID]; pkg.fee != 3 || pkg.weight != grandparent.SerializedSize+parent.SerializedSize {
+		t.Errorf("got grandparent package fee %d weight %d after the child removed, want 3", pkg.fee, pkg.weight)
+	}
+}
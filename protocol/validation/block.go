@@ -127,13 +127,14 @@ func ValidateBlock(b *types.Block, parent *types.BlockHeader, checkpoint *state.
 
 	bcBlock := types.MapBlock(b)
 	blockGasSum := uint64(0)
+	maxBlockGas := consensus.Rules(b.Height).MaxBlockGas
 	validateResults := ValidateTxs(bcBlock.Transactions, bcBlock, converter)
 	for i, validateResult := range validateResults {
 		if validateResult.err != nil {
 			return errors.Wrapf(validateResult.err, "validate of transaction %d of %d", i, len(b.Transactions))
 		}
 
-		if blockGasSum += uint64(validateResult.gasStatus.GasUsed); blockGasSum > consensus.MaxBlockGas {
+		if blockGasSum += uint64(validateResult.gasStatus.GasUsed); blockGasSum > maxBlockGas {
 			return errOverBlockLimit
 		}
 	}
@@ -48,7 +48,7 @@ type GasState struct {
 	StorageGas int64
 }
 
-func (g *GasState) setGas(CGValue int64, txSize int64) error {
+func (g *GasState) setGas(CGValue int64, txSize int64, maxGasAmount int64) error {
 	if CGValue < 0 {
 		return errors.Wrap(ErrGasCalculate, "input CG is negative")
 	}
@@ -60,8 +60,8 @@ func (g *GasState) setGas(CGValue int64, txSize int64) error {
 		return errors.Wrap(ErrGasCalculate, "setGas calc gas amount")
 	}
 
-	if g.GasLeft > consensus.MaxGasAmount {
-		g.GasLeft = consensus.MaxGasAmount
+	if g.GasLeft > maxGasAmount {
+		g.GasLeft = maxGasAmount
 	}
 
 	if g.StorageGas, ok = checked.MulInt64(txSize, consensus.StorageGasRate); !ok {
@@ -110,11 +110,20 @@ type validationState struct {
 	block     *bc.Block
 	tx        *bc.Tx
 	gasStatus *GasState
-	entryID   bc.Hash              // The ID of the nearest enclosing entry
-	sourcePos uint64               // The source position, for validate ValueSources
-	destPos   uint64               // The destination position, for validate ValueDestinations
-	cache     map[bc.Hash]error    // Memoized per-entry validation results
-	converter ProgramConverterFunc // Program converter function
+	entryID   bc.Hash               // The ID of the nearest enclosing entry
+	sourcePos uint64                // The source position, for validate ValueSources
+	destPos   uint64                // The destination position, for validate ValueDestinations
+	cache     map[bc.Hash]error     // Memoized per-entry validation results
+	converter ProgramConverterFunc  // Program converter function
+	tracers   map[bc.Hash]vm.Tracer // The vm tracer of each input entry, only set by TraceTx
+	rules     *consensus.ChainRules // The consensus rules at the block height
+}
+
+func (vs *validationState) chainRules() *consensus.ChainRules {
+	if vs.rules == nil {
+		vs.rules = consensus.Rules(vs.block.Height)
+	}
+	return vs.rules
 }
 
 func checkValid(vs *validationState, e bc.Entry) (err error) {
@@ -173,7 +182,7 @@ func checkValid(vs *validationState, e bc.Entry) (err error) {
 
 		for assetID, amount := range parity {
 			if assetID == *consensus.CGAssetID {
-				if err = vs.gasStatus.setGas(amount, int64(vs.tx.SerializedSize)); err != nil {
+				if err = vs.gasStatus.setGas(amount, int64(vs.tx.SerializedSize), vs.chainRules().MaxGasAmount); err != nil {
 					return err
 				}
 			} else if amount != 0 {
@@ -526,6 +535,24 @@ func checkTimeRange(tx *bc.Tx, block *bc.Block) error {
 
 // ValidateTx validates a transaction.
 func ValidateTx(tx *bc.Tx, block *bc.Block, converter ProgramConverterFunc) (*GasState, error) {
+	return validateTx(tx, block, converter, nil)
+}
+
+// TraceTx validates the transaction like ValidateTx, and records the vm execution steps
+// of each input, the recorders are in the same order with the inputs
+func TraceTx(tx *bc.Tx, block *bc.Block, converter ProgramConverterFunc) (*GasState, []*vm.StepRecorder, error) {
+	recorders := make([]*vm.StepRecorder, len(tx.InputIDs))
+	tracers := make(map[bc.Hash]vm.Tracer, len(tx.InputIDs))
+	for i, inputID := range tx.InputIDs {
+		recorders[i] = &vm.StepRecorder{}
+		tracers[inputID] = recorders[i]
+	}
+
+	gasState, err := validateTx(tx, block, converter, tracers)
+	return gasState, recorders, err
+}
+
+func validateTx(tx *bc.Tx, block *bc.Block, converter ProgramConverterFunc, tracers map[bc.Hash]vm.Tracer) (*GasState, error) {
 	if block.Version == 1 && tx.Version != 1 {
 		return nil, errors.WithDetailf(ErrTxVersion, "block version %d, transaction version %d", block.Version, tx.Version)
 	}
@@ -549,6 +576,7 @@ func ValidateTx(tx *bc.Tx, block *bc.Block, converter ProgramConverterFunc) (*Ga
 		gasStatus: &GasState{},
 		cache:     make(map[bc.Hash]error),
 		converter: converter,
+		tracers:   tracers,
 	}
 
 	if err := checkValid(vs, tx.TxHeader); err != nil {
@@ -39,7 +39,7 @@ func TestGasStatus(t *testing.T) {
 				CGValue: 10000,
 			},
 			f: func(input *GasState) error {
-				return input.setGas(10000, 0)
+				return input.setGas(10000, 0, consensus.MaxGasAmount)
 			},
 			err: nil,
 		},
@@ -55,7 +55,7 @@ func TestGasStatus(t *testing.T) {
 				CGValue: 0,
 			},
 			f: func(input *GasState) error {
-				return input.setGas(-10000, 0)
+				return input.setGas(-10000, 0, consensus.MaxGasAmount)
 			},
 			err: ErrGasCalculate,
 		},
@@ -71,7 +71,7 @@ func TestGasStatus(t *testing.T) {
 				CGValue: 80000000000,
 			},
 			f: func(input *GasState) error {
-				return input.setGas(80000000000, 0)
+				return input.setGas(80000000000, 0, consensus.MaxGasAmount)
 			},
 			err: nil,
 		},
@@ -87,7 +87,7 @@ func TestGasStatus(t *testing.T) {
 				CGValue: math.MaxInt64,
 			},
 			f: func(input *GasState) error {
-				return input.setGas(math.MaxInt64, 0)
+				return input.setGas(math.MaxInt64, 0, consensus.MaxGasAmount)
 			},
 			err: nil,
 		},
@@ -82,6 +82,8 @@ func NewTxVMContext(vs *validationState, entry bc.Entry, prog *bc.Program, state
 		DestPos:       destPos,
 		SpentOutputID: spentOutputID,
 		CheckOutput:   ec.checkOutput,
+		Tracer:        vs.tracers[entryID],
+		MaxDepth:      vs.chainRules().MaxCheckPredicateDepth,
 	}
 
 	return result
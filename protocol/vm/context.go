@@ -33,4 +33,11 @@ type Context struct {
 
 	TxSigHash   func() []byte
 	CheckOutput func(index uint64, amount uint64, assetID []byte, vmVersion uint64, code []byte, state [][]byte, expansion bool) (bool, error)
+
+	// MaxDepth limits the nesting level of CHECKPREDICATE, zero means no limit.
+	MaxDepth int
+
+	// Tracer - if non-nil - will receive every step of the execution,
+	// including the steps of the CHECKPREDICATE child vm.
+	Tracer Tracer
 }
@@ -33,6 +33,10 @@ func opCheckPredicate(vm *virtualMachine) error {
 		return err
 	}
 
+	if vm.context != nil && vm.context.MaxDepth > 0 && vm.depth >= vm.context.MaxDepth {
+		return ErrDepthExceeded
+	}
+
 	vm.deferCost(-256 + 64) // get most of that cost back at the end
 	limitBigInt, err := vm.popBigInt(true)
 	if err != nil {
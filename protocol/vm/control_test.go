@@ -181,6 +181,16 @@ func TestControlOps(t *testing.T) {
 			deferredCost: -49954,
 			dataStack:    [][]byte{{0x05}, {}},
 		},
+	}, {
+		// the nesting level is limited by the consensus rules
+		op: OP_CHECKPREDICATE,
+		startVM: &virtualMachine{
+			runLimit:  50000,
+			depth:     1,
+			context:   &Context{MaxDepth: 1},
+			dataStack: [][]byte{{}, {byte(OP_TRUE)}, {}},
+		},
+		wantErr: ErrDepthExceeded,
 	}}
 
 	limitChecks := []Op{
@@ -7,6 +7,7 @@ var (
 	ErrBadValue           = errors.New("bad value")
 	ErrContext            = errors.New("wrong context")
 	ErrDataStackUnderflow = errors.New("data stack underflow")
+	ErrDepthExceeded      = errors.New("checkpredicate depth exceeded")
 	ErrDisallowedOpcode   = errors.New("disallowed opcode")
 	ErrDivZero            = errors.New("division by zero")
 	ErrFalseVMResult      = errors.New("false VM result")
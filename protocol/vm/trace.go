@@ -0,0 +1,67 @@
+package vm
+
+import (
+	chainjson "coingod/encoding/json"
+)
+
+// TraceStep is the snapshot of the vm after an instruction is executed
+type TraceStep struct {
+	Depth     int                  `json:"depth"`
+	PC        uint32               `json:"pc"`
+	Op        string               `json:"opcode"`
+	Data      chainjson.HexBytes   `json:"data,omitempty"`
+	RunLimit  int64                `json:"run_limit"`
+	DataStack []chainjson.HexBytes `json:"data_stack"`
+	AltStack  []chainjson.HexBytes `json:"alt_stack"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// Tracer receives every step executed by the vm. It is attached to the Context,
+// so each verification has its own tracer and can run concurrently.
+type Tracer interface {
+	CaptureStep(step *TraceStep)
+}
+
+// StepRecorder is a Tracer which keeps all the steps in memory
+type StepRecorder struct {
+	Steps []*TraceStep
+}
+
+// CaptureStep append the step to the recorder
+func (r *StepRecorder) CaptureStep(step *TraceStep) {
+	r.Steps = append(r.Steps, step)
+}
+
+func (vm *virtualMachine) captureStep(pc uint32, inst Instruction, err error) {
+	step := &TraceStep{
+		Depth:     vm.depth,
+		PC:        pc,
+		Op:        inst.Op.String(),
+		Data:      copyBytes(inst.Data),
+		RunLimit:  vm.runLimit,
+		DataStack: copyStack(vm.dataStack),
+		AltStack:  copyStack(vm.altStack),
+	}
+
+	if err != nil {
+		step.Error = err.Error()
+	}
+	vm.context.Tracer.CaptureStep(step)
+}
+
+// copyStack return the stack from bottom to top, the items are copied since the
+// vm may reuse the underlying array
+func copyStack(stack [][]byte) []chainjson.HexBytes {
+	result := make([]chainjson.HexBytes, 0, len(stack))
+	for _, item := range stack {
+		result = append(result, copyBytes(item))
+	}
+	return result
+}
+
+func copyBytes(data []byte) chainjson.HexBytes {
+	if data == nil {
+		return nil
+	}
+	return append(chainjson.HexBytes{}, data...)
+}
@@ -0,0 +1,60 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestTraceSteps(t *testing.T) {
+	prog, err := Assemble("0 0x51 0 CHECKPREDICATE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &StepRecorder{}
+	if _, err := Verify(&Context{VMVersion: 1, Code: prog, Tracer: recorder}, 10000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the step of the predicate is captured before the CHECKPREDICATE finished
+	wantDepths := []int{0, 0, 0, 1, 0}
+	if len(recorder.Steps) != len(wantDepths) {
+		t.Fatalf("got %d steps, want %d", len(recorder.Steps), len(wantDepths))
+	}
+
+	for i, step := range recorder.Steps {
+		if step.Depth != wantDepths[i] {
+			t.Errorf("step %d: got depth %d, want %d", i, step.Depth, wantDepths[i])
+		}
+	}
+
+	last := recorder.Steps[len(recorder.Steps)-1]
+	if last.Op != "CHECKPREDICATE" || len(last.DataStack) != 1 || !AsBool(last.DataStack[0]) {
+		t.Errorf("got last step %+v, want CHECKPREDICATE with true result", last)
+	}
+
+	for i := 1; i < len(recorder.Steps); i++ {
+		if recorder.Steps[i].RunLimit > recorder.Steps[i-1].RunLimit && recorder.Steps[i].Depth == recorder.Steps[i-1].Depth {
+			t.Errorf("step %d: run limit increased from %d to %d", i, recorder.Steps[i-1].RunLimit, recorder.Steps[i].RunLimit)
+		}
+	}
+}
+
+func TestTraceError(t *testing.T) {
+	prog, err := Assemble("1 ADD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &StepRecorder{}
+	if _, err := Verify(&Context{VMVersion: 1, Code: prog, Tracer: recorder}, 10000); err == nil {
+		t.Fatal("expect the program failed")
+	}
+
+	if len(recorder.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(recorder.Steps))
+	}
+
+	if step := recorder.Steps[1]; step.Op != "ADD" || step.Error == "" {
+		t.Errorf("got step %+v, want ADD with error", step)
+	}
+}
@@ -34,7 +34,8 @@ type virtualMachine struct {
 }
 
 // TraceOut - if non-nil - will receive trace output during
-// execution.
+// execution. It is shared by all the vm, use Context.Tracer
+// to trace a single verification.
 var TraceOut io.Writer
 
 // Verify program by running VM
@@ -100,8 +101,16 @@ func (vm *virtualMachine) step() error {
 		return err
 	}
 
+	pc := vm.pc
 	vm.nextPC = vm.pc + inst.Len
+	err = vm.execute(inst)
+	if vm.context != nil && vm.context.Tracer != nil {
+		vm.captureStep(pc, inst, err)
+	}
+	return err
+}
 
+func (vm *virtualMachine) execute(inst Instruction) error {
 	if TraceOut != nil {
 		opname := inst.Op.String()
 		fmt.Fprintf(TraceOut, "vm %d pc %d limit %d %s", vm.depth, vm.pc, vm.runLimit, opname)
@@ -122,11 +131,11 @@ func (vm *virtualMachine) step() error {
 
 	vm.deferredCost = 0
 	vm.data = inst.Data
-	if err = ops[inst.Op].fn(vm); err != nil {
+	if err := ops[inst.Op].fn(vm); err != nil {
 		return err
 	}
 
-	if err = vm.applyCost(vm.deferredCost); err != nil {
+	if err := vm.applyCost(vm.deferredCost); err != nil {
 		return err
 	}
 
@@ -0,0 +1,126 @@
+package signer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"coingod/errors"
+	"coingod/protocol/bc"
+)
+
+// maxGuardVotes is the num of the latest signed votes kept for the surround check
+const maxGuardVotes = 1024
+
+type guardState struct {
+	BlockHeight uint64  `json:"block_height"`
+	BlockHash   bc.Hash `json:"block_hash"`
+	Votes       []*Vote `json:"votes"`
+}
+
+// Guard record the signed blocks and votes, and reject the conflicting ones. The
+// record is saved to the file before the signature is returned, so the protection
+// survives the restart of the signer.
+type Guard struct {
+	mu    sync.Mutex
+	path  string
+	state guardState
+}
+
+// NewGuard load the signed record from the file, the record is only kept in memory
+// when the path is empty
+func NewGuard(path string) (*Guard, error) {
+	g := &Guard{path: path}
+	if path == "" {
+		return g, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return g, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &g.state); err != nil {
+		return nil, errors.Wrap(err, "unmarshal signer guard state")
+	}
+	return g, nil
+}
+
+// CheckBlock record the block if it doesn't conflict with the signed block, the same
+// block could be signed again
+func (g *Guard) CheckBlock(height uint64, hash bc.Hash) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state.BlockHeight != 0 {
+		if height < g.state.BlockHeight || (height == g.state.BlockHeight && hash != g.state.BlockHash) {
+			return errors.WithDetailf(ErrDoubleSignBlock, "signed height %d, request height %d", g.state.BlockHeight, height)
+		}
+
+		if height == g.state.BlockHeight {
+			return nil
+		}
+	}
+
+	state := g.state
+	state.BlockHeight, state.BlockHash = height, hash
+	return g.save(state)
+}
+
+// CheckVote record the vote if it's neither the double vote nor the surround vote of
+// the signed votes, the same vote could be signed again
+func (g *Guard) CheckVote(vote *Vote) error {
+	if vote.SourceHeight >= vote.TargetHeight {
+		return ErrBadVote
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, signed := range g.state.Votes {
+		if signed.TargetHeight == vote.TargetHeight {
+			if *signed == *vote {
+				return nil
+			}
+			return errors.WithDetailf(ErrDoubleVote, "target height %d", vote.TargetHeight)
+		}
+
+		if (signed.SourceHeight < vote.SourceHeight && vote.TargetHeight < signed.TargetHeight) ||
+			(vote.SourceHeight < signed.SourceHeight && signed.TargetHeight < vote.TargetHeight) {
+			return errors.WithDetailf(ErrSurroundVote, "signed vote %d->%d, request vote %d->%d", signed.SourceHeight, signed.TargetHeight, vote.SourceHeight, vote.TargetHeight)
+		}
+	}
+
+	signed := *vote
+	state := g.state
+	state.Votes = append(append([]*Vote{}, g.state.Votes...), &signed)
+	if len(state.Votes) > maxGuardVotes {
+		state.Votes = state.Votes[len(state.Votes)-maxGuardVotes:]
+	}
+	return g.save(state)
+}
+
+// save write the state to the file first, and take the state only when it's written
+func (g *Guard) save(state guardState) error {
+	if g.path != "" {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+
+		tmpPath := g.path + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+			return errors.Wrap(err, "write signer guard state")
+		}
+
+		if err := os.Rename(tmpPath, g.path); err != nil {
+			return errors.Wrap(err, "rename signer guard state")
+		}
+	}
+
+	g.state = state
+	return nil
+}
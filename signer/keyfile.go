@@ -0,0 +1,126 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+
+	"github.com/pborman/uuid"
+
+	"coingod/blockchain/pseudohsm"
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/errors"
+)
+
+const validatorKeyAlias = "validator"
+
+var (
+	// ErrNeedPassword means the key file is encrypted but no password is provided
+	ErrNeedPassword = errors.New("the validator key file is encrypted, password is required")
+	// ErrKeyEncrypted means the key file has already been encrypted
+	ErrKeyEncrypted = errors.New("the validator key file has already been encrypted")
+)
+
+// IsEncryptedKey return whether the content of the key file is the encrypted keystore json
+func IsEncryptedKey(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// LoadKeyFile read the validator key from the file, the file is either the hex of the
+// xprv or the scrypt encrypted json in the format of the pseudohsm keystore
+func LoadKeyFile(path, password string) (*chainkd.XPrv, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsEncryptedKey(data) {
+		if password == "" {
+			return nil, ErrNeedPassword
+		}
+
+		key, err := pseudohsm.DecryptKey(bytes.TrimSpace(data), password)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypt validator key")
+		}
+		return &key.XPrv, nil
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) < 2*len(chainkd.XPrv{}) {
+		return nil, errors.New("validator key file is too short")
+	}
+
+	var xprv chainkd.XPrv
+	if _, err := hex.Decode(xprv[:], data[:2*len(xprv)]); err != nil {
+		return nil, errors.Wrap(err, "decode validator key")
+	}
+	return &xprv, nil
+}
+
+// EncryptKeyFile replace the plain key file by the encrypted keystore json
+func EncryptKeyFile(path, password string, scryptN, scryptP int) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if IsEncryptedKey(data) {
+		return ErrKeyEncrypted
+	}
+
+	if password == "" {
+		return ErrNeedPassword
+	}
+
+	xprv, err := LoadKeyFile(path, "")
+	if err != nil {
+		return err
+	}
+
+	keyJSON, err := pseudohsm.EncryptKey(&pseudohsm.XKey{
+		ID:      uuid.NewRandom(),
+		KeyType: "coingod_kd",
+		Alias:   validatorKeyAlias,
+		XPrv:    *xprv,
+		XPub:    xprv.XPub(),
+	}, password, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+
+	return writeKeyFile(path, keyJSON)
+}
+
+// LoadOrCreateKeyFile read the plain key from the file, a new key is generated and
+// saved when the file doesn't exist
+func LoadOrCreateKeyFile(path string) (*chainkd.XPrv, error) {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return LoadKeyFile(path, "")
+	}
+
+	xprv, err := chainkd.NewXPrv(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeKeyFile(path, []byte(hex.EncodeToString(xprv[:]))); err != nil {
+		return nil, err
+	}
+	return &xprv, nil
+}
+
+// writeKeyFile write the key to a temp file and rename it, so the key file is never
+// left half written
+func writeKeyFile(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, "write key file")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "rename key file")
+	}
+	return nil
+}
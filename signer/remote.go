@@ -0,0 +1,202 @@
+package signer
+
+import (
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/errors"
+	"coingod/protocol/bc/types"
+)
+
+const serviceName = "Signer"
+
+// ErrBadSignerAddress means the address is neither the unix socket nor the loopback tcp address
+var ErrBadSignerAddress = errors.New("signer address must be unix:///path or tcp://127.0.0.1:port")
+
+// the errors from the remote signer are sent as the string, they are converted back
+// so that the caller could check them by errors.Root
+var remoteErrors = []error{ErrDoubleSignBlock, ErrDoubleVote, ErrSurroundVote, ErrBadVote}
+
+// parseAddress split the signer address to the network and the address of net.Dial,
+// the tcp address must be the loopback address since the channel is not encrypted
+func parseAddress(addr string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		return "unix", strings.TrimPrefix(addr, "unix://"), nil
+	case strings.HasPrefix(addr, "tcp://"):
+		address := strings.TrimPrefix(addr, "tcp://")
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return "", "", errors.WithDetail(ErrBadSignerAddress, err.Error())
+		}
+
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "", "", errors.WithDetailf(ErrBadSignerAddress, "%s is not the loopback address", host)
+		}
+		return "tcp", address, nil
+	default:
+		return "", "", ErrBadSignerAddress
+	}
+}
+
+// Service expose the signer by the net/rpc
+type Service struct {
+	signer Signer
+}
+
+// XPub return the public key of the validator
+func (s *Service) XPub(_ bool, reply *chainkd.XPub) error {
+	*reply = s.signer.XPub()
+	return nil
+}
+
+// SignBlock sign the header encoded by MarshalText, the header hash is calculated by
+// the signer itself
+func (s *Service) SignBlock(rawHeader []byte, reply *[]byte) error {
+	header := &types.BlockHeader{}
+	if err := header.UnmarshalText(rawHeader); err != nil {
+		return err
+	}
+
+	signature, err := s.signer.SignBlock(header)
+	if err != nil {
+		return err
+	}
+
+	*reply = signature
+	return nil
+}
+
+// SignVote sign the casper vote
+func (s *Service) SignVote(vote Vote, reply *[]byte) error {
+	signature, err := s.signer.SignVote(&vote)
+	if err != nil {
+		return err
+	}
+
+	*reply = signature
+	return nil
+}
+
+// Listen create the listener of the signer address, the stale unix socket file is removed
+func Listen(addr string) (net.Listener, error) {
+	network, address, err := parseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return net.Listen(network, address)
+}
+
+// Serve the signer on the listener, it returns when the listener is closed
+func Serve(listener net.Listener, signer Signer) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &Service{signer: signer}); err != nil {
+		return err
+	}
+
+	server.Accept(listener)
+	return nil
+}
+
+// RemoteSigner sign by the signer process through the unix socket or the loopback tcp
+type RemoteSigner struct {
+	network string
+	address string
+	xpub    chainkd.XPub
+
+	mu     sync.Mutex
+	client *rpc.Client
+}
+
+// NewRemoteSigner connect to the signer and fetch the public key of the validator
+func NewRemoteSigner(addr string) (*RemoteSigner, error) {
+	network, address, err := parseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &RemoteSigner{network: network, address: address}
+	if err := s.call("XPub", true, &s.xpub); err != nil {
+		return nil, errors.Wrap(err, "fetch xpub from remote signer")
+	}
+	return s, nil
+}
+
+// XPub return the public key of the validator
+func (s *RemoteSigner) XPub() chainkd.XPub {
+	return s.xpub
+}
+
+// SignBlock return the signature of the block header hash
+func (s *RemoteSigner) SignBlock(header *types.BlockHeader) ([]byte, error) {
+	rawHeader, err := header.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	if err := s.call("SignBlock", rawHeader, &signature); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// SignVote return the signature of the casper vote
+func (s *RemoteSigner) SignVote(vote *Vote) ([]byte, error) {
+	var signature []byte
+	if err := s.call("SignVote", *vote, &signature); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// call the signer method, the connection is dialed on demand and redialed once when
+// it's broken
+func (s *RemoteSigner) call(method string, args interface{}, reply interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for retry := 0; ; retry++ {
+		if s.client == nil {
+			client, err := rpc.Dial(s.network, s.address)
+			if err != nil {
+				return errors.Wrap(err, "dial remote signer")
+			}
+			s.client = client
+		}
+
+		err := s.client.Call(serviceName+"."+method, args, reply)
+		if _, ok := err.(rpc.ServerError); ok {
+			return remoteError(err)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		s.client.Close()
+		s.client = nil
+		if retry > 0 {
+			return errors.Wrap(err, "call remote signer")
+		}
+	}
+}
+
+func remoteError(err error) error {
+	for _, remoteErr := range remoteErrors {
+		if strings.Contains(err.Error(), remoteErr.Error()) {
+			return errors.WithDetail(remoteErr, err.Error())
+		}
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+// Package signer signs the block headers and the casper votes for the validator, the
+// signer refuses to sign the conflicting messages which could be slashed.
+package signer
+
+import (
+	"bytes"
+
+	"golang.org/x/crypto/sha3"
+
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+var (
+	// ErrDoubleSignBlock means the signer has signed another block at the same or higher height
+	ErrDoubleSignBlock = errors.New("refuse to sign the conflicting block")
+	// ErrDoubleVote means the signer has signed another vote with the same target height
+	ErrDoubleVote = errors.New("refuse to sign the vote with the same target height")
+	// ErrSurroundVote means the vote surrounds or is surrounded by a signed vote
+	ErrSurroundVote = errors.New("refuse to sign the surround vote")
+	// ErrBadVote means the source of the vote is not lower than the target
+	ErrBadVote = errors.New("vote source height must be lower than the target height")
+)
+
+// Signer is the interface of the validator key, the implementation must refuse to sign
+// the messages conflicting with the signed ones
+type Signer interface {
+	// XPub return the public key of the validator
+	XPub() chainkd.XPub
+	// SignBlock return the signature of the block header hash
+	SignBlock(header *types.BlockHeader) ([]byte, error)
+	// SignVote return the signature of the casper vote
+	SignVote(vote *Vote) ([]byte, error)
+}
+
+// Vote is the casper verification from the source checkpoint to the target checkpoint
+type Vote struct {
+	SourceHeight uint64  `json:"source_height"`
+	SourceHash   bc.Hash `json:"source_hash"`
+	TargetHeight uint64  `json:"target_height"`
+	TargetHash   bc.Hash `json:"target_hash"`
+}
+
+// Message return the message of the vote to sign, it's the sha3 of the source hash and target hash
+func (v *Vote) Message() ([]byte, error) {
+	buff := new(bytes.Buffer)
+	if _, err := v.SourceHash.WriteTo(buff); err != nil {
+		return nil, err
+	}
+
+	if _, err := v.TargetHash.WriteTo(buff); err != nil {
+		return nil, err
+	}
+
+	msg := sha3.Sum256(buff.Bytes())
+	return msg[:], nil
+}
+
+// LocalSigner sign with the key in the process memory
+type LocalSigner struct {
+	xprv  chainkd.XPrv
+	guard *Guard
+}
+
+// NewLocalSigner create the signer of the key, the signed messages are recorded by the guard
+func NewLocalSigner(xprv chainkd.XPrv, guard *Guard) *LocalSigner {
+	return &LocalSigner{xprv: xprv, guard: guard}
+}
+
+// XPub return the public key of the validator
+func (s *LocalSigner) XPub() chainkd.XPub {
+	return s.xprv.XPub()
+}
+
+// SignBlock return the signature of the block header hash
+func (s *LocalSigner) SignBlock(header *types.BlockHeader) ([]byte, error) {
+	hash := header.Hash()
+	if err := s.guard.CheckBlock(header.Height, hash); err != nil {
+		return nil, err
+	}
+
+	return s.xprv.Sign(hash.Bytes()), nil
+}
+
+// SignVote return the signature of the casper vote
+func (s *LocalSigner) SignVote(vote *Vote) ([]byte, error) {
+	message, err := vote.Message()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.CheckVote(vote); err != nil {
+		return nil, err
+	}
+
+	return s.xprv.Sign(message), nil
+}
@@ -0,0 +1,243 @@
+package signer
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"coingod/blockchain/pseudohsm"
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/errors"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+)
+
+func newTestXPrv(t *testing.T) chainkd.XPrv {
+	xprv, err := chainkd.NewXPrv(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return xprv
+}
+
+func TestGuardBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "signer_state.json")
+	guard, err := NewGuard(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashA, hashB := bc.NewHash([32]byte{1}), bc.NewHash([32]byte{2})
+	cases := []struct {
+		height uint64
+		hash   bc.Hash
+		want   error
+	}{
+		{height: 10, hash: hashA, want: nil},
+		{height: 10, hash: hashA, want: nil},
+		{height: 10, hash: hashB, want: ErrDoubleSignBlock},
+		{height: 9, hash: hashB, want: ErrDoubleSignBlock},
+		{height: 11, hash: hashB, want: nil},
+	}
+
+	for i, c := range cases {
+		if err := guard.CheckBlock(c.height, c.hash); errors.Root(err) != c.want {
+			t.Errorf("case %d: got err %v, want %v", i, err, c.want)
+		}
+	}
+
+	// the record survives the restart
+	reloaded, err := NewGuard(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reloaded.CheckBlock(11, hashA); errors.Root(err) != ErrDoubleSignBlock {
+		t.Errorf("got err %v after reload, want %v", err, ErrDoubleSignBlock)
+	}
+}
+
+func TestGuardVote(t *testing.T) {
+	guard, err := NewGuard("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vote := func(source, target uint64, hash byte) *Vote {
+		return &Vote{SourceHeight: source, SourceHash: bc.NewHash([32]byte{hash}), TargetHeight: target, TargetHash: bc.NewHash([32]byte{hash})}
+	}
+
+	cases := []struct {
+		vote *Vote
+		want error
+	}{
+		{vote: vote(100, 200, 1), want: nil},
+		{vote: vote(100, 200, 1), want: nil},
+		{vote: vote(100, 200, 2), want: ErrDoubleVote},
+		{vote: vote(200, 200, 1), want: ErrBadVote},
+		{vote: vote(0, 300, 1), want: ErrSurroundVote},
+		{vote: vote(200, 400, 1), want: nil},
+		{vote: vote(300, 350, 1), want: ErrSurroundVote},
+		{vote: vote(400, 500, 1), want: nil},
+	}
+
+	for i, c := range cases {
+		if err := guard.CheckVote(c.vote); errors.Root(err) != c.want {
+			t.Errorf("case %d: got err %v, want %v", i, err, c.want)
+		}
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	xprv := newTestXPrv(t)
+	guard, err := NewGuard("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := Listen("tcp://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go Serve(listener, NewLocalSigner(xprv, guard))
+
+	remote, err := NewRemoteSigner("tcp://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if remote.XPub() != xprv.XPub() {
+		t.Fatalf("got xpub %s, want %s", remote.XPub().String(), xprv.XPub().String())
+	}
+
+	header := &types.BlockHeader{Version: 1, Height: 5, Timestamp: 1000}
+	signature, err := remote.SignBlock(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := header.Hash()
+	if !xprv.XPub().Verify(hash.Bytes(), signature) {
+		t.Error("remote block signature is invalid")
+	}
+
+	header.Timestamp++
+	if _, err := remote.SignBlock(header); errors.Root(err) != ErrDoubleSignBlock {
+		t.Errorf("got err %v, want %v", err, ErrDoubleSignBlock)
+	}
+
+	vote := &Vote{SourceHeight: 0, TargetHeight: 100, TargetHash: bc.NewHash([32]byte{1})}
+	signature, err = remote.SignVote(vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := vote.Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !xprv.XPub().Verify(message, signature) {
+		t.Error("remote vote signature is invalid")
+	}
+
+	vote.TargetHash = bc.NewHash([32]byte{2})
+	if _, err := remote.SignVote(vote); errors.Root(err) != ErrDoubleVote {
+		t.Errorf("got err %v, want %v", err, ErrDoubleVote)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		addr string
+		ok   bool
+	}{
+		{addr: "unix:///tmp/signer.sock", ok: true},
+		{addr: "tcp://127.0.0.1:9889", ok: true},
+		{addr: "tcp://localhost:9889", ok: true},
+		{addr: "tcp://10.0.0.1:9889", ok: false},
+		{addr: "127.0.0.1:9889", ok: false},
+	}
+
+	for i, c := range cases {
+		if _, _, err := parseAddress(c.addr); (err == nil) != c.ok {
+			t.Errorf("case %d(%s): got err %v", i, c.addr, err)
+		}
+	}
+}
+
+func TestEncryptKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	xprv := newTestXPrv(t)
+	keyPath := filepath.Join(dir, "node_key.txt")
+	if err := ioutil.WriteFile(keyPath, []byte(hex.EncodeToString(xprv[:])), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptKeyFile(keyPath, "password", pseudohsm.LightScryptN, pseudohsm.LightScryptP); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptKeyFile(keyPath, "password", pseudohsm.LightScryptN, pseudohsm.LightScryptP); err != ErrKeyEncrypted {
+		t.Errorf("got err %v, want %v", err, ErrKeyEncrypted)
+	}
+
+	if _, err := LoadKeyFile(keyPath, ""); err != ErrNeedPassword {
+		t.Errorf("got err %v, want %v", err, ErrNeedPassword)
+	}
+
+	if _, err := LoadKeyFile(keyPath, "wrong"); err == nil {
+		t.Error("load the key by the wrong password")
+	}
+
+	got, err := LoadKeyFile(keyPath, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *got != xprv {
+		t.Errorf("got key %s, want %s", got.String(), xprv.String())
+	}
+}
+
+func TestLoadOrCreateKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "p2p_key.txt")
+	xprv, err := LoadOrCreateKeyFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(keyPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temp key file is left: %v", err)
+	}
+
+	got, err := LoadOrCreateKeyFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *got != *xprv {
+		t.Errorf("got key %s, want %s", got.String(), xprv.String())
+	}
+}
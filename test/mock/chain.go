@@ -13,6 +13,7 @@ var (
 
 type Chain struct {
 	bestBlockHeader *types.BlockHeader
+	baseBlockHeight uint64
 	heightMap       map[uint64]*types.Block
 	blockMap        map[bc.Hash]*types.Block
 
@@ -39,13 +40,17 @@ func (c *Chain) BestBlockHeight() uint64 {
 	return c.bestBlockHeader.Height
 }
 
+func (c *Chain) BaseBlockHeight() uint64 {
+	return c.baseBlockHeight
+}
+
 func (c *Chain) CalcNextSeed(hash *bc.Hash) (*bc.Hash, error) {
 	return &bc.Hash{V0: hash.V1, V1: hash.V2, V2: hash.V3, V3: hash.V0}, nil
 }
 
 func (c *Chain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
 	block, ok := c.blockMap[*hash]
-	if !ok {
+	if !ok || block.Height < c.baseBlockHeight {
 		return nil, errors.New("can't find block")
 	}
 	return block, nil
@@ -53,7 +58,7 @@ func (c *Chain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
 
 func (c *Chain) GetBlockByHeight(height uint64) (*types.Block, error) {
 	block, ok := c.heightMap[height]
-	if !ok {
+	if !ok || height < c.baseBlockHeight {
 		return nil, errors.New("can't find block")
 	}
 	return block, nil
@@ -114,6 +119,11 @@ func (c *Chain) ProcessBlock(block *types.Block) (bool, error) {
 	return false, nil
 }
 
+// SetBaseBlockHeight prune the transactions of the blocks below the height
+func (c *Chain) SetBaseBlockHeight(height uint64) {
+	c.baseBlockHeight = height
+}
+
 func (c *Chain) SetBestBlockHeader(header *types.BlockHeader) {
 	c.bestBlockHeader = header
 }
@@ -1,5 +1,18 @@
 package common
 
+// the storage drivers of the toolbar database
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite3"
+)
+
+// DBConfig select the storage driver, the MySQL is used when the driver is empty
+type DBConfig struct {
+	Driver       string
+	MySQLConfig  MySQLConfig
+	SQLiteConfig SQLiteConfig
+}
+
 type MySQLConfig struct {
 	Connection MySQLConnection `json:"connection"`
 	LogMode    bool            `json:"log_mode"`
@@ -12,3 +25,10 @@ type MySQLConnection struct {
 	Password string `json:"password"`
 	DbName   string `json:"database"`
 }
+
+// SQLiteConfig is the config of the embedded database, the path ":memory:" keeps
+// the whole database in memory
+type SQLiteConfig struct {
+	Path    string `json:"path"`
+	LogMode bool   `json:"log_mode"`
+}
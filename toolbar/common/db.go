@@ -5,10 +5,24 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 
 	"coingod/errors"
 )
 
+// NewDB open the database by the selected driver. The tables of the MySQL are imported by
+// the sql dump, while the tables of the embedded SQLite are created from the models.
+func NewDB(cfg *DBConfig, models ...interface{}) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case "", DriverMySQL:
+		return NewMySQLDB(cfg.MySQLConfig)
+	case DriverSQLite:
+		return NewSQLiteDB(cfg.SQLiteConfig, models...)
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported db driver %s", cfg.Driver))
+	}
+}
+
 func NewMySQLDB(cfg MySQLConfig) (*gorm.DB, error) {
 	dsnTemplate := "%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=true&loc=Local"
 	dsn := fmt.Sprintf(dsnTemplate, cfg.Connection.Username, cfg.Connection.Password, cfg.Connection.Host, cfg.Connection.Port, cfg.Connection.DbName)
@@ -24,3 +38,26 @@ func NewMySQLDB(cfg MySQLConfig) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// NewSQLiteDB open the embedded database file and create the tables of the models, the driver
+// requires the binary built with cgo
+func NewSQLiteDB(cfg SQLiteConfig, models ...interface{}) (*gorm.DB, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("sqlite db path is empty")
+	}
+
+	db, err := gorm.Open("sqlite3", cfg.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open sqlite db")
+	}
+
+	// sqlite only allows one writer, and each connection of ":memory:" has its own database
+	db.DB().SetMaxOpenConns(1)
+	db.LogMode(cfg.LogMode)
+	if err := db.AutoMigrate(models...).Error; err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "migrate sqlite tables")
+	}
+
+	return db, nil
+}
@@ -8,10 +8,22 @@ import (
 )
 
 type Config struct {
-	NodeIP      string             `json:"node_ip"`
-	ChainID     string             `json:"chain_id"`
-	MySQLConfig common.MySQLConfig `json:"mysql"`
-	RewardConf  *RewardConfig      `json:"reward_config"`
+	NodeIP       string              `json:"node_ip"`
+	ChainID      string              `json:"chain_id"`
+	GenesisFile  string              `json:"genesis_file"`
+	DBDriver     string              `json:"db_driver"`
+	MySQLConfig  common.MySQLConfig  `json:"mysql"`
+	SQLiteConfig common.SQLiteConfig `json:"sqlite"`
+	RewardConf   *RewardConfig       `json:"reward_config"`
+}
+
+// DBConfig return the storage config, the MySQL is used when the db_driver is not set
+func (c *Config) DBConfig() *common.DBConfig {
+	return &common.DBConfig{
+		Driver:       c.DBDriver,
+		MySQLConfig:  c.MySQLConfig,
+		SQLiteConfig: c.SQLiteConfig,
+	}
 }
 
 type RewardConfig struct {
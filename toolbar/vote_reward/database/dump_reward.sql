@@ -7,7 +7,7 @@
 #
 # Host: 127.0.0.1 (MySQL 5.7.24)
 # Database: vote_reward
-# Generation Time: 2019-07-22 13:41:50 +0000
+# Generation Time: 2026-10-16 16:07:54 +0000
 # ************************************************************
 
 
@@ -46,10 +46,10 @@ CREATE TABLE `utxos` (
   `vote_height` int(11) NOT NULL,
   `veto_height` int(11) NOT NULL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `output_id` (`output_id`),
-  KEY `xpub` (`xpub`),
-  KEY `vote_height` (`vote_height`),
-  KEY `veto_height` (`veto_height`)
+  UNIQUE KEY `uix_utxos_output_id` (`output_id`),
+  KEY `idx_utxos_xpub` (`xpub`),
+  KEY `idx_utxos_vote_height` (`vote_height`),
+  KEY `idx_utxos_veto_height` (`veto_height`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
 
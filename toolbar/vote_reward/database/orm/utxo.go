@@ -2,10 +2,10 @@ package orm
 
 type Utxo struct {
 	ID          uint64 `gorm:"primary_key"`
-	OutputID    string
-	Xpub        string
+	OutputID    string `gorm:"unique_index"`
+	Xpub        string `gorm:"index"`
 	VoteAddress string
 	VoteNum     uint64
-	VoteHeight  uint64
-	VetoHeight  uint64
+	VoteHeight  uint64 `gorm:"index"`
+	VetoHeight  uint64 `gorm:"index"`
 }
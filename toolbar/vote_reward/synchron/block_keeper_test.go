@@ -0,0 +1,101 @@
+package synchron
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"coingod/consensus"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/toolbar/common"
+	"coingod/toolbar/vote_reward/database/orm"
+)
+
+var (
+	testProgram = append([]byte{0x00, 0x14}, make([]byte, 20)...)
+	testVote    = make([]byte, 64)
+)
+
+func newTestKeeper(t *testing.T) *ChainKeeper {
+	db, err := common.NewSQLiteDB(common.SQLiteConfig{Path: ":memory:"}, &orm.ChainStatus{}, &orm.Utxo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Save(&orm.ChainStatus{BlockHeight: 0, BlockHash: "genesis"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &ChainKeeper{db: db}
+}
+
+func attachBlock(t *testing.T, keeper *ChainKeeper, block *types.Block) error {
+	chainStatus := &orm.ChainStatus{}
+	if err := keeper.db.First(chainStatus).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return keeper.AttachBlock(keeper.db, chainStatus, block)
+}
+
+func TestAttachBlock(t *testing.T) {
+	keeper := newTestKeeper(t)
+	defer keeper.db.Close()
+
+	voteTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{V0: 1}, *consensus.CGAssetID, 1000, 0, testProgram, nil)},
+		Outputs: []*types.TxOutput{types.NewVoteOutput(*consensus.CGAssetID, 1000, testProgram, testVote, nil)},
+	})
+	block1 := &types.Block{BlockHeader: types.BlockHeader{Height: 1}, Transactions: []*types.Tx{voteTx}}
+	if err := attachBlock(t, keeper, block1); err != nil {
+		t.Fatal(err)
+	}
+
+	utxo := &orm.Utxo{}
+	if err := keeper.db.Where(&orm.Utxo{OutputID: voteTx.OutputID(0).String()}).First(utxo).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if utxo.Xpub != hex.EncodeToString(testVote) || utxo.VoteNum != 1000 || utxo.VoteHeight != 1 || utxo.VetoHeight != 0 || utxo.VoteAddress == "" {
+		t.Fatalf("got vote utxo %+v", utxo)
+	}
+
+	output := voteTx.Entries[*voteTx.ResultIds[0]].(*bc.VoteOutput)
+	vetoTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewVetoInput(nil, *output.Source.Ref, *consensus.CGAssetID, 1000, output.Source.Position, testProgram, testVote, nil)},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 1000, testProgram, nil)},
+	})
+	block2 := &types.Block{BlockHeader: types.BlockHeader{Height: 2, PreviousBlockHash: block1.Hash()}, Transactions: []*types.Tx{vetoTx}}
+	if err := attachBlock(t, keeper, block2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keeper.db.First(utxo, utxo.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if utxo.VetoHeight != 2 {
+		t.Errorf("got veto height %d, want 2", utxo.VetoHeight)
+	}
+
+	chainStatus := &orm.ChainStatus{}
+	if err := keeper.db.First(chainStatus).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if blockHash := block2.Hash(); chainStatus.BlockHeight != 2 || chainStatus.BlockHash != blockHash.String() {
+		t.Errorf("got chain status %+v, want height 2", chainStatus)
+	}
+
+	// the veto of the unknown vote breaks the db consistency
+	unknownTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewVetoInput(nil, bc.Hash{V0: 9}, *consensus.CGAssetID, 1000, 0, testProgram, testVote, nil)},
+		Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 1000, testProgram, nil)},
+	})
+	block3 := &types.Block{BlockHeader: types.BlockHeader{Height: 3, PreviousBlockHash: block2.Hash()}, Transactions: []*types.Tx{unknownTx}}
+	if err := attachBlock(t, keeper, block3); err != ErrInconsistentDB {
+		t.Errorf("got err %v, want %v", err, ErrInconsistentDB)
+	}
+}
@@ -0,0 +1,393 @@
+package reward
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"coingod/account"
+	"coingod/blockchain/pseudohsm"
+	"coingod/blockchain/txbuilder"
+	cfg "coingod/config"
+	"coingod/consensus"
+	"coingod/crypto/ed25519/chainkd"
+	"coingod/errors"
+	"coingod/follower"
+	"coingod/protocol"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+const (
+	logModule = "votereward"
+
+	// the undo and the snapshot of the blocks in the latest rollbackEpochs epochs are kept
+	rollbackEpochs = 10
+	payoutTxTTL    = 10 * time.Minute
+
+	// the payout transaction is valid in the next payoutTxBlocks blocks, the batch is sent
+	// again when the transaction has expired in the finalized chain without the confirmation
+	payoutTxBlocks = 100
+)
+
+var (
+	errMissingUndo       = errors.New("can't find the undo of the detached block")
+	errMissingSettlement = errors.New("can't find the settlement of the payout transaction")
+	errBadConfig         = errors.New("invalid vote reward config")
+	errSignIncomplete    = errors.New("the payout transaction is not completely signed")
+	errMissingHistory    = errors.New("the blocks before the base block of the chain are not available")
+)
+
+// Chain is the chain service used by the distributor
+type Chain interface {
+	follower.Chain
+	FinalizedHeight() uint64
+	GetHeaderByHeight(height uint64) (*types.BlockHeader, error)
+	PrevCheckpointByPrevHash(prevBlockHash *bc.Hash) (*state.Checkpoint, error)
+}
+
+// Distributor follow the main chain to track the vote outputs of the validator, settle the
+// voter rewards at each checkpoint and pay the settlements which have been finalized
+type Distributor struct {
+	config   *cfg.VoteRewardConfig
+	password string
+	pubKey   string
+	store    *Store
+	chain    Chain
+	accounts *account.Manager
+	hsm      *pseudohsm.HSM
+	status   *status
+
+	buildTx  func(ctx context.Context, batch *Batch, timeRange uint64) (*types.Tx, error)
+	submitTx func(ctx context.Context, tx *types.Tx) error
+}
+
+// NewDistributor create the distributor which continues from the last applied block of the store
+func NewDistributor(config *cfg.Config, store *Store, chain *protocol.Chain, accounts *account.Manager, hsm *pseudohsm.HSM) (*Distributor, error) {
+	rewardConfig := config.VoteReward
+	if rewardConfig.AccountID == "" || rewardConfig.RewardRatio > 100 || rewardConfig.BatchSize <= 0 {
+		return nil, errors.WithDetail(errBadConfig, "account_id is required, reward_ratio must not exceed 100 and batch_size must be positive")
+	}
+
+	if _, err := accounts.FindByID(rewardConfig.AccountID); err != nil {
+		return nil, err
+	}
+
+	bestStatus, err := store.getStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	if bestStatus == nil {
+		genesis, err := chain.GetHeaderByHeight(0)
+		if err != nil {
+			return nil, err
+		}
+
+		bestStatus = &status{Height: 0, Hash: genesis.Hash()}
+	}
+
+	// the votes must be tracked from the genesis, so the distributor can't run on the node
+	// bootstrapped from a snapshot
+	if baseHeight := chain.BaseBlockHeight(); bestStatus.Height < baseHeight {
+		return nil, errors.WithDetailf(errMissingHistory, "distributor height %d, base height %d", bestStatus.Height, baseHeight)
+	}
+
+	d := &Distributor{
+		config:   rewardConfig,
+		password: config.VoteRewardPassword(),
+		pubKey:   config.ValidatorSigner().XPub().String(),
+		store:    store,
+		chain:    chain,
+		accounts: accounts,
+		hsm:      hsm,
+		status:   bestStatus,
+	}
+	d.buildTx = d.buildBatch
+	d.submitTx = func(ctx context.Context, tx *types.Tx) error {
+		return txbuilder.FinalizeTx(ctx, chain, tx)
+	}
+	return d, nil
+}
+
+// ListSettlements return the settlements of the voter rewards by the height order
+func (d *Distributor) ListSettlements() ([]*Settlement, error) {
+	return d.store.ListSettlements()
+}
+
+// Sync apply the main chain blocks and pay the finalized settlements when it has caught up
+// with the chain. It returns error when the blocks to apply are not available in the chain.
+// It must be run as a goroutine.
+func (d *Distributor) Sync() error {
+	return follower.NewFollower("vote reward distributor", d.chain, d).Run()
+}
+
+// BestChain return the height and hash of the last applied block
+func (d *Distributor) BestChain() (uint64, bc.Hash) {
+	return d.status.Height, d.status.Hash
+}
+
+// CaughtUp pay the finalized settlements when the distributor has caught up with the chain
+func (d *Distributor) CaughtUp() {
+	d.payout()
+}
+
+// ApplyBlock track the vote outputs of the block and settle the rewards at the checkpoint,
+// the block must be the child of the last applied block
+func (d *Distributor) ApplyBlock(block *types.Block) error {
+	if block.PreviousBlockHash != d.status.Hash {
+		return errors.New("the applied block is not the child of the distributor status")
+	}
+
+	var added, removed []*voteOutput
+	var confirmed []*payoutTxRef
+	for _, tx := range block.Transactions {
+		ref, err := d.store.getPayoutTx(tx.ID)
+		if err != nil {
+			return err
+		}
+
+		if ref != nil {
+			confirmed = append(confirmed, ref)
+		}
+
+		for _, input := range tx.Inputs {
+			vetoInput, ok := input.TypedInput.(*types.VetoInput)
+			if !ok || hex.EncodeToString(vetoInput.Vote) != d.pubKey {
+				continue
+			}
+
+			outputID, err := input.SpentOutputID()
+			if err != nil {
+				return err
+			}
+
+			output, err := d.store.getVoteOutput(outputID)
+			if err != nil {
+				return err
+			}
+
+			if output != nil {
+				removed = append(removed, output)
+			}
+		}
+
+		for i, output := range tx.Outputs {
+			if vote, ok := output.TypedOutput.(*types.VoteOutput); ok && hex.EncodeToString(vote.Vote) == d.pubKey {
+				added = append(added, &voteOutput{OutputID: *tx.OutputID(i), ControlProgram: output.ControlProgram, Amount: output.Amount})
+			}
+		}
+	}
+
+	var snapshot map[string]uint64
+	var settlement *Settlement
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	if block.Height%blocksOfEpoch == 0 {
+		var err error
+		if snapshot, err = d.snapshot(added, removed); err != nil {
+			return err
+		}
+
+		if settlement, err = d.settle(block); err != nil {
+			return err
+		}
+	}
+
+	newStatus := &status{Height: block.Height, Hash: block.Hash()}
+	if err := d.store.attachBlock(block.Height, added, removed, snapshot, settlement, confirmed, newStatus); err != nil {
+		return err
+	}
+
+	d.status = newStatus
+	if keep := rollbackEpochs * blocksOfEpoch; block.Height > keep {
+		d.store.prune(block.Height - keep)
+	}
+	return nil
+}
+
+// DetachBlock rollback the vote outputs and the settlement of the last applied block
+func (d *Distributor) DetachBlock(block *types.Block) error {
+	if block.Hash() != d.status.Hash {
+		return errors.New("the detached block is not the distributor status")
+	}
+
+	newStatus := &status{Height: block.Height - 1, Hash: block.PreviousBlockHash}
+	if err := d.store.detachBlock(block.Height, newStatus); err != nil {
+		return err
+	}
+
+	d.status = newStatus
+	return nil
+}
+
+// snapshot return the votes of each voter after the vote outputs of the block are applied
+func (d *Distributor) snapshot(added, removed []*voteOutput) (map[string]uint64, error) {
+	votes, err := d.store.currentVotes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, output := range removed {
+		program := hex.EncodeToString(output.ControlProgram)
+		if votes[program] -= output.Amount; votes[program] == 0 {
+			delete(votes, program)
+		}
+	}
+
+	for _, output := range added {
+		votes[hex.EncodeToString(output.ControlProgram)] += output.Amount
+	}
+	return votes, nil
+}
+
+// settle share the validator rewards of the epoch ends at the checkpoint block by the
+// votes at the previous checkpoint, nil is returned when there is nothing to pay
+func (d *Distributor) settle(block *types.Block) (*Settlement, error) {
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	if block.Height < blocksOfEpoch {
+		return nil, nil
+	}
+
+	votes, err := d.store.getSnapshot(block.Height - blocksOfEpoch)
+	if err != nil || len(votes) == 0 {
+		return nil, err
+	}
+
+	hash := block.Hash()
+	checkpoint, err := d.chain.PrevCheckpointByPrevHash(&hash)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := d.accounts.GetCoinbaseControlProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := checkpoint.Rewards[hex.EncodeToString(program)]
+	if rewards == 0 {
+		return nil, nil
+	}
+
+	settlement, err := newSettlement(block.Height, hash, rewards, d.config.RewardRatio, votes, d.config.BatchSize)
+	if err != nil || len(settlement.Batches) == 0 {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "height": block.Height, "rewards": rewards, "distributed": settlement.Distributed}).Info("settle the voter rewards")
+	return settlement, nil
+}
+
+// payout send the unconfirmed batches of the finalized settlements, the finalized blocks
+// could never be rolled back so the payouts are safe from the chain reorganization
+func (d *Distributor) payout() {
+	settlements, err := d.store.ListSettlements()
+	if err != nil {
+		log.WithFields(log.Fields{"module": logModule, "err": err}).Error("vote reward list settlements")
+		return
+	}
+
+	finalizedHeight := d.chain.FinalizedHeight()
+	for _, settlement := range settlements {
+		if settlement.Height > finalizedHeight {
+			return
+		}
+
+		for i, batch := range settlement.Batches {
+			if !batch.needSend(finalizedHeight) {
+				continue
+			}
+
+			if err := d.sendBatch(settlement, i); err != nil {
+				log.WithFields(log.Fields{"module": logModule, "err": err, "height": settlement.Height}).Warn("vote reward send payouts")
+				return
+			}
+		}
+	}
+}
+
+// sendBatch submit the payout transaction of the batch, the transaction id is recorded
+// before the submission so the batch is never paid twice by a crash after it, the batch
+// of the failed submission is sent again when the transaction has expired
+func (d *Distributor) sendBatch(settlement *Settlement, index int) error {
+	ctx := context.Background()
+	batch := settlement.Batches[index]
+	timeRange := d.chain.BestBlockHeight() + payoutTxBlocks
+	tx, err := d.buildTx(ctx, batch, timeRange)
+	if err != nil {
+		return err
+	}
+
+	expiredTxID, expiredTimeRange := batch.TxID, batch.TimeRange
+	txID := tx.ID
+	batch.TxID, batch.TimeRange = &txID, timeRange
+	if err := d.store.savePayoutTx(settlement, index, expiredTxID); err != nil {
+		batch.TxID, batch.TimeRange = expiredTxID, expiredTimeRange
+		return err
+	}
+
+	if err := d.submitTx(ctx, tx); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"module": logModule, "height": settlement.Height, "tx_id": txID.String(), "payouts": len(batch.Payouts)}).Info("send the voter rewards")
+	return nil
+}
+
+func (d *Distributor) buildBatch(ctx context.Context, batch *Batch, timeRange uint64) (*types.Tx, error) {
+	actions := []txbuilder.Action{}
+	total := d.config.Fee
+	for _, payout := range batch.Payouts {
+		action, err := decodeAction(txbuilder.DecodeControlProgramAction, map[string]interface{}{
+			"asset_id":        consensus.CGAssetID.String(),
+			"amount":          payout.Amount,
+			"control_program": hex.EncodeToString(payout.ControlProgram),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, action)
+		total += payout.Amount
+	}
+
+	spendAction, err := decodeAction(d.accounts.DecodeSpendAction, map[string]interface{}{
+		"account_id": d.config.AccountID,
+		"asset_id":   consensus.CGAssetID.String(),
+		"amount":     total,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := txbuilder.Build(ctx, nil, append(actions, spendAction), time.Now().Add(payoutTxTTL), timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := txbuilder.Sign(ctx, tpl, d.password, d.signTemplate); err != nil {
+		return nil, err
+	}
+
+	if !txbuilder.SignProgress(tpl) {
+		return nil, errSignIncomplete
+	}
+	return tpl.Transaction, nil
+}
+
+func (d *Distributor) signTemplate(ctx context.Context, xpub chainkd.XPub, path [][]byte, data [32]byte, password string) ([]byte, error) {
+	return d.hsm.XSign(xpub, path, data[:], password)
+}
+
+func decodeAction(decoder func([]byte) (txbuilder.Action, error), fields map[string]interface{}) (txbuilder.Action, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder(data)
+}
@@ -0,0 +1,209 @@
+package reward
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+type mockChain struct {
+	bestHeight      uint64
+	finalizedHeight uint64
+}
+
+func (c *mockChain) BaseBlockHeight() uint64 {
+	return 0
+}
+
+func (c *mockChain) BestBlockHeight() uint64 {
+	return c.bestHeight
+}
+
+func (c *mockChain) FinalizedHeight() uint64 {
+	return c.finalizedHeight
+}
+
+func (c *mockChain) BlockWaiter(height uint64) <-chan struct{} {
+	return make(chan struct{})
+}
+
+func (c *mockChain) GetBlockByHeight(height uint64) (*types.Block, error) {
+	return nil, nil
+}
+
+func (c *mockChain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
+	return nil, nil
+}
+
+func (c *mockChain) GetHeaderByHeight(height uint64) (*types.BlockHeader, error) {
+	return nil, nil
+}
+
+func (c *mockChain) InMainChain(hash bc.Hash) bool {
+	return true
+}
+
+func (c *mockChain) PrevCheckpointByPrevHash(prevBlockHash *bc.Hash) (*state.Checkpoint, error) {
+	return nil, nil
+}
+
+// newTestDistributor return the distributor whose payout transaction of the batch is
+// identified by the time range, the submission fails when submitErr is set
+func newTestDistributor(chain *mockChain, submitErr *error) (*Distributor, *[]*types.Tx) {
+	submitted := []*types.Tx{}
+	d := &Distributor{store: NewStore(dbm.NewMemDB()), chain: chain, status: &status{}}
+	d.buildTx = func(_ context.Context, batch *Batch, timeRange uint64) (*types.Tx, error) {
+		return types.NewTx(types.TxData{TimeRange: timeRange, Outputs: []*types.TxOutput{types.NewOriginalTxOutput(bc.AssetID{}, batch.Payouts[0].Amount, batch.Payouts[0].ControlProgram, nil)}}), nil
+	}
+	d.submitTx = func(_ context.Context, tx *types.Tx) error {
+		if *submitErr != nil {
+			return *submitErr
+		}
+
+		submitted = append(submitted, tx)
+		return nil
+	}
+	return d, &submitted
+}
+
+func newTestSettlement(height uint64, amounts ...uint64) *Settlement {
+	settlement := &Settlement{Height: height}
+	for _, amount := range amounts {
+		settlement.Batches = append(settlement.Batches, &Batch{Payouts: []*Payout{{ControlProgram: []byte{0x51}, Amount: amount}}})
+	}
+	return settlement
+}
+
+func TestPayoutBeforeSubmission(t *testing.T) {
+	chain := &mockChain{bestHeight: 300, finalizedHeight: 200}
+	var submitErr error
+	d, submitted := newTestDistributor(chain, &submitErr)
+	for _, settlement := range []*Settlement{newTestSettlement(200, 1, 2), newTestSettlement(300, 3)} {
+		if err := d.store.attachBlock(settlement.Height, nil, nil, nil, settlement, nil, &status{Height: settlement.Height}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the batch is recorded before the submission, it's not sent again until the tx expires
+	submitErr = errors.New("mempool is full")
+	d.payout()
+	settlement, err := d.store.GetSettlement(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failedBatch := settlement.Batches[0]
+	if len(*submitted) != 0 || failedBatch.TxID == nil || failedBatch.TimeRange != chain.bestHeight+payoutTxBlocks || settlement.Batches[1].TxID != nil {
+		t.Fatalf("got the batches %v and %v after the failed submission", failedBatch, settlement.Batches[1])
+	}
+
+	if ref, err := d.store.getPayoutTx(*failedBatch.TxID); err != nil || ref == nil || ref.Height != 200 || ref.Batch != 0 {
+		t.Fatalf("got payout tx ref %v, err %v of the failed submission", ref, err)
+	}
+
+	// the settlement above the finalized height is not paid
+	submitErr, chain.bestHeight = nil, 350
+	d.payout()
+	if settlement, err = d.store.GetSettlement(200); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*submitted) != 1 || *settlement.Batches[0].TxID != *failedBatch.TxID {
+		t.Fatalf("got %d submitted payout txs, want 1", len(*submitted))
+	}
+
+	batch, tx := settlement.Batches[1], (*submitted)[0]
+	if batch.TxID == nil || *batch.TxID != tx.ID || batch.TimeRange != chain.bestHeight+payoutTxBlocks {
+		t.Errorf("got tx id %v time range %d, want %s and %d", batch.TxID, batch.TimeRange, tx.ID.String(), chain.bestHeight+payoutTxBlocks)
+	}
+
+	if ref, err := d.store.getPayoutTx(tx.ID); err != nil || ref == nil || ref.Height != 200 || ref.Batch != 1 {
+		t.Errorf("got payout tx ref %v, err %v", ref, err)
+	}
+
+	// the sent batches are waiting for the confirmation before they expire
+	d.payout()
+	if len(*submitted) != 1 {
+		t.Errorf("got %d submitted payout txs after the second payout, want 1", len(*submitted))
+	}
+
+	// the tx of the failed submission expired in the finalized chain, it's sent again with
+	// the settlement which is finalized now
+	chain.bestHeight, chain.finalizedHeight = 500, 401
+	d.payout()
+	if len(*submitted) != 3 {
+		t.Fatalf("got %d submitted payout txs after the expiration, want 3", len(*submitted))
+	}
+
+	if ref, err := d.store.getPayoutTx(*failedBatch.TxID); err != nil || ref != nil {
+		t.Errorf("the expired payout tx is still waited: %v, err %v", ref, err)
+	}
+}
+
+func TestPayoutConfirmAndResend(t *testing.T) {
+	chain := &mockChain{bestHeight: 300, finalizedHeight: 200}
+	var submitErr error
+	d, submitted := newTestDistributor(chain, &submitErr)
+	settlement := newTestSettlement(200, 1, 2)
+	if err := d.store.attachBlock(200, nil, nil, nil, settlement, nil, &status{Height: 200}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.status = &status{Height: 200}
+	d.payout()
+	if len(*submitted) != 2 {
+		t.Fatalf("got %d submitted payout txs, want 2", len(*submitted))
+	}
+
+	// the first payout tx is confirmed at the block 201
+	block := &types.Block{
+		BlockHeader:  types.BlockHeader{Height: 201, PreviousBlockHash: d.status.Hash},
+		Transactions: []*types.Tx{(*submitted)[0]},
+	}
+	if err := d.ApplyBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if settlement, err := d.store.GetSettlement(200); err != nil || settlement.Batches[0].ConfirmedHeight != 201 || settlement.Batches[1].ConfirmedHeight != 0 {
+		t.Fatalf("got settlement %v, err %v after the confirmation", settlement, err)
+	}
+
+	// the second payout tx expired in the finalized chain, it's sent again
+	chain.bestHeight, chain.finalizedHeight = 500, 401
+	d.payout()
+	if len(*submitted) != 3 {
+		t.Fatalf("got %d submitted payout txs, want 3", len(*submitted))
+	}
+
+	expiredTxID, resentTxID := (*submitted)[1].ID, (*submitted)[2].ID
+	if ref, err := d.store.getPayoutTx(expiredTxID); err != nil || ref != nil {
+		t.Errorf("the expired payout tx is still waited: %v, err %v", ref, err)
+	}
+
+	settlement, err := d.store.GetSettlement(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *settlement.Batches[1].TxID != resentTxID || settlement.Batches[1].TimeRange != 600 || settlement.Paid() {
+		t.Errorf("got the resent batch %v, paid %v", settlement.Batches[1], settlement.Paid())
+	}
+
+	// the confirmation is rolled back with the block
+	if err := d.DetachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if settlement, err = d.store.GetSettlement(200); err != nil || settlement.Batches[0].ConfirmedHeight != 0 {
+		t.Fatalf("got settlement %v, err %v after the rollback", settlement, err)
+	}
+
+	if ref, err := d.store.getPayoutTx((*submitted)[0].ID); err != nil || ref == nil {
+		t.Errorf("the rollback payout tx is not waited again: %v, err %v", ref, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package reward distributes the rewards of the validator to its voters inside the node,
+// the rewards of each epoch are shared by the votes at the beginning of the epoch and
+// paid by batched transactions after the epoch is finalized.
+package reward
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+
+	chainjson "coingod/encoding/json"
+	"coingod/protocol/bc"
+)
+
+// Payout is the reward paid to one voter
+type Payout struct {
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	Amount         uint64             `json:"amount"`
+}
+
+// Batch is the payouts sent by one transaction, the transaction id is recorded before the
+// transaction is submitted, and the batch is confirmed when the transaction is on the main chain
+type Batch struct {
+	Payouts []*Payout `json:"payouts"`
+	TxID    *bc.Hash  `json:"tx_id,omitempty"`
+	// the sent transaction can't be packed into the blocks above the time range height
+	TimeRange       uint64 `json:"time_range,omitempty"`
+	ConfirmedHeight uint64 `json:"confirmed_height,omitempty"`
+}
+
+// needSend return whether the batch has never been sent, or the sent transaction has expired
+// in the finalized chain without the confirmation so it could never be confirmed
+func (b *Batch) needSend(finalizedHeight uint64) bool {
+	if b.ConfirmedHeight != 0 {
+		return false
+	}
+	return b.TxID == nil || b.TimeRange < finalizedHeight
+}
+
+// Settlement is the voter rewards of the epoch which ends at the checkpoint
+type Settlement struct {
+	Height      uint64   `json:"height"`
+	Hash        bc.Hash  `json:"hash"`
+	Rewards     uint64   `json:"rewards"`
+	Distributed uint64   `json:"distributed"`
+	Batches     []*Batch `json:"batches"`
+}
+
+// Paid return whether all the batches of the settlement are confirmed
+func (s *Settlement) Paid() bool {
+	for _, batch := range s.Batches {
+		if batch.ConfirmedHeight == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// newSettlement share the ratio percent of the rewards by the votes of each voter
+// control program, the payouts are sorted by the control program to be deterministic
+func newSettlement(height uint64, hash bc.Hash, rewards, ratio uint64, votes map[string]uint64, batchSize int) (*Settlement, error) {
+	settlement := &Settlement{Height: height, Hash: hash, Rewards: rewards, Batches: []*Batch{}}
+	totalVotes := big.NewInt(0)
+	programs := []string{}
+	for program, voteNum := range votes {
+		totalVotes.Add(totalVotes, new(big.Int).SetUint64(voteNum))
+		programs = append(programs, program)
+	}
+
+	if totalVotes.Sign() == 0 {
+		return settlement, nil
+	}
+
+	sort.Strings(programs)
+	distribution := new(big.Int).SetUint64(rewards)
+	distribution.Mul(distribution, new(big.Int).SetUint64(ratio)).Div(distribution, big.NewInt(100))
+	var batch *Batch
+	for _, program := range programs {
+		amount := new(big.Int).SetUint64(votes[program])
+		amount.Mul(amount, distribution).Div(amount, totalVotes)
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		controlProgram, err := hex.DecodeString(program)
+		if err != nil {
+			return nil, err
+		}
+
+		if batch == nil || len(batch.Payouts) >= batchSize {
+			batch = &Batch{}
+			settlement.Batches = append(settlement.Batches, batch)
+		}
+
+		batch.Payouts = append(batch.Payouts, &Payout{ControlProgram: controlProgram, Amount: amount.Uint64()})
+		settlement.Distributed += amount.Uint64()
+	}
+	return settlement, nil
+}
@@ -0,0 +1,75 @@
+package reward
+
+import (
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+)
+
+func TestNewSettlement(t *testing.T) {
+	votes := map[string]uint64{"0014aa": 300, "0014bb": 100, "0014cc": 1}
+	settlement, err := newSettlement(100, bc.Hash{V0: 1}, 1000, 50, votes, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 500 is shared by 401 votes, the smallest voter gets 1
+	wants := []uint64{374, 124, 1}
+	if len(settlement.Batches) != len(wants) {
+		t.Fatalf("got %d batches, want %d", len(settlement.Batches), len(wants))
+	}
+
+	for i, batch := range settlement.Batches {
+		if len(batch.Payouts) != 1 || batch.Payouts[0].Amount != wants[i] {
+			t.Errorf("batch %d got payouts %v, want amount %d", i, batch.Payouts, wants[i])
+		}
+	}
+
+	if settlement.Distributed != 499 || settlement.Paid() {
+		t.Errorf("got distributed %d, paid %v", settlement.Distributed, settlement.Paid())
+	}
+
+	empty, err := newSettlement(100, bc.Hash{V0: 1}, 1000, 50, map[string]uint64{}, 1)
+	if err != nil || len(empty.Batches) != 0 || !empty.Paid() {
+		t.Errorf("got settlement %v, err %v for the empty votes", empty, err)
+	}
+}
+
+func TestStoreAttachDetach(t *testing.T) {
+	store := NewStore(dbm.NewMemDB())
+	first := &voteOutput{OutputID: bc.Hash{V0: 1}, ControlProgram: []byte{0x00, 0x14, 0xaa}, Amount: 100}
+	second := &voteOutput{OutputID: bc.Hash{V0: 2}, ControlProgram: []byte{0x00, 0x14, 0xaa}, Amount: 50}
+	if err := store.attachBlock(1, []*voteOutput{first, second}, nil, nil, nil, nil, &status{Height: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	settlement := &Settlement{Height: 2, Batches: []*Batch{{Payouts: []*Payout{{ControlProgram: first.ControlProgram, Amount: 1}}}}}
+	if err := store.attachBlock(2, nil, []*voteOutput{first}, map[string]uint64{"0014aa": 50}, settlement, nil, &status{Height: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if votes, err := store.currentVotes(); err != nil || votes["0014aa"] != 50 {
+		t.Fatalf("got votes %v, err %v", votes, err)
+	}
+
+	if err := store.detachBlock(2, &status{Height: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if votes, err := store.currentVotes(); err != nil || votes["0014aa"] != 150 {
+		t.Errorf("got votes %v, err %v after the rollback", votes, err)
+	}
+
+	if got, err := store.GetSettlement(2); err != nil || got != nil {
+		t.Errorf("got settlement %v, err %v after the rollback", got, err)
+	}
+
+	if snapshot, err := store.getSnapshot(2); err != nil || snapshot != nil {
+		t.Errorf("got snapshot %v, err %v after the rollback", snapshot, err)
+	}
+
+	if err := store.detachBlock(2, &status{Height: 1}); err != errMissingUndo {
+		t.Errorf("got err %v, want %v", err, errMissingUndo)
+	}
+}
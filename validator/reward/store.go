@@ -0,0 +1,360 @@
+package reward
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+
+	dbm "coingod/database/leveldb"
+	chainjson "coingod/encoding/json"
+	"coingod/errors"
+	"coingod/protocol/bc"
+)
+
+const (
+	colon = byte(0x3a)
+
+	voteOutputs byte = iota + 1
+	blockUndo
+	voteSnapshot
+	settlement
+	distributorStatus
+	payoutTx
+)
+
+var (
+	voteOutputPrefixKey   = []byte{voteOutputs, colon}
+	blockUndoPrefixKey    = []byte{blockUndo, colon}
+	voteSnapshotPrefixKey = []byte{voteSnapshot, colon}
+	settlementPrefixKey   = []byte{settlement, colon}
+	distributorStatusKey  = []byte{distributorStatus, colon}
+	payoutTxPrefixKey     = []byte{payoutTx, colon}
+)
+
+func heightKey(prefix []byte, height uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, height)
+	return append(append([]byte{}, prefix...), buf...)
+}
+
+func voteOutputKey(outputID bc.Hash) []byte {
+	return append(append([]byte{}, voteOutputPrefixKey...), outputID.Bytes()...)
+}
+
+func payoutTxKey(txID bc.Hash) []byte {
+	return append(append([]byte{}, payoutTxPrefixKey...), txID.Bytes()...)
+}
+
+// voteOutput is the unspent vote output for the validator
+type voteOutput struct {
+	OutputID       bc.Hash            `json:"output_id"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	Amount         uint64             `json:"amount"`
+}
+
+// payoutTxRef is the batch paid by the sent transaction, the transactions waiting for
+// the confirmation are indexed by the id
+type payoutTxRef struct {
+	TxID   bc.Hash `json:"tx_id"`
+	Height uint64  `json:"height"`
+	Batch  int     `json:"batch"`
+}
+
+// undo is the vote outputs changed and the payouts confirmed by a block, it's saved for the rollback
+type undo struct {
+	Added     []bc.Hash      `json:"added"`
+	Removed   []*voteOutput  `json:"removed"`
+	Confirmed []*payoutTxRef `json:"confirmed,omitempty"`
+}
+
+// status is the last block applied by the distributor
+type status struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// Store persist the vote outputs of the validator and the settlement progress
+type Store struct {
+	db dbm.DB
+}
+
+// NewStore create the store of the voter reward distribution
+func NewStore(db dbm.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetSettlement return the settlement of the epoch ends at the height, nil is returned
+// when the epoch is not settled
+func (s *Store) GetSettlement(height uint64) (*Settlement, error) {
+	data := s.db.Get(heightKey(settlementPrefixKey, height))
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &Settlement{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListSettlements return all the settlements by the height order
+func (s *Store) ListSettlements() ([]*Settlement, error) {
+	iter := s.db.IteratorPrefix(settlementPrefixKey)
+	defer iter.Release()
+
+	result := []*Settlement{}
+	for iter.Next() {
+		settlement := &Settlement{}
+		if err := json.Unmarshal(iter.Value(), settlement); err != nil {
+			return nil, err
+		}
+
+		result = append(result, settlement)
+	}
+	return result, nil
+}
+
+// savePayoutTx record the sent transaction of the batch and wait for its confirmation, the
+// expired transaction of the batch is no longer waited
+func (s *Store) savePayoutTx(settlement *Settlement, index int, expiredTxID *bc.Hash) error {
+	data, err := json.Marshal(settlement)
+	if err != nil {
+		return err
+	}
+
+	ref := &payoutTxRef{TxID: *settlement.Batches[index].TxID, Height: settlement.Height, Batch: index}
+	refData, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	if expiredTxID != nil {
+		batch.Delete(payoutTxKey(*expiredTxID))
+	}
+
+	batch.Set(heightKey(settlementPrefixKey, settlement.Height), data)
+	batch.Set(payoutTxKey(ref.TxID), refData)
+	batch.Write()
+	return nil
+}
+
+// getPayoutTx return the batch paid by the transaction, nil is returned when the
+// transaction is not a payout waiting for the confirmation
+func (s *Store) getPayoutTx(txID bc.Hash) (*payoutTxRef, error) {
+	data := s.db.Get(payoutTxKey(txID))
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &payoutTxRef{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setConfirmedHeight update the confirmed height of the batches in the db batch, the
+// confirmed payouts are no longer waited and the rollback ones are waited again
+func (s *Store) setConfirmedHeight(batch dbm.Batch, refs []*payoutTxRef, height uint64) error {
+	settlements := make(map[uint64]*Settlement)
+	for _, ref := range refs {
+		settlement, ok := settlements[ref.Height]
+		if !ok {
+			var err error
+			if settlement, err = s.GetSettlement(ref.Height); err != nil {
+				return err
+			}
+
+			if settlement == nil || ref.Batch >= len(settlement.Batches) {
+				return errors.WithDetailf(errMissingSettlement, "height %d batch %d", ref.Height, ref.Batch)
+			}
+			settlements[ref.Height] = settlement
+		}
+
+		settlement.Batches[ref.Batch].ConfirmedHeight = height
+		if height != 0 {
+			batch.Delete(payoutTxKey(ref.TxID))
+			continue
+		}
+
+		data, err := json.Marshal(ref)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(payoutTxKey(ref.TxID), data)
+	}
+
+	for _, settlement := range settlements {
+		data, err := json.Marshal(settlement)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(heightKey(settlementPrefixKey, settlement.Height), data)
+	}
+	return nil
+}
+
+func (s *Store) getStatus() (*status, error) {
+	data := s.db.Get(distributorStatusKey)
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &status{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Store) getVoteOutput(outputID bc.Hash) (*voteOutput, error) {
+	data := s.db.Get(voteOutputKey(outputID))
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &voteOutput{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getSnapshot return the votes of each voter control program at the checkpoint
+func (s *Store) getSnapshot(height uint64) (map[string]uint64, error) {
+	data := s.db.Get(heightKey(voteSnapshotPrefixKey, height))
+	if data == nil {
+		return nil, nil
+	}
+
+	result := map[string]uint64{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// currentVotes sum the unspent vote outputs by the voter control program
+func (s *Store) currentVotes() (map[string]uint64, error) {
+	iter := s.db.IteratorPrefix(voteOutputPrefixKey)
+	defer iter.Release()
+
+	result := map[string]uint64{}
+	for iter.Next() {
+		output := &voteOutput{}
+		if err := json.Unmarshal(iter.Value(), output); err != nil {
+			return nil, err
+		}
+
+		result[hex.EncodeToString(output.ControlProgram)] += output.Amount
+	}
+	return result, nil
+}
+
+// attachBlock save the changes of the block in one batch, the snapshot and the settlement
+// are only saved for the checkpoint block
+func (s *Store) attachBlock(height uint64, added, removed []*voteOutput, snapshot map[string]uint64, settlement *Settlement, confirmed []*payoutTxRef, newStatus *status) error {
+	batch := s.db.NewBatch()
+	if err := s.setConfirmedHeight(batch, confirmed, height); err != nil {
+		return err
+	}
+
+	blockUndo := &undo{Confirmed: confirmed}
+	for _, output := range removed {
+		batch.Delete(voteOutputKey(output.OutputID))
+		blockUndo.Removed = append(blockUndo.Removed, output)
+	}
+
+	for _, output := range added {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(voteOutputKey(output.OutputID), data)
+		blockUndo.Added = append(blockUndo.Added, output.OutputID)
+	}
+
+	data, err := json.Marshal(blockUndo)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(heightKey(blockUndoPrefixKey, height), data)
+	if snapshot != nil {
+		if data, err = json.Marshal(snapshot); err != nil {
+			return err
+		}
+
+		batch.Set(heightKey(voteSnapshotPrefixKey, height), data)
+	}
+
+	if settlement != nil {
+		if data, err = json.Marshal(settlement); err != nil {
+			return err
+		}
+
+		batch.Set(heightKey(settlementPrefixKey, height), data)
+	}
+
+	if data, err = json.Marshal(newStatus); err != nil {
+		return err
+	}
+
+	batch.Set(distributorStatusKey, data)
+	batch.Write()
+	return nil
+}
+
+// detachBlock revert the changes of the block in one batch
+func (s *Store) detachBlock(height uint64, newStatus *status) error {
+	data := s.db.Get(heightKey(blockUndoPrefixKey, height))
+	if data == nil {
+		return errMissingUndo
+	}
+
+	blockUndo := &undo{}
+	if err := json.Unmarshal(data, blockUndo); err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	if err := s.setConfirmedHeight(batch, blockUndo.Confirmed, 0); err != nil {
+		return err
+	}
+
+	for _, outputID := range blockUndo.Added {
+		batch.Delete(voteOutputKey(outputID))
+	}
+
+	for _, output := range blockUndo.Removed {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(voteOutputKey(output.OutputID), data)
+	}
+
+	data, err := json.Marshal(newStatus)
+	if err != nil {
+		return err
+	}
+
+	batch.Delete(heightKey(blockUndoPrefixKey, height))
+	batch.Delete(heightKey(voteSnapshotPrefixKey, height))
+	batch.Delete(heightKey(settlementPrefixKey, height))
+	batch.Set(distributorStatusKey, data)
+	batch.Write()
+	return nil
+}
+
+// prune remove the undo and the snapshot of the block which could not be rolled back
+func (s *Store) prune(height uint64) {
+	s.db.Delete(heightKey(blockUndoPrefixKey, height))
+	s.db.Delete(heightKey(voteSnapshotPrefixKey, height))
+}
@@ -0,0 +1,147 @@
+// Package validator tracks the performance of the validators, it records the proposed and
+// missed blocks, the casper verification participation and the rewards of each validator
+// as the blocks are applied to the main chain.
+package validator
+
+import (
+	"sort"
+)
+
+// maxEpochRecords is the num of the latest epochs kept for each validator
+const maxEpochRecords = 64
+
+// Record is the statistics of the validator during the epochs
+type Record struct {
+	ProposedBlocks        uint64 `json:"proposed_blocks"`
+	ExpectedBlocks        uint64 `json:"expected_blocks"`
+	MissedSlots           uint64 `json:"missed_slots"`
+	Verifications         uint64 `json:"verifications"`
+	ExpectedVerifications uint64 `json:"expected_verifications"`
+	Rewards               uint64 `json:"rewards"`
+}
+
+func (r *Record) add(d *Record) {
+	r.ProposedBlocks += d.ProposedBlocks
+	r.ExpectedBlocks += d.ExpectedBlocks
+	r.MissedSlots += d.MissedSlots
+	r.Verifications += d.Verifications
+	r.ExpectedVerifications += d.ExpectedVerifications
+	r.Rewards += d.Rewards
+}
+
+func (r *Record) sub(d *Record) {
+	r.ProposedBlocks -= d.ProposedBlocks
+	r.ExpectedBlocks -= d.ExpectedBlocks
+	r.MissedSlots -= d.MissedSlots
+	r.Verifications -= d.Verifications
+	r.ExpectedVerifications -= d.ExpectedVerifications
+	r.Rewards -= d.Rewards
+}
+
+func (r *Record) isZero() bool {
+	return *r == Record{}
+}
+
+// ProposalRate return the ratio of the proposed blocks to the expected blocks
+func (r *Record) ProposalRate() float64 {
+	if r.ExpectedBlocks == 0 {
+		return 0
+	}
+	return float64(r.ProposedBlocks) / float64(r.ExpectedBlocks)
+}
+
+// ParticipationRate return the ratio of the casper verifications to the expected ones
+func (r *Record) ParticipationRate() float64 {
+	if r.ExpectedVerifications == 0 {
+		return 0
+	}
+	return float64(r.Verifications) / float64(r.ExpectedVerifications)
+}
+
+// EpochRecord is the statistics of the validator in one epoch, the epoch N contains the
+// blocks from (N-1)*BlocksOfEpoch+1 to N*BlocksOfEpoch and the checkpoint at N*BlocksOfEpoch
+type EpochRecord struct {
+	Epoch uint64 `json:"epoch"`
+	Record
+}
+
+// Stats is the total and the latest epochs statistics of the validator
+type Stats struct {
+	PubKey string `json:"pub_key"`
+	Record
+	Epochs []*EpochRecord `json:"epochs"`
+}
+
+func newStats(pubKey string) *Stats {
+	return &Stats{PubKey: pubKey, Epochs: []*EpochRecord{}}
+}
+
+func (s *Stats) add(epoch uint64, d *Record) {
+	s.Record.add(d)
+	i := sort.Search(len(s.Epochs), func(i int) bool { return s.Epochs[i].Epoch >= epoch })
+	if i == len(s.Epochs) || s.Epochs[i].Epoch != epoch {
+		s.Epochs = append(s.Epochs, nil)
+		copy(s.Epochs[i+1:], s.Epochs[i:])
+		s.Epochs[i] = &EpochRecord{Epoch: epoch}
+	}
+
+	s.Epochs[i].add(d)
+	if len(s.Epochs) > maxEpochRecords {
+		s.Epochs = s.Epochs[len(s.Epochs)-maxEpochRecords:]
+	}
+}
+
+func (s *Stats) sub(epoch uint64, d *Record) {
+	s.Record.sub(d)
+	for i, epochRecord := range s.Epochs {
+		if epochRecord.Epoch != epoch {
+			continue
+		}
+
+		if epochRecord.sub(d); epochRecord.isZero() {
+			s.Epochs = append(s.Epochs[:i], s.Epochs[i+1:]...)
+		}
+		return
+	}
+}
+
+// LatestEpochs return the records of the latest num epochs, the newest comes first
+func (s *Stats) LatestEpochs(num int) []*EpochRecord {
+	result := []*EpochRecord{}
+	for i := len(s.Epochs) - 1; i >= 0 && len(result) < num; i-- {
+		result = append(result, s.Epochs[i])
+	}
+	return result
+}
+
+// change is the record of one validator in one epoch which is caused by a block
+type change struct {
+	PubKey string `json:"pub_key"`
+	Epoch  uint64 `json:"epoch"`
+	Record Record `json:"record"`
+}
+
+// ownerChange is the validator owns the coinbase program since the block, the previous
+// owner is restored when the block is rolled back
+type ownerChange struct {
+	Program    string `json:"program"`
+	PubKey     string `json:"pub_key"`
+	PrevPubKey string `json:"prev_pub_key"`
+}
+
+// delta is the changes of the validators by a block, it's saved for the rollback
+type delta struct {
+	Changes []*change      `json:"changes"`
+	Owners  []*ownerChange `json:"owners,omitempty"`
+}
+
+func (d *delta) add(pubKey string, epoch uint64, record Record) {
+	for _, c := range d.Changes {
+		if c.PubKey == pubKey && c.Epoch == epoch {
+			c.Record.add(&record)
+			return
+		}
+	}
+
+	d.Changes = append(d.Changes, &change{PubKey: pubKey, Epoch: epoch, Record: record})
+}
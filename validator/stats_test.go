@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+)
+
+func TestStatsAddSub(t *testing.T) {
+	stats := newStats("a")
+	for epoch := uint64(maxEpochRecords + 2); epoch > 0; epoch-- {
+		stats.add(epoch, &Record{ProposedBlocks: 1, ExpectedBlocks: 2})
+	}
+
+	if stats.ProposedBlocks != maxEpochRecords+2 || stats.ProposalRate() != 0.5 {
+		t.Fatalf("got total record %v", stats.Record)
+	}
+
+	if len(stats.Epochs) != maxEpochRecords || stats.Epochs[0].Epoch != 3 {
+		t.Fatalf("got %d epochs start from %d", len(stats.Epochs), stats.Epochs[0].Epoch)
+	}
+
+	stats.sub(maxEpochRecords+2, &Record{ProposedBlocks: 1, ExpectedBlocks: 2})
+	if latest := stats.LatestEpochs(2); len(latest) != 2 || latest[0].Epoch != maxEpochRecords+1 || latest[1].Epoch != maxEpochRecords {
+		t.Fatalf("got latest epochs %v", latest)
+	}
+}
+
+func TestStoreSaveBlock(t *testing.T) {
+	store := NewStore(dbm.NewMemDB())
+	d := &delta{}
+	d.add("a", 1, Record{ProposedBlocks: 1, ExpectedBlocks: 1})
+	d.add("b", 1, Record{ExpectedBlocks: 1, MissedSlots: 1})
+	d.add("a", 1, Record{Rewards: 100})
+
+	if err := store.saveBlock(1, d, false, &status{Height: 1, Hash: bc.Hash{V0: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := store.GetStats("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Record{ProposedBlocks: 1, ExpectedBlocks: 1, Rewards: 100}); stats.Record != want || len(stats.Epochs) != 1 {
+		t.Fatalf("got stats %v, want %v", stats.Record, want)
+	}
+
+	saved, err := store.getDelta(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(saved, d) {
+		t.Fatalf("got delta %v, want %v", saved, d)
+	}
+
+	if err := store.saveBlock(1, saved, true, &status{Height: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.ListStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stats := range all {
+		if !stats.isZero() || len(stats.Epochs) != 0 {
+			t.Errorf("got stats %v after the rollback", stats)
+		}
+	}
+
+	if status, err := store.getStatus(); err != nil || status.Height != 0 {
+		t.Errorf("got status %v, err %v", status, err)
+	}
+}
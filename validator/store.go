@@ -0,0 +1,187 @@
+package validator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+)
+
+const (
+	colon = byte(0x3a)
+
+	stats byte = iota + 1
+	blockDelta
+	rewardProgram
+	trackerStatus
+)
+
+var (
+	statsPrefixKey         = []byte{stats, colon}
+	blockDeltaPrefixKey    = []byte{blockDelta, colon}
+	rewardProgramPrefixKey = []byte{rewardProgram, colon}
+	trackerStatusKey       = []byte{trackerStatus, colon}
+)
+
+func statsKey(pubKey string) []byte {
+	return append(statsPrefixKey, []byte(pubKey)...)
+}
+
+func blockDeltaKey(height uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, height)
+	return append(blockDeltaPrefixKey, buf...)
+}
+
+func rewardProgramKey(program string) []byte {
+	return append(rewardProgramPrefixKey, []byte(program)...)
+}
+
+// status is the last block applied by the tracker
+type status struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// Store persist the validator statistics
+type Store struct {
+	db dbm.DB
+}
+
+// NewStore create the store of the validator statistics
+func NewStore(db dbm.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetStats return the statistics of the validator, the empty statistics is returned
+// when the validator is not tracked
+func (s *Store) GetStats(pubKey string) (*Stats, error) {
+	data := s.db.Get(statsKey(pubKey))
+	if data == nil {
+		return newStats(pubKey), nil
+	}
+
+	result := &Stats{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListStats return the statistics of all the tracked validators
+func (s *Store) ListStats() ([]*Stats, error) {
+	iter := s.db.IteratorPrefix(statsPrefixKey)
+	defer iter.Release()
+
+	result := []*Stats{}
+	for iter.Next() {
+		stats := &Stats{}
+		if err := json.Unmarshal(iter.Value(), stats); err != nil {
+			return nil, err
+		}
+
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+func (s *Store) getStatus() (*status, error) {
+	data := s.db.Get(trackerStatusKey)
+	if data == nil {
+		return nil, nil
+	}
+
+	result := &status{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Store) getDelta(height uint64) (*delta, error) {
+	data := s.db.Get(blockDeltaKey(height))
+	if data == nil {
+		return &delta{}, nil
+	}
+
+	result := &delta{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Store) rewardProgramOwner(program string) string {
+	return string(s.db.Get(rewardProgramKey(program)))
+}
+
+// deleteDelta remove the delta of the block which could not be rolled back
+func (s *Store) deleteDelta(height uint64) {
+	s.db.Delete(blockDeltaKey(height))
+}
+
+// saveBlock apply or rollback the delta of the block at the height, include the owners of
+// the coinbase programs, and move the status in one batch
+func (s *Store) saveBlock(height uint64, d *delta, rollback bool, newStatus *status) error {
+	changed := make(map[string]*Stats)
+	for _, c := range d.Changes {
+		stats, ok := changed[c.PubKey]
+		if !ok {
+			var err error
+			if stats, err = s.GetStats(c.PubKey); err != nil {
+				return err
+			}
+			changed[c.PubKey] = stats
+		}
+
+		if rollback {
+			stats.sub(c.Epoch, &c.Record)
+		} else {
+			stats.add(c.Epoch, &c.Record)
+		}
+	}
+
+	batch := s.db.NewBatch()
+	for pubKey, stats := range changed {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(statsKey(pubKey), data)
+	}
+
+	for _, owner := range d.Owners {
+		pubKey := owner.PubKey
+		if rollback {
+			pubKey = owner.PrevPubKey
+		}
+
+		if pubKey == "" {
+			batch.Delete(rewardProgramKey(owner.Program))
+		} else {
+			batch.Set(rewardProgramKey(owner.Program), []byte(pubKey))
+		}
+	}
+
+	if rollback {
+		batch.Delete(blockDeltaKey(height))
+	} else {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		batch.Set(blockDeltaKey(height), data)
+	}
+
+	data, err := json.Marshal(newStatus)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(trackerStatusKey, data)
+	batch.Write()
+	return nil
+}
@@ -0,0 +1,276 @@
+package validator
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"coingod/consensus"
+	"coingod/errors"
+	"coingod/follower"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+const (
+	// the deltas of the blocks in the latest rollbackEpochs epochs are kept for the rollback,
+	// the finalized blocks could never be rolled back
+	rollbackEpochs = 10
+)
+
+var errNoProposer = errors.New("can't find the proposer of the block")
+
+// Chain is the chain service used by the tracker
+type Chain interface {
+	follower.Chain
+	GetHeaderByHash(hash *bc.Hash) (*types.BlockHeader, error)
+	GetHeaderByHeight(height uint64) (*types.BlockHeader, error)
+	PrevCheckpointByPrevHash(prevBlockHash *bc.Hash) (*state.Checkpoint, error)
+}
+
+// Tracker follow the main chain and record the statistics of the validators
+type Tracker struct {
+	mu     sync.RWMutex
+	store  *Store
+	chain  Chain
+	status *status
+}
+
+// NewTracker create the tracker which continues from the last applied block of the store
+func NewTracker(store *Store, chain Chain) (*Tracker, error) {
+	bestStatus, err := store.getStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	// the statistics starts from the snapshot block when the earlier blocks are not available
+	if bestStatus == nil {
+		base, err := chain.GetHeaderByHeight(chain.BaseBlockHeight())
+		if err != nil {
+			return nil, err
+		}
+
+		bestStatus = &status{Height: base.Height, Hash: base.Hash()}
+	}
+	return &Tracker{store: store, chain: chain, status: bestStatus}, nil
+}
+
+// Sync apply the main chain blocks to the statistics, the blocks are detached when the
+// chain is reorganized. It returns error when the blocks to apply are not available in
+// the chain. It must be run as a goroutine.
+func (t *Tracker) Sync() error {
+	return follower.NewFollower("validator tracker", t.chain, t).Run()
+}
+
+// BestChain return the height and hash of the last applied block
+func (t *Tracker) BestChain() (uint64, bc.Hash) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status.Height, t.status.Hash
+}
+
+// Stats return the statistics of the validator
+func (t *Tracker) Stats(pubKey string) (*Stats, error) {
+	return t.store.GetStats(pubKey)
+}
+
+// ListStats return the statistics of all the tracked validators
+func (t *Tracker) ListStats() ([]*Stats, error) {
+	return t.store.ListStats()
+}
+
+// ApplyBlock add the statistics of the block, the block must be the child of the last
+// applied block
+func (t *Tracker) ApplyBlock(block *types.Block) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if block.PreviousBlockHash != t.status.Hash {
+		return errors.New("the applied block is not the child of the tracker status")
+	}
+
+	d, err := t.blockDelta(block)
+	if err != nil {
+		return err
+	}
+
+	newStatus := &status{Height: block.Height, Hash: block.Hash()}
+	if err := t.store.saveBlock(block.Height, d, false, newStatus); err != nil {
+		return err
+	}
+
+	t.status = newStatus
+	if keep := rollbackEpochs * consensus.ActiveNetParams.BlocksOfEpoch; block.Height > keep {
+		t.store.deleteDelta(block.Height - keep)
+	}
+	return nil
+}
+
+// DetachBlock rollback the statistics of the last applied block
+func (t *Tracker) DetachBlock(block *types.Block) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if block.Hash() != t.status.Hash {
+		return errors.New("the detached block is not the tracker status")
+	}
+
+	d, err := t.store.getDelta(block.Height)
+	if err != nil {
+		return err
+	}
+
+	newStatus := &status{Height: block.Height - 1, Hash: block.PreviousBlockHash}
+	if err := t.store.saveBlock(block.Height, d, true, newStatus); err != nil {
+		return err
+	}
+
+	t.status = newStatus
+	return nil
+}
+
+// blockDelta calculate the statistics changed by the block, the checkpoint block also
+// settles the rewards of its epoch and the verifications of the previous checkpoint
+func (t *Tracker) blockDelta(block *types.Block) (*delta, error) {
+	d := &delta{}
+	if block.Height == 0 {
+		return d, nil
+	}
+
+	if err := t.applyProposal(d, block); err != nil {
+		return nil, err
+	}
+
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	if block.Height%blocksOfEpoch != 0 {
+		return d, nil
+	}
+
+	if err := t.applyRewards(d, block); err != nil {
+		return nil, err
+	}
+
+	// the verifications of the previous checkpoint are collected during this epoch
+	if block.Height >= 2*blocksOfEpoch {
+		if err := t.applyVerifications(d, block.Height-blocksOfEpoch); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// applyProposal record the proposer of the block, and the validators of the slots
+// between the previous block and the block which missed their turn
+func (t *Tracker) applyProposal(d *delta, block *types.Block) error {
+	prevHeader, err := t.chain.GetHeaderByHash(&block.PreviousBlockHash)
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := t.chain.PrevCheckpointByPrevHash(&block.PreviousBlockHash)
+	if err != nil {
+		return err
+	}
+
+	epoch := blockEpoch(block.Height)
+	proposer := checkpoint.GetValidator(block.Timestamp)
+	if proposer == nil {
+		return errors.WithDetailf(errNoProposer, "height %d", block.Height)
+	}
+
+	d.add(proposer.PubKey, epoch, Record{ProposedBlocks: 1, ExpectedBlocks: 1})
+	if len(block.Transactions) > 0 && len(block.Transactions[0].Outputs) > 0 {
+		program := hex.EncodeToString(block.Transactions[0].Outputs[0].ControlProgram)
+		if prevOwner := t.store.rewardProgramOwner(program); prevOwner != proposer.PubKey {
+			d.Owners = append(d.Owners, &ownerChange{Program: program, PubKey: proposer.PubKey, PrevPubKey: prevOwner})
+		}
+	}
+
+	interval := consensus.ActiveNetParams.BlockTimeInterval
+	first := prevHeader.Timestamp - prevHeader.Timestamp%interval + interval
+	if start := checkpoint.Timestamp + interval; first < start {
+		first = start
+	}
+
+	last := block.Timestamp - block.Timestamp%interval
+	validators := checkpoint.EffectiveValidators()
+	if last <= first || len(validators) == 0 {
+		return nil
+	}
+
+	// every validator misses one slot in each round of the skipped slots
+	slots := (last - first) / interval
+	if rounds := slots / uint64(len(validators)); rounds > 0 {
+		for _, validator := range validators {
+			d.add(validator.PubKey, epoch, Record{ExpectedBlocks: rounds, MissedSlots: rounds})
+		}
+	}
+
+	for i := uint64(0); i < slots%uint64(len(validators)); i++ {
+		if validator := checkpoint.GetValidator(first + i*interval); validator != nil {
+			d.add(validator.PubKey, epoch, Record{ExpectedBlocks: 1, MissedSlots: 1})
+		}
+	}
+	return nil
+}
+
+// applyRewards attribute the rewards of the checkpoint to the validators by the control
+// program of their coinbase
+func (t *Tracker) applyRewards(d *delta, block *types.Block) error {
+	hash := block.Hash()
+	checkpoint, err := t.chain.PrevCheckpointByPrevHash(&hash)
+	if err != nil {
+		return err
+	}
+
+	epoch := block.Height / consensus.ActiveNetParams.BlocksOfEpoch
+	for program, amount := range checkpoint.Rewards {
+		if pubKey := t.rewardProgramOwner(d, program); pubKey != "" {
+			d.add(pubKey, epoch, Record{Rewards: amount})
+		}
+	}
+	return nil
+}
+
+// rewardProgramOwner return the owner of the coinbase program, the owner changed by the
+// block itself is not saved yet
+func (t *Tracker) rewardProgramOwner(d *delta, program string) string {
+	for _, owner := range d.Owners {
+		if owner.Program == program {
+			return owner.PubKey
+		}
+	}
+	return t.store.rewardProgramOwner(program)
+}
+
+// applyVerifications record whether each validator has signed the checkpoint at the height
+func (t *Tracker) applyVerifications(d *delta, height uint64) error {
+	header, err := t.chain.GetHeaderByHeight(height)
+	if err != nil {
+		return err
+	}
+
+	parent, err := t.chain.PrevCheckpointByPrevHash(&header.PreviousBlockHash)
+	if err != nil {
+		return err
+	}
+
+	epoch := height / consensus.ActiveNetParams.BlocksOfEpoch
+	for _, validator := range parent.EffectiveValidators() {
+		record := Record{ExpectedVerifications: 1}
+		for _, supLink := range header.SupLinks {
+			if len(supLink.Signatures[validator.Order]) != 0 {
+				record.Verifications = 1
+				break
+			}
+		}
+		d.add(validator.PubKey, epoch, record)
+	}
+	return nil
+}
+
+// blockEpoch return the epoch of the block, the checkpoint block belongs to the epoch it ends
+func blockEpoch(height uint64) uint64 {
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	return (height + blocksOfEpoch - 1) / blocksOfEpoch
+}
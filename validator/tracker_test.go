@@ -0,0 +1,231 @@
+package validator
+
+import (
+	"testing"
+
+	"coingod/consensus"
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+	"coingod/protocol/bc/types"
+	"coingod/protocol/state"
+)
+
+type mockChain struct {
+	headers    map[bc.Hash]*types.BlockHeader
+	checkpoint *state.Checkpoint
+}
+
+func (c *mockChain) BaseBlockHeight() uint64 {
+	return 0
+}
+
+func (c *mockChain) BestBlockHeight() uint64 {
+	return 0
+}
+
+func (c *mockChain) BlockWaiter(height uint64) <-chan struct{} {
+	return make(chan struct{})
+}
+
+func (c *mockChain) GetBlockByHeight(height uint64) (*types.Block, error) {
+	return nil, nil
+}
+
+func (c *mockChain) GetBlockByHash(hash *bc.Hash) (*types.Block, error) {
+	return nil, nil
+}
+
+func (c *mockChain) InMainChain(hash bc.Hash) bool {
+	return true
+}
+
+func (c *mockChain) GetHeaderByHash(hash *bc.Hash) (*types.BlockHeader, error) {
+	return c.headers[*hash], nil
+}
+
+func (c *mockChain) GetHeaderByHeight(height uint64) (*types.BlockHeader, error) {
+	for _, header := range c.headers {
+		if header.Height == height {
+			return header, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *mockChain) PrevCheckpointByPrevHash(prevBlockHash *bc.Hash) (*state.Checkpoint, error) {
+	return c.checkpoint, nil
+}
+
+// newTestTracker return the tracker whose last applied block is the prev header, the
+// validators of the checkpoint are c, b and a by the order
+func newTestTracker(prevHeader *types.BlockHeader) (*Tracker, *mockChain) {
+	minVoteNum := consensus.ActiveNetParams.MinValidatorVoteNum
+	chain := &mockChain{
+		headers: map[bc.Hash]*types.BlockHeader{prevHeader.Hash(): prevHeader},
+		checkpoint: &state.Checkpoint{
+			Timestamp: 1000 * consensus.ActiveNetParams.BlockTimeInterval,
+			Status:    state.Justified,
+			Votes:     map[string]uint64{"a": minVoteNum, "b": minVoteNum, "c": minVoteNum},
+		},
+	}
+
+	tracker := &Tracker{
+		store:  NewStore(dbm.NewMemDB()),
+		chain:  chain,
+		status: &status{Height: prevHeader.Height, Hash: prevHeader.Hash()},
+	}
+	return tracker, chain
+}
+
+func newTestBlock(prevHeader *types.BlockHeader, timestamp uint64, coinbaseProgram []byte) *types.Block {
+	return &types.Block{
+		BlockHeader: types.BlockHeader{
+			Height:            prevHeader.Height + 1,
+			PreviousBlockHash: prevHeader.Hash(),
+			Timestamp:         timestamp,
+		},
+		Transactions: []*types.Tx{
+			types.NewTx(types.TxData{
+				Inputs:  []*types.TxInput{types.NewCoinbaseInput(nil)},
+				Outputs: []*types.TxOutput{types.NewOriginalTxOutput(*consensus.CGAssetID, 0, coinbaseProgram, nil)},
+			}),
+		},
+	}
+}
+
+func TestTrackerProposalAndMissedSlots(t *testing.T) {
+	interval := consensus.ActiveNetParams.BlockTimeInterval
+	start := 1000 * interval
+	cases := []struct {
+		desc      string
+		prevSlot  uint64
+		blockSlot uint64
+		want      map[string]Record
+	}{
+		{
+			desc:      "the block follows the previous slot",
+			prevSlot:  1,
+			blockSlot: 2,
+			want: map[string]Record{
+				"b": {ProposedBlocks: 1, ExpectedBlocks: 1},
+			},
+		},
+		{
+			desc:      "the validators miss a whole round",
+			prevSlot:  1,
+			blockSlot: 5,
+			want: map[string]Record{
+				"a": {ExpectedBlocks: 1, MissedSlots: 1},
+				"b": {ProposedBlocks: 1, ExpectedBlocks: 2, MissedSlots: 1},
+				"c": {ExpectedBlocks: 1, MissedSlots: 1},
+			},
+		},
+		{
+			desc:      "the validators miss part of the round",
+			prevSlot:  1,
+			blockSlot: 4,
+			want: map[string]Record{
+				"a": {ExpectedBlocks: 1, MissedSlots: 1},
+				"b": {ExpectedBlocks: 1, MissedSlots: 1},
+				"c": {ProposedBlocks: 1, ExpectedBlocks: 1},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		prevHeader := &types.BlockHeader{Height: 1, Timestamp: start + c.prevSlot*interval}
+		tracker, _ := newTestTracker(prevHeader)
+		block := newTestBlock(prevHeader, start+c.blockSlot*interval, []byte{0x51})
+		if err := tracker.ApplyBlock(block); err != nil {
+			t.Fatalf("case %d(%s): %v", i, c.desc, err)
+		}
+
+		for _, pubKey := range []string{"a", "b", "c"} {
+			stats, err := tracker.Stats(pubKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.Record != c.want[pubKey] {
+				t.Errorf("case %d(%s): got %s record %v, want %v", i, c.desc, pubKey, stats.Record, c.want[pubKey])
+			}
+		}
+	}
+}
+
+func TestTrackerRewardProgramOwner(t *testing.T) {
+	interval := consensus.ActiveNetParams.BlockTimeInterval
+	start := 1000 * interval
+	prevHeader := &types.BlockHeader{Height: 1, Timestamp: start + interval}
+	tracker, chain := newTestTracker(prevHeader)
+	program := "51"
+
+	// the block of slot 2 is proposed by b, and the block of slot 3 is proposed by a
+	block := newTestBlock(prevHeader, start+2*interval, []byte{0x51})
+	if err := tracker.ApplyBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := tracker.store.rewardProgramOwner(program); owner != "b" {
+		t.Fatalf("got owner %q after the first block, want b", owner)
+	}
+
+	chain.headers[block.Hash()] = &block.BlockHeader
+	nextBlock := newTestBlock(&block.BlockHeader, start+3*interval, []byte{0x51})
+	if err := tracker.ApplyBlock(nextBlock); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := tracker.store.rewardProgramOwner(program); owner != "a" {
+		t.Fatalf("got owner %q after the second block, want a", owner)
+	}
+
+	if err := tracker.DetachBlock(nextBlock); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := tracker.store.rewardProgramOwner(program); owner != "b" {
+		t.Fatalf("got owner %q after detach the second block, want b", owner)
+	}
+
+	if err := tracker.DetachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := tracker.store.rewardProgramOwner(program); owner != "" {
+		t.Fatalf("got owner %q after detach the first block, want none", owner)
+	}
+}
+
+func TestTrackerVerifications(t *testing.T) {
+	blocksOfEpoch := consensus.ActiveNetParams.BlocksOfEpoch
+	header := &types.BlockHeader{
+		Height:            blocksOfEpoch,
+		PreviousBlockHash: bc.Hash{V0: 1},
+		SupLinks:          types.SupLinks{{SourceHash: bc.Hash{V0: 2}}, {SourceHash: bc.Hash{V0: 3}}},
+	}
+	// c signs the first supLink and a signs the second one, b doesn't sign
+	header.SupLinks[0].Signatures[0] = []byte{1}
+	header.SupLinks[1].Signatures[2] = []byte{1}
+
+	tracker, _ := newTestTracker(header)
+	d := &delta{}
+	if err := tracker.applyVerifications(d, blocksOfEpoch); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Record{
+		"a": {Verifications: 1, ExpectedVerifications: 1},
+		"b": {ExpectedVerifications: 1},
+		"c": {Verifications: 1, ExpectedVerifications: 1},
+	}
+	if len(d.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(d.Changes), len(want))
+	}
+
+	for _, c := range d.Changes {
+		if c.Epoch != 1 || c.Record != want[c.PubKey] {
+			t.Errorf("got %s record %v of epoch %d, want %v of epoch 1", c.PubKey, c.Record, c.Epoch, want[c.PubKey])
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"coingod/account"
 	"coingod/asset"
 	"coingod/blockchain/query"
+	"coingod/blockchain/txfeed"
 	"coingod/consensus"
 	"coingod/crypto/sha3pool"
 	dbm "coingod/database/leveldb"
@@ -76,6 +77,7 @@ func (w *Wallet) deleteTransactions(batch dbm.Batch, height uint64) {
 	for txIter.Next() {
 		if err := json.Unmarshal(txIter.Value(), &tmpTx); err == nil {
 			batch.Delete(calcTxIndexKey(tmpTx.ID.String()))
+			unindexAccountTx(batch, &tmpTx)
 		}
 		batch.Delete(txIter.Key())
 	}
@@ -121,8 +123,9 @@ type TxSummary struct {
 	Outputs   []Summary `json:"outputs"`
 }
 
-// indexTransactions saves all annotated transactions to the database.
-func (w *Wallet) indexTransactions(batch dbm.Batch, b *types.Block) error {
+// indexTransactions saves all annotated transactions to the database,
+// and returns the txfeed events which should be notified after the batch is written.
+func (w *Wallet) indexTransactions(batch dbm.Batch, b *types.Block) ([]*txfeed.TxFeedEvent, error) {
 	annotatedTxs := w.filterAccountTxs(b)
 	saveExternalAssetDefinition(b, w.DB)
 	annotateTxsAccount(annotatedTxs, w.DB)
@@ -131,18 +134,27 @@ func (w *Wallet) indexTransactions(batch dbm.Batch, b *types.Block) error {
 		rawTx, err := json.Marshal(tx)
 		if err != nil {
 			log.WithFields(log.Fields{"module": logModule, "err": err}).Error("inserting annotated_txs to db")
-			return err
+			return nil, err
 		}
 
 		batch.Set(calcAnnotatedKey(formatKey(b.Height, uint32(tx.Position))), rawTx)
 		batch.Set(calcTxIndexKey(tx.ID.String()), []byte(formatKey(b.Height, uint32(tx.Position))))
+		indexAccountTx(batch, tx)
 
 		// delete unconfirmed transaction
 		batch.Delete(calcUnconfirmedTxKey(tx.ID.String()))
 	}
 
+	var feedEvents []*txfeed.TxFeedEvent
+	if w.TxFeedTracker != nil {
+		var err error
+		if feedEvents, err = w.TxFeedTracker.IndexTransactions(batch, b.Height, annotatedTxs); err != nil {
+			return nil, err
+		}
+	}
+
 	if !w.TxIndexFlag {
-		return nil
+		return feedEvents, nil
 	}
 
 	for position, globalTx := range b.Transactions {
@@ -150,7 +162,7 @@ func (w *Wallet) indexTransactions(batch dbm.Batch, b *types.Block) error {
 		batch.Set(calcGlobalTxIndexKey(globalTx.ID.String()), calcGlobalTxIndex(&blockHash, uint64(position)))
 	}
 
-	return nil
+	return feedEvents, nil
 }
 
 // filterAccountTxs related and build the fully annotated transactions.
@@ -282,6 +294,21 @@ func findTransactionsByAccount(annotatedTx *query.AnnotatedTx, accountID string)
 // GetTransactions get all walletDB transactions, and filter transactions by accountID optional
 func (w *Wallet) GetTransactions(accountID string) ([]*query.AnnotatedTx, error) {
 	annotatedTxs := []*query.AnnotatedTx{}
+	if accountID != "" {
+		filter := &TxFilter{AccountID: accountID, Limit: maxTxLimit}
+		for {
+			txs, next, err := w.ListTransactions(filter)
+			if err != nil {
+				return nil, err
+			}
+
+			annotatedTxs = append(annotatedTxs, txs...)
+			if next == "" {
+				return annotatedTxs, nil
+			}
+			filter.After = next
+		}
+	}
 
 	txIter := w.DB.IteratorPrefix([]byte(TxPrefix))
 	defer txIter.Release()
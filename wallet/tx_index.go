@@ -0,0 +1,229 @@
+package wallet
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"coingod/blockchain/query"
+	dbm "coingod/database/leveldb"
+	"coingod/errors"
+)
+
+const (
+	// AccountTxIndexPrefix is wallet database prefix of the per-account transaction index
+	AccountTxIndexPrefix = "ATI:"
+
+	defaultTxLimit = 100
+	maxTxLimit     = 1000
+)
+
+var (
+	accountTxIndexStatusKey = []byte("accountTxIndexStatus")
+
+	// ErrBadTxCursor means the cursor of the transaction list is in bad format
+	ErrBadTxCursor = errors.New("invalid transaction cursor")
+)
+
+// TxFilter is the filter of the account transaction list, the zero value of each
+// field means no restriction
+type TxFilter struct {
+	AccountID   string
+	AssetID     string
+	StartHeight uint64
+	EndHeight   uint64
+	StartTime   uint64
+	EndTime     uint64
+	After       string
+	Limit       int
+}
+
+// formatTxCursor invert the height and the position, so the latest transaction
+// comes first in the index
+func formatTxCursor(blockHeight uint64, position uint32) string {
+	return formatKey(^blockHeight, ^position)
+}
+
+func parseTxCursor(cursor string) (uint64, uint32, error) {
+	if len(cursor) != len(formatTxCursor(0, 0)) {
+		return 0, 0, ErrBadTxCursor
+	}
+
+	height, err := strconv.ParseUint(cursor[:16], 16, 64)
+	if err != nil {
+		return 0, 0, ErrBadTxCursor
+	}
+
+	position, err := strconv.ParseUint(cursor[16:], 16, 32)
+	if err != nil {
+		return 0, 0, ErrBadTxCursor
+	}
+	return ^height, ^uint32(position), nil
+}
+
+func calcAccountTxIndexPrefix(accountID string) []byte {
+	return []byte(AccountTxIndexPrefix + accountID + ":")
+}
+
+func calcAccountTxIndexKey(accountID, cursor string) []byte {
+	return append(calcAccountTxIndexPrefix(accountID), cursor...)
+}
+
+// txAccountIDs return the local accounts related to the transaction
+func txAccountIDs(tx *query.AnnotatedTx) []string {
+	accountIDs := []string{}
+	seen := make(map[string]bool)
+	add := func(accountID string) {
+		if accountID != "" && !seen[accountID] {
+			seen[accountID] = true
+			accountIDs = append(accountIDs, accountID)
+		}
+	}
+
+	for _, input := range tx.Inputs {
+		add(input.AccountID)
+	}
+
+	for _, output := range tx.Outputs {
+		add(output.AccountID)
+	}
+	return accountIDs
+}
+
+// indexAccountTx add the transaction to the index of each related account
+func indexAccountTx(batch dbm.Batch, tx *query.AnnotatedTx) {
+	cursor := formatTxCursor(tx.BlockHeight, tx.Position)
+	for _, accountID := range txAccountIDs(tx) {
+		batch.Set(calcAccountTxIndexKey(accountID, cursor), []byte(formatKey(tx.BlockHeight, tx.Position)))
+	}
+}
+
+// unindexAccountTx remove the transaction from the index of each related account
+func unindexAccountTx(batch dbm.Batch, tx *query.AnnotatedTx) {
+	cursor := formatTxCursor(tx.BlockHeight, tx.Position)
+	for _, accountID := range txAccountIDs(tx) {
+		batch.Delete(calcAccountTxIndexKey(accountID, cursor))
+	}
+}
+
+// buildAccountTxIndex index the transactions saved by the old version wallet, it only
+// runs once since the following transactions are indexed when the block is attached
+func (w *Wallet) buildAccountTxIndex() error {
+	if w.DB.Get(accountTxIndexStatusKey) != nil {
+		return nil
+	}
+
+	batch := w.DB.NewBatch()
+	txIter := w.DB.IteratorPrefix([]byte(TxPrefix))
+	defer txIter.Release()
+
+	for txIter.Next() {
+		annotatedTx := &query.AnnotatedTx{}
+		if err := json.Unmarshal(txIter.Value(), annotatedTx); err != nil {
+			return err
+		}
+
+		indexAccountTx(batch, annotatedTx)
+	}
+
+	batch.Set(accountTxIndexStatusKey, []byte{1})
+	batch.Write()
+	return nil
+}
+
+func matchTxAsset(tx *query.AnnotatedTx, assetID string) bool {
+	for _, input := range tx.Inputs {
+		if input.AssetID.String() == assetID {
+			return true
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if output.AssetID.String() == assetID {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTransactions return the transactions of the account from the latest to the oldest.
+// The returned cursor is the position of the last scanned transaction and should be
+// passed as the After of the next call, the empty cursor means the list is finished.
+func (w *Wallet) ListTransactions(filter *TxFilter) ([]*query.AnnotatedTx, string, error) {
+	if filter.AccountID == "" {
+		return nil, "", errors.New("account id is required by the transaction index")
+	}
+
+	if filter.After != "" {
+		if _, _, err := parseTxCursor(filter.After); err != nil {
+			return nil, "", err
+		}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTxLimit
+	} else if limit > maxTxLimit {
+		limit = maxTxLimit
+	}
+
+	prefix := calcAccountTxIndexPrefix(filter.AccountID)
+	startKey := calcAccountTxIndexKey(filter.AccountID, filter.After)
+	if filter.EndHeight != 0 {
+		if endKey := calcAccountTxIndexKey(filter.AccountID, formatTxCursor(filter.EndHeight, ^uint32(0))); string(endKey) > string(startKey) {
+			startKey = endKey
+		}
+	}
+
+	iter := w.DB.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	valid := iter.Seek(startKey)
+	if valid && filter.After != "" && string(iter.Key()) == string(calcAccountTxIndexKey(filter.AccountID, filter.After)) {
+		valid = iter.Next()
+	}
+
+	txs := []*query.AnnotatedTx{}
+	next := ""
+	for ; valid && len(txs) < limit; valid = iter.Next() {
+		next = strings.TrimPrefix(string(iter.Key()), string(prefix))
+		height, _, err := parseTxCursor(next)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if height < filter.StartHeight {
+			return txs, "", nil
+		}
+
+		rawTx := w.DB.Get(calcAnnotatedKey(string(iter.Value())))
+		if rawTx == nil {
+			continue
+		}
+
+		annotatedTx := &query.AnnotatedTx{}
+		if err := json.Unmarshal(rawTx, annotatedTx); err != nil {
+			return nil, "", err
+		}
+
+		if filter.StartTime != 0 && annotatedTx.Timestamp < filter.StartTime {
+			return txs, "", nil
+		}
+
+		if filter.EndTime != 0 && annotatedTx.Timestamp > filter.EndTime {
+			continue
+		}
+
+		if filter.AssetID != "" && !matchTxAsset(annotatedTx, filter.AssetID) {
+			continue
+		}
+
+		annotateTxsAsset(w, []*query.AnnotatedTx{annotatedTx})
+		txs = append(txs, annotatedTx)
+	}
+
+	if !valid {
+		next = ""
+	}
+	return txs, next, nil
+}
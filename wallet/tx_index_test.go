@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"coingod/asset"
+	"coingod/blockchain/query"
+	"coingod/consensus"
+	dbm "coingod/database/leveldb"
+	"coingod/protocol/bc"
+)
+
+func mockAccountTx(height uint64, position uint32, accountID string, assetID bc.AssetID) *query.AnnotatedTx {
+	return &query.AnnotatedTx{
+		ID:          bc.Hash{V0: height, V1: uint64(position)},
+		Timestamp:   height * 1000,
+		BlockHeight: height,
+		Position:    position,
+		Inputs:      []*query.AnnotatedInput{{AccountID: accountID, AssetID: *consensus.CGAssetID}},
+		Outputs:     []*query.AnnotatedOutput{{AccountID: accountID, AssetID: assetID}},
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	testDB := dbm.NewMemDB()
+	w := &Wallet{DB: testDB, AssetReg: asset.NewRegistry(testDB, nil)}
+
+	otherAsset := bc.AssetID{V0: 1}
+	batch := testDB.NewBatch()
+	for height := uint64(1); height <= 10; height++ {
+		for position := uint32(0); position < 2; position++ {
+			accountID, assetID := "alice", *consensus.CGAssetID
+			if position == 1 {
+				accountID = "bob"
+			}
+			if height%2 == 0 {
+				assetID = otherAsset
+			}
+
+			tx := mockAccountTx(height, position, accountID, assetID)
+			rawTx, err := json.Marshal(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			batch.Set(calcAnnotatedKey(formatKey(height, position)), rawTx)
+			indexAccountTx(batch, tx)
+		}
+	}
+	batch.Write()
+
+	cases := []struct {
+		desc       string
+		filter     TxFilter
+		wantHeight []uint64
+	}{
+		{desc: "all", filter: TxFilter{AccountID: "alice"}, wantHeight: []uint64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}},
+		{desc: "height range", filter: TxFilter{AccountID: "alice", StartHeight: 3, EndHeight: 6}, wantHeight: []uint64{6, 5, 4, 3}},
+		{desc: "time range", filter: TxFilter{AccountID: "alice", StartTime: 7000, EndTime: 8000}, wantHeight: []uint64{8, 7}},
+		{desc: "asset", filter: TxFilter{AccountID: "alice", AssetID: otherAsset.String(), EndHeight: 7}, wantHeight: []uint64{6, 4, 2}},
+		{desc: "unknown account", filter: TxFilter{AccountID: "carol"}, wantHeight: []uint64{}},
+	}
+
+	for i, c := range cases {
+		// fetch the whole list page by page
+		gotHeight := []uint64{}
+		filter := c.filter
+		filter.Limit = 3
+		for {
+			txs, next, err := w.ListTransactions(&filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tx := range txs {
+				if tx.Outputs[0].AccountID != c.filter.AccountID {
+					t.Errorf("case %d(%s): got tx of account %s", i, c.desc, tx.Outputs[0].AccountID)
+				}
+				gotHeight = append(gotHeight, tx.BlockHeight)
+			}
+
+			if next == "" {
+				break
+			}
+			filter.After = next
+		}
+
+		if len(gotHeight) != len(c.wantHeight) {
+			t.Fatalf("case %d(%s): got heights %v, want %v", i, c.desc, gotHeight, c.wantHeight)
+		}
+
+		for j := range gotHeight {
+			if gotHeight[j] != c.wantHeight[j] {
+				t.Errorf("case %d(%s): got heights %v, want %v", i, c.desc, gotHeight, c.wantHeight)
+				break
+			}
+		}
+	}
+
+	// the rollback transaction is removed from the account index
+	batch = testDB.NewBatch()
+	w.deleteTransactions(batch, 10)
+	batch.Write()
+	if txs, _, err := w.ListTransactions(&TxFilter{AccountID: "bob", Limit: 1}); err != nil || len(txs) != 1 || txs[0].BlockHeight != 9 {
+		t.Errorf("got txs %v, err %v after rollback", txs, err)
+	}
+
+	if _, _, err := w.ListTransactions(&TxFilter{AccountID: "alice", After: "bad"}); err != ErrBadTxCursor {
+		t.Errorf("got err %v, want %v", err, ErrBadTxCursor)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"coingod/account"
 	"coingod/asset"
 	"coingod/blockchain/pseudohsm"
+	"coingod/blockchain/txfeed"
 	"coingod/contract"
 	dbm "coingod/database/leveldb"
 	"coingod/errors"
@@ -30,8 +31,23 @@ var (
 
 	errBestBlockNotFoundInCore = errors.New("best block not found in core")
 	errWalletVersionMismatch   = errors.New("wallet version mismatch")
+
+	// ErrMissingHistory is returned when the wallet is asked to recover the funds of the
+	// existing keys on the node bootstrapped from a snapshot or pruned
+	ErrMissingHistory = errors.New("wallet history before the base block is missing")
 )
 
+// Chain is the block source of the wallet, it's the full chain of the node or the
+// header chain with the related transactions of the light node
+type Chain interface {
+	BaseBlockHeight() uint64
+	BlockExist(*bc.Hash) bool
+	BlockWaiter(uint64) <-chan struct{}
+	GetBlockByHash(*bc.Hash) (*types.Block, error)
+	GetBlockByHeight(uint64) (*types.Block, error)
+	InMainChain(bc.Hash) bool
+}
+
 //StatusInfo is base valid block info to handle orphan block rollback
 type StatusInfo struct {
 	Version    uint
@@ -51,8 +67,9 @@ type Wallet struct {
 	AssetReg        *asset.Registry
 	ContractReg     *contract.Registry
 	Hsm             *pseudohsm.HSM
-	chain           *protocol.Chain
+	chain           Chain
 	RecoveryMgr     *recoveryManager
+	TxFeedTracker   *txfeed.Tracker
 	eventDispatcher *event.Dispatcher
 	txMsgSub        *event.Subscription
 
@@ -60,7 +77,7 @@ type Wallet struct {
 }
 
 //NewWallet return a new wallet instance
-func NewWallet(walletDB dbm.DB, account *account.Manager, asset *asset.Registry, contract *contract.Registry, hsm *pseudohsm.HSM, chain *protocol.Chain, dispatcher *event.Dispatcher, txIndexFlag bool) (*Wallet, error) {
+func NewWallet(walletDB dbm.DB, account *account.Manager, asset *asset.Registry, contract *contract.Registry, hsm *pseudohsm.HSM, chain Chain, dispatcher *event.Dispatcher, txIndexFlag bool) (*Wallet, error) {
 	w := &Wallet{
 		DB:              walletDB,
 		AccountMgr:      account,
@@ -78,11 +95,23 @@ func NewWallet(walletDB dbm.DB, account *account.Manager, asset *asset.Registry,
 		return nil, err
 	}
 
+	if baseHeight := chain.BaseBlockHeight(); baseHeight > 0 {
+		log.WithFields(log.Fields{"module": logModule, "base_height": baseHeight}).Warn("the node is bootstrapped from a snapshot or pruned, the wallet doesn't know the outputs before the base height")
+	}
+
 	if err := w.RecoveryMgr.LoadStatusInfo(); err != nil {
 		return nil, err
 	}
 
+	if err := w.buildAccountTxIndex(); err != nil {
+		return nil, err
+	}
+
 	var err error
+	if w.TxFeedTracker, err = txfeed.NewTracker(walletDB, dispatcher); err != nil {
+		return nil, err
+	}
+
 	w.txMsgSub, err = w.eventDispatcher.Subscribe(protocol.TxMsgEvent{})
 	if err != nil {
 		return nil, err
@@ -151,14 +180,31 @@ func (w *Wallet) loadWalletInfo() error {
 	}
 
 	w.status.Version = currentVersion
-	w.status.WorkHash = bc.Hash{}
-	block, err := w.chain.GetBlockByHeight(0)
+	return w.attachBaseBlock()
+}
+
+// attachBaseBlock restart the wallet from the base block of the chain, which is the genesis
+// block unless the node is bootstrapped from a snapshot
+func (w *Wallet) attachBaseBlock() error {
+	block, err := w.chain.GetBlockByHeight(w.chain.BaseBlockHeight())
 	if err != nil {
 		return err
 	}
+
+	w.status.WorkHash = block.PreviousBlockHash
 	return w.AttachBlock(block)
 }
 
+// CheckFullHistory return error when the chain starts from a snapshot or the old blocks are
+// pruned, the chain only keeps the ids of the unspent outputs so the outputs of the existing
+// keys before the base block can't be found by the wallet
+func (w *Wallet) CheckFullHistory() error {
+	if baseHeight := w.chain.BaseBlockHeight(); baseHeight > 0 {
+		return errors.WithDetailf(ErrMissingHistory, "the chain keeps the blocks from height %d", baseHeight)
+	}
+	return nil
+}
+
 func (w *Wallet) commitWalletInfo(batch dbm.Batch) error {
 	rawWallet, err := json.Marshal(w.status)
 	if err != nil {
@@ -187,7 +233,8 @@ func (w *Wallet) AttachBlock(block *types.Block) error {
 	}
 
 	storeBatch := w.DB.NewBatch()
-	if err := w.indexTransactions(storeBatch, block); err != nil {
+	feedEvents, err := w.indexTransactions(storeBatch, block)
+	if err != nil {
 		return err
 	}
 
@@ -198,7 +245,13 @@ func (w *Wallet) AttachBlock(block *types.Block) error {
 		w.status.BestHeight = w.status.WorkHeight
 		w.status.BestHash = w.status.WorkHash
 	}
-	return w.commitWalletInfo(storeBatch)
+
+	if err := w.commitWalletInfo(storeBatch); err != nil {
+		return err
+	}
+
+	w.notifyTxFeed(feedEvents)
+	return nil
 }
 
 // DetachBlock detach a block and rollback state
@@ -209,6 +262,10 @@ func (w *Wallet) DetachBlock(block *types.Block) error {
 	storeBatch := w.DB.NewBatch()
 	w.detachUtxos(storeBatch, block)
 	w.deleteTransactions(storeBatch, w.status.BestHeight)
+	var feedEvents []*txfeed.TxFeedEvent
+	if w.TxFeedTracker != nil {
+		feedEvents = w.TxFeedTracker.DetachTransactions(storeBatch, w.status.BestHeight)
+	}
 
 	w.status.BestHeight = block.Height - 1
 	w.status.BestHash = block.PreviousBlockHash
@@ -218,7 +275,19 @@ func (w *Wallet) DetachBlock(block *types.Block) error {
 		w.status.WorkHash = w.status.BestHash
 	}
 
-	return w.commitWalletInfo(storeBatch)
+	if err := w.commitWalletInfo(storeBatch); err != nil {
+		return err
+	}
+
+	w.notifyTxFeed(feedEvents)
+	return nil
+}
+
+// notifyTxFeed post the txfeed events, the tracker is absent when the wallet is built without it
+func (w *Wallet) notifyTxFeed(events []*txfeed.TxFeedEvent) {
+	if w.TxFeedTracker != nil {
+		w.TxFeedTracker.Notify(events)
+	}
 }
 
 //WalletUpdate process every valid block and reverse every invalid block which need to rollback
@@ -238,6 +307,11 @@ func (w *Wallet) walletUpdater() {
 			}
 		}
 
+		if baseHeight := w.chain.BaseBlockHeight(); w.status.WorkHeight+1 < baseHeight {
+			log.WithFields(log.Fields{"module": logModule, "wallet_height": w.status.WorkHeight, "base_height": baseHeight}).Error("walletUpdater stop, the blocks to attach are pruned, rescan the wallet")
+			return
+		}
+
 		block, _ := w.chain.GetBlockByHeight(w.status.WorkHeight + 1)
 		if block == nil {
 			w.walletBlockWaiter()
@@ -278,6 +352,13 @@ func (w *Wallet) deleteAccountTxs() {
 		storeBatch.Delete(txIndexIter.Key())
 	}
 
+	accountTxIndexIter := w.DB.IteratorPrefix([]byte(AccountTxIndexPrefix))
+	defer accountTxIndexIter.Release()
+
+	for accountTxIndexIter.Next() {
+		storeBatch.Delete(accountTxIndexIter.Key())
+	}
+
 	storeBatch.Write()
 }
 
@@ -334,9 +415,7 @@ func (w *Wallet) getRescanNotification() {
 }
 
 func (w *Wallet) setRescanStatus() {
-	block, _ := w.chain.GetBlockByHeight(0)
-	w.status.WorkHash = bc.Hash{}
-	w.AttachBlock(block)
+	w.attachBaseBlock()
 }
 
 func (w *Wallet) walletBlockWaiter() {
@@ -354,3 +433,17 @@ func (w *Wallet) GetWalletStatusInfo() StatusInfo {
 
 	return w.status
 }
+
+// ControlPrograms return all the control programs of the wallet accounts
+func (w *Wallet) ControlPrograms() ([][]byte, error) {
+	cps, err := w.AccountMgr.ListControlProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make([][]byte, 0, len(cps))
+	for _, cp := range cps {
+		programs = append(programs, cp.ControlProgram)
+	}
+	return programs, nil
+}
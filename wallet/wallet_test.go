@@ -19,6 +19,7 @@ import (
 	"coingod/crypto/ed25519/chainkd"
 	"coingod/database"
 	dbm "coingod/database/leveldb"
+	"coingod/errors"
 	"coingod/event"
 	"coingod/protocol"
 	"coingod/protocol/bc"
@@ -249,6 +250,33 @@ func TestRescanWallet(t *testing.T) {
 	}
 }
 
+// snapshotChain is the chain of the node bootstrapped from the snapshot at the base height
+type snapshotChain struct {
+	Chain
+	baseHeight uint64
+}
+
+func (c *snapshotChain) BaseBlockHeight() uint64 {
+	return c.baseHeight
+}
+
+func TestCheckFullHistory(t *testing.T) {
+	cases := []struct {
+		baseHeight uint64
+		wantErr    error
+	}{
+		{baseHeight: 0, wantErr: nil},
+		{baseHeight: 100, wantErr: ErrMissingHistory},
+	}
+
+	for i, c := range cases {
+		w := &Wallet{chain: &snapshotChain{baseHeight: c.baseHeight}}
+		if err := w.CheckFullHistory(); errors.Root(err) != c.wantErr {
+			t.Errorf("case %d: got error %v, want %v", i, err, c.wantErr)
+		}
+	}
+}
+
 func TestMemPoolTxQueryLoop(t *testing.T) {
 	dirPath, err := ioutil.TempDir(".", "")
 	if err != nil {